@@ -0,0 +1,21 @@
+package blobstore
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// checkKey refuses keys that are not plain slash-separated paths, such as
+// ones with "." or ".." segments, which would reach outside the root
+// directory or be resolved to another object by the S3 service.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || path.Clean(key) != key || strings.HasPrefix(key, "../") || key == ".." {
+		return fmt.Errorf("%w: %q", ErrInvalidKey, key)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalBlobStore(t *testing.T) {
+	t.Run("it should read back what was put, with the content type of its extension", func(t *testing.T) {
+		store := NewLocalBlobStore(t.TempDir())
+
+		err := store.Put(context.Background(), "covers/1/full.jpg", []byte("image"), "image/jpeg")
+		assert.NoError(t, err)
+
+		body, info, err := store.Get(context.Background(), "covers/1/full.jpg")
+		assert.NoError(t, err)
+		defer body.Close()
+
+		data, _ := io.ReadAll(body)
+		assert.Equal(t, "image", string(data))
+		assert.Equal(t, "image/jpeg", info.ContentType)
+		assert.Equal(t, int64(5), info.Size)
+	})
+
+	t.Run("it should overwrite an existing blob", func(t *testing.T) {
+		store := NewLocalBlobStore(t.TempDir())
+
+		assert.NoError(t, store.Put(context.Background(), "a.txt", []byte("first"), "text/plain"))
+		assert.NoError(t, store.Put(context.Background(), "a.txt", []byte("second"), "text/plain"))
+
+		body, _, err := store.Get(context.Background(), "a.txt")
+		assert.NoError(t, err)
+		defer body.Close()
+
+		data, _ := io.ReadAll(body)
+		assert.Equal(t, "second", string(data))
+	})
+
+	t.Run("it should return ErrBlobNotFound for a deleted or missing blob", func(t *testing.T) {
+		store := NewLocalBlobStore(t.TempDir())
+
+		assert.NoError(t, store.Put(context.Background(), "a.txt", []byte("data"), "text/plain"))
+		assert.NoError(t, store.Delete(context.Background(), "a.txt"))
+		assert.NoError(t, store.Delete(context.Background(), "a.txt"))
+
+		_, _, err := store.Get(context.Background(), "a.txt")
+		assert.ErrorIs(t, err, types.ErrBlobNotFound)
+	})
+
+	t.Run("it should refuse keys leaving its directory", func(t *testing.T) {
+		store := NewLocalBlobStore(t.TempDir())
+
+		for _, key := range []string{"", "/etc/passwd", "../secret", "covers/../../secret", "covers//a"} {
+			err := store.Put(context.Background(), key, []byte("data"), "text/plain")
+			assert.ErrorIs(t, err, ErrInvalidKey, key)
+
+			_, _, err = store.Get(context.Background(), key)
+			assert.ErrorIs(t, err, ErrInvalidKey, key)
+		}
+	})
+}
@@ -7,10 +7,14 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/hoyci/book-store-api/config"
 	_ "github.com/hoyci/book-store-api/docs"
+	"github.com/hoyci/book-store-api/service/admin"
 	"github.com/hoyci/book-store-api/service/auth"
+	"github.com/hoyci/book-store-api/service/author"
 	"github.com/hoyci/book-store-api/service/book"
+	"github.com/hoyci/book-store-api/service/genre"
 	"github.com/hoyci/book-store-api/service/healthcheck"
 	"github.com/hoyci/book-store-api/service/user"
+	"github.com/hoyci/book-store-api/types"
 	"github.com/hoyci/book-store-api/utils"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/collectors"
@@ -40,6 +44,9 @@ func (s *APIServer) SetupRouter(
 	bookHandler *book.BookHandler,
 	userHandler *user.UserHandler,
 	authHandler *auth.AuthHandler,
+	adminHandler *admin.AdminHandler,
+	authorHandler *author.AuthorHandler,
+	genreHandler *genre.GenreHandler,
 ) *mux.Router {
 	utils.InitLogger()
 	router := mux.NewRouter()
@@ -50,10 +57,18 @@ func (s *APIServer) SetupRouter(
 		collectors.NewGoCollector(),
 		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
 	)
+	if authHandler != nil {
+		metricsMiddleware.Register(authHandler.Collectors()...)
+	}
 
 	router.Use(utils.LoggingMiddleware)
 	router.Use(otelmux.Middleware("book-store-api"))
 
+	router.HandleFunc(
+		"/.well-known/jwks.json",
+		metricsMiddleware.WrapHandler("jwks", http.HandlerFunc(authHandler.HandleGetJWKS)),
+	).Methods(http.MethodGet)
+
 	subrouter := router.PathPrefix("/api/v1").Subrouter()
 
 	subrouter.HandleFunc(
@@ -104,16 +119,151 @@ func (s *APIServer) SetupRouter(
 		"/auth/refresh",
 		metricsMiddleware.WrapHandler("auth/refresh", http.HandlerFunc(authHandler.HandleRefreshToken)),
 	).Methods(http.MethodPost)
+	subrouter.HandleFunc(
+		"/auth/password/forgot",
+		metricsMiddleware.WrapHandler("auth/forgot_password", http.HandlerFunc(authHandler.HandleForgotPassword)),
+	).Methods(http.MethodPost)
+	subrouter.HandleFunc(
+		"/auth/password/reset",
+		metricsMiddleware.WrapHandler("auth/reset_password", http.HandlerFunc(authHandler.HandleResetPassword)),
+	).Methods(http.MethodPost)
+	subrouter.HandleFunc(
+		"/auth/restore",
+		metricsMiddleware.WrapHandler("auth/restore_account", http.HandlerFunc(authHandler.HandleRestoreAccount)),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/auth/logout",
+		metricsMiddleware.WrapHandler(
+			"auth/logout",
+			utils.AuthMiddlewareWithScopes(types.ScopeUnverifiedEmail)(http.HandlerFunc(authHandler.HandleLogout)),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/auth/logout-all",
+		metricsMiddleware.WrapHandler(
+			"auth/logout_all",
+			utils.AuthMiddlewareWithScopes(types.ScopeUnverifiedEmail)(http.HandlerFunc(authHandler.HandleLogoutAll)),
+		),
+	).Methods(http.MethodPost)
+	subrouter.HandleFunc(
+		"/auth/oidc/login",
+		metricsMiddleware.WrapHandler("auth/oidc_login", http.HandlerFunc(authHandler.HandleOIDCLogin)),
+	).Methods(http.MethodGet)
+	subrouter.HandleFunc(
+		"/auth/oidc/callback",
+		metricsMiddleware.WrapHandler("auth/oidc_callback", http.HandlerFunc(authHandler.HandleOIDCCallback)),
+	).Methods(http.MethodGet)
+	subrouter.HandleFunc(
+		"/auth/mfa/verify",
+		metricsMiddleware.WrapHandler("auth/verify_mfa", http.HandlerFunc(authHandler.HandleVerifyMFA)),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/auth/mfa/enroll",
+		metricsMiddleware.WrapHandler(
+			"auth/enroll_mfa",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin, types.RoleLibrarian)(http.HandlerFunc(authHandler.HandleEnrollMFA))),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/auth/mfa/confirm",
+		metricsMiddleware.WrapHandler(
+			"auth/confirm_mfa",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin, types.RoleLibrarian)(http.HandlerFunc(authHandler.HandleConfirmMFA))),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/auth/sessions",
+		metricsMiddleware.WrapHandler(
+			"auth/get_sessions",
+			utils.AuthMiddleware(http.HandlerFunc(authHandler.HandleGetSessions)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/auth/sessions/{id}",
+		metricsMiddleware.WrapHandler(
+			"auth/delete_session",
+			utils.AuthMiddleware(http.HandlerFunc(authHandler.HandleDeleteSession)),
+		),
+	).Methods(http.MethodDelete)
+	subrouter.Handle(
+		"/oauth/clients",
+		metricsMiddleware.WrapHandler(
+			"oauth/create_client",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(authHandler.HandleCreateOAuthClient))),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/oauth/clients",
+		metricsMiddleware.WrapHandler(
+			"oauth/get_clients",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(authHandler.HandleGetOAuthClients))),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/oauth/clients/{client_id}",
+		metricsMiddleware.WrapHandler(
+			"oauth/delete_client",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(authHandler.HandleDeleteOAuthClient))),
+		),
+	).Methods(http.MethodDelete)
+	subrouter.Handle(
+		"/oauth/authorize",
+		metricsMiddleware.WrapHandler(
+			"oauth/authorize",
+			utils.AuthMiddleware(http.HandlerFunc(authHandler.HandleOAuthAuthorize)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/oauth/authorize",
+		metricsMiddleware.WrapHandler(
+			"oauth/consent",
+			utils.AuthMiddleware(http.HandlerFunc(authHandler.HandleOAuthConsent)),
+		),
+	).Methods(http.MethodPost)
+	subrouter.HandleFunc(
+		"/oauth/token",
+		metricsMiddleware.WrapHandler("oauth/token", http.HandlerFunc(authHandler.HandleOAuthToken)),
+	).Methods(http.MethodPost)
+	subrouter.HandleFunc(
+		"/oauth/introspect",
+		metricsMiddleware.WrapHandler("oauth/introspect", http.HandlerFunc(authHandler.HandleOAuthIntrospect)),
+	).Methods(http.MethodPost)
+	subrouter.HandleFunc(
+		"/oauth/revoke",
+		metricsMiddleware.WrapHandler("oauth/revoke", http.HandlerFunc(authHandler.HandleOAuthRevoke)),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/oauth/consents",
+		metricsMiddleware.WrapHandler(
+			"oauth/get_consents",
+			utils.AuthMiddleware(http.HandlerFunc(authHandler.HandleGetOAuthConsents)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/oauth/consents/{client_id}",
+		metricsMiddleware.WrapHandler(
+			"oauth/delete_consent",
+			utils.AuthMiddleware(http.HandlerFunc(authHandler.HandleDeleteOAuthConsent)),
+		),
+	).Methods(http.MethodDelete)
 
 	subrouter.HandleFunc(
 		"/users",
 		metricsMiddleware.WrapHandler("create_user", http.HandlerFunc(userHandler.HandleCreateUser)),
 	).Methods(http.MethodPost)
+	subrouter.HandleFunc(
+		"/users/verify",
+		metricsMiddleware.WrapHandler("verify_email", http.HandlerFunc(userHandler.HandleVerifyEmail)),
+	).Methods(http.MethodGet)
+	subrouter.HandleFunc(
+		"/users/verify/resend",
+		metricsMiddleware.WrapHandler("resend_verification_email", http.HandlerFunc(userHandler.HandleResendVerificationEmail)),
+	).Methods(http.MethodPost)
 	subrouter.Handle(
 		"/users",
 		metricsMiddleware.WrapHandler(
 			"get_user",
-			utils.AuthMiddleware(http.HandlerFunc(userHandler.HandleGetUserByID)),
+			utils.AuthMiddlewareWithScopes(types.ScopeUnverifiedEmail)(http.HandlerFunc(userHandler.HandleGetUserByID)),
 		),
 	).Methods(http.MethodGet)
 	subrouter.Handle(
@@ -123,11 +273,53 @@ func (s *APIServer) SetupRouter(
 			utils.AuthMiddleware(http.HandlerFunc(userHandler.HandleUpdateUserByID)),
 		),
 	).Methods(http.MethodPut)
+	subrouter.Handle(
+		"/users/password",
+		metricsMiddleware.WrapHandler(
+			"change_password",
+			utils.AuthMiddleware(http.HandlerFunc(userHandler.HandleChangePassword)),
+		),
+	).Methods(http.MethodPut)
+	subrouter.Handle(
+		"/users/me/export",
+		metricsMiddleware.WrapHandler(
+			"export_user_data",
+			utils.AuthMiddlewareWithScopes(types.ScopeUnverifiedEmail)(http.HandlerFunc(userHandler.HandleExportUserData)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/users/me/erase",
+		metricsMiddleware.WrapHandler(
+			"erase_user",
+			utils.AuthMiddlewareWithScopes(types.ScopeUnverifiedEmail)(http.HandlerFunc(userHandler.HandleEraseUser)),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/users/tokens",
+		metricsMiddleware.WrapHandler(
+			"create_api_key",
+			utils.AuthMiddleware(http.HandlerFunc(userHandler.HandleCreateAPIKey)),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/users/tokens",
+		metricsMiddleware.WrapHandler(
+			"get_api_keys",
+			utils.AuthMiddleware(http.HandlerFunc(userHandler.HandleGetAPIKeys)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/users/tokens/{id}",
+		metricsMiddleware.WrapHandler(
+			"delete_api_key",
+			utils.AuthMiddleware(http.HandlerFunc(userHandler.HandleDeleteAPIKey)),
+		),
+	).Methods(http.MethodDelete)
 	subrouter.Handle(
 		"/users",
 		metricsMiddleware.WrapHandler(
 			"delete_user",
-			utils.AuthMiddleware(http.HandlerFunc(userHandler.HandleDeleteUserByID)),
+			utils.AuthMiddlewareWithScopes(types.ScopeUnverifiedEmail)(http.HandlerFunc(userHandler.HandleDeleteUserByID)),
 		),
 	).Methods(http.MethodDelete)
 
@@ -135,37 +327,268 @@ func (s *APIServer) SetupRouter(
 		"/books",
 		metricsMiddleware.WrapHandler(
 			"create_book",
-			utils.AuthMiddleware(http.HandlerFunc(bookHandler.HandleCreateBook)),
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleCreateBook)),
 		),
 	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/books/import/isbn",
+		metricsMiddleware.WrapHandler(
+			"import_book_by_isbn",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleImportBookByISBN)),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/books/search",
+		metricsMiddleware.WrapHandler(
+			"search_books",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleSearchBooks)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/books/trash",
+		metricsMiddleware.WrapHandler(
+			"get_deleted_books",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleGetDeletedBooks)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/books/isbn/{isbn}",
+		metricsMiddleware.WrapHandler(
+			"get_book_by_isbn",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleGetBookByISBN)),
+		),
+	).Methods(http.MethodGet)
 	subrouter.Handle(
 		"/books/{id}",
 		metricsMiddleware.WrapHandler(
 			"get_book_by_id",
-			utils.AuthMiddleware(http.HandlerFunc(bookHandler.HandleGetBookByID)),
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleGetBookByID)),
 		),
 	).Methods(http.MethodGet)
 	subrouter.Handle(
 		"/books",
 		metricsMiddleware.WrapHandler(
 			"get_books",
-			utils.AuthMiddleware(http.HandlerFunc(bookHandler.HandleGetBooks)),
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleGetBooks)),
 		),
 	).Methods(http.MethodGet)
 	subrouter.Handle(
 		"/books/{id}",
 		metricsMiddleware.WrapHandler(
 			"update_book_by_id",
-			utils.AuthMiddleware(http.HandlerFunc(bookHandler.HandleUpdateBookByID)),
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleUpdateBookByID)),
 		),
 	).Methods(http.MethodPut)
 	subrouter.Handle(
 		"/books/{id}",
 		metricsMiddleware.WrapHandler(
 			"delete_book_by_id",
-			utils.AuthMiddleware(http.HandlerFunc(bookHandler.HandleDeleteBookByID)),
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleDeleteBookByID)),
 		),
 	).Methods(http.MethodDelete)
+	subrouter.Handle(
+		"/books/{id}/restore",
+		metricsMiddleware.WrapHandler(
+			"restore_book",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleRestoreBook)),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/books/{id}/shares",
+		metricsMiddleware.WrapHandler(
+			"share_book",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleShareBook)),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/books/{id}/shares",
+		metricsMiddleware.WrapHandler(
+			"get_book_shares",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleGetBookShares)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/books/{id}/shares/{userId}",
+		metricsMiddleware.WrapHandler(
+			"revoke_book_share",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleRevokeBookShare)),
+		),
+	).Methods(http.MethodDelete)
+	subrouter.Handle(
+		"/books/{id}/authors",
+		metricsMiddleware.WrapHandler(
+			"get_book_authors",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleGetBookAuthors)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/books/{id}/authors",
+		metricsMiddleware.WrapHandler(
+			"set_book_authors",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleSetBookAuthors)),
+		),
+	).Methods(http.MethodPut)
+	subrouter.Handle(
+		"/books/{id}/cover",
+		metricsMiddleware.WrapHandler(
+			"upload_book_cover",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(http.HandlerFunc(bookHandler.HandleUploadBookCover)),
+		),
+	).Methods(http.MethodPut)
+	subrouter.HandleFunc(
+		"/covers/{key:.+}",
+		metricsMiddleware.WrapHandler("get_cover", http.HandlerFunc(bookHandler.HandleGetCover)),
+	).Methods(http.MethodGet)
+
+	subrouter.Handle(
+		"/authors",
+		metricsMiddleware.WrapHandler(
+			"create_author",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(http.HandlerFunc(authorHandler.HandleCreateAuthor)),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/authors",
+		metricsMiddleware.WrapHandler(
+			"get_authors",
+			utils.ClientAuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(authorHandler.HandleGetAuthors)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/authors/{id}",
+		metricsMiddleware.WrapHandler(
+			"get_author_by_id",
+			utils.ClientAuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(authorHandler.HandleGetAuthorByID)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/authors/{id}",
+		metricsMiddleware.WrapHandler(
+			"update_author_by_id",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(utils.RequireRole(types.RoleAdmin, types.RoleLibrarian)(http.HandlerFunc(authorHandler.HandleUpdateAuthorByID))),
+		),
+	).Methods(http.MethodPut)
+	subrouter.Handle(
+		"/authors/{id}",
+		metricsMiddleware.WrapHandler(
+			"delete_author_by_id",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(utils.RequireRole(types.RoleAdmin, types.RoleLibrarian)(http.HandlerFunc(authorHandler.HandleDeleteAuthorByID))),
+		),
+	).Methods(http.MethodDelete)
+
+	subrouter.Handle(
+		"/genres",
+		metricsMiddleware.WrapHandler(
+			"create_genre",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(genreHandler.HandleCreateGenre))),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/genres",
+		metricsMiddleware.WrapHandler(
+			"get_genres",
+			utils.ClientAuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(genreHandler.HandleGetGenres)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/genres/{id}",
+		metricsMiddleware.WrapHandler(
+			"get_genre_by_id",
+			utils.ClientAuthMiddlewareWithScopes(types.ScopeBooksRead, types.ScopeBooksWrite)(http.HandlerFunc(genreHandler.HandleGetGenreByID)),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/genres/{id}",
+		metricsMiddleware.WrapHandler(
+			"update_genre_by_id",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(genreHandler.HandleUpdateGenreByID))),
+		),
+	).Methods(http.MethodPut)
+	subrouter.Handle(
+		"/genres/{id}",
+		metricsMiddleware.WrapHandler(
+			"delete_genre_by_id",
+			utils.AuthMiddlewareWithScopes(types.ScopeBooksWrite)(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(genreHandler.HandleDeleteGenreByID))),
+		),
+	).Methods(http.MethodDelete)
+
+	subrouter.Handle(
+		"/admin/users",
+		metricsMiddleware.WrapHandler(
+			"admin/get_users",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleGetUsers))),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/admin/users/{id}/role",
+		metricsMiddleware.WrapHandler(
+			"admin/update_user_role",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleUpdateUserRole))),
+		),
+	).Methods(http.MethodPut)
+	subrouter.Handle(
+		"/admin/users/{id}/disable",
+		metricsMiddleware.WrapHandler(
+			"admin/disable_user",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleDisableUser))),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/admin/users/{id}/restore",
+		metricsMiddleware.WrapHandler(
+			"admin/restore_user",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleRestoreUser))),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/admin/users/{id}",
+		metricsMiddleware.WrapHandler(
+			"admin/get_user",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleGetUser))),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/admin/users/{id}",
+		metricsMiddleware.WrapHandler(
+			"admin/delete_user",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleDeleteUser))),
+		),
+	).Methods(http.MethodDelete)
+	subrouter.Handle(
+		"/admin/users/{id}/logout",
+		metricsMiddleware.WrapHandler(
+			"admin/logout_user",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleLogoutUser))),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/admin/audit-logs",
+		metricsMiddleware.WrapHandler(
+			"admin/get_audit_logs",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleGetAuditLogs))),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/admin/books",
+		metricsMiddleware.WrapHandler(
+			"admin/get_books",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleGetAllBooks))),
+		),
+	).Methods(http.MethodGet)
+	subrouter.Handle(
+		"/admin/books/{id}/disable",
+		metricsMiddleware.WrapHandler(
+			"admin/disable_book",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleDisableBook))),
+		),
+	).Methods(http.MethodPost)
+	subrouter.Handle(
+		"/admin/books/{id}/restore",
+		metricsMiddleware.WrapHandler(
+			"admin/restore_book",
+			utils.AuthMiddleware(utils.RequireRole(types.RoleAdmin)(http.HandlerFunc(adminHandler.HandleRestoreBook))),
+		),
+	).Methods(http.MethodPost)
 
 	s.Router = router
 
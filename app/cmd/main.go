@@ -2,17 +2,30 @@ package main
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
 	"log"
 	"net/http"
+	"strings"
+	"time"
 
+	"github.com/hoyci/book-store-api/blobstore"
 	"github.com/hoyci/book-store-api/cmd/api"
 	"github.com/hoyci/book-store-api/config"
 	"github.com/hoyci/book-store-api/db"
+	"github.com/hoyci/book-store-api/mailer"
+	"github.com/hoyci/book-store-api/metadata"
+	"github.com/hoyci/book-store-api/oidc"
+	"github.com/hoyci/book-store-api/purger"
+	"github.com/hoyci/book-store-api/service/admin"
 	"github.com/hoyci/book-store-api/service/auth"
+	"github.com/hoyci/book-store-api/service/author"
 	"github.com/hoyci/book-store-api/service/book"
+	"github.com/hoyci/book-store-api/service/genre"
 	"github.com/hoyci/book-store-api/service/healthcheck"
 	"github.com/hoyci/book-store-api/service/user"
+	"github.com/hoyci/book-store-api/types"
 	"github.com/hoyci/book-store-api/utils"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
@@ -36,25 +49,232 @@ func main() {
 	apiServer := api.NewApiServer(path, db)
 
 	initTracer()
+	initJWTKeys()
+	initMFAEncryptionKey()
+	initPasswordHashing()
 
 	healthCheckHandler := healthcheck.NewHealthCheckHandler(config.Envs)
 
-	bookStore := book.NewBookStore(db)
-	bookHandler := book.NewBookHandler(bookStore)
+	coverStore := initCoverStore()
+	bookStore := book.NewBookStore(db, coverStore)
+	metadataProvider := initMetadataProvider()
+	bookHandler := book.NewBookHandler(bookStore, metadataProvider, coverStore)
 
-	userStore := user.NewUserStore(db)
-	userHandler := user.NewUserHandler(userStore)
+	authorStore := author.NewAuthorStore(db)
+	authorHandler := author.NewAuthorHandler(authorStore)
+
+	genreStore := genre.NewGenreStore(db)
+	genreHandler := genre.NewGenreHandler(genreStore)
+
+	mailSender := initMailer()
 
 	authStore := auth.NewAuthStore(db)
+	utils.SetTokenDenylist(authStore)
+
+	userStore := user.NewUserStore(db, coverStore)
+	utils.SetAPIKeyAuthenticator(userStore)
+	userHandler := user.NewUserHandler(userStore, authStore, mailSender)
+
 	uuidGen := &utils.UUIDGeneratorUtil{}
-	authHandler := auth.NewAuthHandler(userStore, authStore, uuidGen)
+	oidcProvider := initOIDCProvider()
+	authHandler := auth.NewAuthHandler(userStore, authStore, uuidGen, mailSender, oidcProvider)
+
+	auditStore := admin.NewAuditStore(db)
+	adminHandler := admin.NewAdminHandler(userStore, bookStore, authStore, auditStore)
 
-	apiServer.SetupRouter(healthCheckHandler, bookHandler, userHandler, authHandler)
+	apiServer.SetupRouter(healthCheckHandler, bookHandler, userHandler, authHandler, adminHandler, authorHandler, genreHandler)
+
+	initTrashPurger(bookStore, userStore)
 
 	log.Println("Listening on:", path)
 	http.ListenAndServe(path, apiServer.Router)
 }
 
+func initJWTKeys() {
+	if config.Envs.JWTKeys == "" {
+		if config.Envs.Environment == "production" {
+			log.Fatal("JWT_KEYS and JWT_SIGNING_KEY_ID must be set in production")
+		}
+		log.Println("JWT_KEYS is not set, signing tokens with the HS256 shared secret")
+		return
+	}
+
+	keySet, err := utils.LoadJWTKeySet(config.Envs.JWTSigningKeyID, config.Envs.JWTKeys)
+	if err != nil {
+		log.Fatalf("failed to load JWT keys: %v", err)
+	}
+
+	utils.SetJWTKeySet(keySet)
+}
+
+// initMFAEncryptionKey makes up a key outside production, so MFA works out of
+// the box, but enrollments made with it don't survive a restart.
+func initMFAEncryptionKey() {
+	if config.Envs.MFAEncryptionKey == "" {
+		if config.Envs.Environment == "production" {
+			log.Fatal("MFA_ENCRYPTION_KEY must be set in production")
+		}
+
+		key := make([]byte, utils.SecretKeySize)
+		if _, err := rand.Read(key); err != nil {
+			log.Fatalf("failed to generate an MFA encryption key: %v", err)
+		}
+		config.Envs.MFAEncryptionKey = base64.StdEncoding.EncodeToString(key)
+		log.Println("MFA_ENCRYPTION_KEY is not set, using a random key until the next restart")
+		return
+	}
+
+	if _, err := utils.ParseSecretKey(config.Envs.MFAEncryptionKey); err != nil {
+		log.Fatalf("invalid MFA_ENCRYPTION_KEY: %v", err)
+	}
+}
+
+func initPasswordHashing() {
+	hasher := utils.GetPasswordHasher()
+	if err := hasher.Validate(); err != nil {
+		log.Fatalf("invalid password hashing settings: %v", err)
+	}
+
+	if config.Envs.PasswordBlocklistFile == "" {
+		log.Println("PASSWORD_BLOCKLIST_FILE is not set, breached passwords won't be rejected")
+		return
+	}
+
+	blocked, err := utils.LoadPasswordBlocklist(config.Envs.PasswordBlocklistFile)
+	if err != nil {
+		log.Fatalf("failed to load password blocklist: %v", err)
+	}
+
+	policy := utils.GetPasswordPolicy()
+	policy.Blocked = blocked
+	utils.SetPasswordPolicy(policy)
+}
+
+func initTrashPurger(bookStore types.BookStore, userStore types.UserStore) {
+	if config.Envs.TrashPurgeInterval <= 0 {
+		log.Println("TRASH_PURGE_INTERVAL is not positive, deleted books and users won't be purged")
+		return
+	}
+
+	trashPurger := purger.New(
+		time.Duration(config.Envs.TrashRetention)*time.Second,
+		time.Duration(config.Envs.TrashPurgeInterval)*time.Second,
+	).
+		Add("books", bookStore).
+		Add("users", userStore)
+
+	go trashPurger.Run(context.Background())
+}
+
+func initOIDCProvider() types.OIDCProvider {
+	if config.Envs.OIDCIssuerURL == "" {
+		log.Println("OIDC_ISSUER_URL is not set, OIDC login is disabled")
+		return nil
+	}
+
+	provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+		IssuerURL:    config.Envs.OIDCIssuerURL,
+		ClientID:     config.Envs.OIDCClientID,
+		ClientSecret: config.Envs.OIDCClientSecret,
+		RedirectURL:  config.Envs.OIDCRedirectURL,
+		Scopes:       strings.Fields(config.Envs.OIDCScopes),
+	}, nil)
+	if err != nil {
+		log.Fatalf("failed to set up the OIDC provider: %v", err)
+	}
+
+	return provider
+}
+
+func initMetadataProvider() types.MetadataProvider {
+	var provider types.MetadataProvider
+	switch config.Envs.MetadataProvider {
+	case "openlibrary":
+		provider = metadata.NewOpenLibraryProvider(config.Envs.MetadataBaseURL, nil)
+	case "fixture":
+		log.Println("METADATA_PROVIDER is fixture, importing books from", config.Envs.MetadataFixturesDir)
+		fixtures, err := metadata.LoadFixtureProvider(config.Envs.MetadataFixturesDir)
+		if err != nil {
+			log.Fatalf("failed to load metadata fixtures: %v", err)
+		}
+		provider = fixtures
+	case "":
+		log.Println("METADATA_PROVIDER is not set, importing books by ISBN is disabled")
+		return nil
+	default:
+		log.Fatalf("unsupported METADATA_PROVIDER %q", config.Envs.MetadataProvider)
+		return nil
+	}
+
+	if config.Envs.MetadataCacheTTL <= 0 || config.Envs.MetadataCacheSize <= 0 {
+		return provider
+	}
+
+	return metadata.NewCachedProvider(
+		provider,
+		time.Duration(config.Envs.MetadataCacheTTL)*time.Second,
+		int(config.Envs.MetadataCacheSize),
+	)
+}
+
+func initCoverStore() types.BlobStore {
+	switch config.Envs.CoverStorage {
+	case "local":
+		log.Println("COVER_STORAGE is local, storing covers in", config.Envs.CoverDir)
+		return blobstore.NewLocalBlobStore(config.Envs.CoverDir)
+	case "s3":
+		store, err := blobstore.NewS3BlobStore(
+			config.Envs.S3Endpoint,
+			config.Envs.S3Region,
+			config.Envs.S3Bucket,
+			config.Envs.S3AccessKeyID,
+			config.Envs.S3SecretAccessKey,
+			nil,
+		)
+		if err != nil {
+			log.Fatalf("failed to configure S3 cover storage: %v", err)
+		}
+		return store
+	case "":
+		log.Println("COVER_STORAGE is not set, uploading covers is disabled")
+		return nil
+	default:
+		log.Fatalf("unsupported COVER_STORAGE %q", config.Envs.CoverStorage)
+		return nil
+	}
+}
+
+// initMailer queues emails for a background worker whatever the driver, so
+// sending one never holds up a response.
+func initMailer() types.Mailer {
+	return mailer.NewQueuedMailer(
+		initMailDriver(),
+		int(config.Envs.MailQueueSize),
+		time.Duration(config.Envs.MailSendTimeout)*time.Second,
+	)
+}
+
+func initMailDriver() types.Mailer {
+	switch config.Envs.MailDriver {
+	case "smtp":
+		return mailer.NewSMTPMailer(
+			config.Envs.SMTPHost,
+			config.Envs.SMTPPort,
+			config.Envs.SMTPUsername,
+			config.Envs.SMTPPassword,
+			config.Envs.MailFrom,
+		)
+	case "file":
+		log.Println("MAIL_DRIVER is file, writing emails to", config.Envs.MailDir)
+		return mailer.NewFileMailer(config.Envs.MailDir, config.Envs.MailFrom)
+	case "memory":
+		return mailer.NewMemoryMailer()
+	default:
+		log.Fatalf("unsupported MAIL_DRIVER %q", config.Envs.MailDriver)
+		return nil
+	}
+}
+
 func initTracer() {
 	exporter, _ := otlptracegrpc.New(
 		context.Background(),
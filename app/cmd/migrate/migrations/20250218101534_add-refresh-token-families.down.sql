@@ -0,0 +1,5 @@
+DROP TABLE security_events;
+
+DROP INDEX IF EXISTS idx_refresh_tokens_family_id;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS family_id;
\ No newline at end of file
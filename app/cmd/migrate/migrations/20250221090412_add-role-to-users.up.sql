@@ -0,0 +1,3 @@
+ALTER TABLE users
+    ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'reader'
+    CONSTRAINT users_role_check CHECK (role IN ('admin', 'librarian', 'reader'));
@@ -0,0 +1,3 @@
+DROP TABLE email_verification_tokens;
+
+ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
\ No newline at end of file
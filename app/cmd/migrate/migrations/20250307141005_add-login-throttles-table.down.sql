@@ -0,0 +1 @@
+DROP TABLE login_throttles;
\ No newline at end of file
@@ -0,0 +1,9 @@
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS scope;
+
+ALTER TABLE refresh_tokens DROP COLUMN IF EXISTS client_id;
+
+DROP TABLE oauth_consents;
+
+DROP TABLE oauth_authorization_codes;
+
+DROP TABLE oauth_clients;
\ No newline at end of file
@@ -0,0 +1 @@
+DROP TABLE admin_audit_logs;
\ No newline at end of file
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_books_deleted_at;
+
+DROP INDEX IF EXISTS idx_users_deleted_at;
+
+ALTER TABLE books DROP COLUMN IF EXISTS disabled_by_admin;
+
+ALTER TABLE users DROP COLUMN IF EXISTS disabled_by_admin;
\ No newline at end of file
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_users_books_book_id;
+
+DROP INDEX IF EXISTS idx_users_books_user_id_book_id;
+
+ALTER TABLE users_books DROP COLUMN IF EXISTS permission;
\ No newline at end of file
@@ -0,0 +1,7 @@
+ALTER TABLE users_books
+    ADD COLUMN IF NOT EXISTS permission VARCHAR(10) NOT NULL DEFAULT 'owner'
+    CONSTRAINT users_books_permission_check CHECK (permission IN ('owner', 'editor', 'viewer'));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_books_user_id_book_id ON users_books (user_id, book_id);
+
+CREATE INDEX IF NOT EXISTS idx_users_books_book_id ON users_books (book_id);
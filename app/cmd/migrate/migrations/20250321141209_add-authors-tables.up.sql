@@ -0,0 +1,37 @@
+CREATE TABLE IF NOT EXISTS authors (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    name_key VARCHAR(255) NOT NULL UNIQUE,
+    bio TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS book_authors (
+    book_id INT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    author_id INT NOT NULL REFERENCES authors (id) ON DELETE RESTRICT,
+    role VARCHAR(20) NOT NULL DEFAULT 'author'
+        CONSTRAINT book_authors_role_check CHECK (role IN ('author', 'translator', 'illustrator')),
+    position INT NOT NULL DEFAULT 0,
+    PRIMARY KEY (book_id, author_id, role)
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_authors_author_id ON book_authors (author_id);
+
+-- name_key must match utils.AuthorNameKey: lower case, letters and digits
+-- only, so "J. R. R. Tolkien" and "J.R.R. Tolkien" become one author.
+INSERT INTO authors (name, name_key)
+SELECT DISTINCT ON (name_key) name, name_key
+FROM (
+    SELECT id, TRIM(author) AS name, LOWER(REGEXP_REPLACE(author, '[^[:alnum:]]+', '', 'g')) AS name_key
+    FROM books
+) keyed_books
+WHERE name_key <> ''
+ORDER BY name_key, id
+ON CONFLICT (name_key) DO NOTHING;
+
+INSERT INTO book_authors (book_id, author_id, role)
+SELECT b.id, a.id, 'author'
+FROM books b
+INNER JOIN authors a ON a.name_key = LOWER(REGEXP_REPLACE(b.author, '[^[:alnum:]]+', '', 'g'))
+ON CONFLICT DO NOTHING;
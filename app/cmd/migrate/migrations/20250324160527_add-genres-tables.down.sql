@@ -0,0 +1,14 @@
+ALTER TABLE books ADD COLUMN IF NOT EXISTS genres TEXT[];
+
+UPDATE books b
+SET genres = ARRAY(
+    SELECT g.name
+    FROM book_genres bg
+    INNER JOIN genres g ON g.id = bg.genre_id
+    WHERE bg.book_id = b.id
+    ORDER BY g.name
+);
+
+DROP TABLE IF EXISTS book_genres;
+
+DROP TABLE IF EXISTS genres;
\ No newline at end of file
@@ -0,0 +1,42 @@
+CREATE TABLE IF NOT EXISTS genres (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    slug VARCHAR(100) NOT NULL UNIQUE,
+    parent_id INT REFERENCES genres (id) ON DELETE RESTRICT,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP,
+    CONSTRAINT genres_parent_id_check CHECK (parent_id <> id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_genres_parent_id ON genres (parent_id);
+
+CREATE TABLE IF NOT EXISTS book_genres (
+    book_id INT NOT NULL REFERENCES books (id) ON DELETE CASCADE,
+    genre_id INT NOT NULL REFERENCES genres (id) ON DELETE RESTRICT,
+    PRIMARY KEY (book_id, genre_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_book_genres_genre_id ON book_genres (genre_id);
+
+-- slug must match utils.GenreSlug: lower case, runs of anything but letters
+-- and digits turned into a single dash, so "Sci-Fi" and "sci fi" become one
+-- genre. Every existing genre starts at the top of the hierarchy.
+INSERT INTO genres (name, slug)
+SELECT DISTINCT ON (slug) name, slug
+FROM (
+    SELECT b.id, TRIM(g.name) AS name, TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(g.name, '[^[:alnum:]]+', '-', 'g'))) AS slug
+    FROM books b
+    CROSS JOIN LATERAL UNNEST(b.genres) AS g(name)
+) slugged_genres
+WHERE slug <> ''
+ORDER BY slug, id
+ON CONFLICT (slug) DO NOTHING;
+
+INSERT INTO book_genres (book_id, genre_id)
+SELECT b.id, gr.id
+FROM books b
+CROSS JOIN LATERAL UNNEST(b.genres) AS g(name)
+INNER JOIN genres gr ON gr.slug = TRIM(BOTH '-' FROM LOWER(REGEXP_REPLACE(g.name, '[^[:alnum:]]+', '-', 'g')))
+ON CONFLICT DO NOTHING;
+
+ALTER TABLE books DROP COLUMN IF EXISTS genres;
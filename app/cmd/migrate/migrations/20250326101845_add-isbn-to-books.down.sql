@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_books_isbn_13;
+
+DROP INDEX IF EXISTS idx_books_owner_id_isbn_13;
+
+ALTER TABLE books
+    DROP COLUMN IF EXISTS isbn_13,
+    DROP COLUMN IF EXISTS isbn_10,
+    DROP COLUMN IF EXISTS owner_id;
\ No newline at end of file
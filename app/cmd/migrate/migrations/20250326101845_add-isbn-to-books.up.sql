@@ -0,0 +1,17 @@
+ALTER TABLE books ADD COLUMN IF NOT EXISTS owner_id INT;
+
+UPDATE books b
+SET owner_id = ub.user_id
+FROM users_books ub
+WHERE ub.book_id = b.id
+AND ub.permission = 'owner';
+
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS isbn_10 VARCHAR(10),
+    ADD COLUMN IF NOT EXISTS isbn_13 VARCHAR(13);
+
+-- A library holds a single copy of each ISBN. Books in the trash are left out
+-- so that a deleted book can be added again.
+CREATE UNIQUE INDEX IF NOT EXISTS idx_books_owner_id_isbn_13 ON books (owner_id, isbn_13) WHERE isbn_13 IS NOT NULL AND deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_books_isbn_13 ON books (isbn_13) WHERE isbn_13 IS NOT NULL;
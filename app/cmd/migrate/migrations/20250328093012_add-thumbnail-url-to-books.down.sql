@@ -0,0 +1 @@
+ALTER TABLE books DROP COLUMN IF EXISTS thumbnail_url;
\ No newline at end of file
@@ -0,0 +1,3 @@
+-- Set with image_url when a cover is uploaded, and cleared when image_url is
+-- changed to another URL.
+ALTER TABLE books ADD COLUMN IF NOT EXISTS thumbnail_url VARCHAR(255);
@@ -0,0 +1,3 @@
+-- Access tokens issued before this time are refused, so that logging a user
+-- out or disabling them takes effect before their tokens expire.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tokens_valid_after TIMESTAMP;
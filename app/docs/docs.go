@@ -15,103 +15,96 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/auth/login": {
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Publica as chaves públicas usadas para verificar os tokens emitidos pela API, incluindo as chaves anteriores ainda aceitas durante uma rotação. Com tokens assinados pelo segredo HS256 compartilhado, a lista é vazia. Servido na raiz do servidor, fora de /api/v1.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Auth"
                 ],
-                "summary": "Realizar login do usuário",
-                "parameters": [
-                    {
-                        "description": "Dados para login do usuário",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/types.UserLoginPayload"
-                        }
-                    }
-                ],
+                "summary": "Obter chaves públicas (JWKS)",
                 "responses": {
                     "200": {
-                        "description": "Tokens de acesso e refresh",
-                        "schema": {
-                            "$ref": "#/definitions/types.UserLoginResponse"
-                        }
-                    },
-                    "400": {
-                        "description": "Validation errors for payload",
-                        "schema": {
-                            "$ref": "#/definitions/types.BadRequestStructResponse"
-                        }
-                    },
-                    "404": {
-                        "description": "No user found with the given email",
-                        "schema": {
-                            "$ref": "#/definitions/types.NotFoundResponse"
-                        }
-                    },
-                    "500": {
-                        "description": "An unexpected error occurred",
+                        "description": "Chaves públicas",
                         "schema": {
-                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                            "$ref": "#/definitions/types.JWKSResponse"
                         }
                     }
                 }
             }
         },
-        "/auth/refresh": {
-            "post": {
-                "consumes": [
-                    "application/json"
+        "/admin/audit-logs": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Lista as ações feitas pela API de administração, das mais recentes para as mais antigas. Apenas administradores.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Auth"
+                    "Admin"
                 ],
-                "summary": "Atualizar tokens (Refresh Token)",
+                "summary": "Listar o registro de auditoria",
                 "parameters": [
                     {
-                        "description": "Payload contendo o refresh token",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/types.RefreshTokenPayload"
-                        }
+                        "type": "integer",
+                        "description": "ID de quem executou a ação",
+                        "name": "actor_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user ou book",
+                        "name": "target_type",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID do alvo, usado junto com target_type",
+                        "name": "target_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Página (padrão 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Itens por página (padrão 20, máximo 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Novos tokens de acesso e refresh",
+                        "description": "Registro de auditoria",
                         "schema": {
-                            "$ref": "#/definitions/types.UpdateRefreshTokenResponse"
+                            "$ref": "#/definitions/types.GetAuditLogsResponse"
                         }
                     },
                     "400": {
-                        "description": "Validation errors for payload",
+                        "description": "Validation errors for query parameters",
                         "schema": {
                             "$ref": "#/definitions/types.BadRequestStructResponse"
                         }
                     },
                     "401": {
-                        "description": "Refresh token is invalid or has been expired",
+                        "description": "Unauthorized",
                         "schema": {
                             "$ref": "#/definitions/types.UnauthorizedResponse"
                         }
                     },
-                    "404": {
-                        "description": "No refresh token found with the given user ID",
+                    "403": {
+                        "description": "Forbidden",
                         "schema": {
-                            "$ref": "#/definitions/types.NotFoundResponse"
+                            "$ref": "#/definitions/types.ForbiddenResponse"
                         }
                     },
                     "500": {
@@ -119,32 +112,68 @@ const docTemplate = `{
                         "schema": {
                             "$ref": "#/definitions/types.InternalServerErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
                     }
                 }
             }
         },
-        "/books": {
+        "/admin/books": {
             "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lista todos os livros, de qualquer usuário e incluindo os desativados. Apenas administradores.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Admin"
+                ],
+                "summary": "Listar todos os livros",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Página (padrão 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Itens por página (padrão 20, máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
                 ],
-                "summary": "Listar livros",
                 "responses": {
                     "200": {
                         "description": "Lista de livros",
                         "schema": {
-                            "$ref": "#/definitions/types.GetBooksResponse"
+                            "$ref": "#/definitions/types.GetAllBooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
                         }
                     },
                     "500": {
@@ -160,45 +189,57 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/admin/books/{id}/disable": {
             "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Admin"
                 ],
-                "summary": "Criar novo livro",
+                "summary": "Desativar um livro",
                 "parameters": [
                     {
-                        "description": "Dados do livro",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/types.CreateBookPayload"
-                        }
+                        "type": "integer",
+                        "description": "ID do livro",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "ID do novo livro",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Book ID must be a positive integer",
                         "schema": {
-                            "$ref": "#/definitions/types.CreateBookResponse"
+                            "$ref": "#/definitions/types.BadRequestResponse"
                         }
                     },
-                    "400": {
-                        "description": "Validation errors for payload",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No active book found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
                         }
                     },
                     "500": {
@@ -216,23 +257,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/books/{id}": {
-            "get": {
+        "/admin/books/{id}/restore": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Admin"
                 ],
-                "summary": "Obter livro por ID",
+                "summary": "Reativar um livro",
                 "parameters": [
                     {
                         "type": "integer",
@@ -243,11 +281,8 @@ const docTemplate = `{
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "Detalhes do livro",
-                        "schema": {
-                            "$ref": "#/definitions/types.Book"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
                         "description": "Book ID must be a positive integer",
@@ -255,12 +290,30 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.BadRequestResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
                     "404": {
-                        "description": "No book found with given ID",
+                        "description": "No disabled book found with given ID",
                         "schema": {
                             "$ref": "#/definitions/types.NotFoundResponse"
                         }
                     },
+                    "409": {
+                        "description": "Another book with this ISBN is already in the owner's library",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
                     "500": {
                         "description": "An unexpected error occurred",
                         "schema": {
@@ -274,58 +327,66 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/admin/users": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Lista todos os usuários, incluindo os desativados. Apenas administradores.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Admin"
                 ],
-                "summary": "Atualizar livro por ID",
+                "summary": "Listar todos os usuários",
                 "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trecho do username ou do email",
+                        "name": "search",
+                        "in": "query"
+                    },
                     {
                         "type": "integer",
-                        "description": "ID do livro a ser atualizado",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "Página (padrão 1)",
+                        "name": "page",
+                        "in": "query"
                     },
                     {
-                        "description": "Dados para atualização do livro",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/types.UpdateBookPayload"
-                        }
+                        "type": "integer",
+                        "description": "Itens por página (padrão 20, máximo 100)",
+                        "name": "limit",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Livro atualizado",
+                        "description": "Lista de usuários",
                         "schema": {
-                            "$ref": "#/definitions/types.Book"
+                            "$ref": "#/definitions/types.GetUsersResponse"
                         }
                     },
                     "400": {
-                        "description": "Validation errors for payload",
+                        "description": "Validation errors for query parameters",
                         "schema": {
                             "$ref": "#/definitions/types.BadRequestStructResponse"
                         }
                     },
-                    "404": {
-                        "description": "No book found with given ID",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/types.NotFoundResponse"
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
                         }
                     },
                     "500": {
@@ -341,44 +402,59 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/users/{id}": {
+            "get": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Retorna qualquer usuário, incluindo os desativados. Apenas administradores.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Books"
+                    "Admin"
                 ],
-                "summary": "Excluir livro por ID",
+                "summary": "Consultar um usuário",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "ID do livro a ser excluído",
+                        "description": "ID do usuário",
                         "name": "id",
                         "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
-                    "204": {
-                        "description": "No Content"
+                    "200": {
+                        "description": "Usuário",
+                        "schema": {
+                            "$ref": "#/definitions/types.UserResponse"
+                        }
                     },
                     "400": {
-                        "description": "Book ID must be a positive integer",
+                        "description": "User ID must be a positive integer",
                         "schema": {
                             "$ref": "#/definitions/types.BadRequestResponse"
                         }
                     },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
                     "404": {
-                        "description": "No book found with given ID",
+                        "description": "No user found with given ID",
                         "schema": {
                             "$ref": "#/definitions/types.NotFoundResponse"
                         }
@@ -396,35 +472,36 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/users": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Retrieves user details based on the authenticated user's ID extracted from the request context.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Remove o usuário e todos os seus dados, incluindo os livros que só ele possui e suas capas enviadas. Não pode ser desfeito.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
+                ],
+                "summary": "Excluir um usuário definitivamente",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do usuário",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Get user by ID",
                 "responses": {
-                    "200": {
-                        "description": "User details successfully retrieved",
-                        "schema": {
-                            "$ref": "#/definitions/types.UserResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": "Bad request",
+                        "description": "User ID must be a positive integer",
                         "schema": {
                             "$ref": "#/definitions/types.BadRequestResponse"
                         }
@@ -435,57 +512,63 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.UnauthorizedResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
                     "404": {
-                        "description": "User not found",
+                        "description": "No user found with given ID",
                         "schema": {
                             "$ref": "#/definitions/types.NotFoundResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "An unexpected error occurred",
                         "schema": {
                             "$ref": "#/definitions/types.InternalServerErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
                     }
                 }
-            },
-            "put": {
+            }
+        },
+        "/admin/users/{id}/disable": {
+            "post": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Updates user details based on the authenticated user's ID extracted from the request context.",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "O usuário não consegue mais entrar e os access tokens já emitidos são recusados na hora.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Update user by ID",
+                "summary": "Desativar um usuário",
                 "parameters": [
                     {
-                        "description": "User update payload",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/types.UpdateUserPayload"
-                        }
+                        "type": "integer",
+                        "description": "ID do usuário",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "User successfully updated",
-                        "schema": {
-                            "$ref": "#/definitions/types.UserResponse"
-                        }
+                    "204": {
+                        "description": "No Content"
                     },
                     "400": {
-                        "description": "Invalid request body",
+                        "description": "User ID must be a positive integer",
                         "schema": {
                             "$ref": "#/definitions/types.BadRequestResponse"
                         }
@@ -496,53 +579,149 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.UnauthorizedResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
                     "404": {
-                        "description": "User not found",
+                        "description": "No user found with given ID",
                         "schema": {
                             "$ref": "#/definitions/types.NotFoundResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "An unexpected error occurred",
                         "schema": {
                             "$ref": "#/definitions/types.InternalServerErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
                     }
                 }
-            },
+            }
+        },
+        "/admin/users/{id}/logout": {
             "post": {
-                "consumes": [
-                    "application/json"
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
                 ],
+                "description": "Revoga todos os refresh tokens do usuário, incluindo os emitidos para aplicativos OAuth, e recusa na hora os access tokens já emitidos. Chaves de API não são afetadas.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Admin"
                 ],
-                "summary": "Criar um novo usuário",
+                "summary": "Encerrar as sessões de um usuário",
                 "parameters": [
                     {
-                        "description": "Payload contendo os dados do novo usuário",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
+                        "type": "integer",
+                        "description": "ID do usuário",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "User ID must be a positive integer",
                         "schema": {
-                            "$ref": "#/definitions/types.CreateUserRequestPayload"
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No user found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
                         }
                     }
+                }
+            }
+        },
+        "/admin/users/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reativar um usuário",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do usuário",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Usuário criado com sucesso",
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "User ID must be a positive integer",
                         "schema": {
-                            "$ref": "#/definitions/types.CreateUserResponse"
+                            "$ref": "#/definitions/types.BadRequestResponse"
                         }
                     },
-                    "400": {
-                        "description": "Validation errors for payload",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No disabled user found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
                         }
                     },
                     "500": {
@@ -558,21 +737,56 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/admin/users/{id}/role": {
+            "put": {
                 "security": [
                     {
                         "BearerAuth": []
                     }
                 ],
-                "description": "Deletes the user associated with the authenticated user's ID extracted from the request context.",
+                "description": "Os access tokens já emitidos para o usuário deixam de valer, pois carregam o papel anterior. As sessões recebem o novo papel ao renovar o access token.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Users"
+                    "Admin"
+                ],
+                "summary": "Alterar o papel de um usuário",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do usuário",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Novo papel do usuário",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.UpdateUserRolePayload"
+                        }
+                    }
                 ],
-                "summary": "Delete user by ID",
                 "responses": {
-                    "204": {
-                        "description": "User successfully deleted"
+                    "200": {
+                        "description": "Usuário atualizado",
+                        "schema": {
+                            "$ref": "#/definitions/types.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
                     },
                     "401": {
                         "description": "Unauthorized",
@@ -580,189 +794,5373 @@ const docTemplate = `{
                             "$ref": "#/definitions/types.UnauthorizedResponse"
                         }
                     },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
                     "404": {
-                        "description": "User not found",
+                        "description": "No user found with given ID",
                         "schema": {
                             "$ref": "#/definitions/types.NotFoundResponse"
                         }
                     },
                     "500": {
-                        "description": "Internal server error",
+                        "description": "An unexpected error occurred",
                         "schema": {
                             "$ref": "#/definitions/types.InternalServerErrorResponse"
                         }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/login": {
+            "post": {
+                "description": "Quando o usuário tem MFA ativo, retorna um token de desafio (mfa_token) que deve ser trocado pelos tokens em /auth/mfa/verify.\nTentativas com falha são contadas por conta e por IP. Ao atingir o limite, o login fica bloqueado por um tempo que dobra a cada nova falha.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Realizar login do usuário",
+                "parameters": [
+                    {
+                        "description": "Dados para login do usuário",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.UserLoginPayload"
+                        }
                     }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens de acesso e refresh, ou token de desafio MFA",
+                        "schema": {
+                            "$ref": "#/definitions/types.UserLoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid email or password",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Email address is not verified",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts, try again later",
+                        "schema": {
+                            "$ref": "#/definitions/types.TooManyRequestsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoga o refresh token informado e invalida o access token usado na requisição até a sua expiração.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Encerrar a sessão atual",
+                "parameters": [
+                    {
+                        "description": "Refresh token da sessão a ser encerrada",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.RefreshTokenPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Refresh token is invalid or has been expired",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/logout-all": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Revoga todos os refresh tokens do usuário e invalida todos os access tokens emitidos até agora, inclusive o usado na requisição.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Encerrar todas as sessões",
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/mfa/confirm": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Ativa o MFA a partir de um código gerado pelo aplicativo autenticador e retorna códigos de recuperação de uso único. Os códigos não podem ser consultados novamente.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Confirmar cadastro de MFA",
+                "parameters": [
+                    {
+                        "description": "Código TOTP atual",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ConfirmMFAPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Códigos de recuperação",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConfirmMFAResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No MFA enrollment found",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "MFA is already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/mfa/enroll": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Gera um segredo TOTP (RFC 6238) para o usuário autenticado. O MFA só é ativado após a confirmação com um código em /auth/mfa/confirm.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Iniciar cadastro de MFA",
+                "responses": {
+                    "200": {
+                        "description": "Segredo TOTP e URI de provisionamento",
+                        "schema": {
+                            "$ref": "#/definitions/types.EnrollMFAResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "MFA is already enabled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/mfa/verify": {
+            "post": {
+                "description": "Troca o token de desafio retornado pelo login e um código TOTP ou de recuperação pelos tokens de acesso e refresh. O token de desafio só pode ser usado uma vez, mesmo que o código esteja errado.\nCódigos errados são contados por usuário. Ao atingir o limite, a verificação fica bloqueada por um tempo que dobra a cada nova falha.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Concluir login com MFA",
+                "parameters": [
+                    {
+                        "description": "Token de desafio e código",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.VerifyMFAPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens de acesso e refresh",
+                        "schema": {
+                            "$ref": "#/definitions/types.UserLoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid MFA code",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Email address is not verified",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts, try again later",
+                        "schema": {
+                            "$ref": "#/definitions/types.TooManyRequestsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/callback": {
+            "get": {
+                "description": "Recebe o retorno do provedor OIDC, valida o ID token e emite os tokens de acesso e refresh. No primeiro login a identidade é vinculada ao usuário com o mesmo email, desde que o provedor o tenha verificado. Usuários com MFA ativo recebem um token de desafio.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Concluir login com provedor OIDC",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Código de autorização",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Estado gerado em /auth/oidc/login",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens de acesso e refresh, ou token de desafio MFA",
+                        "schema": {
+                            "$ref": "#/definitions/types.UserLoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Login state is invalid or has expired",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Identity provider login failed",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "No account is associated with this email",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "OIDC login is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/oidc/login": {
+            "get": {
+                "description": "Redireciona para o provedor OpenID Connect configurado usando o fluxo authorization code com PKCE.",
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Iniciar login com provedor OIDC",
+                "responses": {
+                    "302": {
+                        "description": "Redirecionamento para o provedor"
+                    },
+                    "404": {
+                        "description": "OIDC login is not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/forgot": {
+            "post": {
+                "description": "Envia um link de redefinição de senha para o email informado. A resposta é a mesma quer o email esteja cadastrado ou não.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Solicitar redefinição de senha",
+                "parameters": [
+                    {
+                        "description": "Email da conta",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ForgotPasswordPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Reset link sent if the email is registered",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForgotPasswordResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/password/reset": {
+            "post": {
+                "description": "Define uma nova senha usando o token recebido por email. O token só pode ser usado uma vez e todas as sessões do usuário são encerradas, inclusive os access tokens já emitidos.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Redefinir senha",
+                "parameters": [
+                    {
+                        "description": "Token de redefinição e nova senha",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ResetPasswordPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/refresh": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Atualizar tokens (Refresh Token)",
+                "parameters": [
+                    {
+                        "description": "Payload contendo o refresh token",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.RefreshTokenPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Novos tokens de acesso e refresh",
+                        "schema": {
+                            "$ref": "#/definitions/types.UpdateRefreshTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Refresh token is invalid or has been expired",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Email address is not verified",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/restore": {
+            "post": {
+                "description": "Reativa uma conta excluída pelo próprio usuário, desde que dentro do período de retenção. Exige email e senha, e as tentativas com falha contam para o mesmo limite do login. Contas desativadas por um administrador não podem ser restauradas por aqui.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Restaurar conta excluída",
+                "parameters": [
+                    {
+                        "description": "Email e senha da conta",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.RestoreAccountPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Conta restaurada",
+                        "schema": {
+                            "$ref": "#/definitions/types.RestoreAccountResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Invalid email or password",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts, try again later",
+                        "schema": {
+                            "$ref": "#/definitions/types.TooManyRequestsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lista as sessões (refresh tokens) ativas do usuário autenticado, uma por dispositivo.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Listar sessões ativas",
+                "responses": {
+                    "200": {
+                        "description": "Sessões ativas",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetSessionsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/auth/sessions/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Auth"
+                ],
+                "summary": "Revogar uma sessão",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID da sessão",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Session ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No session found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/authors": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authors"
+                ],
+                "summary": "Listar autores",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Trecho do nome do autor",
+                        "name": "search",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Página (padrão 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Itens por página (padrão 20, máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Lista de autores",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetAuthorsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cadastra um autor no catálogo compartilhado. Nomes que só diferem em maiúsculas, espaços ou pontuação são considerados o mesmo autor.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authors"
+                ],
+                "summary": "Criar autor",
+                "parameters": [
+                    {
+                        "description": "Dados do autor",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateAuthorPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "ID do novo autor",
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateAuthorResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "An author with this name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/authors/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authors"
+                ],
+                "summary": "Obter autor por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do autor",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Detalhes do autor",
+                        "schema": {
+                            "$ref": "#/definitions/types.Author"
+                        }
+                    },
+                    "400": {
+                        "description": "Author ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No author found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Atualiza o nome e a biografia de um autor. Apenas administradores e bibliotecários.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authors"
+                ],
+                "summary": "Atualizar autor por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do autor",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dados do autor",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.UpdateAuthorPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Autor atualizado",
+                        "schema": {
+                            "$ref": "#/definitions/types.Author"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No author found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "An author with this name already exists",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Exclui um autor que não está creditado em nenhum livro. Apenas administradores e bibliotecários.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Authors"
+                ],
+                "summary": "Excluir autor por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do autor",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Author ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No author found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The author is still credited on books",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Listar livros",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Página (padrão 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Itens por página (padrão 20, máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar por autor (busca parcial)",
+                        "name": "author",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Filtrar pelo ID de um autor creditado no livro",
+                        "name": "author_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filtrar por gênero (slug ou nome), incluindo seus subgêneros",
+                        "name": "genre",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ano de lançamento mínimo",
+                        "name": "release_year_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Ano de lançamento máximo",
+                        "name": "release_year_max",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Número mínimo de páginas",
+                        "name": "pages_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Número máximo de páginas",
+                        "name": "pages_max",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "name",
+                            "release_year",
+                            "created_at"
+                        ],
+                        "type": "string",
+                        "description": "Campo de ordenação",
+                        "name": "sort",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "asc",
+                            "desc"
+                        ],
+                        "type": "string",
+                        "description": "Direção da ordenação",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Lista de livros",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetBooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "image_url é opcional: a capa pode ser enviada depois em PUT /books/{id}/cover.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Criar novo livro",
+                "parameters": [
+                    {
+                        "description": "Dados do livro",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateBookPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "ID do novo livro",
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateBookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "ISBN-10 and ISBN-13 do not match",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A book with this ISBN is already in your library",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/import/isbn": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cria um livro com os dados do catálogo externo para o ISBN informado. Os demais campos, quando enviados, substituem os do catálogo ou completam os que ele não tem.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Importar livro por ISBN",
+                "parameters": [
+                    {
+                        "description": "ISBN, gêneros e campos a substituir",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ImportBookByISBNPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "ID do novo livro",
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateBookResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "One or more genres do not exist",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No catalog entry found for given ISBN",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A book with this ISBN is already in your library",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "502": {
+                        "description": "The book catalog is unavailable",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadGatewayResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/isbn/{isbn}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Busca o livro com o ISBN informado entre os livros do usuário e os compartilhados com ele, priorizando os do próprio usuário. Aceita ISBN-10 ou ISBN-13, com ou sem hífens.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Obter livro por ISBN",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ISBN-10 ou ISBN-13 do livro",
+                        "name": "isbn",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Detalhes do livro",
+                        "schema": {
+                            "$ref": "#/definitions/types.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "ISBN is not valid",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No book found with given ISBN",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/search": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Busca textual (com correspondência por prefixo) no nome, descrição e autor dos livros do usuário, ordenada por relevância. O snippet é HTML escapado, com os termos encontrados entre tags \u003cmark\u003e.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Buscar livros por texto",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Texto a ser buscado",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Página (padrão 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Itens por página (padrão 20, máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Livros encontrados",
+                        "schema": {
+                            "$ref": "#/definitions/types.SearchBooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/trash": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lista os livros excluídos pelo usuário que ainda podem ser restaurados, dos mais recentes para os mais antigos. Após o período de retenção eles são removidos definitivamente.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Listar livros na lixeira",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Página (padrão 1)",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Itens por página (padrão 20, máximo 100)",
+                        "name": "limit",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Livros na lixeira",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetBooksResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for query parameters",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Obter livro por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do livro",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Detalhes do livro",
+                        "schema": {
+                            "$ref": "#/definitions/types.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "Book ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No book found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Atualizar livro por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do livro a ser atualizado",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dados para atualização do livro",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.UpdateBookPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Livro atualizado",
+                        "schema": {
+                            "$ref": "#/definitions/types.Book"
+                        }
+                    },
+                    "400": {
+                        "description": "ISBN-10 and ISBN-13 do not match",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "The book is shared with the user as viewer",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No book found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Another book with this ISBN is already in the owner's library",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Excluir livro por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do livro a ser excluído",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Book ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only the owner can delete the book",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No book found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/authors": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lista os autores creditados no livro, com o papel de cada um, na ordem em que são creditados.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Listar autores do livro",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do livro",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Autores do livro",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetBookAuthorsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Book ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No book found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Substitui os autores creditados no livro, na ordem enviada. O campo author do livro passa a listar os nomes dos que têm o papel author. Donos e editores do livro podem alterá-los.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Definir autores do livro",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do livro",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Autores e seus papéis",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.SetBookAuthorsPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Autores do livro",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetBookAuthorsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "The book is shared with the user as viewer",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No book or author found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/cover": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Envia a imagem da capa do livro (JPEG, PNG ou GIF), que é convertida em JPEG em tamanho completo (até 1200x1800) e em miniatura (até 200x300). O tipo da imagem é detectado pelo conteúdo, não pelo nome ou cabeçalho do arquivo. image_url e thumbnail_url do livro passam a apontar para as capas enviadas. Donos e editores do livro podem alterá-la.",
+                "consumes": [
+                    "multipart/form-data"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Enviar capa do livro",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do livro",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Imagem da capa",
+                        "name": "cover",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "URLs da capa",
+                        "schema": {
+                            "$ref": "#/definitions/types.BookCover"
+                        }
+                    },
+                    "400": {
+                        "description": "Book ID must be a positive integer, body is not multipart or has no cover file, or the image could not be read",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "The book is shared with the user as viewer",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No book found with given ID or cover uploads are not enabled",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "413": {
+                        "description": "The cover is too large",
+                        "schema": {
+                            "$ref": "#/definitions/types.PayloadTooLargeResponse"
+                        }
+                    },
+                    "415": {
+                        "description": "The cover must be a JPEG, PNG or GIF image",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnsupportedMediaTypeResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/restore": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Restaura um livro excluído pelo usuário dentro do período de retenção. Livros desativados por um administrador não podem ser restaurados por aqui.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Restaurar livro da lixeira",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do livro a ser restaurado",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Book ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No deleted book found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Another book with this ISBN is already in your library",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/shares": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lista quem tem acesso a um livro do usuário, incluindo o próprio dono.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Listar compartilhamentos do livro",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do livro",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Usuários com acesso ao livro",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetBookSharesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Book ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only the owner can list the shares",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No book found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Compartilha um livro do usuário com outro usuário, identificado pelo email, como editor ou leitor. Compartilhar de novo com o mesmo usuário altera a permissão dele. Editores podem atualizar o livro, leitores apenas visualizá-lo; só o dono pode excluí-lo.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Compartilhar livro",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do livro a ser compartilhado",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Email do usuário e permissão",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ShareBookPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Compartilhamento criado ou atualizado",
+                        "schema": {
+                            "$ref": "#/definitions/types.BookShare"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Only the owner can share the book",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No book or user found",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The book can't be shared with its owner",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/books/{id}/shares/{userId}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove o acesso de um usuário a um livro. O dono pode revogar qualquer compartilhamento, e um usuário pode remover o próprio acesso a um livro compartilhado com ele.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Revogar compartilhamento do livro",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do livro",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "ID do usuário que perderá o acesso",
+                        "name": "userId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Book ID or user ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No share found for the given book and user",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/covers/{key}": {
+            "get": {
+                "description": "Devolve uma capa enviada por PUT /books/{id}/cover. Cada envio gera uma URL nova, então a resposta pode ser guardada em cache indefinidamente.",
+                "produces": [
+                    "image/jpeg"
+                ],
+                "tags": [
+                    "Books"
+                ],
+                "summary": "Obter capa de livro",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Caminho da capa, como aparece em image_url e thumbnail_url",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Imagem da capa",
+                        "schema": {
+                            "type": "file"
+                        }
+                    },
+                    "304": {
+                        "description": "A capa não mudou desde a versão em cache (If-None-Match)"
+                    },
+                    "404": {
+                        "description": "No cover found",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lista todos os gêneros em ordem alfabética. A hierarquia é dada pelo parent_id de cada um.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Listar gêneros",
+                "responses": {
+                    "200": {
+                        "description": "Lista de gêneros",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetGenresResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cadastra um gênero, opcionalmente como subgênero de outro. O slug é gerado a partir do nome quando não informado. Apenas administradores.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Criar gênero",
+                "parameters": [
+                    {
+                        "description": "Dados do gênero",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateGenrePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "ID do novo gênero",
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateGenreResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A genre with this slug already exists",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/genres/{id}": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Obter gênero por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do gênero",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Detalhes do gênero",
+                        "schema": {
+                            "$ref": "#/definitions/types.Genre"
+                        }
+                    },
+                    "400": {
+                        "description": "Genre ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No genre found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Renomeia um gênero ou o move para outro gênero pai. Um parent_id nulo o leva para o topo da hierarquia. Apenas administradores.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Atualizar gênero por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do gênero",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Dados do gênero",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.UpdateGenrePayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Gênero atualizado",
+                        "schema": {
+                            "$ref": "#/definitions/types.Genre"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No genre found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "A genre with this slug already exists ou A genre can't be nested under itself or its subgenres",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Exclui um gênero sem subgêneros e sem livros. Apenas administradores.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Genres"
+                ],
+                "summary": "Excluir gênero por ID",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID do gênero",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Genre ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No genre found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "The genre still has subgenres or books",
+                        "schema": {
+                            "$ref": "#/definitions/types.ConflictResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/authorize": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Valida o pedido de autorização de um aplicativo e retorna o que deve ser exibido na tela de consentimento.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Validar pedido de autorização OAuth",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Deve ser code",
+                        "name": "response_type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do aplicativo",
+                        "name": "client_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "URI de retorno registrada",
+                        "name": "redirect_uri",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Escopos separados por espaço",
+                        "name": "scope",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Valor devolvido ao aplicativo",
+                        "name": "state",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Desafio PKCE",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Deve ser S256",
+                        "name": "code_challenge_method",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Dados para a tela de consentimento",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthAuthorizeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Redirect URI is not registered for this client",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Registra a decisão do usuário. Quando aprovado, grava o consentimento e retorna a URI de retorno com o código de autorização; quando negado, a URI de retorno leva error=access_denied.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Responder pedido de autorização OAuth",
+                "parameters": [
+                    {
+                        "description": "Pedido de autorização e decisão do usuário",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthConsentPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "URI para onde o usuário deve ser redirecionado",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthRedirectResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/clients": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Listar aplicativos OAuth",
+                "responses": {
+                    "200": {
+                        "description": "Aplicativos registrados",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetOAuthClientsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Registra um aplicativo de terceiros. O client_secret de clientes confidenciais só é retornado nesta resposta.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Registrar aplicativo OAuth",
+                "parameters": [
+                    {
+                        "description": "Dados do aplicativo",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateOAuthClientPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Aplicativo registrado",
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateOAuthClientResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/clients/{client_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove o aplicativo junto com os consentimentos e tokens emitidos para ele.",
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Remover aplicativo OAuth",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do aplicativo",
+                        "name": "client_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Aplicativo removido"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/types.ForbiddenResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No OAuth client found",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/consents": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Lista os aplicativos de terceiros aos quais o usuário autenticado concedeu acesso.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Listar aplicativos autorizados",
+                "responses": {
+                    "200": {
+                        "description": "Consentimentos concedidos",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetOAuthConsentsResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/consents/{client_id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Remove o consentimento dado ao aplicativo e revoga os refresh tokens que ele tem do usuário.",
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Revogar acesso de um aplicativo",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "ID do aplicativo",
+                        "name": "client_id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Acesso revogado"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No consent found for this client",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/introspect": {
+            "post": {
+                "description": "Introspecção de token (RFC 7662). Exige um cliente confidencial e só revela tokens emitidos para ele; os demais são reportados como inativos.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Inspecionar token OAuth",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access ou refresh token",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "access_token ou refresh_token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Estado do token",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthIntrospectionResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_request",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid_client",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/revoke": {
+            "post": {
+                "description": "Revogação de token (RFC 7009). Revogar um refresh token encerra a concessão inteira. Tokens inválidos ou de outros clientes são ignorados e a resposta é sempre 200.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Revogar token OAuth",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access ou refresh token",
+                        "name": "token",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "access_token ou refresh_token",
+                        "name": "token_type_hint",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Token revogado"
+                    },
+                    "400": {
+                        "description": "invalid_request",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid_client",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/oauth/token": {
+            "post": {
+                "description": "Endpoint de token do OAuth2 (RFC 6749). Aceita os grants authorization_code (com PKCE), refresh_token e client_credentials. O cliente se autentica por HTTP Basic ou pelos campos client_id e client_secret.\nTokens do client_credentials não representam nenhum usuário e só são aceitos na leitura de autores e gêneros.",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "OAuth"
+                ],
+                "summary": "Emitir tokens OAuth",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "authorization_code, refresh_token ou client_credentials",
+                        "name": "grant_type",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Código de autorização",
+                        "name": "code",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "URI de retorno usada no pedido de autorização",
+                        "name": "redirect_uri",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Verificador PKCE",
+                        "name": "code_verifier",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Refresh token",
+                        "name": "refresh_token",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Escopos pedidos",
+                        "name": "scope",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ID do aplicativo",
+                        "name": "client_id",
+                        "in": "formData"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Segredo do aplicativo",
+                        "name": "client_secret",
+                        "in": "formData"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Tokens emitidos",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthTokenResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "invalid_request, invalid_grant, invalid_scope, unauthorized_client or unsupported_grant_type",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "invalid_client",
+                        "schema": {
+                            "$ref": "#/definitions/types.OAuthErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Retrieves user details based on the authenticated user's ID extracted from the request context.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Get user by ID",
+                "responses": {
+                    "200": {
+                        "description": "User details successfully retrieved",
+                        "schema": {
+                            "$ref": "#/definitions/types.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Updates user details based on the authenticated user's ID extracted from the request context. A changed email is unverified until confirmed through the link sent to it.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update user by ID",
+                "parameters": [
+                    {
+                        "description": "User update payload",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.UpdateUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "User successfully updated",
+                        "schema": {
+                            "$ref": "#/definitions/types.UserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Invalid request body",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Criar um novo usuário",
+                "parameters": [
+                    {
+                        "description": "Payload contendo os dados do novo usuário",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateUserRequestPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Usuário criado com sucesso",
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateUserResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Deletes the user associated with the authenticated user's ID extracted from the request context.",
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete user by ID",
+                "responses": {
+                    "204": {
+                        "description": "User successfully deleted"
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/erase": {
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Apaga de forma definitiva e imediata a conta e todos os dados do usuário: perfil, livros que só ele possui e suas capas enviadas, vínculos com livros compartilhados, sessões, chaves de API e eventos de segurança. Não há período de retenção nem como desfazer. Exige a senha atual, e senhas erradas contam para o mesmo limite de tentativas do login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Apagar conta definitivamente",
+                "parameters": [
+                    {
+                        "description": "Senha atual",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.EraseUserPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts, try again later",
+                        "schema": {
+                            "$ref": "#/definitions/types.TooManyRequestsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/me/export": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Baixa um arquivo JSON com todos os dados guardados sobre o usuário: perfil, livros (inclusive os da lixeira), sessões, chaves de API, eventos de segurança e ações administrativas sobre a conta. Segredos como hashes de senha e de tokens não são incluídos.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Exportar dados pessoais",
+                "responses": {
+                    "200": {
+                        "description": "Dados do usuário",
+                        "schema": {
+                            "$ref": "#/definitions/types.UserDataExport"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/password": {
+            "put": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Altera a senha do usuário autenticado após conferir a senha atual. As demais sessões do usuário são encerradas e todos os access tokens já emitidos deixam de valer; a sessão atual continua e obtém um novo access token em /auth/refresh.\nSenhas atuais erradas contam para o mesmo limite de tentativas do login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Alterar senha",
+                "parameters": [
+                    {
+                        "description": "Senha atual e nova senha",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ChangePasswordPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "User not found",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "429": {
+                        "description": "Too many failed login attempts, try again later",
+                        "schema": {
+                            "$ref": "#/definitions/types.TooManyRequestsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/tokens": {
+            "get": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Listar chaves de API",
+                "responses": {
+                    "200": {
+                        "description": "Chaves de API do usuário",
+                        "schema": {
+                            "$ref": "#/definitions/types.GetAPIKeysResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "description": "Cria uma chave de API para automações. A chave só é exibida nesta resposta. Sem escopos, ela recebe books:read e books:write. Nenhuma chave acessa as rotas da conta, de tokens ou de administração.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Criar chave de API",
+                "parameters": [
+                    {
+                        "description": "Nome, escopos e expiração da chave",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateAPIKeyPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Chave de API criada",
+                        "schema": {
+                            "$ref": "#/definitions/types.CreateAPIKeyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/tokens/{id}": {
+            "delete": {
+                "security": [
+                    {
+                        "BearerAuth": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Revogar chave de API",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "ID da chave de API",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    },
+                    "400": {
+                        "description": "API key ID must be a positive integer",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/types.UnauthorizedResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "No API key found with given ID",
+                        "schema": {
+                            "$ref": "#/definitions/types.NotFoundResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/verify": {
+            "get": {
+                "description": "Confirma o email do usuário com o token enviado no cadastro.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Confirmar email",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Token de verificação recebido por email",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Email successfully verified",
+                        "schema": {
+                            "$ref": "#/definitions/types.VerifyEmailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Verification token is invalid or has expired",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/verify/resend": {
+            "post": {
+                "description": "Envia um novo link de verificação se o email estiver cadastrado e ainda não verificado. A resposta é sempre a mesma.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Reenviar email de verificação",
+                "parameters": [
+                    {
+                        "description": "Email da conta",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/types.ResendVerificationEmailPayload"
+                        }
+                    }
+                ],
+                "responses": {
+                    "202": {
+                        "description": "Verification email sent if the account is pending verification",
+                        "schema": {
+                            "$ref": "#/definitions/types.ResendVerificationEmailResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Validation errors for payload",
+                        "schema": {
+                            "$ref": "#/definitions/types.BadRequestStructResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "An unexpected error occurred",
+                        "schema": {
+                            "$ref": "#/definitions/types.InternalServerErrorResponse"
+                        }
+                    },
+                    "503": {
+                        "description": "Request canceled",
+                        "schema": {
+                            "$ref": "#/definitions/types.ContextCanceledResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "types.APIKey": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token_prefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.AuditLog": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "details": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "target_id": {
+                    "type": "integer"
+                },
+                "target_type": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.Author": {
+            "type": "object",
+            "properties": {
+                "bio": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BadGatewayResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BadRequestResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BadRequestStructResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.Book": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.BookGenre"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "isbn_10": {
+                    "type": "string"
+                },
+                "isbn_13": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "number_of_pages": {
+                    "type": "integer"
+                },
+                "release_year": {
+                    "type": "integer"
+                },
+                "thumbnail_url": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BookAuthor": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "role": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BookAuthorPayload": {
+            "type": "object",
+            "required": [
+                "author_id",
+                "role"
+            ],
+            "properties": {
+                "author_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "author",
+                        "translator",
+                        "illustrator"
+                    ]
+                }
+            }
+        },
+        "types.BookCover": {
+            "type": "object",
+            "properties": {
+                "image_url": {
+                    "type": "string"
+                },
+                "thumbnail_url": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BookGenre": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "slug": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BookSearchResult": {
+            "type": "object",
+            "properties": {
+                "author": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "deleted_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.BookGenre"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "isbn_10": {
+                    "type": "string"
+                },
+                "isbn_13": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "number_of_pages": {
+                    "type": "integer"
+                },
+                "rank": {
+                    "type": "number"
+                },
+                "release_year": {
+                    "type": "integer"
+                },
+                "snippet": {
+                    "type": "string"
+                },
+                "thumbnail_url": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.BookShare": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "permission": {
+                    "type": "string"
+                },
+                "user_id": {
+                    "type": "integer"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ChangePasswordPayload": {
+            "type": "object",
+            "required": [
+                "confirm_password",
+                "current_password",
+                "password"
+            ],
+            "properties": {
+                "confirm_password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "current_password": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                }
+            }
+        },
+        "types.ConfirmMFAPayload": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ConfirmMFAResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.ConflictResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ContextCanceledResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.CreateAPIKeyPayload": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "expires_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100
+                },
+                "scopes": {
+                    "type": "array",
+                    "uniqueItems": true,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.CreateAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "token": {
+                    "type": "string"
+                },
+                "token_prefix": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.CreateAuthorPayload": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "bio": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 2
+                }
+            }
+        },
+        "types.CreateAuthorResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.CreateBookPayload": {
+            "type": "object",
+            "required": [
+                "author",
+                "description",
+                "genre_ids",
+                "name",
+                "number_of_pages",
+                "release_year"
+            ],
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "minLength": 3
+                },
+                "description": {
+                    "type": "string",
+                    "minLength": 5
+                },
+                "genre_ids": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "isbn_10": {
+                    "type": "string"
+                },
+                "isbn_13": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "minLength": 3
+                },
+                "number_of_pages": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "release_year": {
+                    "type": "integer",
+                    "maximum": 2099,
+                    "minimum": 1500
+                }
+            }
+        },
+        "types.CreateBookResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.CreateGenrePayload": {
+            "type": "object",
+            "required": [
+                "name",
+                "slug"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
+        "types.CreateGenreResponse": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.CreateOAuthClientPayload": {
+            "type": "object",
+            "required": [
+                "grant_types",
+                "name",
+                "redirect_uris",
+                "scopes"
+            ],
+            "properties": {
+                "confidential": {
+                    "type": "boolean"
+                },
+                "grant_types": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 3
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "type": "array",
+                    "minItems": 1,
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.CreateOAuthClientResponse": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "client_secret": {
+                    "type": "string"
+                },
+                "confidential": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "grant_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.CreateUserRequestPayload": {
+            "type": "object",
+            "required": [
+                "confirm_password",
+                "email",
+                "password",
+                "username"
+            ],
+            "properties": {
+                "confirm_password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "email": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "username": {
+                    "type": "string",
+                    "minLength": 5
+                }
+            }
+        },
+        "types.CreateUserResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.EnrollMFAResponse": {
+            "type": "object",
+            "properties": {
+                "provisioning_uri": {
+                    "type": "string"
+                },
+                "secret": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.EraseUserPayload": {
+            "type": "object",
+            "required": [
+                "password"
+            ],
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ForbiddenResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ForgotPasswordPayload": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ForgotPasswordResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.Genre": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "parent_id": {
+                    "type": "integer"
+                },
+                "slug": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.GetAPIKeysResponse": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.APIKey"
+                    }
+                }
+            }
+        },
+        "types.GetAllBooksResponse": {
+            "type": "object",
+            "properties": {
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Book"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.GetAuditLogsResponse": {
+            "type": "object",
+            "properties": {
+                "audit_logs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.AuditLog"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.GetAuthorsResponse": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Author"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.GetBookAuthorsResponse": {
+            "type": "object",
+            "properties": {
+                "authors": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.BookAuthor"
+                    }
+                }
+            }
+        },
+        "types.GetBookSharesResponse": {
+            "type": "object",
+            "properties": {
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.BookShare"
+                    }
+                }
+            }
+        },
+        "types.GetBooksResponse": {
+            "type": "object",
+            "properties": {
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Book"
+                    }
+                },
+                "limit": {
+                    "type": "integer"
+                },
+                "next": {
+                    "type": "string"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "prev": {
+                    "type": "string"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.GetGenresResponse": {
+            "type": "object",
+            "properties": {
+                "genres": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Genre"
+                    }
+                }
+            }
+        },
+        "types.GetOAuthClientsResponse": {
+            "type": "object",
+            "properties": {
+                "clients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.OAuthClient"
+                    }
+                }
+            }
+        },
+        "types.GetOAuthConsentsResponse": {
+            "type": "object",
+            "properties": {
+                "consents": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.OAuthConsent"
+                    }
+                }
+            }
+        },
+        "types.GetSessionsResponse": {
+            "type": "object",
+            "properties": {
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SessionResponse"
+                    }
+                }
+            }
+        },
+        "types.GetUsersResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "total_pages": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.UserResponse"
+                    }
+                }
+            }
+        },
+        "types.ImportBookByISBNPayload": {
+            "type": "object",
+            "required": [
+                "genre_ids",
+                "isbn"
+            ],
+            "properties": {
+                "author": {
+                    "type": "string",
+                    "minLength": 3
+                },
+                "description": {
+                    "type": "string",
+                    "minLength": 5
+                },
+                "genre_ids": {
+                    "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "image_url": {
+                    "type": "string"
+                },
+                "isbn": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string",
+                    "minLength": 3
+                },
+                "number_of_pages": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "release_year": {
+                    "type": "integer",
+                    "maximum": 2099,
+                    "minimum": 1500
+                }
+            }
+        },
+        "types.InternalServerErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.JWK": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                },
+                "y": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.JWKSResponse": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.JWK"
+                    }
+                }
+            }
+        },
+        "types.NotFoundResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.OAuthAuthorizeResponse": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "client_name": {
+                    "type": "string"
+                },
+                "consent_granted": {
+                    "description": "ConsentGranted tells whether the user already agreed to every\nrequested scope, in which case the app may skip the consent screen.",
+                    "type": "boolean"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.OAuthClient": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "confidential": {
+                    "type": "boolean"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "grant_types": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "redirect_uris": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "types.OAuthConsent": {
+            "type": "object",
+            "properties": {
+                "client_id": {
+                    "type": "string"
+                },
+                "client_name": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.OAuthConsentPayload": {
+            "type": "object",
+            "required": [
+                "approved",
+                "client_id",
+                "code_challenge",
+                "code_challenge_method",
+                "redirect_uri",
+                "response_type",
+                "scope"
+            ],
+            "properties": {
+                "approved": {
+                    "type": "boolean"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "code_challenge": {
+                    "type": "string",
+                    "maxLength": 128,
+                    "minLength": 43
+                },
+                "code_challenge_method": {
+                    "type": "string"
+                },
+                "redirect_uri": {
+                    "type": "string"
+                },
+                "response_type": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.OAuthErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                },
+                "error_description": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.OAuthIntrospectionResponse": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "client_id": {
+                    "type": "string"
+                },
+                "exp": {
+                    "type": "integer"
+                },
+                "iat": {
+                    "type": "integer"
+                },
+                "jti": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "sub": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.OAuthRedirectResponse": {
+            "type": "object",
+            "properties": {
+                "redirect_to": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.OAuthTokenResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                },
+                "expires_in": {
+                    "type": "integer"
+                },
+                "refresh_token": {
+                    "type": "string"
+                },
+                "scope": {
+                    "type": "string"
+                },
+                "token_type": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.PayloadTooLargeResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.RefreshTokenPayload": {
+            "type": "object",
+            "required": [
+                "refresh_token"
+            ],
+            "properties": {
+                "refresh_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.ResendVerificationEmailPayload": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
-        "types.BadRequestResponse": {
+        },
+        "types.ResendVerificationEmailResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "message": {
                     "type": "string"
                 }
             }
         },
-        "types.BadRequestStructResponse": {
+        "types.ResetPasswordPayload": {
             "type": "object",
+            "required": [
+                "confirm_password",
+                "password",
+                "token"
+            ],
             "properties": {
-                "error": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "confirm_password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                },
+                "token": {
+                    "type": "string"
                 }
             }
         },
-        "types.Book": {
+        "types.RestoreAccountPayload": {
             "type": "object",
+            "required": [
+                "email",
+                "password"
+            ],
             "properties": {
-                "author": {
+                "email": {
                     "type": "string"
                 },
-                "created_at": {
+                "password": {
+                    "type": "string",
+                    "minLength": 8
+                }
+            }
+        },
+        "types.RestoreAccountResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
                     "type": "string"
+                }
+            }
+        },
+        "types.SearchBooksResponse": {
+            "type": "object",
+            "properties": {
+                "limit": {
+                    "type": "integer"
                 },
-                "deleted_at": {
+                "next": {
                     "type": "string"
                 },
-                "description": {
+                "page": {
+                    "type": "integer"
+                },
+                "prev": {
                     "type": "string"
                 },
-                "genres": {
+                "results": {
                     "type": "array",
                     "items": {
-                        "type": "string"
+                        "$ref": "#/definitions/types.BookSearchResult"
                     }
                 },
-                "id": {
+                "total": {
                     "type": "integer"
                 },
-                "image_url": {
+                "total_pages": {
+                    "type": "integer"
+                }
+            }
+        },
+        "types.SecurityEvent": {
+            "type": "object",
+            "properties": {
+                "created_at": {
                     "type": "string"
                 },
-                "name": {
+                "event_type": {
                     "type": "string"
                 },
-                "number_of_pages": {
+                "id": {
                     "type": "integer"
                 },
-                "release_year": {
-                    "type": "integer"
+                "ip_address": {
+                    "type": "string"
                 },
-                "updated_at": {
+                "user_agent": {
                     "type": "string"
                 }
             }
         },
-        "types.ContextCanceledResponse": {
+        "types.SessionResponse": {
             "type": "object",
             "properties": {
-                "error": {
+                "created_at": {
                     "type": "string"
-                }
-            }
-        },
-        "types.CreateBookPayload": {
-            "type": "object",
-            "required": [
-                "author",
-                "description",
-                "genres",
-                "image_url",
-                "name",
-                "number_of_pages",
-                "release_year"
-            ],
-            "properties": {
-                "author": {
-                    "type": "string",
-                    "minLength": 3
                 },
-                "description": {
-                    "type": "string",
-                    "minLength": 5
+                "expires_at": {
+                    "type": "string"
                 },
-                "genres": {
-                    "type": "array",
-                    "items": {
-                        "type": "string"
-                    }
+                "id": {
+                    "type": "integer"
                 },
-                "image_url": {
+                "ip_address": {
                     "type": "string"
                 },
-                "name": {
-                    "type": "string",
-                    "minLength": 3
-                },
-                "number_of_pages": {
-                    "type": "integer",
-                    "minimum": 1
+                "last_used_at": {
+                    "type": "string"
                 },
-                "release_year": {
-                    "type": "integer",
-                    "maximum": 2099,
-                    "minimum": 1500
+                "user_agent": {
+                    "type": "string"
                 }
             }
         },
-        "types.CreateBookResponse": {
+        "types.SetBookAuthorsPayload": {
             "type": "object",
+            "required": [
+                "authors"
+            ],
             "properties": {
-                "id": {
-                    "type": "integer"
+                "authors": {
+                    "type": "array",
+                    "maxItems": 50,
+                    "minItems": 1,
+                    "items": {
+                        "$ref": "#/definitions/types.BookAuthorPayload"
+                    }
                 }
             }
         },
-        "types.CreateUserRequestPayload": {
+        "types.ShareBookPayload": {
             "type": "object",
             "required": [
-                "confirm_password",
                 "email",
-                "password",
-                "username"
+                "permission"
             ],
             "properties": {
-                "confirm_password": {
-                    "type": "string",
-                    "minLength": 8
-                },
                 "email": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string",
-                    "minLength": 8
-                },
-                "username": {
+                "permission": {
                     "type": "string",
-                    "minLength": 5
+                    "enum": [
+                        "editor",
+                        "viewer"
+                    ]
                 }
             }
         },
-        "types.CreateUserResponse": {
+        "types.TooManyRequestsResponse": {
             "type": "object",
             "properties": {
-                "message": {
+                "error": {
                     "type": "string"
                 }
             }
         },
-        "types.GetBooksResponse": {
-            "type": "object",
-            "properties": {
-                "books": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/types.Book"
-                    }
-                }
-            }
-        },
-        "types.InternalServerErrorResponse": {
+        "types.UnauthorizedResponse": {
             "type": "object",
             "properties": {
                 "error": {
@@ -770,7 +6168,7 @@ const docTemplate = `{
                 }
             }
         },
-        "types.NotFoundResponse": {
+        "types.UnsupportedMediaTypeResponse": {
             "type": "object",
             "properties": {
                 "error": {
@@ -778,22 +6176,20 @@ const docTemplate = `{
                 }
             }
         },
-        "types.RefreshTokenPayload": {
+        "types.UpdateAuthorPayload": {
             "type": "object",
             "required": [
-                "refresh_token"
+                "name"
             ],
             "properties": {
-                "refresh_token": {
-                    "type": "string"
-                }
-            }
-        },
-        "types.UnauthorizedResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string"
+                "bio": {
+                    "type": "string",
+                    "maxLength": 5000
+                },
+                "name": {
+                    "type": "string",
+                    "maxLength": 255,
+                    "minLength": 2
                 }
             }
         },
@@ -802,8 +6198,7 @@ const docTemplate = `{
             "required": [
                 "author",
                 "description",
-                "genres",
-                "image_url",
+                "genre_ids",
                 "name",
                 "number_of_pages",
                 "release_year"
@@ -817,15 +6212,23 @@ const docTemplate = `{
                     "type": "string",
                     "minLength": 5
                 },
-                "genres": {
+                "genre_ids": {
                     "type": "array",
+                    "maxItems": 20,
+                    "minItems": 1,
                     "items": {
-                        "type": "string"
+                        "type": "integer"
                     }
                 },
                 "image_url": {
                     "type": "string"
                 },
+                "isbn_10": {
+                    "type": "string"
+                },
+                "isbn_13": {
+                    "type": "string"
+                },
                 "name": {
                     "type": "string",
                     "minLength": 3
@@ -841,6 +6244,28 @@ const docTemplate = `{
                 }
             }
         },
+        "types.UpdateGenrePayload": {
+            "type": "object",
+            "required": [
+                "name",
+                "slug"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "maxLength": 100,
+                    "minLength": 2
+                },
+                "parent_id": {
+                    "type": "integer",
+                    "minimum": 1
+                },
+                "slug": {
+                    "type": "string",
+                    "maxLength": 100
+                }
+            }
+        },
         "types.UpdateRefreshTokenResponse": {
             "type": "object",
             "properties": {
@@ -868,6 +6293,63 @@ const docTemplate = `{
                 }
             }
         },
+        "types.UpdateUserRolePayload": {
+            "type": "object",
+            "required": [
+                "role"
+            ],
+            "properties": {
+                "role": {
+                    "type": "string",
+                    "enum": [
+                        "admin",
+                        "librarian",
+                        "reader"
+                    ]
+                }
+            }
+        },
+        "types.UserDataExport": {
+            "type": "object",
+            "properties": {
+                "api_keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.APIKey"
+                    }
+                },
+                "audit_logs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.AuditLog"
+                    }
+                },
+                "books": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.Book"
+                    }
+                },
+                "exported_at": {
+                    "type": "string"
+                },
+                "profile": {
+                    "$ref": "#/definitions/types.UserResponse"
+                },
+                "security_events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SecurityEvent"
+                    }
+                },
+                "sessions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/types.SessionResponse"
+                    }
+                }
+            }
+        },
         "types.UserLoginPayload": {
             "type": "object",
             "required": [
@@ -890,6 +6372,12 @@ const docTemplate = `{
                 "access_token": {
                     "type": "string"
                 },
+                "mfa_required": {
+                    "type": "boolean"
+                },
+                "mfa_token": {
+                    "type": "string"
+                },
                 "refresh_token": {
                     "type": "string"
                 }
@@ -907,9 +6395,15 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "emailVerifiedAt": {
+                    "type": "string"
+                },
                 "id": {
                     "type": "integer"
                 },
+                "role": {
+                    "type": "string"
+                },
                 "updatedAt": {
                     "type": "string"
                 },
@@ -917,6 +6411,29 @@ const docTemplate = `{
                     "type": "string"
                 }
             }
+        },
+        "types.VerifyEmailResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                }
+            }
+        },
+        "types.VerifyMFAPayload": {
+            "type": "object",
+            "required": [
+                "code",
+                "mfa_token"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                },
+                "mfa_token": {
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
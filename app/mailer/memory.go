@@ -0,0 +1,39 @@
+package mailer
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hoyci/book-store-api/types"
+)
+
+// MemoryMailer keeps sent emails in memory instead of delivering them. It is
+// meant for tests.
+type MemoryMailer struct {
+	mu     sync.Mutex
+	emails []types.Email
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (m *MemoryMailer) Send(ctx context.Context, email types.Email) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.emails = append(m.emails, email)
+
+	return nil
+}
+
+func (m *MemoryMailer) Sent() []types.Email {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return append([]types.Email(nil), m.emails...)
+}
@@ -0,0 +1,64 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+var ErrMailQueueFull = errors.New("mail queue is full")
+
+// QueuedMailer hands emails to a background worker and returns right away.
+// Endpoints that answer the same way whether an account exists, like the
+// password reset, would otherwise give it away by how long they take when an
+// email is actually sent. Delivery errors can only be logged, and emails
+// still queued when the process exits are lost.
+type QueuedMailer struct {
+	mailer  types.Mailer
+	timeout time.Duration
+	queue   chan types.Email
+}
+
+// NewQueuedMailer delivers through mailer, one email at a time, giving each
+// at most timeout. Send fails once size emails are waiting.
+func NewQueuedMailer(mailer types.Mailer, size int, timeout time.Duration) *QueuedMailer {
+	m := &QueuedMailer{
+		mailer:  mailer,
+		timeout: timeout,
+		queue:   make(chan types.Email, size),
+	}
+
+	go m.run()
+
+	return m
+}
+
+// Send doesn't stop at the end of ctx: the request that asked for the email
+// is usually over by the time it is delivered.
+func (m *QueuedMailer) Send(ctx context.Context, email types.Email) error {
+	select {
+	case m.queue <- email:
+		return nil
+	default:
+		return ErrMailQueueFull
+	}
+}
+
+func (m *QueuedMailer) run() {
+	for email := range m.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		err := m.mailer.Send(ctx, email)
+		cancel()
+
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"subject": email.Subject,
+				"error":   err.Error(),
+			}).Error("Failed to deliver a queued email")
+		}
+	}
+}
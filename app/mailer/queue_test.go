@@ -0,0 +1,67 @@
+package mailer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// blockingMailer holds every email until its context ends.
+type blockingMailer struct {
+	done chan error
+}
+
+func (m *blockingMailer) Send(ctx context.Context, email types.Email) error {
+	<-ctx.Done()
+	m.done <- ctx.Err()
+	return ctx.Err()
+}
+
+func TestQueuedMailer(t *testing.T) {
+	utils.InitLogger()
+
+	t.Run("it should return before the email is delivered and give up after the timeout", func(t *testing.T) {
+		blocking := &blockingMailer{done: make(chan error, 1)}
+		queued := NewQueuedMailer(blocking, 1, 50*time.Millisecond)
+
+		start := time.Now()
+		err := queued.Send(context.Background(), types.Email{To: "johndoe@email.com"})
+		assert.NoError(t, err)
+		assert.Less(t, time.Since(start), 50*time.Millisecond)
+
+		select {
+		case err := <-blocking.done:
+			assert.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-time.After(time.Second):
+			t.Fatal("the queued email was not given up on")
+		}
+	})
+
+	t.Run("it should deliver queued emails after the request context ends", func(t *testing.T) {
+		memory := NewMemoryMailer()
+		queued := NewQueuedMailer(memory, 1, time.Second)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		err := queued.Send(ctx, types.Email{To: "johndoe@email.com"})
+		cancel()
+		assert.NoError(t, err)
+
+		assert.Eventually(t, func() bool { return len(memory.Sent()) == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("it should fail when the queue is full", func(t *testing.T) {
+		blocking := &blockingMailer{done: make(chan error, 2)}
+		queued := NewQueuedMailer(blocking, 1, time.Second)
+
+		assert.NoError(t, queued.Send(context.Background(), types.Email{To: "first@email.com"}))
+		assert.Eventually(t, func() bool { return len(queued.queue) == 0 }, time.Second, time.Millisecond)
+		assert.NoError(t, queued.Send(context.Background(), types.Email{To: "second@email.com"}))
+
+		err := queued.Send(context.Background(), types.Email{To: "third@email.com"})
+		assert.ErrorIs(t, err, ErrMailQueueFull)
+	})
+}
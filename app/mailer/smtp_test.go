@@ -0,0 +1,40 @@
+package mailer
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSMTPMailerTimeout(t *testing.T) {
+	// The server accepts connections but never greets, like one that hangs.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	smtpMailer := NewSMTPMailer(host, port, "", "", "Book Store <no-reply@bookstore.local>")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err = smtpMailer.Send(ctx, types.Email{To: "johndoe@email.com"})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), time.Second)
+}
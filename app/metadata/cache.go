@@ -0,0 +1,111 @@
+package metadata
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/hoyci/book-store-api/types"
+)
+
+// CachedProvider keeps the answers of another provider in memory for ttl, so
+// importing the same book again doesn't reach the catalog. ISBNs the catalog
+// doesn't know are cached too. Failed lookups are not.
+type CachedProvider struct {
+	provider   types.MetadataProvider
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	metadata  *types.BookMetadata
+	err       error
+	expiresAt time.Time
+}
+
+// NewCachedProvider holds at most maxEntries answers. When full, expired ones
+// are dropped first, then the ones closest to expiring.
+func NewCachedProvider(provider types.MetadataProvider, ttl time.Duration, maxEntries int) *CachedProvider {
+	return &CachedProvider{
+		provider:   provider,
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]cacheEntry),
+	}
+}
+
+func (c *CachedProvider) LookupISBN(ctx context.Context, isbn13 string) (*types.BookMetadata, error) {
+	if entry, ok := c.get(isbn13); ok {
+		return copyMetadata(entry.metadata), entry.err
+	}
+
+	metadata, err := c.provider.LookupISBN(ctx, isbn13)
+	if err != nil && !errors.Is(err, types.ErrMetadataNotFound) {
+		return nil, err
+	}
+
+	c.set(isbn13, cacheEntry{metadata: copyMetadata(metadata), err: err, expiresAt: c.now().Add(c.ttl)})
+
+	return metadata, err
+}
+
+func (c *CachedProvider) get(isbn13 string) (cacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[isbn13]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (c *CachedProvider) set(isbn13 string, entry cacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[isbn13]; !ok && len(c.entries) >= c.maxEntries {
+		c.evict()
+	}
+
+	c.entries[isbn13] = entry
+}
+
+// evict makes room for one entry. It must be called with mu held.
+func (c *CachedProvider) evict() {
+	now := c.now()
+	for isbn13, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, isbn13)
+		}
+	}
+
+	for len(c.entries) > 0 && len(c.entries) >= c.maxEntries {
+		var oldest string
+		for isbn13, entry := range c.entries {
+			if oldest == "" || entry.expiresAt.Before(c.entries[oldest].expiresAt) {
+				oldest = isbn13
+			}
+		}
+		delete(c.entries, oldest)
+	}
+}
+
+// copyMetadata keeps callers from changing what is cached.
+func copyMetadata(metadata *types.BookMetadata) *types.BookMetadata {
+	if metadata == nil {
+		return nil
+	}
+
+	copied := *metadata
+	copied.Authors = append([]string(nil), metadata.Authors...)
+
+	return &copied
+}
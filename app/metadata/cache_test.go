@@ -0,0 +1,104 @@
+package metadata
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type countingProvider struct {
+	provider types.MetadataProvider
+	err      error
+	calls    int
+}
+
+func (p *countingProvider) LookupISBN(ctx context.Context, isbn13 string) (*types.BookMetadata, error) {
+	p.calls++
+	if p.err != nil {
+		return nil, p.err
+	}
+
+	return p.provider.LookupISBN(ctx, isbn13)
+}
+
+func TestCachedProvider(t *testing.T) {
+	fixtures, err := LoadFixtureProvider("testdata")
+	if err != nil {
+		t.Fatalf("Failed to load fixtures: %v", err)
+	}
+
+	t.Run("it should reach the provider once per ISBN until the entry expires", func(t *testing.T) {
+		provider := &countingProvider{provider: fixtures}
+		cache := NewCachedProvider(provider, time.Hour, 10)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		for i := 0; i < 3; i++ {
+			metadata, err := cache.LookupISBN(context.Background(), "9780441013593")
+			assert.NoError(t, err)
+			assert.Equal(t, "Dune", metadata.Name)
+		}
+		assert.Equal(t, 1, provider.calls)
+
+		now = now.Add(time.Hour)
+		_, err := cache.LookupISBN(context.Background(), "9780441013593")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, provider.calls)
+	})
+
+	t.Run("it should cache ISBNs the catalog does not know", func(t *testing.T) {
+		provider := &countingProvider{provider: fixtures}
+		cache := NewCachedProvider(provider, time.Hour, 10)
+
+		for i := 0; i < 2; i++ {
+			metadata, err := cache.LookupISBN(context.Background(), "9791234567896")
+			assert.Nil(t, metadata)
+			assert.ErrorIs(t, err, types.ErrMetadataNotFound)
+		}
+		assert.Equal(t, 1, provider.calls)
+	})
+
+	t.Run("it should not cache failed lookups", func(t *testing.T) {
+		provider := &countingProvider{err: fmt.Errorf("catalog answered 503")}
+		cache := NewCachedProvider(provider, time.Hour, 10)
+
+		for i := 0; i < 2; i++ {
+			_, err := cache.LookupISBN(context.Background(), "9780441013593")
+			assert.Error(t, err)
+		}
+		assert.Equal(t, 2, provider.calls)
+	})
+
+	t.Run("it should drop the entry closest to expiring when full", func(t *testing.T) {
+		provider := &countingProvider{provider: fixtures}
+		cache := NewCachedProvider(provider, time.Hour, 1)
+		now := time.Now()
+		cache.now = func() time.Time { return now }
+
+		cache.LookupISBN(context.Background(), "9780441013593")
+		now = now.Add(time.Minute)
+		cache.LookupISBN(context.Background(), "9780306406157")
+		cache.LookupISBN(context.Background(), "9780306406157")
+
+		assert.Equal(t, 2, provider.calls)
+		assert.Len(t, cache.entries, 1)
+
+		cache.LookupISBN(context.Background(), "9780441013593")
+		assert.Equal(t, 3, provider.calls)
+	})
+
+	t.Run("it should not let callers change the cached entry", func(t *testing.T) {
+		cache := NewCachedProvider(fixtures, time.Hour, 10)
+
+		metadata, _ := cache.LookupISBN(context.Background(), "9780441013593")
+		metadata.Authors[0] = "Someone Else"
+
+		metadata, _ = cache.LookupISBN(context.Background(), "9780441013593")
+		assert.Equal(t, []string{"Frank Herbert"}, metadata.Authors)
+	})
+}
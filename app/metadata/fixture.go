@@ -0,0 +1,61 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hoyci/book-store-api/types"
+)
+
+// FixtureProvider answers from a fixed set of catalog entries instead of an
+// external catalog. It is meant for tests and local development.
+type FixtureProvider struct {
+	books map[string]types.BookMetadata
+}
+
+// NewFixtureProvider serves the given entries, keyed by ISBN-13.
+func NewFixtureProvider(books map[string]types.BookMetadata) *FixtureProvider {
+	return &FixtureProvider{books: books}
+}
+
+// LoadFixtureProvider reads one catalog entry per JSON file in dir. Each file
+// holds a types.BookMetadata and is served under its isbn_13.
+func LoadFixtureProvider(dir string) (*FixtureProvider, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	books := make(map[string]types.BookMetadata, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading metadata fixture: %w", err)
+		}
+
+		var book types.BookMetadata
+		if err := json.Unmarshal(data, &book); err != nil {
+			return nil, fmt.Errorf("error decoding metadata fixture %s: %w", path, err)
+		}
+		books[book.ISBN13] = book
+	}
+
+	return NewFixtureProvider(books), nil
+}
+
+func (p *FixtureProvider) LookupISBN(ctx context.Context, isbn13 string) (*types.BookMetadata, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	book, ok := p.books[isbn13]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", types.ErrMetadataNotFound, isbn13)
+	}
+	book.Authors = append([]string(nil), book.Authors...)
+
+	return &book, nil
+}
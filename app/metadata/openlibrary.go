@@ -0,0 +1,128 @@
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hoyci/book-store-api/types"
+)
+
+// OpenLibraryProvider reads book metadata from the Open Library books API,
+// or from any catalog answering in the same format.
+type OpenLibraryProvider struct {
+	baseURL string
+	client  *http.Client
+}
+
+type openLibraryBook struct {
+	Title         string `json:"title"`
+	Subtitle      string `json:"subtitle"`
+	Notes         string `json:"notes"`
+	NumberOfPages int    `json:"number_of_pages"`
+	PublishDate   string `json:"publish_date"`
+	Authors       []struct {
+		Name string `json:"name"`
+	} `json:"authors"`
+	Excerpts []struct {
+		Text string `json:"text"`
+	} `json:"excerpts"`
+	Cover struct {
+		Small  string `json:"small"`
+		Medium string `json:"medium"`
+		Large  string `json:"large"`
+	} `json:"cover"`
+}
+
+// NewOpenLibraryProvider talks to the catalog at baseURL, e.g.
+// https://openlibrary.org. A nil client uses one with a 10 second timeout.
+func NewOpenLibraryProvider(baseURL string, client *http.Client) *OpenLibraryProvider {
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	return &OpenLibraryProvider{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+func (p *OpenLibraryProvider) LookupISBN(ctx context.Context, isbn13 string) (*types.BookMetadata, error) {
+	bibkey := "ISBN:" + isbn13
+
+	query := url.Values{}
+	query.Set("bibkeys", bibkey)
+	query.Set("format", "json")
+	query.Set("jscmd", "data")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.baseURL+"/api/books?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error calling catalog: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("catalog answered %d", res.StatusCode)
+	}
+
+	// Books the catalog doesn't know are left out of the response, which is
+	// then an empty object.
+	var books map[string]openLibraryBook
+	if err := json.NewDecoder(io.LimitReader(res.Body, 1<<20)).Decode(&books); err != nil {
+		return nil, fmt.Errorf("error decoding catalog response: %w", err)
+	}
+
+	book, ok := books[bibkey]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", types.ErrMetadataNotFound, isbn13)
+	}
+
+	metadata := &types.BookMetadata{
+		ISBN13:        isbn13,
+		Name:          book.Title,
+		Description:   book.Notes,
+		ReleaseYear:   releaseYear(book.PublishDate),
+		NumberOfPages: book.NumberOfPages,
+		ImageUrl:      firstNonEmpty(book.Cover.Large, book.Cover.Medium, book.Cover.Small),
+	}
+	if book.Subtitle != "" {
+		metadata.Name += ": " + book.Subtitle
+	}
+	if metadata.Description == "" && len(book.Excerpts) > 0 {
+		metadata.Description = book.Excerpts[0].Text
+	}
+	for _, author := range book.Authors {
+		metadata.Authors = append(metadata.Authors, author.Name)
+	}
+
+	return metadata, nil
+}
+
+var yearPattern = regexp.MustCompile(`\b\d{4}\b`)
+
+// releaseYear finds the year in the free-form publish dates catalogs use,
+// such as "1965", "June 1990" or "2005-03-01". It is 0 when there is none.
+func releaseYear(publishDate string) int {
+	year, _ := strconv.Atoi(yearPattern.FindString(publishDate))
+	return year
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+
+	return ""
+}
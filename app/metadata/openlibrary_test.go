@@ -0,0 +1,77 @@
+package metadata
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenLibraryLookupISBN(t *testing.T) {
+	newCatalog := func(t *testing.T, status int, body string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.Equal(t, "/api/books", r.URL.Path)
+			assert.Equal(t, "ISBN:9780441013593", r.URL.Query().Get("bibkeys"))
+			assert.Equal(t, "data", r.URL.Query().Get("jscmd"))
+
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+	}
+
+	t.Run("it should map the catalog entry", func(t *testing.T) {
+		catalog := newCatalog(t, http.StatusOK, `{"ISBN:9780441013593": {
+			"title": "Dune",
+			"subtitle": "Deluxe Edition",
+			"authors": [{"name": "Frank Herbert"}],
+			"number_of_pages": 528,
+			"publish_date": "August 2, 2005",
+			"excerpts": [{"text": "A beginning is the time for taking the most delicate care."}],
+			"cover": {"small": "https://covers.example.com/s.jpg", "large": "https://covers.example.com/l.jpg"}
+		}}`)
+		defer catalog.Close()
+
+		metadata, err := NewOpenLibraryProvider(catalog.URL+"/", nil).LookupISBN(context.Background(), "9780441013593")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.BookMetadata{
+			ISBN13:        "9780441013593",
+			Name:          "Dune: Deluxe Edition",
+			Description:   "A beginning is the time for taking the most delicate care.",
+			Authors:       []string{"Frank Herbert"},
+			ReleaseYear:   2005,
+			NumberOfPages: 528,
+			ImageUrl:      "https://covers.example.com/l.jpg",
+		}, metadata)
+	})
+
+	t.Run("it should return ErrMetadataNotFound when the catalog has no entry", func(t *testing.T) {
+		catalog := newCatalog(t, http.StatusOK, `{}`)
+		defer catalog.Close()
+
+		metadata, err := NewOpenLibraryProvider(catalog.URL, nil).LookupISBN(context.Background(), "9780441013593")
+
+		assert.Nil(t, metadata)
+		assert.ErrorIs(t, err, types.ErrMetadataNotFound)
+	})
+
+	t.Run("it should fail when the catalog answers with an error", func(t *testing.T) {
+		catalog := newCatalog(t, http.StatusServiceUnavailable, `upstream down`)
+		defer catalog.Close()
+
+		metadata, err := NewOpenLibraryProvider(catalog.URL, nil).LookupISBN(context.Background(), "9780441013593")
+
+		assert.Nil(t, metadata)
+		assert.EqualError(t, err, "catalog answered 503")
+	})
+}
+
+func TestReleaseYear(t *testing.T) {
+	assert.Equal(t, 1965, releaseYear("1965"))
+	assert.Equal(t, 1990, releaseYear("June 1990"))
+	assert.Equal(t, 2005, releaseYear("2005-03-01"))
+	assert.Equal(t, 0, releaseYear("unknown"))
+}
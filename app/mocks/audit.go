@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuditStore struct {
+	mock.Mock
+}
+
+func (m *MockAuditStore) CreateAuditLog(ctx context.Context, payload types.CreateAuditLogPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuditStore) GetAuditLogs(ctx context.Context, options types.GetAuditLogsOptions) ([]*types.AuditLog, int, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).([]*types.AuditLog), args.Int(1), args.Error(2)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/hoyci/book-store-api/types"
 	"github.com/stretchr/testify/mock"
@@ -11,12 +12,192 @@ type MockAuthStore struct {
 	mock.Mock
 }
 
-func (m *MockAuthStore) GetRefreshTokenByUserID(ctx context.Context, userID int) (*types.RefreshToken, error) {
-	args := m.Called(ctx, userID)
+func (m *MockAuthStore) GetRefreshTokenByJti(ctx context.Context, jti string) (*types.RefreshToken, error) {
+	args := m.Called(ctx, jti)
 	return args.Get(0).(*types.RefreshToken), args.Error(1)
 }
 
-func (m *MockAuthStore) UpsertRefreshToken(ctx context.Context, payload types.UpdateRefreshTokenPayload) error {
+func (m *MockAuthStore) GetRefreshTokensByUserID(ctx context.Context, userID int) ([]*types.RefreshToken, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*types.RefreshToken), args.Error(1)
+}
+
+func (m *MockAuthStore) CreateRefreshToken(ctx context.Context, payload types.CreateRefreshTokenPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) RotateRefreshToken(ctx context.Context, payload types.RotateRefreshTokenPayload) error {
 	args := m.Called(ctx, payload)
 	return args.Error(0)
 }
+
+func (m *MockAuthStore) DeleteRefreshToken(ctx context.Context, userID int, jti string) error {
+	args := m.Called(ctx, userID, jti)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) DeleteRefreshTokenByID(ctx context.Context, userID int, id int) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) DeleteRefreshTokensByUserID(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) RevokeTokensByUserID(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) RevokeOtherTokens(ctx context.Context, userID int, familyID string) error {
+	args := m.Called(ctx, userID, familyID)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) DeleteRefreshTokenFamily(ctx context.Context, userID int, familyID string) error {
+	args := m.Called(ctx, userID, familyID)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) CreateSecurityEvent(ctx context.Context, payload types.CreateSecurityEventPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) RevokeAccessToken(ctx context.Context, payload types.RevokeAccessTokenPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) IsAccessTokenRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error) {
+	args := m.Called(ctx, jti, userID, issuedAt)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *MockAuthStore) CreatePasswordResetToken(ctx context.Context, payload types.CreatePasswordResetTokenPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (int, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuthStore) GetMFAByUserID(ctx context.Context, userID int) (*types.UserMFA, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*types.UserMFA), args.Error(1)
+}
+
+func (m *MockAuthStore) UpsertPendingMFA(ctx context.Context, userID int, encryptedSecret string) error {
+	args := m.Called(ctx, userID, encryptedSecret)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) EnableMFA(ctx context.Context, payload types.EnableMFAPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) UpdateMFALastUsedStep(ctx context.Context, userID int, step int64) error {
+	args := m.Called(ctx, userID, step)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) ConsumeMFARecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	args := m.Called(ctx, userID, codeHash)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) GetLoginLockout(ctx context.Context, keys []string) (*time.Time, error) {
+	args := m.Called(ctx, keys)
+	return args.Get(0).(*time.Time), args.Error(1)
+}
+
+func (m *MockAuthStore) RecordFailedLogin(ctx context.Context, key string, window time.Duration) (int, error) {
+	args := m.Called(ctx, key, window)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuthStore) LockLogin(ctx context.Context, key string, until time.Time) error {
+	args := m.Called(ctx, key, until)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) ResetLoginThrottle(ctx context.Context, key string) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) CreateOIDCLoginState(ctx context.Context, payload types.CreateOIDCLoginStatePayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (*types.OIDCLoginState, error) {
+	args := m.Called(ctx, stateHash)
+	return args.Get(0).(*types.OIDCLoginState), args.Error(1)
+}
+
+func (m *MockAuthStore) GetUserIDByIdentity(ctx context.Context, issuer string, subject string) (int, error) {
+	args := m.Called(ctx, issuer, subject)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockAuthStore) LinkIdentity(ctx context.Context, payload types.LinkIdentityPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) CreateOAuthClient(ctx context.Context, payload types.CreateOAuthClientDatabasePayload) (*types.OAuthClient, error) {
+	args := m.Called(ctx, payload)
+	return args.Get(0).(*types.OAuthClient), args.Error(1)
+}
+
+func (m *MockAuthStore) GetOAuthClientByClientID(ctx context.Context, clientID string) (*types.OAuthClient, error) {
+	args := m.Called(ctx, clientID)
+	return args.Get(0).(*types.OAuthClient), args.Error(1)
+}
+
+func (m *MockAuthStore) GetOAuthClients(ctx context.Context) ([]*types.OAuthClient, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*types.OAuthClient), args.Error(1)
+}
+
+func (m *MockAuthStore) DeleteOAuthClient(ctx context.Context, clientID string) error {
+	args := m.Called(ctx, clientID)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) CreateOAuthAuthorizationCode(ctx context.Context, payload types.CreateOAuthAuthorizationCodePayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (*types.OAuthAuthorizationCode, error) {
+	args := m.Called(ctx, codeHash)
+	return args.Get(0).(*types.OAuthAuthorizationCode), args.Error(1)
+}
+
+func (m *MockAuthStore) GetOAuthConsent(ctx context.Context, userID int, clientID string) (*types.OAuthConsent, error) {
+	args := m.Called(ctx, userID, clientID)
+	return args.Get(0).(*types.OAuthConsent), args.Error(1)
+}
+
+func (m *MockAuthStore) GetOAuthConsentsByUserID(ctx context.Context, userID int) ([]*types.OAuthConsent, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*types.OAuthConsent), args.Error(1)
+}
+
+func (m *MockAuthStore) UpsertOAuthConsent(ctx context.Context, userID int, clientID string, scopes []string) error {
+	args := m.Called(ctx, userID, clientID, scopes)
+	return args.Error(0)
+}
+
+func (m *MockAuthStore) DeleteOAuthConsent(ctx context.Context, userID int, clientID string) error {
+	args := m.Called(ctx, userID, clientID)
+	return args.Error(0)
+}
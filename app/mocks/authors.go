@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAuthorStore struct {
+	mock.Mock
+}
+
+func (m *MockAuthorStore) Create(ctx context.Context, author types.CreateAuthorPayload) (int, error) {
+	args := m.Called(ctx, author)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockAuthorStore) GetByID(ctx context.Context, id int) (*types.Author, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*types.Author), args.Error(1)
+}
+
+func (m *MockAuthorStore) GetMany(ctx context.Context, options types.GetAuthorsOptions) ([]*types.Author, int, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).([]*types.Author), args.Int(1), args.Error(2)
+}
+
+func (m *MockAuthorStore) UpdateByID(ctx context.Context, id int, author types.UpdateAuthorPayload) (*types.Author, error) {
+	args := m.Called(ctx, id, author)
+	return args.Get(0).(*types.Author), args.Error(1)
+}
+
+func (m *MockAuthorStore) DeleteByID(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
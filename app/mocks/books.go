@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/hoyci/book-store-api/types"
 	"github.com/stretchr/testify/mock"
@@ -21,9 +22,19 @@ func (m *MockBookStore) GetByID(ctx context.Context, id int) (*types.Book, error
 	return args.Get(0).(*types.Book), args.Error(1)
 }
 
-func (m *MockBookStore) GetMany(ctx context.Context) ([]*types.Book, error) {
-	args := m.Called(ctx)
-	return args.Get(0).([]*types.Book), args.Error(1)
+func (m *MockBookStore) GetByISBN(ctx context.Context, isbn13 string) (*types.Book, error) {
+	args := m.Called(ctx, isbn13)
+	return args.Get(0).(*types.Book), args.Error(1)
+}
+
+func (m *MockBookStore) GetMany(ctx context.Context, options types.GetBooksOptions) ([]*types.Book, int, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).([]*types.Book), args.Int(1), args.Error(2)
+}
+
+func (m *MockBookStore) Search(ctx context.Context, options types.SearchBooksOptions) ([]*types.BookSearchResult, int, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).([]*types.BookSearchResult), args.Int(1), args.Error(2)
 }
 
 func (m *MockBookStore) UpdateByID(ctx context.Context, id int, newBook types.UpdateBookPayload) (*types.Book, error) {
@@ -36,3 +47,68 @@ func (m *MockBookStore) DeleteByID(ctx context.Context, id int) error {
 
 	return args.Error(0)
 }
+
+func (m *MockBookStore) GetAll(ctx context.Context, options types.GetAllBooksOptions) ([]*types.Book, int, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).([]*types.Book), args.Int(1), args.Error(2)
+}
+
+func (m *MockBookStore) DisableByID(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockBookStore) RestoreByID(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockBookStore) GetDeleted(ctx context.Context, options types.GetDeletedBooksOptions) ([]*types.Book, int, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).([]*types.Book), args.Int(1), args.Error(2)
+}
+
+func (m *MockBookStore) RestoreDeletedByID(ctx context.Context, id int, deletedAfter time.Time) error {
+	args := m.Called(ctx, id, deletedAfter)
+	return args.Error(0)
+}
+
+func (m *MockBookStore) ShareByID(ctx context.Context, id int, share types.ShareBookPayload) (*types.BookShare, error) {
+	args := m.Called(ctx, id, share)
+	return args.Get(0).(*types.BookShare), args.Error(1)
+}
+
+func (m *MockBookStore) GetSharesByID(ctx context.Context, id int) ([]*types.BookShare, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]*types.BookShare), args.Error(1)
+}
+
+func (m *MockBookStore) RevokeShareByID(ctx context.Context, id int, userID int) error {
+	args := m.Called(ctx, id, userID)
+	return args.Error(0)
+}
+
+func (m *MockBookStore) GetAuthorsByID(ctx context.Context, id int) ([]*types.BookAuthor, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]*types.BookAuthor), args.Error(1)
+}
+
+func (m *MockBookStore) SetAuthorsByID(ctx context.Context, id int, authors []types.BookAuthorPayload) ([]*types.BookAuthor, error) {
+	args := m.Called(ctx, id, authors)
+	return args.Get(0).([]*types.BookAuthor), args.Error(1)
+}
+
+func (m *MockBookStore) CheckCanEditByID(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockBookStore) SetCoverByID(ctx context.Context, id int, cover types.BookCover) (*types.BookCover, error) {
+	args := m.Called(ctx, id, cover)
+	return args.Get(0).(*types.BookCover), args.Error(1)
+}
+
+func (m *MockBookStore) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Int(0), args.Error(1)
+}
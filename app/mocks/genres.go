@@ -0,0 +1,37 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockGenreStore struct {
+	mock.Mock
+}
+
+func (m *MockGenreStore) Create(ctx context.Context, genre types.CreateGenrePayload) (int, error) {
+	args := m.Called(ctx, genre)
+	return args.Get(0).(int), args.Error(1)
+}
+
+func (m *MockGenreStore) GetByID(ctx context.Context, id int) (*types.Genre, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(*types.Genre), args.Error(1)
+}
+
+func (m *MockGenreStore) GetAll(ctx context.Context) ([]*types.Genre, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]*types.Genre), args.Error(1)
+}
+
+func (m *MockGenreStore) UpdateByID(ctx context.Context, id int, genre types.UpdateGenrePayload) (*types.Genre, error) {
+	args := m.Called(ctx, id, genre)
+	return args.Get(0).(*types.Genre), args.Error(1)
+}
+
+func (m *MockGenreStore) DeleteByID(ctx context.Context, id int) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
@@ -0,0 +1,22 @@
+package mocks
+
+import (
+	"context"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockOIDCProvider struct {
+	mock.Mock
+}
+
+func (m *MockOIDCProvider) AuthCodeURL(state string, nonce string, codeChallenge string) string {
+	args := m.Called(state, nonce, codeChallenge)
+	return args.String(0)
+}
+
+func (m *MockOIDCProvider) Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*types.OIDCIdentity, error) {
+	args := m.Called(ctx, code, codeVerifier, nonce)
+	return args.Get(0).(*types.OIDCIdentity), args.Error(1)
+}
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/hoyci/book-store-api/types"
 	"github.com/stretchr/testify/mock"
@@ -35,3 +36,88 @@ func (m *MockUserStore) DeleteByID(ctx context.Context, userID int) error {
 	args := m.Called(ctx, userID)
 	return args.Error(0)
 }
+
+func (m *MockUserStore) GetMany(ctx context.Context, options types.GetUsersOptions) ([]*types.UserResponse, int, error) {
+	args := m.Called(ctx, options)
+	return args.Get(0).([]*types.UserResponse), args.Int(1), args.Error(2)
+}
+
+func (m *MockUserStore) GetAnyByID(ctx context.Context, userID int) (*types.UserResponse, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*types.UserResponse), args.Error(1)
+}
+
+func (m *MockUserStore) HardDeleteByID(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) DisableByID(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) RestoreByID(ctx context.Context, userID int) error {
+	args := m.Called(ctx, userID)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) ExportByID(ctx context.Context, userID int) (*types.UserDataExport, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).(*types.UserDataExport), args.Error(1)
+}
+
+func (m *MockUserStore) GetDeletedByEmail(ctx context.Context, email string, deletedAfter time.Time) (*types.GetByEmailResponse, error) {
+	args := m.Called(ctx, email, deletedAfter)
+	return args.Get(0).(*types.GetByEmailResponse), args.Error(1)
+}
+
+func (m *MockUserStore) UpdateRoleByID(ctx context.Context, userID int, role string) (*types.UserResponse, error) {
+	args := m.Called(ctx, userID, role)
+	return args.Get(0).(*types.UserResponse), args.Error(1)
+}
+
+func (m *MockUserStore) GetPasswordHashByID(ctx context.Context, userID int) (string, error) {
+	args := m.Called(ctx, userID)
+	return args.String(0), args.Error(1)
+}
+
+func (m *MockUserStore) UpdatePasswordByID(ctx context.Context, userID int, passwordHash string) error {
+	args := m.Called(ctx, userID, passwordHash)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) CreateEmailVerificationToken(ctx context.Context, payload types.CreateEmailVerificationTokenPayload) error {
+	args := m.Called(ctx, payload)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) VerifyEmail(ctx context.Context, tokenHash string) (int, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Int(0), args.Error(1)
+}
+
+func (m *MockUserStore) CreateAPIKey(ctx context.Context, payload types.CreateAPIKeyDatabasePayload) (*types.APIKey, error) {
+	args := m.Called(ctx, payload)
+	return args.Get(0).(*types.APIKey), args.Error(1)
+}
+
+func (m *MockUserStore) GetAPIKeysByUserID(ctx context.Context, userID int) ([]*types.APIKey, error) {
+	args := m.Called(ctx, userID)
+	return args.Get(0).([]*types.APIKey), args.Error(1)
+}
+
+func (m *MockUserStore) DeleteAPIKey(ctx context.Context, userID int, id int) error {
+	args := m.Called(ctx, userID, id)
+	return args.Error(0)
+}
+
+func (m *MockUserStore) AuthenticateAPIKey(ctx context.Context, tokenHash string) (*types.APIKeyOwner, error) {
+	args := m.Called(ctx, tokenHash)
+	return args.Get(0).(*types.APIKeyOwner), args.Error(1)
+}
+
+func (m *MockUserStore) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	args := m.Called(ctx, deletedBefore)
+	return args.Int(0), args.Error(1)
+}
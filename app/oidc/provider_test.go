@@ -0,0 +1,246 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hoyci/book-store-api/oidc"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockOIDCServer is a minimal OpenID Connect provider. It issues an ID token
+// with the claims set by the test for any code whose PKCE verifier matches
+// codeChallenge. Its JWKS always publishes the key it was created with as
+// mock-key, so tests can change signingKey or kid to forge tokens.
+type mockOIDCServer struct {
+	*httptest.Server
+	signingKey    *rsa.PrivateKey
+	kid           string
+	codeChallenge string
+	claims        jwt.MapClaims
+}
+
+func newMockOIDCServer(t *testing.T) *mockOIDCServer {
+	signingKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+
+	server := &mockOIDCServer{signingKey: signingKey, kid: "mock-key"}
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 server.URL,
+			"authorization_endpoint": server.URL + "/authorize",
+			"token_endpoint":         server.URL + "/token",
+			"jwks_uri":               server.URL + "/jwks",
+		})
+	})
+
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(types.JWKSResponse{Keys: []types.JWK{{
+			Kty: "RSA",
+			Kid: "mock-key",
+			Use: "sig",
+			Alg: "RS256",
+			N:   base64.RawURLEncoding.EncodeToString(signingKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(signingKey.E)).Bytes()),
+		}}})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+
+		clientID, clientSecret, _ := r.BasicAuth()
+		if clientID != "book-store" || clientSecret != "client-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_client"})
+			return
+		}
+
+		if r.Form.Get("code") != "valid-code" || utils.PKCEChallenge(r.Form.Get("code_verifier")) != server.codeChallenge {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, server.claims)
+		token.Header["kid"] = server.kid
+		idToken, _ := token.SignedString(server.signingKey)
+
+		json.NewEncoder(w).Encode(map[string]string{"id_token": idToken, "access_token": "access", "token_type": "Bearer"})
+	})
+
+	server.Server = httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func (s *mockOIDCServer) validClaims(nonce string) jwt.MapClaims {
+	return jwt.MapClaims{
+		"iss":            s.URL,
+		"sub":            "248289761001",
+		"aud":            "book-store",
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+		"nonce":          nonce,
+		"email":          "johndoe@email.com",
+		"email_verified": true,
+	}
+}
+
+func newProvider(t *testing.T, server *mockOIDCServer) *oidc.Provider {
+	provider, err := oidc.NewProvider(context.Background(), oidc.Config{
+		IssuerURL:    server.URL,
+		ClientID:     "book-store",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://localhost:8080/api/v1/auth/oidc/callback",
+		Scopes:       []string{"openid", "email"},
+	}, server.Client())
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+
+	return provider
+}
+
+func TestProvider(t *testing.T) {
+	t.Run("it should build the authorization URL with PKCE", func(t *testing.T) {
+		server := newMockOIDCServer(t)
+		provider := newProvider(t, server)
+
+		authURL, err := url.Parse(provider.AuthCodeURL("state", "nonce", "challenge"))
+		assert.NoError(t, err)
+
+		query := authURL.Query()
+		assert.Equal(t, server.URL+"/authorize", authURL.Scheme+"://"+authURL.Host+authURL.Path)
+		assert.Equal(t, "code", query.Get("response_type"))
+		assert.Equal(t, "book-store", query.Get("client_id"))
+		assert.Equal(t, "openid email", query.Get("scope"))
+		assert.Equal(t, "state", query.Get("state"))
+		assert.Equal(t, "nonce", query.Get("nonce"))
+		assert.Equal(t, "challenge", query.Get("code_challenge"))
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+	})
+
+	t.Run("it should refuse a discovery document for another issuer", func(t *testing.T) {
+		server := newMockOIDCServer(t)
+
+		_, err := oidc.NewProvider(context.Background(), oidc.Config{IssuerURL: server.URL + "/other"}, server.Client())
+
+		assert.Error(t, err)
+	})
+
+	t.Run("it should exchange the code and return the verified identity", func(t *testing.T) {
+		server := newMockOIDCServer(t)
+		provider := newProvider(t, server)
+
+		server.codeChallenge = utils.PKCEChallenge("code-verifier")
+		server.claims = server.validClaims("nonce")
+
+		identity, err := provider.Exchange(context.Background(), "valid-code", "code-verifier", "nonce")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.OIDCIdentity{
+			Issuer:        server.URL,
+			Subject:       "248289761001",
+			Email:         "johndoe@email.com",
+			EmailVerified: true,
+		}, identity)
+	})
+
+	t.Run("it should fail when the code verifier does not match the challenge", func(t *testing.T) {
+		server := newMockOIDCServer(t)
+		provider := newProvider(t, server)
+
+		server.codeChallenge = utils.PKCEChallenge("code-verifier")
+		server.claims = server.validClaims("nonce")
+
+		_, err := provider.Exchange(context.Background(), "valid-code", "another-verifier", "nonce")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("it should refuse an ID token with another nonce", func(t *testing.T) {
+		server := newMockOIDCServer(t)
+		provider := newProvider(t, server)
+
+		server.codeChallenge = utils.PKCEChallenge("code-verifier")
+		server.claims = server.validClaims("another-nonce")
+
+		_, err := provider.Exchange(context.Background(), "valid-code", "code-verifier", "nonce")
+
+		assert.ErrorContains(t, err, "nonce")
+	})
+
+	t.Run("it should refuse an ID token issued to another client", func(t *testing.T) {
+		server := newMockOIDCServer(t)
+		provider := newProvider(t, server)
+
+		server.codeChallenge = utils.PKCEChallenge("code-verifier")
+		server.claims = server.validClaims("nonce")
+		server.claims["aud"] = "another-client"
+
+		_, err := provider.Exchange(context.Background(), "valid-code", "code-verifier", "nonce")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("it should refuse an expired ID token", func(t *testing.T) {
+		server := newMockOIDCServer(t)
+		provider := newProvider(t, server)
+
+		server.codeChallenge = utils.PKCEChallenge("code-verifier")
+		server.claims = server.validClaims("nonce")
+		server.claims["exp"] = time.Now().Add(-time.Hour).Unix()
+
+		_, err := provider.Exchange(context.Background(), "valid-code", "code-verifier", "nonce")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("it should refuse an ID token signed with an unpublished key", func(t *testing.T) {
+		server := newMockOIDCServer(t)
+		provider := newProvider(t, server)
+
+		server.codeChallenge = utils.PKCEChallenge("code-verifier")
+		server.claims = server.validClaims("nonce")
+
+		forgedKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Failed to generate RSA key: %v", err)
+		}
+		server.signingKey = forgedKey
+
+		_, err = provider.Exchange(context.Background(), "valid-code", "code-verifier", "nonce")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("it should refuse an ID token naming an unknown key", func(t *testing.T) {
+		server := newMockOIDCServer(t)
+		provider := newProvider(t, server)
+
+		server.codeChallenge = utils.PKCEChallenge("code-verifier")
+		server.claims = server.validClaims("nonce")
+		server.kid = "unknown-key"
+
+		_, err := provider.Exchange(context.Background(), "valid-code", "code-verifier", "nonce")
+
+		assert.ErrorContains(t, err, "unknown key id")
+	})
+}
@@ -0,0 +1,75 @@
+package purger
+
+import (
+	"context"
+	"time"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/sirupsen/logrus"
+)
+
+type target struct {
+	name  string
+	store types.TrashPurger
+}
+
+// Purger hard-deletes soft-deleted rows once they have been in the trash for
+// longer than the retention period. Targets are purged in the order they
+// were added.
+type Purger struct {
+	retention time.Duration
+	interval  time.Duration
+	targets   []target
+}
+
+func New(retention time.Duration, interval time.Duration) *Purger {
+	return &Purger{retention: retention, interval: interval}
+}
+
+func (p *Purger) Add(name string, store types.TrashPurger) *Purger {
+	p.targets = append(p.targets, target{name: name, store: store})
+	return p
+}
+
+// Run purges right away and then on every interval until ctx is done.
+func (p *Purger) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		p.PurgeOnce(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// PurgeOnce runs every target once. A failing target is logged and doesn't
+// stop the others, it will be retried on the next run.
+func (p *Purger) PurgeOnce(ctx context.Context) {
+	deletedBefore := time.Now().Add(-p.retention)
+
+	for _, target := range p.targets {
+		purged, err := target.store.PurgeDeleted(ctx, deletedBefore)
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"target": target.name,
+				"purged": purged,
+				"error":  err.Error(),
+			}).Error("Failed to purge deleted rows")
+			continue
+		}
+
+		if purged > 0 {
+			utils.Log.WithFields(logrus.Fields{
+				"target":         target.name,
+				"purged":         purged,
+				"deleted_before": deletedBefore,
+			}).Info("Purged deleted rows")
+		}
+	}
+}
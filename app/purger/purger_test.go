@@ -0,0 +1,91 @@
+package purger
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	mu      sync.Mutex
+	calls   []time.Time
+	results []int
+	err     error
+}
+
+func (f *fakeStore) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls = append(f.calls, deletedBefore)
+	if f.err != nil {
+		return 0, f.err
+	}
+
+	return f.results[len(f.calls)-1], nil
+}
+
+func TestPurgeOnce(t *testing.T) {
+	utils.InitLogger()
+
+	t.Run("it should purge every target with the retention cutoff", func(t *testing.T) {
+		books := &fakeStore{results: []int{3}}
+		users := &fakeStore{results: []int{0}}
+
+		New(24*time.Hour, time.Hour).
+			Add("books", books).
+			Add("users", users).
+			PurgeOnce(context.Background())
+
+		assert.Len(t, books.calls, 1)
+		assert.Len(t, users.calls, 1)
+		assert.WithinDuration(t, time.Now().Add(-24*time.Hour), books.calls[0], time.Minute)
+		assert.Equal(t, books.calls[0], users.calls[0])
+	})
+
+	t.Run("it should keep going when a target fails", func(t *testing.T) {
+		books := &fakeStore{err: fmt.Errorf("database error")}
+		users := &fakeStore{results: []int{1}}
+
+		New(24*time.Hour, time.Hour).
+			Add("books", books).
+			Add("users", users).
+			PurgeOnce(context.Background())
+
+		assert.Len(t, books.calls, 1)
+		assert.Len(t, users.calls, 1)
+	})
+}
+
+func TestRun(t *testing.T) {
+	utils.InitLogger()
+
+	t.Run("it should purge right away and stop when the context is done", func(t *testing.T) {
+		books := &fakeStore{results: []int{0, 0, 0}}
+		ctx, cancel := context.WithCancel(context.Background())
+
+		done := make(chan struct{})
+		go func() {
+			New(24*time.Hour, time.Hour).Add("books", books).Run(ctx)
+			close(done)
+		}()
+
+		assert.Eventually(t, func() bool {
+			books.mu.Lock()
+			defer books.mu.Unlock()
+			return len(books.calls) > 0
+		}, time.Second, 10*time.Millisecond)
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("Run did not return after the context was canceled")
+		}
+	})
+}
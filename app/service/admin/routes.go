@@ -0,0 +1,666 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+)
+
+var validate = validator.New()
+
+const (
+	defaultPage  = 1
+	defaultLimit = 20
+)
+
+type AdminHandler struct {
+	userStore  types.UserStore
+	bookStore  types.BookStore
+	authStore  types.AuthStore
+	auditStore types.AuditStore
+}
+
+func NewAdminHandler(userStore types.UserStore, bookStore types.BookStore, authStore types.AuthStore, auditStore types.AuditStore) *AdminHandler {
+	return &AdminHandler{
+		userStore:  userStore,
+		bookStore:  bookStore,
+		authStore:  authStore,
+		auditStore: auditStore,
+	}
+}
+
+func parsePagination(query url.Values) (page int, limit int, err error) {
+	page, limit = defaultPage, defaultLimit
+
+	if value := query.Get("page"); value != "" {
+		page, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("'page' must be an integer")
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("'limit' must be an integer")
+		}
+	}
+
+	return page, limit, nil
+}
+
+func parseID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, fmt.Errorf("id must be positive, got %d", id)
+	}
+
+	return id, nil
+}
+
+func writeValidationError(w http.ResponseWriter, err error, context string) {
+	var errorMessages []string
+	for _, e := range err.(validator.ValidationErrors) {
+		errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+	}
+
+	utils.WriteError(w, http.StatusBadRequest, err, context, types.BadRequestStructResponse{Error: errorMessages})
+}
+
+// audit records an action that already succeeded. Callers fail the request
+// when it can't be written, so that an admin is never left believing an
+// action went through unnoticed; the action itself can't be undone by then.
+func (h *AdminHandler) audit(r *http.Request, action string, targetType string, targetID int, details map[string]string) error {
+	actorID, _ := utils.GetClaimFromContext[int](r, "UserID")
+
+	err := h.auditStore.CreateAuditLog(
+		r.Context(),
+		types.CreateAuditLogPayload{
+			ActorID:    actorID,
+			Action:     action,
+			TargetType: targetType,
+			TargetID:   targetID,
+			Details:    details,
+			IPAddress:  utils.ClientIP(r),
+			UserAgent:  r.UserAgent(),
+		},
+	)
+	if err != nil {
+		return fmt.Errorf("error recording %s of %s %d by user %d: %w", action, targetType, targetID, actorID, err)
+	}
+
+	return nil
+}
+
+// @Summary Listar todos os usuários
+// @Description Lista todos os usuários, incluindo os desativados. Apenas administradores.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param search query string false "Trecho do username ou do email"
+// @Param page query int false "Página (padrão 1)"
+// @Param limit query int false "Itens por página (padrão 20, máximo 100)"
+// @Success 200 {object} types.GetUsersResponse "Lista de usuários"
+// @Failure 400 {object} types.BadRequestResponse "Query parameter is not a valid integer"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for query parameters"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/users [get]
+func (h *AdminHandler) HandleGetUsers(w http.ResponseWriter, r *http.Request) {
+	page, limit, err := parsePagination(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetUsers", types.BadRequestResponse{Error: fmt.Sprintf("Query parameter %s", err.Error())})
+		return
+	}
+
+	options := types.GetUsersOptions{Page: page, Limit: limit, Search: strings.TrimSpace(r.URL.Query().Get("search"))}
+	if err := validate.Struct(options); err != nil {
+		writeValidationError(w, err, "HandleGetUsers")
+		return
+	}
+
+	users, total, err := h.userStore.GetMany(r.Context(), options)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetUsers", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetUsers", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetUsersResponse{
+		Users:      users,
+		Total:      total,
+		Page:       options.Page,
+		Limit:      options.Limit,
+		TotalPages: (total + options.Limit - 1) / options.Limit,
+	})
+}
+
+// @Summary Alterar o papel de um usuário
+// @Description Os access tokens já emitidos para o usuário deixam de valer, pois carregam o papel anterior. As sessões recebem o novo papel ao renovar o access token.
+// @Tags Admin
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do usuário"
+// @Param request body types.UpdateUserRolePayload true "Novo papel do usuário"
+// @Success 200 {object} types.UserResponse "Usuário atualizado"
+// @Failure 400 {object} types.BadRequestResponse "User ID must be a positive integer"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No user found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/users/{id}/role [put]
+func (h *AdminHandler) HandleUpdateUserRole(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleUpdateUserRole", types.BadRequestResponse{Error: "User ID must be a positive integer"})
+		return
+	}
+
+	var payload types.UpdateUserRolePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleUpdateUserRole", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		writeValidationError(w, err, "HandleUpdateUserRole")
+		return
+	}
+
+	adminID, _ := utils.GetClaimFromContext[int](r, "UserID")
+	if id == adminID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("admin %d tried to change their own role", adminID), "HandleUpdateUserRole", types.BadRequestResponse{Error: "You cannot change your own role"})
+		return
+	}
+
+	updatedUser, err := h.userStore.UpdateRoleByID(r.Context(), id, payload.Role)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleUpdateUserRole", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleUpdateUserRole", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUpdateUserRole", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if err := h.audit(r, types.AuditActionUserRoleUpdated, types.AuditTargetUser, id, map[string]string{"role": payload.Role}); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUpdateUserRole", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, updatedUser)
+}
+
+// @Summary Desativar um usuário
+// @Description O usuário não consegue mais entrar e os access tokens já emitidos são recusados na hora.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do usuário"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "User ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No user found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/users/{id}/disable [post]
+func (h *AdminHandler) HandleDisableUser(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleDisableUser", types.BadRequestResponse{Error: "User ID must be a positive integer"})
+		return
+	}
+
+	adminID, _ := utils.GetClaimFromContext[int](r, "UserID")
+	if id == adminID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("admin %d tried to disable their own account", adminID), "HandleDisableUser", types.BadRequestResponse{Error: "You cannot disable your own account"})
+		return
+	}
+
+	err = h.userStore.DisableByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleDisableUser", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleDisableUser", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDisableUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if err := h.audit(r, types.AuditActionUserDisabled, types.AuditTargetUser, id, nil); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDisableUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Reativar um usuário
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do usuário"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "User ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No disabled user found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/users/{id}/restore [post]
+func (h *AdminHandler) HandleRestoreUser(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleRestoreUser", types.BadRequestResponse{Error: "User ID must be a positive integer"})
+		return
+	}
+
+	err = h.userStore.RestoreByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleRestoreUser", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleRestoreUser", types.NotFoundResponse{Error: fmt.Sprintf("No disabled user found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if err := h.audit(r, types.AuditActionUserRestored, types.AuditTargetUser, id, nil); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Consultar um usuário
+// @Description Retorna qualquer usuário, incluindo os desativados. Apenas administradores.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do usuário"
+// @Success 200 {object} types.UserResponse "Usuário"
+// @Failure 400 {object} types.BadRequestResponse "User ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No user found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/users/{id} [get]
+func (h *AdminHandler) HandleGetUser(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetUser", types.BadRequestResponse{Error: "User ID must be a positive integer"})
+		return
+	}
+
+	foundUser, err := h.userStore.GetAnyByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetUser", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleGetUser", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, foundUser)
+}
+
+// @Summary Encerrar as sessões de um usuário
+// @Description Revoga todos os refresh tokens do usuário, incluindo os emitidos para aplicativos OAuth, e recusa na hora os access tokens já emitidos. Chaves de API não são afetadas.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do usuário"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "User ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No user found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/users/{id}/logout [post]
+func (h *AdminHandler) HandleLogoutUser(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleLogoutUser", types.BadRequestResponse{Error: "User ID must be a positive integer"})
+		return
+	}
+
+	_, err = h.userStore.GetAnyByID(r.Context(), id)
+	if err == nil {
+		err = h.authStore.RevokeTokensByUserID(r.Context(), id)
+	}
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleLogoutUser", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleLogoutUser", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleLogoutUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if err := h.audit(r, types.AuditActionUserLoggedOut, types.AuditTargetUser, id, nil); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleLogoutUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Excluir um usuário definitivamente
+// @Description Remove o usuário e todos os seus dados, incluindo os livros que só ele possui e suas capas enviadas. Não pode ser desfeito.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do usuário"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "User ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No user found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/users/{id} [delete]
+func (h *AdminHandler) HandleDeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleDeleteUser", types.BadRequestResponse{Error: "User ID must be a positive integer"})
+		return
+	}
+
+	adminID, _ := utils.GetClaimFromContext[int](r, "UserID")
+	if id == adminID {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("admin %d tried to delete their own account", adminID), "HandleDeleteUser", types.BadRequestResponse{Error: "You cannot delete your own account"})
+		return
+	}
+
+	err = h.userStore.HardDeleteByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleDeleteUser", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleDeleteUser", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDeleteUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if err := h.audit(r, types.AuditActionUserDeleted, types.AuditTargetUser, id, nil); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDeleteUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Listar o registro de auditoria
+// @Description Lista as ações feitas pela API de administração, das mais recentes para as mais antigas. Apenas administradores.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param actor_id query int false "ID de quem executou a ação"
+// @Param target_type query string false "user ou book"
+// @Param target_id query int false "ID do alvo, usado junto com target_type"
+// @Param page query int false "Página (padrão 1)"
+// @Param limit query int false "Itens por página (padrão 20, máximo 100)"
+// @Success 200 {object} types.GetAuditLogsResponse "Registro de auditoria"
+// @Failure 400 {object} types.BadRequestResponse "Query parameter is not a valid integer"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for query parameters"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/audit-logs [get]
+func (h *AdminHandler) HandleGetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	page, limit, err := parsePagination(query)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetAuditLogs", types.BadRequestResponse{Error: fmt.Sprintf("Query parameter %s", err.Error())})
+		return
+	}
+
+	options := types.GetAuditLogsOptions{Page: page, Limit: limit, TargetType: query.Get("target_type")}
+
+	if value := query.Get("actor_id"); value != "" {
+		options.ActorID, err = strconv.Atoi(value)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleGetAuditLogs", types.BadRequestResponse{Error: "Query parameter 'actor_id' must be an integer"})
+			return
+		}
+	}
+
+	if value := query.Get("target_id"); value != "" {
+		options.TargetID, err = strconv.Atoi(value)
+		if err != nil {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleGetAuditLogs", types.BadRequestResponse{Error: "Query parameter 'target_id' must be an integer"})
+			return
+		}
+	}
+
+	if err := validate.Struct(options); err != nil {
+		writeValidationError(w, err, "HandleGetAuditLogs")
+		return
+	}
+
+	auditLogs, total, err := h.auditStore.GetAuditLogs(r.Context(), options)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetAuditLogs", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetAuditLogs", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetAuditLogsResponse{
+		AuditLogs:  auditLogs,
+		Total:      total,
+		Page:       options.Page,
+		Limit:      options.Limit,
+		TotalPages: (total + options.Limit - 1) / options.Limit,
+	})
+}
+
+// @Summary Listar todos os livros
+// @Description Lista todos os livros, de qualquer usuário e incluindo os desativados. Apenas administradores.
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param page query int false "Página (padrão 1)"
+// @Param limit query int false "Itens por página (padrão 20, máximo 100)"
+// @Success 200 {object} types.GetAllBooksResponse "Lista de livros"
+// @Failure 400 {object} types.BadRequestResponse "Query parameter is not a valid integer"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for query parameters"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/books [get]
+func (h *AdminHandler) HandleGetAllBooks(w http.ResponseWriter, r *http.Request) {
+	page, limit, err := parsePagination(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetAllBooks", types.BadRequestResponse{Error: fmt.Sprintf("Query parameter %s", err.Error())})
+		return
+	}
+
+	options := types.GetAllBooksOptions{Page: page, Limit: limit}
+	if err := validate.Struct(options); err != nil {
+		writeValidationError(w, err, "HandleGetAllBooks")
+		return
+	}
+
+	books, total, err := h.bookStore.GetAll(r.Context(), options)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetAllBooks", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetAllBooks", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetAllBooksResponse{
+		Books:      books,
+		Total:      total,
+		Page:       options.Page,
+		Limit:      options.Limit,
+		TotalPages: (total + options.Limit - 1) / options.Limit,
+	})
+}
+
+// @Summary Desativar um livro
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do livro"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Book ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No active book found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/books/{id}/disable [post]
+func (h *AdminHandler) HandleDisableBook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleDisableBook", types.BadRequestResponse{Error: "Book ID must be a positive integer"})
+		return
+	}
+
+	err = h.bookStore.DisableByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleDisableBook", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleDisableBook", types.NotFoundResponse{Error: fmt.Sprintf("No active book found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDisableBook", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if err := h.audit(r, types.AuditActionBookDisabled, types.AuditTargetBook, id, nil); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDisableBook", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Reativar um livro
+// @Tags Admin
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do livro"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Book ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No disabled book found with given ID"
+// @Failure 409 {object} types.ConflictResponse "Another book with this ISBN is already in the owner's library"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /admin/books/{id}/restore [post]
+func (h *AdminHandler) HandleRestoreBook(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleRestoreBook", types.BadRequestResponse{Error: "Book ID must be a positive integer"})
+		return
+	}
+
+	err = h.bookStore.RestoreByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleRestoreBook", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleRestoreBook", types.NotFoundResponse{Error: fmt.Sprintf("No disabled book found with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, types.ErrBookISBNExists) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleRestoreBook", types.ConflictResponse{Error: "Another book with this ISBN is already in the owner's library"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreBook", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if err := h.audit(r, types.AuditActionBookRestored, types.AuditTargetBook, id, nil); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreBook", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,830 @@
+package admin_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/cmd/api"
+	"github.com/hoyci/book-store-api/mocks"
+	"github.com/hoyci/book-store-api/service/admin"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+// setupTestServer accepts any audit log, tests check the ones they expect
+// with AssertCalled.
+func setupTestServer() (*mocks.MockUserStore, *mocks.MockBookStore, *mocks.MockAuthStore, *mocks.MockAuditStore, *httptest.Server, *mux.Router) {
+	mockUserStore := new(mocks.MockUserStore)
+	mockBookStore := new(mocks.MockBookStore)
+	mockAuthStore := new(mocks.MockAuthStore)
+	mockAuditStore := new(mocks.MockAuditStore)
+	mockAuditStore.On("CreateAuditLog", mock.Anything, mock.Anything).Return(nil)
+	mockAdminHandler := admin.NewAdminHandler(mockUserStore, mockBookStore, mockAuthStore, mockAuditStore)
+	apiServer := api.NewApiServer(":8080", nil)
+	router := apiServer.SetupRouter(nil, nil, nil, nil, mockAdminHandler, nil, nil)
+	ts := httptest.NewServer(router)
+	return mockUserStore, mockBookStore, mockAuthStore, mockAuditStore, ts, router
+}
+
+func TestHandleGetUsers(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should forbid users that are not admins", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		for _, role := range []string{types.RoleReader, types.RoleLibrarian} {
+			req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/users", nil)
+			req.Header.Set("Authorization", "Bearer "+utils.GenerateTestTokenWithRole(2, "JohnDoe", "johndoe@email.com", role))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+			responseBody, _ := io.ReadAll(res.Body)
+			expected := `{"error":"You do not have permission to access this resource"}`
+			assert.JSONEq(t, expected, string(responseBody))
+		}
+
+		mockUserStore.AssertNotCalled(t, "GetMany", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when limit is out of range", func(t *testing.T) {
+		_, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/users?limit=500", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'Limit' is invalid: lte"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should return error when the request context is canceled", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		canceledCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		mockUserStore.On("GetMany", mock.Anything, mock.Anything).Return(([]*types.UserResponse)(nil), 0, context.Canceled)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/users", nil).WithContext(canceledCtx)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+
+	t.Run("it should list every user, disabled ones included", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		deletedAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		mockUserStore.On("GetMany", mock.Anything, types.GetUsersOptions{Page: 2, Limit: 1}).Return(
+			[]*types.UserResponse{
+				{
+					ID:        2,
+					Username:  "JohnDoe",
+					Email:     "johndoe@email.com",
+					Role:      types.RoleReader,
+					CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+					DeletedAt: &deletedAt,
+				},
+			},
+			3,
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/users?page=2&limit=1", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{
+			"users": [
+				{"id":2,"username":"JohnDoe","email":"johndoe@email.com","role":"reader","emailVerifiedAt":null,"createdAt":"2025-01-01T00:00:00Z","deletedAt":"2025-02-01T00:00:00Z","updatedAt":null}
+			],
+			"total": 3,
+			"page": 2,
+			"limit": 1,
+			"total_pages": 3
+		}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should search users by username or email", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("GetMany", mock.Anything, types.GetUsersOptions{Page: 1, Limit: 20, Search: "john"}).Return([]*types.UserResponse{}, 0, nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/users?search=john", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"users":[],"total":0,"page":1,"limit":20,"total_pages":0}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleUpdateUserRole(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when role is not valid", func(t *testing.T) {
+		_, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/admin/users/2/role", bytes.NewBufferString(`{"role":"owner"}`))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'Role' is invalid: oneof"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should not let an admin change their own role", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/admin/users/1/role", bytes.NewBufferString(`{"role":"reader"}`))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		mockUserStore.AssertNotCalled(t, "UpdateRoleByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the user does not exist", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("UpdateRoleByID", mock.Anything, 42, types.RoleLibrarian).Return((*types.UserResponse)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/admin/users/42/role", bytes.NewBufferString(`{"role":"librarian"}`))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No user found with ID 42"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should change the role of the user", func(t *testing.T) {
+		mockUserStore, _, _, mockAuditStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("UpdateRoleByID", mock.Anything, 2, types.RoleLibrarian).Return(
+			&types.UserResponse{
+				ID:        2,
+				Username:  "JohnDoe",
+				Email:     "johndoe@email.com",
+				Role:      types.RoleLibrarian,
+				CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			},
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/admin/users/2/role", bytes.NewBufferString(`{"role":"librarian"}`))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"id":2,"username":"JohnDoe","email":"johndoe@email.com","role":"librarian","emailVerifiedAt":null,"createdAt":"2025-01-01T00:00:00Z","deletedAt":null,"updatedAt":null}`
+		assert.JSONEq(t, expected, string(responseBody))
+
+		mockAuditStore.AssertCalled(t, "CreateAuditLog", mock.Anything, mock.MatchedBy(func(payload types.CreateAuditLogPayload) bool {
+			return payload.ActorID == 1 &&
+				payload.Action == types.AuditActionUserRoleUpdated &&
+				payload.TargetType == types.AuditTargetUser &&
+				payload.TargetID == 2 &&
+				payload.Details["role"] == types.RoleLibrarian
+		}))
+	})
+}
+
+func TestHandleDisableUser(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when user ID is not a positive integer", func(t *testing.T) {
+		_, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/users/abc/disable", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"User ID must be a positive integer"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the user does not exist", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("DisableByID", mock.Anything, 42).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/users/42/disable", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("it should disable the user", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("DisableByID", mock.Anything, 2).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/users/2/disable", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		mockUserStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleRestoreUser(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when there is no disabled user with the given ID", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("RestoreByID", mock.Anything, 2).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/users/2/restore", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No disabled user found with ID 2"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should restore the user", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("RestoreByID", mock.Anything, 2).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/users/2/restore", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
+
+func TestHandleGetUser(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when the user does not exist", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("GetAnyByID", mock.Anything, 42).Return((*types.UserResponse)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/users/42", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No user found with ID 42"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should return disabled users", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		deletedAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		mockUserStore.On("GetAnyByID", mock.Anything, 2).Return(
+			&types.UserResponse{
+				ID:        2,
+				Username:  "JohnDoe",
+				Email:     "johndoe@email.com",
+				Role:      types.RoleReader,
+				CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				DeletedAt: &deletedAt,
+			},
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/users/2", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"id":2,"username":"JohnDoe","email":"johndoe@email.com","role":"reader","emailVerifiedAt":null,"createdAt":"2025-01-01T00:00:00Z","deletedAt":"2025-02-01T00:00:00Z","updatedAt":null}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleLogoutUser(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when the user does not exist", func(t *testing.T) {
+		mockUserStore, _, mockAuthStore, mockAuditStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("GetAnyByID", mock.Anything, 42).Return((*types.UserResponse)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/users/42/logout", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		mockAuthStore.AssertNotCalled(t, "RevokeTokensByUserID", mock.Anything, mock.Anything)
+		mockAuditStore.AssertNotCalled(t, "CreateAuditLog", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should revoke every session of the user", func(t *testing.T) {
+		mockUserStore, _, mockAuthStore, mockAuditStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("GetAnyByID", mock.Anything, 2).Return(&types.UserResponse{ID: 2}, nil)
+		mockAuthStore.On("RevokeTokensByUserID", mock.Anything, 2).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/users/2/logout", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		mockAuthStore.AssertExpectations(t)
+		mockAuditStore.AssertCalled(t, "CreateAuditLog", mock.Anything, mock.MatchedBy(func(payload types.CreateAuditLogPayload) bool {
+			return payload.ActorID == 1 && payload.Action == types.AuditActionUserLoggedOut && payload.TargetID == 2
+		}))
+	})
+}
+
+func TestHandleDeleteUser(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should not let an admin delete their own account", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/admin/users/1", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"You cannot delete your own account"}`
+		assert.JSONEq(t, expected, string(responseBody))
+		mockUserStore.AssertNotCalled(t, "HardDeleteByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the user does not exist", func(t *testing.T) {
+		mockUserStore, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("HardDeleteByID", mock.Anything, 42).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/admin/users/42", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("it should delete the user and record it", func(t *testing.T) {
+		mockUserStore, _, _, mockAuditStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("HardDeleteByID", mock.Anything, 2).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/admin/users/2", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		req.Header.Set("User-Agent", "admin-console")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		mockAuditStore.AssertCalled(t, "CreateAuditLog", mock.Anything, mock.MatchedBy(func(payload types.CreateAuditLogPayload) bool {
+			return payload.ActorID == 1 &&
+				payload.Action == types.AuditActionUserDeleted &&
+				payload.TargetType == types.AuditTargetUser &&
+				payload.TargetID == 2 &&
+				payload.UserAgent == "admin-console"
+		}))
+	})
+
+	t.Run("it should fail when the audit log cannot be written", func(t *testing.T) {
+		mockUserStore := new(mocks.MockUserStore)
+		mockAuditStore := new(mocks.MockAuditStore)
+		mockAdminHandler := admin.NewAdminHandler(mockUserStore, new(mocks.MockBookStore), new(mocks.MockAuthStore), mockAuditStore)
+		router := api.NewApiServer(":8080", nil).SetupRouter(nil, nil, nil, nil, mockAdminHandler, nil, nil)
+
+		mockUserStore.On("HardDeleteByID", mock.Anything, 2).Return(nil)
+		mockAuditStore.On("CreateAuditLog", mock.Anything, mock.Anything).Return(fmt.Errorf("database error"))
+
+		req := httptest.NewRequest(http.MethodDelete, "/api/v1/admin/users/2", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusInternalServerError, w.Result().StatusCode)
+	})
+}
+
+func TestHandleGetAuditLogs(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when target type is not valid", func(t *testing.T) {
+		_, _, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/audit-logs?target_type=author", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'TargetType' is invalid: oneof"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should list the audit logs of a target", func(t *testing.T) {
+		_, _, _, mockAuditStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuditStore.On("GetAuditLogs", mock.Anything, types.GetAuditLogsOptions{Page: 1, Limit: 20, TargetType: types.AuditTargetUser, TargetID: 2}).Return(
+			[]*types.AuditLog{
+				{
+					ID:         1,
+					ActorID:    1,
+					Action:     types.AuditActionUserRoleUpdated,
+					TargetType: types.AuditTargetUser,
+					TargetID:   2,
+					Details:    map[string]string{"role": types.RoleLibrarian},
+					IPAddress:  "10.0.0.1",
+					UserAgent:  "admin-console",
+					CreatedAt:  time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC),
+				},
+			},
+			1,
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/audit-logs?target_type=user&target_id=2", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{
+			"audit_logs": [
+				{"id":1,"actor_id":1,"action":"user.role_updated","target_type":"user","target_id":2,"details":{"role":"librarian"},"ip_address":"10.0.0.1","user_agent":"admin-console","created_at":"2025-03-14T10:00:00Z"}
+			],
+			"total": 1,
+			"page": 1,
+			"limit": 20,
+			"total_pages": 1
+		}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleGetAllBooks(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should forbid users that are not admins", func(t *testing.T) {
+		_, mockBookStore, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		for _, role := range []string{types.RoleReader, types.RoleLibrarian} {
+			req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/books", nil)
+			req.Header.Set("Authorization", "Bearer "+utils.GenerateTestTokenWithRole(2, "JohnDoe", "johndoe@email.com", role))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		}
+		mockBookStore.AssertNotCalled(t, "GetAll", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the books cannot be listed", func(t *testing.T) {
+		_, mockBookStore, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("GetAll", mock.Anything, mock.Anything).Return(([]*types.Book)(nil), 0, sql.ErrConnDone)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/books", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("it should let admins list every book", func(t *testing.T) {
+		_, mockBookStore, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("GetAll", mock.Anything, types.GetAllBooksOptions{Page: 1, Limit: 20}).Return(
+			[]*types.Book{
+				{
+					ID:            1,
+					Name:          "Dune",
+					Description:   "Sci-fi classic",
+					Author:        "Frank Herbert",
+					Genres:        []*types.BookGenre{{ID: 2, Name: "Sci-Fi", Slug: "sci-fi"}},
+					ReleaseYear:   1965,
+					NumberOfPages: 412,
+					ImageUrl:      "http://example.com/dune.jpg",
+					CreatedAt:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+				},
+			},
+			1,
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/admin/books", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{
+			"books": [
+				{"id":1,"name":"Dune","description":"Sci-fi classic","author":"Frank Herbert","genres":[{"id":2,"name":"Sci-Fi","slug":"sci-fi"}],"release_year":1965,"number_of_pages":412,"image_url":"http://example.com/dune.jpg","thumbnail_url":null,"isbn_10":null,"isbn_13":null,"created_at":"2025-01-01T00:00:00Z","deleted_at":null,"updated_at":null}
+			],
+			"total": 1,
+			"page": 1,
+			"limit": 20,
+			"total_pages": 1
+		}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleDisableBook(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should forbid librarians", func(t *testing.T) {
+		_, mockBookStore, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/books/7/disable", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestTokenWithRole(3, "librarian", "librarian@email.com", types.RoleLibrarian))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		mockBookStore.AssertNotCalled(t, "DisableByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when there is no active book with the given ID", func(t *testing.T) {
+		_, mockBookStore, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("DisableByID", mock.Anything, 7).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/books/7/disable", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No active book found with ID 7"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should disable any book regardless of ownership", func(t *testing.T) {
+		_, mockBookStore, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("DisableByID", mock.Anything, 7).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/books/7/disable", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
+
+func TestHandleRestoreBook(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when the book cannot be restored", func(t *testing.T) {
+		_, mockBookStore, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("RestoreByID", mock.Anything, 7).Return(sql.ErrConnDone)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/books/7/restore", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("it should restore the book", func(t *testing.T) {
+		_, mockBookStore, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("RestoreByID", mock.Anything, 7).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/admin/books/7/restore", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/hoyci/book-store-api/types"
+)
+
+type AuditStore struct {
+	db *sql.DB
+}
+
+func NewAuditStore(db *sql.DB) *AuditStore {
+	return &AuditStore{db: db}
+}
+
+func (s *AuditStore) CreateAuditLog(ctx context.Context, payload types.CreateAuditLogPayload) error {
+	details := payload.Details
+	if details == nil {
+		details = map[string]string{}
+	}
+
+	encodedDetails, err := json.Marshal(details)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO admin_audit_logs (actor_id, action, target_type, target_id, details, ip_address, user_agent)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		payload.ActorID,
+		payload.Action,
+		payload.TargetType,
+		payload.TargetID,
+		encodedDetails,
+		payload.IPAddress,
+		payload.UserAgent,
+	)
+
+	return err
+}
+
+func (s *AuditStore) GetAuditLogs(ctx context.Context, options types.GetAuditLogsOptions) ([]*types.AuditLog, int, error) {
+	const filter = `WHERE ($1 = 0 OR actor_id = $1)
+           AND ($2 = '' OR target_type = $2)
+           AND ($3 = 0 OR target_id = $3)`
+
+	var total int
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(*) FROM admin_audit_logs "+filter,
+		options.ActorID,
+		options.TargetType,
+		options.TargetID,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, actor_id, action, target_type, target_id, details, COALESCE(ip_address, ''), COALESCE(user_agent, ''), created_at
+         FROM admin_audit_logs `+filter+`
+         ORDER BY created_at DESC, id DESC
+         LIMIT $4 OFFSET $5`,
+		options.ActorID,
+		options.TargetType,
+		options.TargetID,
+		options.Limit,
+		(options.Page-1)*options.Limit,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	auditLogs := []*types.AuditLog{}
+
+	for rows.Next() {
+		auditLog := &types.AuditLog{}
+		var details []byte
+		err := rows.Scan(
+			&auditLog.ID,
+			&auditLog.ActorID,
+			&auditLog.Action,
+			&auditLog.TargetType,
+			&auditLog.TargetID,
+			&details,
+			&auditLog.IPAddress,
+			&auditLog.UserAgent,
+			&auditLog.CreatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+
+		if err := json.Unmarshal(details, &auditLog.Details); err != nil {
+			return nil, 0, err
+		}
+		auditLogs = append(auditLogs, auditLog)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	return auditLogs, total, nil
+}
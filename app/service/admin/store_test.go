@@ -0,0 +1,136 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateAuditLog(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuditStore(db)
+	query := `INSERT INTO admin_audit_logs \(actor_id, action, target_type, target_id, details, ip_address, user_agent\)
+         VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, \$7\)`
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, types.AuditActionUserDisabled, types.AuditTargetUser, 2, []byte(`{}`), "10.0.0.1", "admin-console").
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		err := store.CreateAuditLog(context.Background(), types.CreateAuditLogPayload{
+			ActorID:    1,
+			Action:     types.AuditActionUserDisabled,
+			TargetType: types.AuditTargetUser,
+			TargetID:   2,
+			IPAddress:  "10.0.0.1",
+			UserAgent:  "admin-console",
+		})
+
+		assert.Error(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully create audit log", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, types.AuditActionUserRoleUpdated, types.AuditTargetUser, 2, []byte(`{"role":"librarian"}`), "10.0.0.1", "admin-console").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := store.CreateAuditLog(context.Background(), types.CreateAuditLogPayload{
+			ActorID:    1,
+			Action:     types.AuditActionUserRoleUpdated,
+			TargetType: types.AuditTargetUser,
+			TargetID:   2,
+			Details:    map[string]string{"role": types.RoleLibrarian},
+			IPAddress:  "10.0.0.1",
+			UserAgent:  "admin-console",
+		})
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetAuditLogs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuditStore(db)
+	countQuery := `SELECT COUNT\(\*\) FROM admin_audit_logs WHERE`
+	selectQuery := `SELECT id, actor_id, action, target_type, target_id, details, COALESCE\(ip_address, ''\), COALESCE\(user_agent, ''\), created_at
+         FROM admin_audit_logs WHERE`
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectQuery(countQuery).
+			WithArgs(0, "", 0).
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		auditLogs, total, err := store.GetAuditLogs(context.Background(), types.GetAuditLogsOptions{Page: 1, Limit: 20})
+
+		assert.Error(t, err)
+		assert.Nil(t, auditLogs)
+		assert.Zero(t, total)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get audit logs", func(t *testing.T) {
+		createdAt := time.Date(2025, 3, 14, 10, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(countQuery).
+			WithArgs(0, types.AuditTargetUser, 2).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+
+		mock.ExpectQuery(selectQuery).
+			WithArgs(0, types.AuditTargetUser, 2, 20, 20).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "action", "target_type", "target_id", "details", "ip_address", "user_agent", "created_at"}).
+				AddRow(1, 1, types.AuditActionUserRoleUpdated, types.AuditTargetUser, 2, []byte(`{"role":"librarian"}`), "10.0.0.1", "admin-console", createdAt))
+
+		auditLogs, total, err := store.GetAuditLogs(context.Background(), types.GetAuditLogsOptions{
+			Page:       2,
+			Limit:      20,
+			TargetType: types.AuditTargetUser,
+			TargetID:   2,
+		})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 21, total)
+		assert.Equal(t, []*types.AuditLog{
+			{
+				ID:         1,
+				ActorID:    1,
+				Action:     types.AuditActionUserRoleUpdated,
+				TargetType: types.AuditTargetUser,
+				TargetID:   2,
+				Details:    map[string]string{"role": types.RoleLibrarian},
+				IPAddress:  "10.0.0.1",
+				UserAgent:  "admin-console",
+				CreatedAt:  createdAt,
+			},
+		}, auditLogs)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
@@ -0,0 +1,1027 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/config"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+)
+
+var (
+	errUnknownOAuthClient         = errors.New("unknown oauth client")
+	errInvalidOAuthClient         = errors.New("invalid oauth client credentials")
+	errOAuthGrantNotAllowed       = errors.New("grant type is not allowed for this client")
+	errOAuthRedirectURINotAllowed = errors.New("redirect uri is not registered for this client")
+	errOAuthScopeNotAllowed       = errors.New("scope is not allowed for this client")
+)
+
+// @Summary Registrar aplicativo OAuth
+// @Description Registra um aplicativo de terceiros. O client_secret de clientes confidenciais só é retornado nesta resposta.
+// @Tags OAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.CreateOAuthClientPayload true "Dados do aplicativo"
+// @Success 201 {object} types.CreateOAuthClientResponse "Aplicativo registrado"
+// @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /oauth/clients [post]
+func (h *AuthHandler) HandleCreateOAuthClient(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleCreateOAuthClient", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	var requestPayload types.CreateOAuthClientPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateOAuthClient", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateOAuthClient", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	if !requestPayload.Confidential && slices.Contains(requestPayload.GrantTypes, types.OAuthGrantClientCredentials) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("public client requested the client_credentials grant"), "HandleCreateOAuthClient", types.BadRequestResponse{Error: "Public clients cannot use the client_credentials grant"})
+		return
+	}
+
+	databasePayload := types.CreateOAuthClientDatabasePayload{
+		ClientID:     h.UUIDGen.New(),
+		Name:         requestPayload.Name,
+		RedirectURIs: requestPayload.RedirectURIs,
+		Scopes:       requestPayload.Scopes,
+		GrantTypes:   requestPayload.GrantTypes,
+		CreatedBy:    userID,
+	}
+
+	var clientSecret string
+	if requestPayload.Confidential {
+		var err error
+		clientSecret, databasePayload.ClientSecretHash, err = utils.GenerateOpaqueToken()
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err, "HandleCreateOAuthClient", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+			return
+		}
+	}
+
+	client, err := h.authStore.CreateOAuthClient(r.Context(), databasePayload)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleCreateOAuthClient", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleCreateOAuthClient", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.CreateOAuthClientResponse{OAuthClient: *client, ClientSecret: clientSecret})
+}
+
+// @Summary Listar aplicativos OAuth
+// @Tags OAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.GetOAuthClientsResponse "Aplicativos registrados"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /oauth/clients [get]
+func (h *AuthHandler) HandleGetOAuthClients(w http.ResponseWriter, r *http.Request) {
+	clients, err := h.authStore.GetOAuthClients(r.Context())
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetOAuthClients", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetOAuthClients", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetOAuthClientsResponse{Clients: clients})
+}
+
+// @Summary Remover aplicativo OAuth
+// @Description Remove o aplicativo junto com os consentimentos e tokens emitidos para ele.
+// @Tags OAuth
+// @Security BearerAuth
+// @Param client_id path string true "ID do aplicativo"
+// @Success 204 "Aplicativo removido"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No OAuth client found"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /oauth/clients/{client_id} [delete]
+func (h *AuthHandler) HandleDeleteOAuthClient(w http.ResponseWriter, r *http.Request) {
+	clientID := mux.Vars(r)["client_id"]
+
+	err := h.authStore.DeleteOAuthClient(r.Context(), clientID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleDeleteOAuthClient", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleDeleteOAuthClient", types.NotFoundResponse{Error: fmt.Sprintf("No OAuth client found with ID %s", clientID)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDeleteOAuthClient", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Validar pedido de autorização OAuth
+// @Description Valida o pedido de autorização de um aplicativo e retorna o que deve ser exibido na tela de consentimento.
+// @Tags OAuth
+// @Security BearerAuth
+// @Produce json
+// @Param response_type query string true "Deve ser code"
+// @Param client_id query string true "ID do aplicativo"
+// @Param redirect_uri query string true "URI de retorno registrada"
+// @Param scope query string true "Escopos separados por espaço"
+// @Param state query string false "Valor devolvido ao aplicativo"
+// @Param code_challenge query string true "Desafio PKCE"
+// @Param code_challenge_method query string true "Deve ser S256"
+// @Success 200 {object} types.OAuthAuthorizeResponse "Dados para a tela de consentimento"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 400 {object} types.BadRequestResponse "Redirect URI is not registered for this client"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /oauth/authorize [get]
+func (h *AuthHandler) HandleOAuthAuthorize(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleOAuthAuthorize", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	query := r.URL.Query()
+	requestPayload := types.OAuthAuthorizeRequest{
+		ResponseType:        query.Get("response_type"),
+		ClientID:            query.Get("client_id"),
+		RedirectURI:         query.Get("redirect_uri"),
+		Scope:               query.Get("scope"),
+		State:               query.Get("state"),
+		CodeChallenge:       query.Get("code_challenge"),
+		CodeChallengeMethod: query.Get("code_challenge_method"),
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleOAuthAuthorize", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	client, scopes, err := h.checkAuthorizeRequest(r.Context(), requestPayload)
+	if err != nil {
+		writeAuthorizeRequestError(w, err, "HandleOAuthAuthorize")
+		return
+	}
+
+	consent, err := h.authStore.GetOAuthConsent(r.Context(), userID, client.ClientID)
+	if err != nil && err != sql.ErrNoRows {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOAuthAuthorize", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthAuthorize", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	consentGranted := consent != nil
+	for _, scope := range scopes {
+		if consent == nil || !slices.Contains(consent.Scopes, scope) {
+			consentGranted = false
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.OAuthAuthorizeResponse{
+		ClientID:       client.ClientID,
+		ClientName:     client.Name,
+		Scopes:         scopes,
+		ConsentGranted: consentGranted,
+	})
+}
+
+// @Summary Responder pedido de autorização OAuth
+// @Description Registra a decisão do usuário. Quando aprovado, grava o consentimento e retorna a URI de retorno com o código de autorização; quando negado, a URI de retorno leva error=access_denied.
+// @Tags OAuth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.OAuthConsentPayload true "Pedido de autorização e decisão do usuário"
+// @Success 200 {object} types.OAuthRedirectResponse "URI para onde o usuário deve ser redirecionado"
+// @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /oauth/authorize [post]
+func (h *AuthHandler) HandleOAuthConsent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleOAuthConsent", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	var requestPayload types.OAuthConsentPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleOAuthConsent", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleOAuthConsent", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	client, scopes, err := h.checkAuthorizeRequest(r.Context(), requestPayload.OAuthAuthorizeRequest)
+	if err != nil {
+		writeAuthorizeRequestError(w, err, "HandleOAuthConsent")
+		return
+	}
+
+	if !*requestPayload.Approved {
+		utils.WriteJSON(w, http.StatusOK, types.OAuthRedirectResponse{
+			RedirectTo: redirectURIWithParams(requestPayload.RedirectURI, map[string]string{"error": "access_denied", "state": requestPayload.State}),
+		})
+		return
+	}
+
+	err = h.authStore.UpsertOAuthConsent(r.Context(), userID, client.ClientID, scopes)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOAuthConsent", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthConsent", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	code, codeHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthConsent", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err = h.authStore.CreateOAuthAuthorizationCode(r.Context(), types.CreateOAuthAuthorizationCodePayload{
+		CodeHash:      codeHash,
+		ClientID:      client.ClientID,
+		UserID:        userID,
+		RedirectURI:   requestPayload.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		CodeChallenge: requestPayload.CodeChallenge,
+		ExpiresAt:     time.Now().Add(time.Duration(config.Envs.OAuthCodeTTL) * time.Second),
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOAuthConsent", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthConsent", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.OAuthRedirectResponse{
+		RedirectTo: redirectURIWithParams(requestPayload.RedirectURI, map[string]string{"code": code, "state": requestPayload.State}),
+	})
+}
+
+// checkAuthorizeRequest returns the client and the deduplicated scopes of a
+// valid authorization request.
+func (h *AuthHandler) checkAuthorizeRequest(ctx context.Context, request types.OAuthAuthorizeRequest) (*types.OAuthClient, []string, error) {
+	client, err := h.authStore.GetOAuthClientByClientID(ctx, request.ClientID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil, errUnknownOAuthClient
+		}
+		return nil, nil, err
+	}
+
+	if !slices.Contains(client.GrantTypes, types.OAuthGrantAuthorizationCode) {
+		return nil, nil, errOAuthGrantNotAllowed
+	}
+
+	if !slices.Contains(client.RedirectURIs, request.RedirectURI) {
+		return nil, nil, errOAuthRedirectURINotAllowed
+	}
+
+	scopes, ok := grantableScopes(request.Scope, client.Scopes)
+	if !ok {
+		return nil, nil, errOAuthScopeNotAllowed
+	}
+
+	return client, scopes, nil
+}
+
+// writeAuthorizeRequestError answers invalid authorization requests directly
+// instead of redirecting, since the redirect URI can't be trusted yet.
+func writeAuthorizeRequestError(w http.ResponseWriter, err error, handler string) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		utils.WriteError(w, http.StatusServiceUnavailable, err, handler, types.ContextCanceledResponse{Error: "Request canceled"})
+	case err == errUnknownOAuthClient:
+		utils.WriteError(w, http.StatusBadRequest, err, handler, types.BadRequestResponse{Error: "Unknown client"})
+	case err == errOAuthGrantNotAllowed:
+		utils.WriteError(w, http.StatusBadRequest, err, handler, types.BadRequestResponse{Error: "This client cannot use the authorization code flow"})
+	case err == errOAuthRedirectURINotAllowed:
+		utils.WriteError(w, http.StatusBadRequest, err, handler, types.BadRequestResponse{Error: "Redirect URI is not registered for this client"})
+	case err == errOAuthScopeNotAllowed:
+		utils.WriteError(w, http.StatusBadRequest, err, handler, types.BadRequestResponse{Error: "Requested scope is not allowed for this client"})
+	default:
+		utils.WriteError(w, http.StatusInternalServerError, err, handler, types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+	}
+}
+
+// @Summary Emitir tokens OAuth
+// @Description Endpoint de token do OAuth2 (RFC 6749). Aceita os grants authorization_code (com PKCE), refresh_token e client_credentials. O cliente se autentica por HTTP Basic ou pelos campos client_id e client_secret.
+// @Description Tokens do client_credentials não representam nenhum usuário e só são aceitos na leitura de autores e gêneros.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param grant_type formData string true "authorization_code, refresh_token ou client_credentials"
+// @Param code formData string false "Código de autorização"
+// @Param redirect_uri formData string false "URI de retorno usada no pedido de autorização"
+// @Param code_verifier formData string false "Verificador PKCE"
+// @Param refresh_token formData string false "Refresh token"
+// @Param scope formData string false "Escopos pedidos"
+// @Param client_id formData string false "ID do aplicativo"
+// @Param client_secret formData string false "Segredo do aplicativo"
+// @Success 200 {object} types.OAuthTokenResponse "Tokens emitidos"
+// @Failure 400 {object} types.OAuthErrorResponse "invalid_request, invalid_grant, invalid_scope, unauthorized_client or unsupported_grant_type"
+// @Failure 401 {object} types.OAuthErrorResponse "invalid_client"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /oauth/token [post]
+func (h *AuthHandler) HandleOAuthToken(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, err, "HandleOAuthToken", "invalid_request", "Body is not a valid form")
+		return
+	}
+
+	client, ok := h.authenticateOAuthClient(w, r, "HandleOAuthToken")
+	if !ok {
+		return
+	}
+
+	grantType := r.PostForm.Get("grant_type")
+	switch grantType {
+	case types.OAuthGrantAuthorizationCode, types.OAuthGrantRefreshToken, types.OAuthGrantClientCredentials:
+		if !slices.Contains(client.GrantTypes, grantType) {
+			writeOAuthError(w, http.StatusBadRequest, errOAuthGrantNotAllowed, "HandleOAuthToken", "unauthorized_client", "This client cannot use the "+grantType+" grant")
+			return
+		}
+	default:
+		writeOAuthError(w, http.StatusBadRequest, fmt.Errorf("unsupported grant type %q", grantType), "HandleOAuthToken", "unsupported_grant_type", "")
+		return
+	}
+
+	switch grantType {
+	case types.OAuthGrantAuthorizationCode:
+		h.handleAuthorizationCodeGrant(w, r, client)
+	case types.OAuthGrantRefreshToken:
+		h.handleRefreshTokenGrant(w, r, client)
+	case types.OAuthGrantClientCredentials:
+		h.handleClientCredentialsGrant(w, r, client)
+	}
+}
+
+func (h *AuthHandler) handleAuthorizationCodeGrant(w http.ResponseWriter, r *http.Request, client *types.OAuthClient) {
+	code := r.PostForm.Get("code")
+	codeVerifier := r.PostForm.Get("code_verifier")
+	if code == "" || codeVerifier == "" {
+		writeOAuthError(w, http.StatusBadRequest, fmt.Errorf("missing code or code_verifier"), "HandleOAuthToken", "invalid_request", "code and code_verifier are required")
+		return
+	}
+
+	authorizationCode, err := h.authStore.ConsumeOAuthAuthorizationCode(r.Context(), utils.HashOpaqueToken(code))
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOAuthToken", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			writeOAuthError(w, http.StatusBadRequest, err, "HandleOAuthToken", "invalid_grant", "Authorization code is invalid or has expired")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if authorizationCode.ClientID != client.ClientID ||
+		authorizationCode.RedirectURI != r.PostForm.Get("redirect_uri") ||
+		utils.PKCEChallenge(codeVerifier) != authorizationCode.CodeChallenge {
+		writeOAuthError(w, http.StatusBadRequest, fmt.Errorf("authorization code does not match the token request of client %s", client.ClientID), "HandleOAuthToken", "invalid_grant", "Authorization code is invalid or has expired")
+		return
+	}
+
+	user, err := h.userStore.GetByID(r.Context(), authorizationCode.UserID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOAuthToken", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			writeOAuthError(w, http.StatusBadRequest, err, "HandleOAuthToken", "invalid_grant", "Authorization code is invalid or has expired")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	response, err := h.issueOAuthTokens(r, client, user, authorizationCode.Scope)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// issueOAuthTokens starts a grant for a user. A refresh token is only issued
+// to clients registered for the refresh_token grant, and it is stored in the
+// same table as first-party sessions, tagged with the client and scope.
+func (h *AuthHandler) issueOAuthTokens(r *http.Request, client *types.OAuthClient, user *types.UserResponse, scope string) (*types.OAuthTokenResponse, error) {
+	response := &types.OAuthTokenResponse{TokenType: "Bearer", ExpiresIn: config.Envs.OAuthAccessTokenTTL, Scope: scope}
+
+	var familyID string
+	if slices.Contains(client.GrantTypes, types.OAuthGrantRefreshToken) {
+		familyID = h.UUIDGen.New()
+
+		refreshToken, err := utils.CreateRefreshJWT(user.ID, user.Username, user.Email, user.Role, familyID, config.Envs.JWTSecret, config.Envs.OAuthRefreshTokenTTL, h.UUIDGen)
+		if err != nil {
+			return nil, err
+		}
+
+		refreshTokenClaims, err := utils.VerifyJWT(refreshToken, config.Envs.JWTSecret)
+		if err != nil {
+			return nil, err
+		}
+
+		err = h.authStore.CreateRefreshToken(
+			r.Context(),
+			types.CreateRefreshTokenPayload{
+				UserID:    user.ID,
+				Jti:       refreshTokenClaims.RegisteredClaims.ID,
+				FamilyID:  familyID,
+				UserAgent: r.UserAgent(),
+				IPAddress: utils.ClientIP(r),
+				ClientID:  client.ClientID,
+				Scope:     scope,
+				ExpiresAt: refreshTokenClaims.RegisteredClaims.ExpiresAt.Time,
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		response.RefreshToken = refreshToken
+	}
+
+	accessToken, err := utils.CreateOAuthAccessJWT(user.ID, user.Username, user.Email, user.Role, scope, familyID, client.ClientID, config.Envs.JWTSecret, config.Envs.OAuthAccessTokenTTL, h.UUIDGen)
+	if err != nil {
+		return nil, err
+	}
+	response.AccessToken = accessToken
+
+	return response, nil
+}
+
+func (h *AuthHandler) handleRefreshTokenGrant(w http.ResponseWriter, r *http.Request, client *types.OAuthClient) {
+	refreshToken := r.PostForm.Get("refresh_token")
+	if refreshToken == "" {
+		writeOAuthError(w, http.StatusBadRequest, fmt.Errorf("missing refresh_token"), "HandleOAuthToken", "invalid_request", "refresh_token is required")
+		return
+	}
+
+	claims, err := utils.VerifyJWT(refreshToken, config.Envs.JWTSecret)
+	if err != nil || claims.FamilyID == "" {
+		if err == nil {
+			err = fmt.Errorf("access token sent as refresh token")
+		}
+		writeOAuthError(w, http.StatusBadRequest, err, "HandleOAuthToken", "invalid_grant", "Refresh token is invalid or has been expired")
+		return
+	}
+
+	storedToken, err := h.authStore.GetRefreshTokenByJti(r.Context(), claims.RegisteredClaims.ID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOAuthToken", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			if err := h.revokeReusedRefreshTokenFamily(r, claims); err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+				return
+			}
+
+			writeOAuthError(w, http.StatusBadRequest, err, "HandleOAuthToken", "invalid_grant", "Refresh token is invalid or has been expired")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if storedToken.ClientID != client.ClientID || storedToken.UserID != claims.UserID {
+		writeOAuthError(w, http.StatusBadRequest, fmt.Errorf("refresh token of another client sent by client %s", client.ClientID), "HandleOAuthToken", "invalid_grant", "Refresh token is invalid or has been expired")
+		return
+	}
+
+	// The new access token may be narrowed down, but the refresh token keeps
+	// the scope originally granted.
+	scope := storedToken.Scope
+	if requestedScope := r.PostForm.Get("scope"); requestedScope != "" {
+		scopes, ok := grantableScopes(requestedScope, strings.Fields(storedToken.Scope))
+		if !ok {
+			writeOAuthError(w, http.StatusBadRequest, errOAuthScopeNotAllowed, "HandleOAuthToken", "invalid_scope", "Requested scope exceeds the granted scope")
+			return
+		}
+		scope = strings.Join(scopes, " ")
+	}
+
+	user, err := h.userStore.GetByID(r.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOAuthToken", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			writeOAuthError(w, http.StatusBadRequest, err, "HandleOAuthToken", "invalid_grant", "Refresh token is invalid or has been expired")
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	accessToken, err := utils.CreateOAuthAccessJWT(user.ID, user.Username, user.Email, user.Role, scope, storedToken.FamilyID, client.ClientID, config.Envs.JWTSecret, config.Envs.OAuthAccessTokenTTL, h.UUIDGen)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	newRefreshToken, err := utils.CreateRefreshJWT(user.ID, user.Username, user.Email, user.Role, storedToken.FamilyID, config.Envs.JWTSecret, config.Envs.OAuthRefreshTokenTTL, h.UUIDGen)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	newRefreshTokenClaims, err := utils.VerifyJWT(newRefreshToken, config.Envs.JWTSecret)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err = h.authStore.RotateRefreshToken(
+		r.Context(),
+		types.RotateRefreshTokenPayload{
+			PreviousJti: storedToken.Jti,
+			Jti:         newRefreshTokenClaims.RegisteredClaims.ID,
+			UserAgent:   r.UserAgent(),
+			IPAddress:   utils.ClientIP(r),
+			ExpiresAt:   newRefreshTokenClaims.RegisteredClaims.ExpiresAt.Time,
+		})
+	if err != nil {
+		if err == sql.ErrNoRows {
+			writeOAuthError(w, http.StatusBadRequest, err, "HandleOAuthToken", "invalid_grant", "Refresh token is invalid or has been expired")
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.OAuthTokenResponse{
+		AccessToken:  accessToken,
+		TokenType:    "Bearer",
+		ExpiresIn:    config.Envs.OAuthAccessTokenTTL,
+		RefreshToken: newRefreshToken,
+		Scope:        scope,
+	})
+}
+
+// handleClientCredentialsGrant issues a token that acts on behalf of the
+// client itself, so it carries no user and no refresh token. Routes only take
+// it when they use utils.ClientAuthMiddlewareWithScopes.
+func (h *AuthHandler) handleClientCredentialsGrant(w http.ResponseWriter, r *http.Request, client *types.OAuthClient) {
+	if !client.Confidential {
+		writeOAuthError(w, http.StatusBadRequest, fmt.Errorf("public client %s requested client credentials", client.ClientID), "HandleOAuthToken", "unauthorized_client", "Public clients cannot use the client_credentials grant")
+		return
+	}
+
+	scopes := client.Scopes
+	if requestedScope := r.PostForm.Get("scope"); requestedScope != "" {
+		var ok bool
+		scopes, ok = grantableScopes(requestedScope, client.Scopes)
+		if !ok {
+			writeOAuthError(w, http.StatusBadRequest, errOAuthScopeNotAllowed, "HandleOAuthToken", "invalid_scope", "Requested scope is not allowed for this client")
+			return
+		}
+	}
+	scope := strings.Join(scopes, " ")
+
+	accessToken, err := utils.CreateOAuthAccessJWT(0, "", "", "", scope, "", client.ClientID, config.Envs.JWTSecret, config.Envs.OAuthAccessTokenTTL, h.UUIDGen)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.OAuthTokenResponse{
+		AccessToken: accessToken,
+		TokenType:   "Bearer",
+		ExpiresIn:   config.Envs.OAuthAccessTokenTTL,
+		Scope:       scope,
+	})
+}
+
+// @Summary Inspecionar token OAuth
+// @Description Introspecção de token (RFC 7662). Exige um cliente confidencial e só revela tokens emitidos para ele; os demais são reportados como inativos.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Produce json
+// @Param token formData string true "Access ou refresh token"
+// @Param token_type_hint formData string false "access_token ou refresh_token"
+// @Success 200 {object} types.OAuthIntrospectionResponse "Estado do token"
+// @Failure 400 {object} types.OAuthErrorResponse "invalid_request"
+// @Failure 401 {object} types.OAuthErrorResponse "invalid_client"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /oauth/introspect [post]
+func (h *AuthHandler) HandleOAuthIntrospect(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, err, "HandleOAuthIntrospect", "invalid_request", "Body is not a valid form")
+		return
+	}
+
+	client, ok := h.authenticateOAuthClient(w, r, "HandleOAuthIntrospect")
+	if !ok {
+		return
+	}
+
+	if !client.Confidential {
+		writeOAuthError(w, http.StatusUnauthorized, fmt.Errorf("public client %s tried to introspect a token", client.ClientID), "HandleOAuthIntrospect", "invalid_client", "Only confidential clients can introspect tokens")
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeOAuthError(w, http.StatusBadRequest, fmt.Errorf("missing token"), "HandleOAuthIntrospect", "invalid_request", "token is required")
+		return
+	}
+
+	response, err := h.introspectOAuthToken(r.Context(), client, token)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOAuthIntrospect", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthIntrospect", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+func (h *AuthHandler) introspectOAuthToken(ctx context.Context, client *types.OAuthClient, token string) (*types.OAuthIntrospectionResponse, error) {
+	inactive := &types.OAuthIntrospectionResponse{Active: false}
+
+	claims, err := utils.VerifyJWT(token, config.Envs.JWTSecret)
+	if err != nil {
+		return inactive, nil
+	}
+
+	response := &types.OAuthIntrospectionResponse{
+		Active:   true,
+		ClientID: client.ClientID,
+		Username: claims.Username,
+		Jti:      claims.RegisteredClaims.ID,
+		Sub:      client.ClientID,
+	}
+	if claims.UserID != 0 {
+		response.Sub = strconv.Itoa(claims.UserID)
+	}
+	if claims.RegisteredClaims.ExpiresAt != nil {
+		response.Exp = claims.RegisteredClaims.ExpiresAt.Unix()
+	}
+	if claims.RegisteredClaims.IssuedAt != nil {
+		response.Iat = claims.RegisteredClaims.IssuedAt.Unix()
+	}
+
+	if claims.FamilyID != "" {
+		storedToken, err := h.authStore.GetRefreshTokenByJti(ctx, claims.RegisteredClaims.ID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return inactive, nil
+			}
+			return nil, err
+		}
+
+		if storedToken.ClientID != client.ClientID {
+			return inactive, nil
+		}
+
+		response.Scope = storedToken.Scope
+		response.TokenType = "refresh_token"
+		return response, nil
+	}
+
+	if claims.ClientID != client.ClientID {
+		return inactive, nil
+	}
+
+	revoked, err := utils.IsAccessTokenRevoked(ctx, h.authStore, claims)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return inactive, nil
+	}
+
+	response.Scope = claims.Scope
+	response.TokenType = "Bearer"
+	return response, nil
+}
+
+// @Summary Revogar token OAuth
+// @Description Revogação de token (RFC 7009). Revogar um refresh token encerra a concessão inteira. Tokens inválidos ou de outros clientes são ignorados e a resposta é sempre 200.
+// @Tags OAuth
+// @Accept x-www-form-urlencoded
+// @Param token formData string true "Access ou refresh token"
+// @Param token_type_hint formData string false "access_token ou refresh_token"
+// @Success 200 "Token revogado"
+// @Failure 400 {object} types.OAuthErrorResponse "invalid_request"
+// @Failure 401 {object} types.OAuthErrorResponse "invalid_client"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /oauth/revoke [post]
+func (h *AuthHandler) HandleOAuthRevoke(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeOAuthError(w, http.StatusBadRequest, err, "HandleOAuthRevoke", "invalid_request", "Body is not a valid form")
+		return
+	}
+
+	client, ok := h.authenticateOAuthClient(w, r, "HandleOAuthRevoke")
+	if !ok {
+		return
+	}
+
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeOAuthError(w, http.StatusBadRequest, fmt.Errorf("missing token"), "HandleOAuthRevoke", "invalid_request", "token is required")
+		return
+	}
+
+	if err := h.revokeOAuthToken(r.Context(), client, token); err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOAuthRevoke", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOAuthRevoke", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *AuthHandler) revokeOAuthToken(ctx context.Context, client *types.OAuthClient, token string) error {
+	claims, err := utils.VerifyJWT(token, config.Envs.JWTSecret)
+	if err != nil {
+		return nil
+	}
+
+	if claims.FamilyID != "" {
+		storedToken, err := h.authStore.GetRefreshTokenByJti(ctx, claims.RegisteredClaims.ID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil
+			}
+			return err
+		}
+
+		if storedToken.ClientID != client.ClientID {
+			return nil
+		}
+
+		err = h.authStore.DeleteRefreshTokenFamily(ctx, storedToken.UserID, storedToken.FamilyID)
+		if err != nil && err != sql.ErrNoRows {
+			return err
+		}
+		return nil
+	}
+
+	if claims.ClientID != client.ClientID {
+		return nil
+	}
+
+	return h.revokeAccessToken(ctx, claims)
+}
+
+// @Summary Listar aplicativos autorizados
+// @Description Lista os aplicativos de terceiros aos quais o usuário autenticado concedeu acesso.
+// @Tags OAuth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.GetOAuthConsentsResponse "Consentimentos concedidos"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /oauth/consents [get]
+func (h *AuthHandler) HandleGetOAuthConsents(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleGetOAuthConsents", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	consents, err := h.authStore.GetOAuthConsentsByUserID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetOAuthConsents", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetOAuthConsents", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetOAuthConsentsResponse{Consents: consents})
+}
+
+// @Summary Revogar acesso de um aplicativo
+// @Description Remove o consentimento dado ao aplicativo e revoga os refresh tokens que ele tem do usuário.
+// @Tags OAuth
+// @Security BearerAuth
+// @Param client_id path string true "ID do aplicativo"
+// @Success 204 "Acesso revogado"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 404 {object} types.NotFoundResponse "No consent found for this client"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /oauth/consents/{client_id} [delete]
+func (h *AuthHandler) HandleDeleteOAuthConsent(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleDeleteOAuthConsent", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	clientID := mux.Vars(r)["client_id"]
+
+	err := h.authStore.DeleteOAuthConsent(r.Context(), userID, clientID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleDeleteOAuthConsent", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleDeleteOAuthConsent", types.NotFoundResponse{Error: "No consent found for this client"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDeleteOAuthConsent", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// authenticateOAuthClient reads the client credentials from HTTP Basic auth
+// or, failing that, from the form. Public clients send their client_id only.
+// On failure the error response is already written.
+func (h *AuthHandler) authenticateOAuthClient(w http.ResponseWriter, r *http.Request, handler string) (*types.OAuthClient, bool) {
+	clientID, clientSecret, hasBasicAuth := r.BasicAuth()
+	if hasBasicAuth {
+		// RFC 6749 section 2.3.1 form-encodes both values before the
+		// Basic auth encoding.
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID = r.PostForm.Get("client_id")
+		clientSecret = r.PostForm.Get("client_secret")
+	}
+
+	if clientID == "" {
+		writeOAuthError(w, http.StatusUnauthorized, errInvalidOAuthClient, handler, "invalid_client", "Client authentication failed")
+		return nil, false
+	}
+
+	client, err := h.authStore.GetOAuthClientByClientID(r.Context(), clientID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, handler, types.ContextCanceledResponse{Error: "Request canceled"})
+			return nil, false
+		}
+
+		if err == sql.ErrNoRows {
+			writeOAuthError(w, http.StatusUnauthorized, errUnknownOAuthClient, handler, "invalid_client", "Client authentication failed")
+			return nil, false
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, handler, types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return nil, false
+	}
+
+	secretMatches := subtle.ConstantTimeCompare([]byte(utils.HashOpaqueToken(clientSecret)), []byte(client.ClientSecretHash)) == 1
+	if client.Confidential && !secretMatches || !client.Confidential && clientSecret != "" {
+		writeOAuthError(w, http.StatusUnauthorized, errInvalidOAuthClient, handler, "invalid_client", "Client authentication failed")
+		return nil, false
+	}
+
+	return client, true
+}
+
+func writeOAuthError(w http.ResponseWriter, status int, err error, handler string, code string, description string) {
+	if status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="book-store-api"`)
+	}
+
+	utils.WriteError(w, status, err, handler, types.OAuthErrorResponse{Error: code, ErrorDescription: description})
+}
+
+// grantableScopes splits a space separated scope and checks every scope is
+// allowed. Repeated scopes are dropped.
+func grantableScopes(scope string, allowed []string) ([]string, bool) {
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(allowed, s) {
+			return nil, false
+		}
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+
+	return scopes, len(scopes) > 0
+}
+
+func redirectURIWithParams(redirectURI string, params map[string]string) string {
+	u, err := url.Parse(redirectURI)
+	if err != nil {
+		return redirectURI
+	}
+
+	query := u.Query()
+	for key, value := range params {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
@@ -0,0 +1,686 @@
+package auth_test
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/cmd/api"
+	"github.com/hoyci/book-store-api/config"
+	"github.com/hoyci/book-store-api/mocks"
+	"github.com/hoyci/book-store-api/service/auth"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+const (
+	oauthClientSecret = "client-secret"
+	oauthCodeVerifier = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	oauthRedirectURI  = "https://app.example.com/callback"
+)
+
+func newOAuthClient(confidential bool, grantTypes ...string) *types.OAuthClient {
+	client := &types.OAuthClient{
+		ID:           1,
+		ClientID:     "client-1",
+		Name:         "Reading Tracker",
+		RedirectURIs: []string{oauthRedirectURI},
+		Scopes:       []string{types.ScopeBooksRead, types.ScopeBooksWrite},
+		GrantTypes:   grantTypes,
+		Confidential: confidential,
+	}
+	if confidential {
+		client.ClientSecretHash = utils.HashOpaqueToken(oauthClientSecret)
+	}
+	return client
+}
+
+func setupOAuthTestServer() (*mocks.MockUserStore, *mocks.MockAuthStore, *mocks.MockUUIDGenerator, *httptest.Server, *mux.Router) {
+	mockUUID := new(mocks.MockUUIDGenerator)
+	mockAuthStore := new(mocks.MockAuthStore)
+	mockUserStore := new(mocks.MockUserStore)
+	mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, nil, nil)
+	apiServer := api.NewApiServer(":8080", nil)
+	router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+	ts := httptest.NewServer(router)
+	return mockUserStore, mockAuthStore, mockUUID, ts, router
+}
+
+func newOAuthFormRequest(target string, form url.Values, withSecret bool) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if withSecret {
+		req.SetBasicAuth("client-1", oauthClientSecret)
+	}
+	return req
+}
+
+func TestHandleCreateOAuthClient(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "JohnDoe", "johndoe@email.com", types.RoleAdmin)
+
+	t.Run("it should only allow admins", func(t *testing.T) {
+		_, _, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/oauth/clients", bytes.NewReader([]byte(`{}`)))
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Result().StatusCode)
+	})
+
+	t.Run("it should refuse the client_credentials grant for public clients", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		body := `{"name":"Reading Tracker","redirect_uris":["https://app.example.com/callback"],"scopes":["books:read"],"grant_types":["client_credentials"],"confidential":false}`
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/oauth/clients", bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Public clients cannot use the client_credentials grant"}`, string(responseBody))
+		mockAuthStore.AssertNotCalled(t, "CreateOAuthClient", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should register a confidential client and return its secret once", func(t *testing.T) {
+		_, mockAuthStore, mockUUID, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("client-1")
+		mockAuthStore.On("CreateOAuthClient", mock.Anything, mock.MatchedBy(func(payload types.CreateOAuthClientDatabasePayload) bool {
+			return payload.ClientID == "client-1" && payload.ClientSecretHash != "" && payload.CreatedBy == 1
+		})).Return(newOAuthClient(true, types.OAuthGrantAuthorizationCode), nil)
+
+		body := `{"name":"Reading Tracker","redirect_uris":["https://app.example.com/callback"],"scopes":["books:read","books:write"],"grant_types":["authorization_code"],"confidential":true}`
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/oauth/clients", bytes.NewReader([]byte(body)))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var response types.CreateOAuthClientResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.Equal(t, "client-1", response.ClientID)
+		assert.NotEmpty(t, response.ClientSecret)
+
+		createPayload := mockAuthStore.Calls[0].Arguments.Get(1).(types.CreateOAuthClientDatabasePayload)
+		assert.Equal(t, utils.HashOpaqueToken(response.ClientSecret), createPayload.ClientSecretHash)
+	})
+}
+
+func TestHandleDeleteOAuthClient(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "JohnDoe", "johndoe@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when the client does not exist", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("DeleteOAuthClient", mock.Anything, "client-1").Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/oauth/clients/client-1", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Result().StatusCode)
+	})
+
+	t.Run("it should delete the client", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("DeleteOAuthClient", mock.Anything, "client-1").Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/oauth/clients/client-1", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+	})
+}
+
+func TestHandleOAuthAuthorize(t *testing.T) {
+	authorizeURL := func(ts *httptest.Server, redirectURI string, scope string) string {
+		query := url.Values{}
+		query.Set("response_type", "code")
+		query.Set("client_id", "client-1")
+		query.Set("redirect_uri", redirectURI)
+		query.Set("scope", scope)
+		query.Set("state", "xyz")
+		query.Set("code_challenge", utils.PKCEChallenge(oauthCodeVerifier))
+		query.Set("code_challenge_method", "S256")
+		return ts.URL + "/api/v1/oauth/authorize?" + query.Encode()
+	}
+
+	t.Run("it should refuse a redirect URI that is not registered", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(false, types.OAuthGrantAuthorizationCode), nil)
+
+		req := httptest.NewRequest(http.MethodGet, authorizeURL(ts, "https://evil.example.com/callback", "books:read"), nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Redirect URI is not registered for this client"}`, string(responseBody))
+	})
+
+	t.Run("it should refuse a scope the client was not registered for", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		client := newOAuthClient(false, types.OAuthGrantAuthorizationCode)
+		client.Scopes = []string{types.ScopeBooksRead}
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(client, nil)
+
+		req := httptest.NewRequest(http.MethodGet, authorizeURL(ts, oauthRedirectURI, "books:read books:write"), nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Requested scope is not allowed for this client"}`, string(responseBody))
+	})
+
+	t.Run("it should report whether the user already consented", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(false, types.OAuthGrantAuthorizationCode), nil)
+		mockAuthStore.On("GetOAuthConsent", mock.Anything, 1, "client-1").Return(&types.OAuthConsent{ClientID: "client-1", Scopes: []string{types.ScopeBooksRead}}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, authorizeURL(ts, oauthRedirectURI, "books:read books:write"), nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"client_id":"client-1","client_name":"Reading Tracker","scopes":["books:read","books:write"],"consent_granted":false}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleOAuthConsent(t *testing.T) {
+	consentBody := func(approved bool) []byte {
+		return []byte(fmt.Sprintf(
+			`{"response_type":"code","client_id":"client-1","redirect_uri":%q,"scope":"books:read","state":"xyz","code_challenge":%q,"code_challenge_method":"S256","approved":%t}`,
+			oauthRedirectURI, utils.PKCEChallenge(oauthCodeVerifier), approved,
+		))
+	}
+
+	t.Run("it should redirect with access_denied when the user refuses", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(false, types.OAuthGrantAuthorizationCode), nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/oauth/authorize", bytes.NewReader(consentBody(false)))
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"redirect_to":"https://app.example.com/callback?error=access_denied&state=xyz"}`, string(responseBody))
+		mockAuthStore.AssertNotCalled(t, "UpsertOAuthConsent", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should store the consent and redirect with a code", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(false, types.OAuthGrantAuthorizationCode), nil)
+		mockAuthStore.On("UpsertOAuthConsent", mock.Anything, 1, "client-1", []string{types.ScopeBooksRead}).Return(nil)
+		mockAuthStore.On("CreateOAuthAuthorizationCode", mock.Anything, mock.MatchedBy(func(payload types.CreateOAuthAuthorizationCodePayload) bool {
+			return payload.ClientID == "client-1" &&
+				payload.UserID == 1 &&
+				payload.Scope == types.ScopeBooksRead &&
+				payload.CodeChallenge == utils.PKCEChallenge(oauthCodeVerifier)
+		})).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/oauth/authorize", bytes.NewReader(consentBody(true)))
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.OAuthRedirectResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+
+		redirectTo, err := url.Parse(response.RedirectTo)
+		assert.NoError(t, err)
+		assert.Equal(t, "xyz", redirectTo.Query().Get("state"))
+
+		codePayload := mockAuthStore.Calls[2].Arguments.Get(1).(types.CreateOAuthAuthorizationCodePayload)
+		assert.Equal(t, utils.HashOpaqueToken(redirectTo.Query().Get("code")), codePayload.CodeHash)
+	})
+}
+
+func TestHandleOAuthToken(t *testing.T) {
+	user := &types.UserResponse{ID: 1, Username: "JohnDoe", Email: "johndoe@email.com", Role: types.RoleReader}
+
+	codeForm := func(verifier string) url.Values {
+		form := url.Values{}
+		form.Set("grant_type", types.OAuthGrantAuthorizationCode)
+		form.Set("code", "auth-code")
+		form.Set("redirect_uri", oauthRedirectURI)
+		form.Set("code_verifier", verifier)
+		form.Set("client_id", "client-1")
+		return form
+	}
+
+	authorizationCode := &types.OAuthAuthorizationCode{
+		ClientID:      "client-1",
+		UserID:        1,
+		RedirectURI:   oauthRedirectURI,
+		Scope:         types.ScopeBooksRead,
+		CodeChallenge: utils.PKCEChallenge(oauthCodeVerifier),
+	}
+
+	t.Run("it should refuse a wrong client secret", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(true, types.OAuthGrantClientCredentials), nil)
+
+		form := url.Values{}
+		form.Set("grant_type", types.OAuthGrantClientCredentials)
+		req := newOAuthFormRequest(ts.URL+"/api/v1/oauth/token", form, false)
+		req.SetBasicAuth("client-1", "wrong-secret")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		assert.NotEmpty(t, res.Header.Get("WWW-Authenticate"))
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"invalid_client","error_description":"Client authentication failed"}`, string(responseBody))
+	})
+
+	t.Run("it should refuse a grant the client is not registered for", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(false, types.OAuthGrantAuthorizationCode), nil)
+
+		form := url.Values{}
+		form.Set("grant_type", types.OAuthGrantRefreshToken)
+		form.Set("client_id", "client-1")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/token", form, false))
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var response types.OAuthErrorResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.Equal(t, "unauthorized_client", response.Error)
+	})
+
+	t.Run("it should refuse a code verifier that does not match the challenge", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(false, types.OAuthGrantAuthorizationCode), nil)
+		mockAuthStore.On("ConsumeOAuthAuthorizationCode", mock.Anything, utils.HashOpaqueToken("auth-code")).Return(authorizationCode, nil)
+
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/token", codeForm("another-verifier-that-is-long-enough-to-be-valid"), false))
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		var response types.OAuthErrorResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.Equal(t, "invalid_grant", response.Error)
+	})
+
+	t.Run("it should exchange the code for tokens", func(t *testing.T) {
+		mockUserStore, mockAuthStore, mockUUID, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(false, types.OAuthGrantAuthorizationCode, types.OAuthGrantRefreshToken), nil)
+		mockAuthStore.On("ConsumeOAuthAuthorizationCode", mock.Anything, utils.HashOpaqueToken("auth-code")).Return(authorizationCode, nil)
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(user, nil)
+		mockAuthStore.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(payload types.CreateRefreshTokenPayload) bool {
+			return payload.UserID == 1 && payload.ClientID == "client-1" && payload.Scope == types.ScopeBooksRead
+		})).Return(nil)
+
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/token", codeForm(oauthCodeVerifier), false))
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+
+		var response types.OAuthTokenResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.Equal(t, "Bearer", response.TokenType)
+		assert.Equal(t, types.ScopeBooksRead, response.Scope)
+		assert.NotEmpty(t, response.RefreshToken)
+
+		claims, err := utils.VerifyJWT(response.AccessToken, config.Envs.JWTSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, claims.UserID)
+		assert.Equal(t, "client-1", claims.ClientID)
+		assert.Equal(t, types.ScopeBooksRead, claims.Scope)
+	})
+
+	t.Run("it should treat an unknown refresh token as reuse and revoke its family", func(t *testing.T) {
+		_, mockAuthStore, mockUUID, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		refreshToken, err := utils.CreateRefreshJWT(1, "JohnDoe", "johndoe@email.com", types.RoleReader, "family-1", config.Envs.JWTSecret, 3600, mockUUID)
+		if err != nil {
+			t.Fatalf("Failed to create refresh token: %v", err)
+		}
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(false, types.OAuthGrantAuthorizationCode, types.OAuthGrantRefreshToken), nil)
+		mockAuthStore.On("GetRefreshTokenByJti", mock.Anything, "mocked-uuid").Return((*types.RefreshToken)(nil), sql.ErrNoRows)
+		mockAuthStore.On("DeleteRefreshTokenFamily", mock.Anything, 1, "family-1").Return(nil)
+		mockAuthStore.On("CreateSecurityEvent", mock.Anything, mock.Anything).Return(nil)
+
+		form := url.Values{}
+		form.Set("grant_type", types.OAuthGrantRefreshToken)
+		form.Set("refresh_token", refreshToken)
+		form.Set("client_id", "client-1")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/token", form, false))
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		mockAuthStore.AssertCalled(t, "DeleteRefreshTokenFamily", mock.Anything, 1, "family-1")
+	})
+
+	t.Run("it should refuse a refresh token issued to another client", func(t *testing.T) {
+		_, mockAuthStore, mockUUID, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		refreshToken, err := utils.CreateRefreshJWT(1, "JohnDoe", "johndoe@email.com", types.RoleReader, "family-1", config.Envs.JWTSecret, 3600, mockUUID)
+		if err != nil {
+			t.Fatalf("Failed to create refresh token: %v", err)
+		}
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(false, types.OAuthGrantAuthorizationCode, types.OAuthGrantRefreshToken), nil)
+		mockAuthStore.On("GetRefreshTokenByJti", mock.Anything, "mocked-uuid").Return(&types.RefreshToken{UserID: 1, Jti: "mocked-uuid", FamilyID: "family-1", ClientID: "client-2"}, nil)
+
+		form := url.Values{}
+		form.Set("grant_type", types.OAuthGrantRefreshToken)
+		form.Set("refresh_token", refreshToken)
+		form.Set("client_id", "client-1")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/token", form, false))
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		mockAuthStore.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should issue a token without user for the client credentials grant", func(t *testing.T) {
+		_, mockAuthStore, mockUUID, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(true, types.OAuthGrantClientCredentials), nil)
+
+		form := url.Values{}
+		form.Set("grant_type", types.OAuthGrantClientCredentials)
+		form.Set("scope", types.ScopeBooksRead)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/token", form, true))
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.OAuthTokenResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.Empty(t, response.RefreshToken)
+
+		claims, err := utils.VerifyJWT(response.AccessToken, config.Envs.JWTSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, 0, claims.UserID)
+		assert.Equal(t, "client-1", claims.ClientID)
+		assert.Equal(t, types.ScopeBooksRead, claims.Scope)
+	})
+}
+
+func TestHandleOAuthIntrospect(t *testing.T) {
+	t.Run("it should refuse public clients", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(false, types.OAuthGrantAuthorizationCode), nil)
+
+		form := url.Values{}
+		form.Set("token", "token")
+		form.Set("client_id", "client-1")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/introspect", form, false))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Result().StatusCode)
+	})
+
+	t.Run("it should report tokens of other clients as inactive", func(t *testing.T) {
+		_, mockAuthStore, mockUUID, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		accessToken, err := utils.CreateOAuthAccessJWT(1, "JohnDoe", "johndoe@email.com", types.RoleReader, types.ScopeBooksRead, "family-1", "client-2", config.Envs.JWTSecret, 3600, mockUUID)
+		if err != nil {
+			t.Fatalf("Failed to create access token: %v", err)
+		}
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(true, types.OAuthGrantAuthorizationCode), nil)
+
+		form := url.Values{}
+		form.Set("token", accessToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/introspect", form, true))
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"active":false}`, string(responseBody))
+	})
+
+	t.Run("it should describe an active access token", func(t *testing.T) {
+		_, mockAuthStore, mockUUID, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		accessToken, err := utils.CreateOAuthAccessJWT(1, "JohnDoe", "johndoe@email.com", types.RoleReader, types.ScopeBooksRead, "family-1", "client-1", config.Envs.JWTSecret, 3600, mockUUID)
+		if err != nil {
+			t.Fatalf("Failed to create access token: %v", err)
+		}
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(true, types.OAuthGrantAuthorizationCode), nil)
+		mockAuthStore.On("IsAccessTokenRevoked", mock.Anything, "mocked-uuid", mock.Anything, mock.Anything).Return(false, nil)
+
+		form := url.Values{}
+		form.Set("token", accessToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/introspect", form, true))
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.OAuthIntrospectionResponse
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&response))
+		assert.True(t, response.Active)
+		assert.Equal(t, "Bearer", response.TokenType)
+		assert.Equal(t, types.ScopeBooksRead, response.Scope)
+		assert.Equal(t, "1", response.Sub)
+		assert.Equal(t, "JohnDoe", response.Username)
+	})
+}
+
+func TestHandleOAuthRevoke(t *testing.T) {
+	t.Run("it should answer 200 for an invalid token", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(true, types.OAuthGrantAuthorizationCode), nil)
+
+		form := url.Values{}
+		form.Set("token", "not-a-token")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/revoke", form, true))
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+	})
+
+	t.Run("it should revoke the whole grant of a refresh token", func(t *testing.T) {
+		_, mockAuthStore, mockUUID, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		refreshToken, err := utils.CreateRefreshJWT(1, "JohnDoe", "johndoe@email.com", types.RoleReader, "family-1", config.Envs.JWTSecret, 3600, mockUUID)
+		if err != nil {
+			t.Fatalf("Failed to create refresh token: %v", err)
+		}
+
+		mockAuthStore.On("GetOAuthClientByClientID", mock.Anything, "client-1").Return(newOAuthClient(true, types.OAuthGrantAuthorizationCode), nil)
+		mockAuthStore.On("GetRefreshTokenByJti", mock.Anything, "mocked-uuid").Return(&types.RefreshToken{UserID: 1, Jti: "mocked-uuid", FamilyID: "family-1", ClientID: "client-1", ExpiresAt: time.Now().Add(time.Hour)}, nil)
+		mockAuthStore.On("DeleteRefreshTokenFamily", mock.Anything, 1, "family-1").Return(nil)
+
+		form := url.Values{}
+		form.Set("token", refreshToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, newOAuthFormRequest(ts.URL+"/api/v1/oauth/revoke", form, true))
+
+		assert.Equal(t, http.StatusOK, w.Result().StatusCode)
+		mockAuthStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleDeleteOAuthConsent(t *testing.T) {
+	t.Run("it should throw an error when there is no consent for the client", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("DeleteOAuthConsent", mock.Anything, 1, "client-1").Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/oauth/consents/client-1", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"No consent found for this client"}`, string(responseBody))
+	})
+
+	t.Run("it should revoke the access of the client", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupOAuthTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("DeleteOAuthConsent", mock.Anything, 1, "client-1").Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/oauth/consents/client-1", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Result().StatusCode)
+	})
+}
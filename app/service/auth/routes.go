@@ -1,45 +1,102 @@
 package auth
 
 import (
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
 	"github.com/hoyci/book-store-api/config"
 	"github.com/hoyci/book-store-api/types"
 	"github.com/hoyci/book-store-api/utils"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
 )
 
 var validate = validator.New()
 
+const mfaRecoveryCodeCount = 10
+
+func resetPasswordValidator(sl validator.StructLevel) {
+	data := sl.Current().Interface().(types.ResetPasswordPayload)
+	if data.Password != data.ConfirmPassword {
+		sl.ReportError(data.ConfirmPassword, "ConfirmPassword", "ConfirmPassword", "password_mismatch", "")
+	}
+}
+
 type AuthHandler struct {
 	userStore types.UserStore
 	authStore types.AuthStore
 	UUIDGen   types.UUIDGenerator
+	mailer    types.Mailer
+	oidc      types.OIDCProvider
+	metrics   *authMetrics
+}
+
+type authMetrics struct {
+	failedLogins  prometheus.Counter
+	blockedLogins prometheus.Counter
+	loginLockouts *prometheus.CounterVec
 }
 
 func NewAuthHandler(
 	userStore types.UserStore,
 	authStore types.AuthStore,
 	UUIDGen types.UUIDGenerator,
+	mailer types.Mailer,
+	oidc types.OIDCProvider,
 ) *AuthHandler {
+	validate.RegisterStructValidation(resetPasswordValidator, types.ResetPasswordPayload{})
+
 	return &AuthHandler{
 		userStore: userStore,
 		authStore: authStore,
 		UUIDGen:   UUIDGen,
+		mailer:    mailer,
+		oidc:      oidc,
+		metrics: &authMetrics{
+			failedLogins: prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "auth_failed_logins_total",
+				Help: "Tracks login attempts rejected for a wrong email or password.",
+			}),
+			blockedLogins: prometheus.NewCounter(prometheus.CounterOpts{
+				Name: "auth_blocked_logins_total",
+				Help: "Tracks login attempts refused because of an active lockout.",
+			}),
+			loginLockouts: prometheus.NewCounterVec(prometheus.CounterOpts{
+				Name: "auth_login_lockouts_total",
+				Help: "Tracks lockouts applied after repeated failed logins, by account, by IP or by MFA.",
+			}, []string{"scope"}),
+		},
 	}
 }
 
+// Collectors returns the handler's metrics so the router can expose them
+// next to the HTTP ones.
+func (h *AuthHandler) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{h.metrics.failedLogins, h.metrics.blockedLogins, h.metrics.loginLockouts}
+}
+
 // @Summary Realizar login do usuário
+// @Description Quando o usuário tem MFA ativo, retorna um token de desafio (mfa_token) que deve ser trocado pelos tokens em /auth/mfa/verify.
+// @Description Tentativas com falha são contadas por conta e por IP. Ao atingir o limite, o login fica bloqueado por um tempo que dobra a cada nova falha.
 // @Tags Auth
 // @Accept json
 // @Produce json
 // @Param request body types.UserLoginPayload true "Dados para login do usuário"
-// @Success 200 {object} types.UserLoginResponse "Tokens de acesso e refresh"
+// @Success 200 {object} types.UserLoginResponse "Tokens de acesso e refresh, ou token de desafio MFA"
 // @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
 // @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
-// @Failure 404 {object} types.NotFoundResponse "No user found with the given email"
+// @Failure 401 {object} types.UnauthorizedResponse "Invalid email or password"
+// @Failure 403 {object} types.ForbiddenResponse "Email address is not verified"
+// @Failure 429 {object} types.TooManyRequestsResponse "Too many failed login attempts, try again later"
 // @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
 // @Router /auth/login [post]
 func (h *AuthHandler) HandleUserLogin(w http.ResponseWriter, r *http.Request) {
@@ -59,56 +116,153 @@ func (h *AuthHandler) HandleUserLogin(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	throttles := utils.LoginThrottles(requestPayload.Email, utils.ClientIP(r))
+
+	lockedUntil, err := h.authStore.GetLoginLockout(r.Context(), utils.LoginThrottleKeys(throttles))
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleUserLogin", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+	if lockedUntil != nil {
+		h.metrics.blockedLogins.Inc()
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(*lockedUntil).Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("login attempt while locked out until %s", lockedUntil.Format(time.RFC3339)), "HandleUserLogin", types.TooManyRequestsResponse{Error: "Too many failed login attempts, try again later"})
+		return
+	}
+
 	user, err := h.userStore.GetByEmail(r.Context(), requestPayload.Email)
 	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleUserLogin", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
 		if err == sql.ErrNoRows {
-			utils.WriteError(w, http.StatusNotFound, err, "HandleUserLogin", types.NotFoundResponse{Error: fmt.Sprintf("No user found with email %s", requestPayload.Email)})
+			// Unknown emails cost the same time and count against the same
+			// limits as wrong passwords, so neither reveals who has an account.
+			_, _ = utils.CheckPassword(r.Context(), dummyPasswordHash, requestPayload.Password)
+
+			if err := h.recordFailedLogin(r, throttles, 0); err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+				return
+			}
+
+			utils.WriteError(w, http.StatusUnauthorized, err, "HandleUserLogin", types.UnauthorizedResponse{Error: "Invalid email or password"})
 			return
 		}
 		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
 		return
 	}
 
-	err = utils.CheckPassword(r.Context(), user.PasswordHash, requestPayload.Password)
+	needsRehash, err := utils.CheckPassword(r.Context(), user.PasswordHash, requestPayload.Password)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "The email or password is incorrect"})
+		if err := h.recordFailedLogin(r, throttles, user.ID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusUnauthorized, err, "HandleUserLogin", types.UnauthorizedResponse{Error: "Invalid email or password"})
+		return
+	}
+
+	// The password is only known in clear text right now, so this is the
+	// moment to move the hash to the configured algorithm and parameters.
+	// Failing to do so doesn't stop the login, it will be retried next time.
+	if needsRehash {
+		h.rehashPassword(r.Context(), user.ID, requestPayload.Password)
+	}
+
+	scope, ok := accessTokenScope(user.EmailVerifiedAt)
+	if !ok {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("user %d tried to log in with an unverified email", user.ID), "HandleUserLogin", types.ForbiddenResponse{Error: "Email address is not verified"})
+		return
 	}
 
-	accessToken, err := utils.CreateJWT(user.ID, user.Username, user.Email, config.Envs.JWTSecret, 3600, h.UUIDGen)
+	response, err := h.completeLogin(r, user.ID, user.Username, user.Email, user.Role, scope)
 	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleUserLogin", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
 		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	// Until the second factor is checked too, the login hasn't succeeded and
+	// the failures counted against the account stay.
+	if !response.MFARequired {
+		err = h.authStore.ResetLoginThrottle(r.Context(), throttles[0].Key)
+		if err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+			return
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// completeLogin is shared by every way of logging in once the user is known.
+// Users with MFA enabled get a challenge token instead of a session.
+func (h *AuthHandler) completeLogin(r *http.Request, userID int, username string, email string, role string, scope string) (*types.UserLoginResponse, error) {
+	mfa, err := h.authStore.GetMFAByUserID(r.Context(), userID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
 	}
 
-	refreshToken, err := utils.CreateJWT(user.ID, user.Username, user.Email, config.Envs.JWTSecret, config.Envs.JWTExpirationInSeconds, h.UUIDGen)
+	if mfa != nil && mfa.EnabledAt != nil {
+		mfaToken, err := utils.CreateAccessJWT(userID, username, email, role, types.ScopeMFAChallenge, "", config.Envs.JWTSecret, config.Envs.MFAChallengeTTL, h.UUIDGen)
+		if err != nil {
+			return nil, err
+		}
+
+		return &types.UserLoginResponse{MFARequired: true, MFAToken: mfaToken}, nil
+	}
+
+	return h.startSession(r, userID, username, email, role, scope)
+}
+
+// startSession issues the access and refresh tokens of a new session and
+// stores the refresh token under a new family.
+func (h *AuthHandler) startSession(r *http.Request, userID int, username string, email string, role string, scope string) (*types.UserLoginResponse, error) {
+	familyID := h.UUIDGen.New()
+
+	accessToken, err := utils.CreateAccessJWT(userID, username, email, role, scope, familyID, config.Envs.JWTSecret, 3600, h.UUIDGen)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return nil, err
+	}
+
+	refreshToken, err := utils.CreateRefreshJWT(userID, username, email, role, familyID, config.Envs.JWTSecret, config.Envs.JWTExpirationInSeconds, h.UUIDGen)
+	if err != nil {
+		return nil, err
 	}
 
 	refreshTokenClaims, err := utils.VerifyJWT(refreshToken, config.Envs.JWTSecret)
 	if err != nil {
-		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
-		return
+		return nil, err
 	}
 
-	err = h.authStore.UpsertRefreshToken(
+	err = h.authStore.CreateRefreshToken(
 		r.Context(),
-		types.UpdateRefreshTokenPayload{
+		types.CreateRefreshTokenPayload{
 			UserID:    refreshTokenClaims.UserID,
 			Jti:       refreshTokenClaims.RegisteredClaims.ID,
+			FamilyID:  familyID,
+			UserAgent: r.UserAgent(),
+			IPAddress: utils.ClientIP(r),
 			ExpiresAt: refreshTokenClaims.RegisteredClaims.ExpiresAt.Time,
 		},
 	)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			utils.WriteError(w, http.StatusNotFound, err, "HandleGetBookByID", types.NotFoundResponse{Error: fmt.Sprintf("No userID found with ID %d", refreshTokenClaims.UserID)})
-			return
-		}
-
-		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
-		return
+		return nil, err
 	}
 
-	utils.WriteJSON(w, http.StatusOK, types.UserLoginResponse{AccessToken: accessToken, RefreshToken: refreshToken})
+	return &types.UserLoginResponse{AccessToken: accessToken, RefreshToken: refreshToken}, nil
 }
 
 // @Summary Atualizar tokens (Refresh Token)
@@ -120,7 +274,7 @@ func (h *AuthHandler) HandleUserLogin(w http.ResponseWriter, r *http.Request) {
 // @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
 // @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
 // @Failure 401 {object} types.UnauthorizedResponse "Refresh token is invalid or has been expired"
-// @Failure 404 {object} types.NotFoundResponse "No refresh token found with the given user ID"
+// @Failure 403 {object} types.ForbiddenResponse "Email address is not verified"
 // @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
 // @Router /auth/refresh [post]
 func (h *AuthHandler) HandleRefreshToken(w http.ResponseWriter, r *http.Request) {
@@ -146,29 +300,76 @@ func (h *AuthHandler) HandleRefreshToken(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	storedToken, err := h.authStore.GetRefreshTokenByUserID(r.Context(), claims.UserID)
+	storedToken, err := h.authStore.GetRefreshTokenByJti(r.Context(), claims.RegisteredClaims.ID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleRefreshToken", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			// A validly signed token that is no longer stored has either been
+			// revoked or already rotated. If its family is still alive, the
+			// token was replayed, so the whole family is revoked.
+			if claims.FamilyID != "" {
+				if err := h.revokeReusedRefreshTokenFamily(r, claims); err != nil {
+					utils.WriteError(w, http.StatusInternalServerError, err, "HandleRefreshToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+					return
+				}
+			}
+
+			utils.WriteError(w, http.StatusUnauthorized, err, "HandleRefreshToken", types.UnauthorizedResponse{Error: "Refresh token is invalid or has been expired"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRefreshToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if storedToken.UserID != claims.UserID {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("stored refresh token does not belong to the claims user"), "HandleRefreshToken", types.UnauthorizedResponse{Error: "Refresh token is invalid or has been expired"})
+		return
+	}
+
+	// Tokens issued to OAuth clients are refreshed at /oauth/token, where
+	// they keep their scope.
+	if storedToken.ClientID != "" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("refresh token of OAuth client %s sent to the first-party refresh endpoint", storedToken.ClientID), "HandleRefreshToken", types.UnauthorizedResponse{Error: "Refresh token is invalid or has been expired"})
+		return
+	}
+
+	// Claims are rebuilt from the users row so role changes and disabled
+	// accounts take effect on the next refresh instead of on the next login.
+	user, err := h.userStore.GetByID(r.Context(), claims.UserID)
 	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleRefreshToken", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
 		if err == sql.ErrNoRows {
-			utils.WriteError(w, http.StatusNotFound, err, "HandleRefreshToken", types.NotFoundResponse{Error: fmt.Sprintf("No refresh token found with user ID %d", claims.UserID)})
+			utils.WriteError(w, http.StatusUnauthorized, err, "HandleRefreshToken", types.UnauthorizedResponse{Error: "Refresh token is invalid or has been expired"})
 			return
 		}
 		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRefreshToken", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
 		return
 	}
 
-	if storedToken.Jti != claims.RegisteredClaims.ID {
-		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("stored JTI does not match the claims ID"), "HandleRefreshToken", types.UnauthorizedResponse{Error: "Refresh token is invalid or has been expired"})
+	scope, ok := accessTokenScope(user.EmailVerifiedAt)
+	if !ok {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("user %d tried to refresh tokens with an unverified email", user.ID), "HandleRefreshToken", types.ForbiddenResponse{Error: "Email address is not verified"})
 		return
 	}
 
-	newAccessToken, err := utils.CreateJWT(claims.UserID, claims.Username, claims.Email, config.Envs.JWTSecret, 3600, h.UUIDGen)
+	newAccessToken, err := utils.CreateAccessJWT(user.ID, user.Username, user.Email, user.Role, scope, storedToken.FamilyID, config.Envs.JWTSecret, 3600, h.UUIDGen)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "Refresh token is invalid or has been expired"})
+		return
 	}
 
-	newRefreshToken, err := utils.CreateJWT(claims.UserID, claims.Username, claims.Email, config.Envs.JWTSecret, config.Envs.JWTExpirationInSeconds, h.UUIDGen)
+	newRefreshToken, err := utils.CreateRefreshJWT(user.ID, user.Username, user.Email, user.Role, storedToken.FamilyID, config.Envs.JWTSecret, config.Envs.JWTExpirationInSeconds, h.UUIDGen)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUserLogin", types.InternalServerErrorResponse{Error: "Refresh token is invalid or has been expired"})
+		return
 	}
 
 	newRefreshTokenClaims, err := utils.VerifyJWT(newRefreshToken, config.Envs.JWTSecret)
@@ -177,16 +378,18 @@ func (h *AuthHandler) HandleRefreshToken(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	err = h.authStore.UpsertRefreshToken(
+	err = h.authStore.RotateRefreshToken(
 		r.Context(),
-		types.UpdateRefreshTokenPayload{
-			UserID:    newRefreshTokenClaims.UserID,
-			Jti:       newRefreshTokenClaims.RegisteredClaims.ID,
-			ExpiresAt: newRefreshTokenClaims.RegisteredClaims.ExpiresAt.Time,
+		types.RotateRefreshTokenPayload{
+			PreviousJti: storedToken.Jti,
+			Jti:         newRefreshTokenClaims.RegisteredClaims.ID,
+			UserAgent:   r.UserAgent(),
+			IPAddress:   utils.ClientIP(r),
+			ExpiresAt:   newRefreshTokenClaims.RegisteredClaims.ExpiresAt.Time,
 		})
 	if err != nil {
 		if err == sql.ErrNoRows {
-			utils.WriteError(w, http.StatusNotFound, err, "HandleGetBookByID", types.NotFoundResponse{Error: fmt.Sprintf("No userID found with ID %d", newRefreshTokenClaims.UserID)})
+			utils.WriteError(w, http.StatusUnauthorized, err, "HandleRefreshToken", types.UnauthorizedResponse{Error: "Refresh token is invalid or has been expired"})
 			return
 		}
 
@@ -196,3 +399,1110 @@ func (h *AuthHandler) HandleRefreshToken(w http.ResponseWriter, r *http.Request)
 
 	utils.WriteJSON(w, http.StatusOK, types.UpdateRefreshTokenResponse{AccessToken: newAccessToken, RefreshToken: newRefreshToken})
 }
+
+func (h *AuthHandler) rehashPassword(ctx context.Context, userID int, password string) {
+	passwordHash, err := utils.HashPassword(ctx, password)
+	if err == nil {
+		err = h.userStore.UpdatePasswordByID(ctx, userID, passwordHash)
+	}
+
+	if err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"user_id": userID,
+			"error":   err.Error(),
+		}).Error("Failed to rehash password")
+	}
+}
+
+// dummyPasswordHash is checked against when the email is unknown, so that
+// login takes as long as for a wrong password.
+var dummyPasswordHash, _ = utils.HashPassword(context.Background(), "book-store-dummy-password")
+
+func (h *AuthHandler) recordFailedLogin(r *http.Request, throttles []utils.LoginThrottle, userID int) error {
+	h.metrics.failedLogins.Inc()
+
+	locked, err := utils.RecordFailedLogin(r, h.authStore, throttles, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, throttle := range locked {
+		h.metrics.loginLockouts.WithLabelValues(throttle.Scope).Inc()
+	}
+
+	return nil
+}
+
+// accessTokenScope tells which scope the access token of a user should carry.
+// Unverified users get a restricted token or, in reject mode, no token at all.
+func accessTokenScope(emailVerifiedAt *time.Time) (string, bool) {
+	if emailVerifiedAt != nil {
+		return "", true
+	}
+
+	if config.Envs.EmailVerificationMode == types.EmailVerificationModeRestrict {
+		return types.ScopeUnverifiedEmail, true
+	}
+
+	return "", false
+}
+
+func (h *AuthHandler) revokeReusedRefreshTokenFamily(r *http.Request, claims *types.CustomClaims) error {
+	err := h.authStore.DeleteRefreshTokenFamily(r.Context(), claims.UserID, claims.FamilyID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	}
+
+	utils.Log.WithFields(logrus.Fields{
+		"user_id":   claims.UserID,
+		"family_id": claims.FamilyID,
+		"jti":       claims.RegisteredClaims.ID,
+	}).Warn("Refresh token reuse detected, token family revoked")
+
+	return h.authStore.CreateSecurityEvent(
+		r.Context(),
+		types.CreateSecurityEventPayload{
+			UserID:    claims.UserID,
+			EventType: types.SecurityEventRefreshTokenReuse,
+			FamilyID:  claims.FamilyID,
+			UserAgent: r.UserAgent(),
+			IPAddress: utils.ClientIP(r),
+		},
+	)
+}
+
+func (h *AuthHandler) revokeAccessToken(ctx context.Context, claims *types.CustomClaims) error {
+	if claims.RegisteredClaims.ExpiresAt == nil {
+		return fmt.Errorf("access token has no expiration")
+	}
+
+	return h.authStore.RevokeAccessToken(
+		ctx,
+		types.RevokeAccessTokenPayload{
+			UserID:    claims.UserID,
+			Jti:       claims.RegisteredClaims.ID,
+			ExpiresAt: claims.RegisteredClaims.ExpiresAt.Time,
+		},
+	)
+}
+
+// @Summary Encerrar a sessão atual
+// @Description Revoga o refresh token informado e invalida o access token usado na requisição até a sua expiração.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.RefreshTokenPayload true "Refresh token da sessão a ser encerrada"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Refresh token is invalid or has been expired"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Router /auth/logout [post]
+func (h *AuthHandler) HandleLogout(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetClaimsFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve claims from context"), "HandleLogout", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	var requestPayload types.RefreshTokenPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleLogout", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleLogout", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	refreshTokenClaims, err := utils.VerifyJWT(requestPayload.RefreshToken, config.Envs.JWTSecret)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err, "HandleLogout", types.UnauthorizedResponse{Error: "Refresh token is invalid or has been expired"})
+		return
+	}
+
+	if refreshTokenClaims.UserID != claims.UserID {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("refresh token does not belong to the authenticated user"), "HandleLogout", types.UnauthorizedResponse{Error: "Refresh token is invalid or has been expired"})
+		return
+	}
+
+	err = h.authStore.DeleteRefreshToken(r.Context(), claims.UserID, refreshTokenClaims.RegisteredClaims.ID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleLogout", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if err := h.revokeAccessToken(r.Context(), claims); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleLogout", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Encerrar todas as sessões
+// @Description Revoga todos os refresh tokens do usuário e invalida todos os access tokens emitidos até agora, inclusive o usado na requisição.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 204 "No Content"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Router /auth/logout-all [post]
+func (h *AuthHandler) HandleLogoutAll(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetClaimsFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve claims from context"), "HandleLogoutAll", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err := h.authStore.RevokeTokensByUserID(r.Context(), claims.UserID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleLogoutAll", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if err := h.revokeAccessToken(r.Context(), claims); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleLogoutAll", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Listar sessões ativas
+// @Description Lista as sessões (refresh tokens) ativas do usuário autenticado, uma por dispositivo.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.GetSessionsResponse "Sessões ativas"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /auth/sessions [get]
+func (h *AuthHandler) HandleGetSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleGetSessions", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	tokens, err := h.authStore.GetRefreshTokensByUserID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetSessions", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetSessions", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	sessions := make([]*types.SessionResponse, 0, len(tokens))
+	for _, token := range tokens {
+		sessions = append(sessions, &types.SessionResponse{
+			ID:         token.ID,
+			UserAgent:  token.UserAgent,
+			IPAddress:  token.IPAddress,
+			CreatedAt:  token.CreatedAt,
+			LastUsedAt: token.LastUsedAt,
+			ExpiresAt:  token.ExpiresAt,
+		})
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetSessionsResponse{Sessions: sessions})
+}
+
+// @Summary Revogar uma sessão
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID da sessão"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Session ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 404 {object} types.NotFoundResponse "No session found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /auth/sessions/{id} [delete]
+func (h *AuthHandler) HandleDeleteSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleDeleteSession", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleDeleteSession", types.BadRequestResponse{Error: "Session ID must be a positive integer"})
+		return
+	}
+
+	err = h.authStore.DeleteRefreshTokenByID(r.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleDeleteSession", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleDeleteSession", types.NotFoundResponse{Error: fmt.Sprintf("No session found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDeleteSession", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Solicitar redefinição de senha
+// @Description Envia um link de redefinição de senha para o email informado. A resposta é a mesma quer o email esteja cadastrado ou não.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body types.ForgotPasswordPayload true "Email da conta"
+// @Success 202 {object} types.ForgotPasswordResponse "Reset link sent if the email is registered"
+// @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /auth/password/forgot [post]
+func (h *AuthHandler) HandleForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var requestPayload types.ForgotPasswordPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleForgotPassword", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleForgotPassword", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	response := types.ForgotPasswordResponse{Message: "If the email is registered, a password reset link has been sent"}
+
+	user, err := h.userStore.GetByEmail(r.Context(), requestPayload.Email)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleForgotPassword", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		// Unknown emails get the same answer as known ones so the endpoint
+		// can't be used to find out who has an account.
+		if err == sql.ErrNoRows {
+			utils.WriteJSON(w, http.StatusAccepted, response)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleForgotPassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	token, tokenHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleForgotPassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err = h.authStore.CreatePasswordResetToken(
+		r.Context(),
+		types.CreatePasswordResetTokenPayload{
+			UserID:    user.ID,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Duration(config.Envs.PasswordResetTTL) * time.Second),
+		},
+	)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleForgotPassword", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleForgotPassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err = h.mailer.Send(r.Context(), types.Email{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nUse the link below to choose a new password. It expires in %d minutes and can only be used once.\n\n%s?token=%s\n\nIf you didn't ask for a password reset, you can ignore this email.\n",
+			user.Username,
+			config.Envs.PasswordResetTTL/60,
+			config.Envs.PasswordResetURL,
+			url.QueryEscape(token),
+		),
+	})
+	if err != nil {
+		// Failing the request would tell the caller the email exists, so the
+		// error is only logged.
+		utils.Log.WithFields(logrus.Fields{
+			"user_id": user.ID,
+			"error":   err.Error(),
+		}).Error("Failed to send password reset email")
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, response)
+}
+
+// @Summary Redefinir senha
+// @Description Define uma nova senha usando o token recebido por email. O token só pode ser usado uma vez e todas as sessões do usuário são encerradas, inclusive os access tokens já emitidos.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body types.ResetPasswordPayload true "Token de redefinição e nova senha"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Reset token is invalid or has expired"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /auth/password/reset [post]
+func (h *AuthHandler) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	var requestPayload types.ResetPasswordPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleResetPassword", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleResetPassword", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	if err := utils.CheckPasswordPolicy(requestPayload.Password); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleResetPassword", types.BadRequestResponse{Error: err.Error()})
+		return
+	}
+
+	userID, err := h.authStore.ConsumePasswordResetToken(r.Context(), utils.HashOpaqueToken(requestPayload.Token))
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleResetPassword", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleResetPassword", types.BadRequestResponse{Error: "Reset token is invalid or has expired"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleResetPassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	passwordHash, err := utils.HashPassword(r.Context(), requestPayload.Password)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleResetPassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err = h.userStore.UpdatePasswordByID(r.Context(), userID, passwordHash)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleResetPassword", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleResetPassword", types.BadRequestResponse{Error: "Reset token is invalid or has expired"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleResetPassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	// Whoever knew the old password may still hold a session, so every
+	// refresh and access token is revoked along with it.
+	err = h.authStore.RevokeTokensByUserID(r.Context(), userID)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleResetPassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Restaurar conta excluída
+// @Description Reativa uma conta excluída pelo próprio usuário, desde que dentro do período de retenção. Exige email e senha, e as tentativas com falha contam para o mesmo limite do login. Contas desativadas por um administrador não podem ser restauradas por aqui.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body types.RestoreAccountPayload true "Email e senha da conta"
+// @Success 200 {object} types.RestoreAccountResponse "Conta restaurada"
+// @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Invalid email or password"
+// @Failure 429 {object} types.TooManyRequestsResponse "Too many failed login attempts, try again later"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /auth/restore [post]
+func (h *AuthHandler) HandleRestoreAccount(w http.ResponseWriter, r *http.Request) {
+	var requestPayload types.RestoreAccountPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleRestoreAccount", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleRestoreAccount", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	throttles := utils.LoginThrottles(requestPayload.Email, utils.ClientIP(r))
+
+	lockedUntil, err := h.authStore.GetLoginLockout(r.Context(), utils.LoginThrottleKeys(throttles))
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleRestoreAccount", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreAccount", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+	if lockedUntil != nil {
+		h.metrics.blockedLogins.Inc()
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(*lockedUntil).Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("account restore attempt while locked out until %s", lockedUntil.Format(time.RFC3339)), "HandleRestoreAccount", types.TooManyRequestsResponse{Error: "Too many failed login attempts, try again later"})
+		return
+	}
+
+	deletedAfter := time.Now().Add(-time.Duration(config.Envs.TrashRetention) * time.Second)
+	user, err := h.userStore.GetDeletedByEmail(r.Context(), requestPayload.Email, deletedAfter)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleRestoreAccount", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			// Active, disabled, purged and unknown accounts all get the same
+			// answer as a wrong password.
+			_, _ = utils.CheckPassword(r.Context(), dummyPasswordHash, requestPayload.Password)
+
+			if err := h.recordFailedLogin(r, throttles, 0); err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreAccount", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+				return
+			}
+
+			utils.WriteError(w, http.StatusUnauthorized, err, "HandleRestoreAccount", types.UnauthorizedResponse{Error: "Invalid email or password"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreAccount", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if _, err := utils.CheckPassword(r.Context(), user.PasswordHash, requestPayload.Password); err != nil {
+		if err := h.recordFailedLogin(r, throttles, user.ID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreAccount", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusUnauthorized, err, "HandleRestoreAccount", types.UnauthorizedResponse{Error: "Invalid email or password"})
+		return
+	}
+
+	err = h.authStore.ResetLoginThrottle(r.Context(), throttles[0].Key)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreAccount", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err = h.userStore.RestoreByID(r.Context(), user.ID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleRestoreAccount", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreAccount", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.RestoreAccountResponse{Message: "Account restored, you can log in again"})
+}
+
+// @Summary Obter chaves públicas (JWKS)
+// @Description Publica as chaves públicas usadas para verificar os tokens emitidos pela API, incluindo as chaves anteriores ainda aceitas durante uma rotação. Com tokens assinados pelo segredo HS256 compartilhado, a lista é vazia. Servido na raiz do servidor, fora de /api/v1.
+// @Tags Auth
+// @Produce json
+// @Success 200 {object} types.JWKSResponse "Chaves públicas"
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) HandleGetJWKS(w http.ResponseWriter, r *http.Request) {
+	jwks := types.JWKSResponse{Keys: []types.JWK{}}
+	if keySet := utils.GetJWTKeySet(); keySet != nil {
+		jwks = keySet.JWKS()
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	utils.WriteJSON(w, http.StatusOK, jwks)
+}
+
+// @Summary Iniciar cadastro de MFA
+// @Description Gera um segredo TOTP (RFC 6238) para o usuário autenticado. O MFA só é ativado após a confirmação com um código em /auth/mfa/confirm.
+// @Tags Auth
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.EnrollMFAResponse "Segredo TOTP e URI de provisionamento"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 409 {object} types.ConflictResponse "MFA is already enabled"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /auth/mfa/enroll [post]
+func (h *AuthHandler) HandleEnrollMFA(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetClaimsFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve claims from context"), "HandleEnrollMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleEnrollMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	encryptedSecret, err := utils.EncryptSecret(secret, config.Envs.MFAEncryptionKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleEnrollMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err = h.authStore.UpsertPendingMFA(r.Context(), claims.UserID, encryptedSecret)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleEnrollMFA", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusConflict, err, "HandleEnrollMFA", types.ConflictResponse{Error: "MFA is already enabled"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleEnrollMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.EnrollMFAResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(config.Envs.MFAIssuer, claims.Email, secret),
+	})
+}
+
+// @Summary Confirmar cadastro de MFA
+// @Description Ativa o MFA a partir de um código gerado pelo aplicativo autenticador e retorna códigos de recuperação de uso único. Os códigos não podem ser consultados novamente.
+// @Tags Auth
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.ConfirmMFAPayload true "Código TOTP atual"
+// @Success 200 {object} types.ConfirmMFAResponse "Códigos de recuperação"
+// @Failure 400 {object} types.BadRequestResponse "Invalid MFA code"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No MFA enrollment found"
+// @Failure 409 {object} types.ConflictResponse "MFA is already enabled"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /auth/mfa/confirm [post]
+func (h *AuthHandler) HandleConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetClaimsFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve claims from context"), "HandleConfirmMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	var requestPayload types.ConfirmMFAPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleConfirmMFA", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleConfirmMFA", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	mfa, err := h.authStore.GetMFAByUserID(r.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleConfirmMFA", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleConfirmMFA", types.NotFoundResponse{Error: "No MFA enrollment found"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleConfirmMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if mfa.EnabledAt != nil {
+		utils.WriteError(w, http.StatusConflict, fmt.Errorf("user %d already has MFA enabled", claims.UserID), "HandleConfirmMFA", types.ConflictResponse{Error: "MFA is already enabled"})
+		return
+	}
+
+	secret, err := utils.DecryptSecret(mfa.Secret, config.Envs.MFAEncryptionKey)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleConfirmMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	step, ok := utils.ValidateTOTP(secret, requestPayload.Code, time.Now())
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user %d sent an invalid MFA code", claims.UserID), "HandleConfirmMFA", types.BadRequestResponse{Error: "Invalid MFA code"})
+		return
+	}
+
+	recoveryCodes, err := utils.GenerateRecoveryCodes(mfaRecoveryCodeCount)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleConfirmMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	recoveryCodeHashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		recoveryCodeHashes = append(recoveryCodeHashes, utils.HashOpaqueToken(utils.NormalizeRecoveryCode(code)))
+	}
+
+	err = h.authStore.EnableMFA(
+		r.Context(),
+		types.EnableMFAPayload{
+			UserID:             claims.UserID,
+			Step:               step,
+			RecoveryCodeHashes: recoveryCodeHashes,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleConfirmMFA", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusConflict, err, "HandleConfirmMFA", types.ConflictResponse{Error: "MFA is already enabled"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleConfirmMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.ConfirmMFAResponse{RecoveryCodes: recoveryCodes})
+}
+
+// @Summary Concluir login com MFA
+// @Description Troca o token de desafio retornado pelo login e um código TOTP ou de recuperação pelos tokens de acesso e refresh. O token de desafio só pode ser usado uma vez, mesmo que o código esteja errado.
+// @Description Códigos errados são contados por usuário. Ao atingir o limite, a verificação fica bloqueada por um tempo que dobra a cada nova falha.
+// @Tags Auth
+// @Accept json
+// @Produce json
+// @Param request body types.VerifyMFAPayload true "Token de desafio e código"
+// @Success 200 {object} types.UserLoginResponse "Tokens de acesso e refresh"
+// @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "MFA token is invalid or has expired"
+// @Failure 401 {object} types.UnauthorizedResponse "Invalid MFA code"
+// @Failure 403 {object} types.ForbiddenResponse "Email address is not verified"
+// @Failure 429 {object} types.TooManyRequestsResponse "Too many failed login attempts, try again later"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /auth/mfa/verify [post]
+func (h *AuthHandler) HandleVerifyMFA(w http.ResponseWriter, r *http.Request) {
+	var requestPayload types.VerifyMFAPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleVerifyMFA", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleVerifyMFA", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	claims, err := utils.VerifyJWT(requestPayload.MFAToken, config.Envs.JWTSecret)
+	if err != nil {
+		utils.WriteError(w, http.StatusUnauthorized, err, "HandleVerifyMFA", types.UnauthorizedResponse{Error: "MFA token is invalid or has expired"})
+		return
+	}
+
+	if claims.Scope != types.ScopeMFAChallenge {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("token with scope %q used as MFA token", claims.Scope), "HandleVerifyMFA", types.UnauthorizedResponse{Error: "MFA token is invalid or has expired"})
+		return
+	}
+
+	revoked, err := utils.IsAccessTokenRevoked(r.Context(), h.authStore, claims)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleVerifyMFA", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleVerifyMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+	if revoked {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("MFA token %s was already used", claims.RegisteredClaims.ID), "HandleVerifyMFA", types.UnauthorizedResponse{Error: "MFA token is invalid or has expired"})
+		return
+	}
+
+	mfaThrottle := utils.MFAThrottle(claims.UserID)
+
+	lockedUntil, err := h.authStore.GetLoginLockout(r.Context(), []string{mfaThrottle.Key})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleVerifyMFA", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleVerifyMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+	if lockedUntil != nil {
+		h.metrics.blockedLogins.Inc()
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(*lockedUntil).Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("MFA attempt while locked out until %s", lockedUntil.Format(time.RFC3339)), "HandleVerifyMFA", types.TooManyRequestsResponse{Error: "Too many failed login attempts, try again later"})
+		return
+	}
+
+	// The challenge is spent before the code is checked, so every guess costs
+	// the caller a new password login.
+	if err := h.revokeAccessToken(r.Context(), claims); err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleVerifyMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err = h.verifyMFACode(r.Context(), claims.UserID, requestPayload.Code)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleVerifyMFA", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			if err := h.recordFailedLogin(r, []utils.LoginThrottle{mfaThrottle}, claims.UserID); err != nil {
+				utils.WriteError(w, http.StatusInternalServerError, err, "HandleVerifyMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+				return
+			}
+
+			utils.WriteError(w, http.StatusUnauthorized, err, "HandleVerifyMFA", types.UnauthorizedResponse{Error: "Invalid MFA code"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleVerifyMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	user, err := h.userStore.GetByID(r.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleVerifyMFA", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusUnauthorized, err, "HandleVerifyMFA", types.UnauthorizedResponse{Error: "MFA token is invalid or has expired"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleVerifyMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	scope, ok := accessTokenScope(user.EmailVerifiedAt)
+	if !ok {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("user %d tried to log in with an unverified email", user.ID), "HandleVerifyMFA", types.ForbiddenResponse{Error: "Email address is not verified"})
+		return
+	}
+
+	// Both factors passed, so this is where the login succeeded.
+	for _, key := range []string{mfaThrottle.Key, utils.LoginThrottles(user.Email, utils.ClientIP(r))[0].Key} {
+		if err := h.authStore.ResetLoginThrottle(r.Context(), key); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err, "HandleVerifyMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+			return
+		}
+	}
+
+	response, err := h.startSession(r, user.ID, user.Username, user.Email, user.Role, scope)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleVerifyMFA", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+// verifyMFACode accepts either a TOTP code or one of the user's recovery
+// codes. It returns sql.ErrNoRows when the code is wrong or already used.
+func (h *AuthHandler) verifyMFACode(ctx context.Context, userID int, code string) error {
+	mfa, err := h.authStore.GetMFAByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if mfa.EnabledAt == nil {
+		return sql.ErrNoRows
+	}
+
+	if !isTOTPCode(code) {
+		return h.authStore.ConsumeMFARecoveryCode(ctx, userID, utils.HashOpaqueToken(utils.NormalizeRecoveryCode(code)))
+	}
+
+	secret, err := utils.DecryptSecret(mfa.Secret, config.Envs.MFAEncryptionKey)
+	if err != nil {
+		return err
+	}
+
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return sql.ErrNoRows
+	}
+
+	return h.authStore.UpdateMFALastUsedStep(ctx, userID, step)
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// @Summary Iniciar login com provedor OIDC
+// @Description Redireciona para o provedor OpenID Connect configurado usando o fluxo authorization code com PKCE.
+// @Tags Auth
+// @Success 302 "Redirecionamento para o provedor"
+// @Failure 404 {object} types.NotFoundResponse "OIDC login is not enabled"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /auth/oidc/login [get]
+func (h *AuthHandler) HandleOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("oidc provider is not configured"), "HandleOIDCLogin", types.NotFoundResponse{Error: "OIDC login is not enabled"})
+		return
+	}
+
+	state, stateHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOIDCLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	nonce, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOIDCLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	codeVerifier, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOIDCLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err = h.authStore.CreateOIDCLoginState(r.Context(), types.CreateOIDCLoginStatePayload{
+		StateHash:    stateHash,
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(time.Duration(config.Envs.OIDCStateTTL) * time.Second),
+	})
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOIDCLogin", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOIDCLogin", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	http.Redirect(w, r, h.oidc.AuthCodeURL(state, nonce, utils.PKCEChallenge(codeVerifier)), http.StatusFound)
+}
+
+// @Summary Concluir login com provedor OIDC
+// @Description Recebe o retorno do provedor OIDC, valida o ID token e emite os tokens de acesso e refresh. No primeiro login a identidade é vinculada ao usuário com o mesmo email, desde que o provedor o tenha verificado. Usuários com MFA ativo recebem um token de desafio.
+// @Tags Auth
+// @Produce json
+// @Param code query string true "Código de autorização"
+// @Param state query string true "Estado gerado em /auth/oidc/login"
+// @Success 200 {object} types.UserLoginResponse "Tokens de acesso e refresh, ou token de desafio MFA"
+// @Failure 400 {object} types.BadRequestResponse "Login state is invalid or has expired"
+// @Failure 401 {object} types.UnauthorizedResponse "Identity provider login failed"
+// @Failure 403 {object} types.ForbiddenResponse "No account is associated with this email"
+// @Failure 404 {object} types.NotFoundResponse "OIDC login is not enabled"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /auth/oidc/callback [get]
+func (h *AuthHandler) HandleOIDCCallback(w http.ResponseWriter, r *http.Request) {
+	if h.oidc == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("oidc provider is not configured"), "HandleOIDCCallback", types.NotFoundResponse{Error: "OIDC login is not enabled"})
+		return
+	}
+
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		utils.WriteError(w, http.StatusUnauthorized, fmt.Errorf("identity provider returned %s: %s", providerError, query.Get("error_description")), "HandleOIDCCallback", types.UnauthorizedResponse{Error: "Identity provider login failed"})
+		return
+	}
+
+	code, state := query.Get("code"), query.Get("state")
+	if code == "" || state == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("missing code or state"), "HandleOIDCCallback", types.BadRequestResponse{Error: "Missing code or state"})
+		return
+	}
+
+	loginState, err := h.authStore.ConsumeOIDCLoginState(r.Context(), utils.HashOpaqueToken(state))
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOIDCCallback", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleOIDCCallback", types.BadRequestResponse{Error: "Login state is invalid or has expired"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOIDCCallback", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	identity, err := h.oidc.Exchange(r.Context(), code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOIDCCallback", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusUnauthorized, err, "HandleOIDCCallback", types.UnauthorizedResponse{Error: "Identity provider login failed"})
+		return
+	}
+
+	userID, err := h.findOIDCUser(r.Context(), identity)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOIDCCallback", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == errOIDCEmailNotVerified {
+			utils.WriteError(w, http.StatusForbidden, err, "HandleOIDCCallback", types.ForbiddenResponse{Error: "Email address is not verified by the identity provider"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusForbidden, err, "HandleOIDCCallback", types.ForbiddenResponse{Error: "No account is associated with this email"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOIDCCallback", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	user, err := h.userStore.GetByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOIDCCallback", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusForbidden, err, "HandleOIDCCallback", types.ForbiddenResponse{Error: "No account is associated with this email"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOIDCCallback", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	scope, ok := accessTokenScope(user.EmailVerifiedAt)
+	if !ok {
+		utils.WriteError(w, http.StatusForbidden, fmt.Errorf("user %d tried to log in with an unverified email", user.ID), "HandleOIDCCallback", types.ForbiddenResponse{Error: "Email address is not verified"})
+		return
+	}
+
+	response, err := h.completeLogin(r, user.ID, user.Username, user.Email, user.Role, scope)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleOIDCCallback", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleOIDCCallback", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, response)
+}
+
+var errOIDCEmailNotVerified = errors.New("identity provider did not verify the email")
+
+// findOIDCUser returns the user an identity is linked to. An identity seen
+// for the first time is linked to the user with the same email, but only
+// when the provider vouches for that email.
+func (h *AuthHandler) findOIDCUser(ctx context.Context, identity *types.OIDCIdentity) (int, error) {
+	userID, err := h.authStore.GetUserIDByIdentity(ctx, identity.Issuer, identity.Subject)
+	if err != sql.ErrNoRows {
+		return userID, err
+	}
+
+	if !identity.EmailVerified || identity.Email == "" {
+		return 0, errOIDCEmailNotVerified
+	}
+
+	user, err := h.userStore.GetByEmail(ctx, identity.Email)
+	if err != nil {
+		return 0, err
+	}
+
+	err = h.authStore.LinkIdentity(ctx, types.LinkIdentityPayload{
+		UserID:  user.ID,
+		Issuer:  identity.Issuer,
+		Subject: identity.Subject,
+		Email:   identity.Email,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return user.ID, nil
+}
@@ -3,34 +3,50 @@ package auth_test
 import (
 	"bytes"
 	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
 	"database/sql"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/gorilla/mux"
 	"github.com/hoyci/book-store-api/cmd/api"
 	"github.com/hoyci/book-store-api/config"
+	"github.com/hoyci/book-store-api/mailer"
 	"github.com/hoyci/book-store-api/mocks"
 	"github.com/hoyci/book-store-api/service/auth"
 	"github.com/hoyci/book-store-api/types"
 	"github.com/hoyci/book-store-api/utils"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"golang.org/x/crypto/bcrypt"
 )
 
+var emailVerifiedAt = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
 func TestHandleUserLogin(t *testing.T) {
+	passwordHash, err := utils.HashPassword(context.Background(), "123mudar")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
 	setupTestServer := func() (*mocks.MockUserStore, *mocks.MockAuthStore, *mocks.MockUUIDGenerator, *httptest.Server, *mux.Router, config.Config) {
 		mockUUID := new(mocks.MockUUIDGenerator)
 		mockAuthStore := new(mocks.MockAuthStore)
 		mockUserStore := new(mocks.MockUserStore)
-		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID)
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, nil, nil)
 		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
 		ts := httptest.NewServer(router)
 		return mockUserStore, mockAuthStore, mockUUID, ts, router, apiServer.Config
 	}
@@ -144,11 +160,14 @@ func TestHandleUserLogin(t *testing.T) {
 		assert.JSONEq(t, expectedResponse, string(responseBody))
 	})
 
-	t.Run("it should throw an error when call endpoint with a non-existent user ID", func(t *testing.T) {
-		mockUserStore, _, _, ts, router, _ := setupTestServer()
+	t.Run("it should answer an unknown email like a wrong password", func(t *testing.T) {
+		mockUserStore, mockAuthStore, _, ts, router, _ := setupTestServer()
 		defer ts.Close()
 
-		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return((*types.UserResponse)(nil), sql.ErrNoRows)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "account:johndoe@email.com", mock.Anything).Return(1, nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, "ip:") }), mock.Anything).Return(1, nil)
+		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return((*types.GetByEmailResponse)(nil), sql.ErrNoRows)
 
 		payload := types.UserLoginPayload{
 			Email:    "johndoe@email.com",
@@ -164,23 +183,27 @@ func TestHandleUserLogin(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
 
 		responseBody, _ := io.ReadAll(res.Body)
-		expected := `{"error": "No user found with email johndoe@email.com"}`
+		expected := `{"error":"Invalid email or password"}`
 		assert.JSONEq(t, expected, string(responseBody))
+		mockAuthStore.AssertExpectations(t)
+		mockAuthStore.AssertNotCalled(t, "LockLogin", mock.Anything, mock.Anything, mock.Anything)
 	})
 
 	t.Run("it should return error when the request context is canceled during the process of get user by email", func(t *testing.T) {
-		mockUserStore, _, _, ts, router, _ := setupTestServer()
+		mockUserStore, mockAuthStore, _, ts, router, _ := setupTestServer()
 		defer ts.Close()
 
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+
 		canceledCtx, cancel := context.WithCancel(context.Background())
 		cancel()
 
 		mockUserStore.On("GetByEmail", mock.MatchedBy(func(ctx context.Context) bool {
 			return ctx.Err() == context.Canceled
-		}), mock.Anything).Return((*types.UserResponse)(nil), context.Canceled)
+		}), mock.Anything).Return((*types.GetByEmailResponse)(nil), context.Canceled)
 
 		payload := types.UserLoginPayload{
 			Email:    "johndoe@email.com",
@@ -206,10 +229,12 @@ func TestHandleUserLogin(t *testing.T) {
 	})
 
 	t.Run("it should throw a database find error", func(t *testing.T) {
-		mockUserStore, _, _, ts, router, _ := setupTestServer()
+		mockUserStore, mockAuthStore, _, ts, router, _ := setupTestServer()
 		defer ts.Close()
 
-		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return((*types.UserResponse)(nil), fmt.Errorf("no row found with email: 'johndoe@email.com'"))
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+
+		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return((*types.GetByEmailResponse)(nil), fmt.Errorf("no row found with email: 'johndoe@email.com'"))
 
 		payload := types.UserLoginPayload{
 			Email:    "johndoe@email.com",
@@ -239,18 +264,24 @@ func TestHandleUserLogin(t *testing.T) {
 		mockUUID.On("New").Return("mocked-uuid")
 
 		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return(
-			&types.UserResponse{
-				ID:        1,
-				Username:  "JohnDoe",
-				Email:     "johndoe@email.com",
-				CreatedAt: time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
-				UpdatedAt: nil,
-				DeletedAt: nil,
+			&types.GetByEmailResponse{
+				ID:              1,
+				Username:        "JohnDoe",
+				Email:           "johndoe@email.com",
+				PasswordHash:    passwordHash,
+				Role:            types.RoleAdmin,
+				EmailVerifiedAt: &emailVerifiedAt,
+				CreatedAt:       time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
+				UpdatedAt:       nil,
+				DeletedAt:       nil,
 			},
 			nil,
 		)
 
-		mockAuthStore.On("UpsertRefreshToken", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, "account:johndoe@email.com").Return(nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return((*types.UserMFA)(nil), sql.ErrNoRows)
+		mockAuthStore.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
 
 		payload := types.UserLoginPayload{
 			Email:    "johndoe@email.com",
@@ -295,26 +326,359 @@ func TestHandleUserLogin(t *testing.T) {
 		assert.Equal(t, "johndoe@email.com", access_token_claims.Email, "Email claim mismatch")
 		assert.Equal(t, "JohnDoe", access_token_claims.Username, "Username claim mismatch")
 		assert.Equal(t, 1, access_token_claims.UserID, "UserID claim mismatch")
+		assert.Equal(t, types.RoleAdmin, access_token_claims.Role, "Role claim mismatch")
+		assert.Equal(t, "mocked-uuid", access_token_claims.SessionID, "SessionID claim mismatch")
 
 		refresh_token_claims, err := utils.VerifyJWT(refresh_token, config.JWTSecret)
 		assert.NoError(t, err, "Failed to verify JWT token")
 
 		assert.Equal(t, 1, refresh_token_claims.UserID, "UserID claim mismatch")
 	})
+
+	t.Run("it should rehash an outdated password hash after a successful login", func(t *testing.T) {
+		mockUserStore, mockAuthStore, mockUUID, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		bcryptHash, _ := bcrypt.GenerateFromPassword([]byte("123mudar"), bcrypt.MinCost)
+
+		mockUUID.On("New").Return("mocked-uuid")
+
+		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return(
+			&types.GetByEmailResponse{
+				ID:              1,
+				Username:        "JohnDoe",
+				Email:           "johndoe@email.com",
+				PasswordHash:    string(bcryptHash),
+				Role:            types.RoleReader,
+				EmailVerifiedAt: &emailVerifiedAt,
+				CreatedAt:       time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
+			},
+			nil,
+		)
+		mockUserStore.On("UpdatePasswordByID", mock.Anything, 1, mock.MatchedBy(func(passwordHash string) bool {
+			return strings.HasPrefix(passwordHash, "$argon2id$")
+		})).Return(nil)
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, "account:johndoe@email.com").Return(nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return((*types.UserMFA)(nil), sql.ErrNoRows)
+		mockAuthStore.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+
+		payload := types.UserLoginPayload{
+			Email:    "johndoe@email.com",
+			Password: "123mudar",
+		}
+		marshalled, _ := json.Marshal(payload)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth", bytes.NewBuffer(marshalled))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		mockUserStore.AssertCalled(t, "UpdatePasswordByID", mock.Anything, 1, mock.Anything)
+	})
+
+	t.Run("it should refuse an unverified user in reject mode", func(t *testing.T) {
+		mockUserStore, mockAuthStore, _, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		defer func(mode string) { config.Envs.EmailVerificationMode = mode }(config.Envs.EmailVerificationMode)
+		config.Envs.EmailVerificationMode = types.EmailVerificationModeReject
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, mock.Anything).Return(nil)
+
+		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return(
+			&types.GetByEmailResponse{
+				ID:           1,
+				Username:     "JohnDoe",
+				Email:        "johndoe@email.com",
+				PasswordHash: passwordHash,
+				Role:         types.RoleReader,
+			},
+			nil,
+		)
+
+		payload := []byte(`{"email":"johndoe@email.com","password":"123mudar"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Email address is not verified"}`
+		assert.JSONEq(t, expected, string(responseBody))
+		mockAuthStore.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should issue a restricted access token to an unverified user in restrict mode", func(t *testing.T) {
+		mockUserStore, mockAuthStore, mockUUID, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		defer func(mode string) { config.Envs.EmailVerificationMode = mode }(config.Envs.EmailVerificationMode)
+		config.Envs.EmailVerificationMode = types.EmailVerificationModeRestrict
+
+		mockUUID.On("New").Return("mocked-uuid")
+		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return(
+			&types.GetByEmailResponse{
+				ID:           1,
+				Username:     "JohnDoe",
+				Email:        "johndoe@email.com",
+				PasswordHash: passwordHash,
+				Role:         types.RoleReader,
+			},
+			nil,
+		)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, "account:johndoe@email.com").Return(nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return((*types.UserMFA)(nil), sql.ErrNoRows)
+		mockAuthStore.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+
+		payload := []byte(`{"email":"johndoe@email.com","password":"123mudar"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.UserLoginResponse
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+
+		claims, err := utils.VerifyJWT(response.AccessToken, config.Envs.JWTSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, types.ScopeUnverifiedEmail, claims.Scope)
+	})
+
+	t.Run("it should return an MFA challenge instead of tokens when MFA is enabled", func(t *testing.T) {
+		mockUserStore, mockAuthStore, mockUUID, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return(
+			&types.GetByEmailResponse{
+				ID:              1,
+				Username:        "JohnDoe",
+				Email:           "johndoe@email.com",
+				PasswordHash:    passwordHash,
+				Role:            types.RoleAdmin,
+				EmailVerifiedAt: &emailVerifiedAt,
+			},
+			nil,
+		)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return(&types.UserMFA{UserID: 1, EnabledAt: &emailVerifiedAt}, nil)
+
+		payload := []byte(`{"email":"johndoe@email.com","password":"123mudar"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.UserLoginResponse
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+
+		assert.True(t, response.MFARequired)
+		assert.Empty(t, response.AccessToken)
+		assert.Empty(t, response.RefreshToken)
+
+		claims, err := utils.VerifyJWT(response.MFAToken, config.Envs.JWTSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, types.ScopeMFAChallenge, claims.Scope)
+		mockAuthStore.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+		mockAuthStore.AssertNotCalled(t, "ResetLoginThrottle", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should answer a wrong password with a generic error", func(t *testing.T) {
+		mockUserStore, mockAuthStore, _, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return(
+			&types.GetByEmailResponse{
+				ID:              1,
+				Username:        "JohnDoe",
+				Email:           "johndoe@email.com",
+				PasswordHash:    passwordHash,
+				Role:            types.RoleReader,
+				EmailVerifiedAt: &emailVerifiedAt,
+			},
+			nil,
+		)
+
+		payload := []byte(`{"email":"johndoe@email.com","password":"wrong-password"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Invalid email or password"}`, string(responseBody))
+		mockAuthStore.AssertNumberOfCalls(t, "RecordFailedLogin", 2)
+		mockAuthStore.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should refuse to check credentials while the login is locked", func(t *testing.T) {
+		mockUserStore, mockAuthStore, _, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		lockedUntil := time.Now().Add(90 * time.Second)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.MatchedBy(func(keys []string) bool {
+			return len(keys) == 2 && keys[0] == "account:johndoe@email.com" && keys[1] == "ip:192.0.2.1"
+		})).Return(&lockedUntil, nil)
+
+		payload := []byte(`{"email":"JohnDoe@email.com","password":"123mudar"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		assert.Contains(t, []string{"89", "90"}, res.Header.Get("Retry-After"))
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Too many failed login attempts, try again later"}`, string(responseBody))
+		mockUserStore.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should lock the account with exponential backoff once the limit is reached", func(t *testing.T) {
+		mockUserStore, mockAuthStore, _, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "account:johndoe@email.com", mock.Anything).Return(int(config.Envs.LoginMaxFailures)+2, nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "ip:192.0.2.1", mock.Anything).Return(1, nil)
+		mockAuthStore.On("LockLogin", mock.Anything, "account:johndoe@email.com", mock.MatchedBy(func(until time.Time) bool {
+			expected := time.Now().Add(time.Duration(config.Envs.LoginLockoutBase*4) * time.Second)
+			return until.Sub(expected).Abs() < 5*time.Second
+		})).Return(nil)
+		mockAuthStore.On("CreateSecurityEvent", mock.Anything, mock.MatchedBy(func(payload types.CreateSecurityEventPayload) bool {
+			return payload.UserID == 1 && payload.EventType == types.SecurityEventLoginLockout
+		})).Return(nil)
+		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return(
+			&types.GetByEmailResponse{ID: 1, Email: "johndoe@email.com", PasswordHash: passwordHash},
+			nil,
+		)
+
+		payload := []byte(`{"email":"johndoe@email.com","password":"wrong-password"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		mockAuthStore.AssertExpectations(t)
+
+		metricsReq := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/metrics", nil)
+		metricsW := httptest.NewRecorder()
+
+		router.ServeHTTP(metricsW, metricsReq)
+
+		metrics, _ := io.ReadAll(metricsW.Result().Body)
+		assert.Contains(t, string(metrics), `auth_login_lockouts_total{scope="account"} 1`)
+		assert.Contains(t, string(metrics), `auth_failed_logins_total 1`)
+	})
+
+	t.Run("it should keep the IP locked out whatever X-Forwarded-For says", func(t *testing.T) {
+		mockUserStore, mockAuthStore, _, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		hasPeerIP := func(keys []string) bool {
+			return len(keys) == 2 && keys[1] == "ip:192.0.2.1"
+		}
+		lockedUntil := time.Now().Add(time.Minute)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.MatchedBy(hasPeerIP)).Return((*time.Time)(nil), nil).Once()
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.MatchedBy(hasPeerIP)).Return(&lockedUntil, nil).Once()
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "account:johndoe@email.com", mock.Anything).Return(1, nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "ip:192.0.2.1", mock.Anything).Return(int(config.Envs.LoginMaxFailuresPerIP), nil)
+		mockAuthStore.On("LockLogin", mock.Anything, "ip:192.0.2.1", mock.Anything).Return(nil)
+		mockUserStore.On("GetByEmail", mock.Anything, "johndoe@email.com").Return(
+			&types.GetByEmailResponse{ID: 1, Email: "johndoe@email.com", PasswordHash: passwordHash},
+			nil,
+		)
+
+		for i, attempt := range []struct {
+			email          string
+			forwardedFor   string
+			expectedStatus int
+		}{
+			{email: "johndoe@email.com", forwardedFor: "198.51.100.1", expectedStatus: http.StatusUnauthorized},
+			{email: "janedoe@email.com", forwardedFor: "198.51.100.2", expectedStatus: http.StatusTooManyRequests},
+		} {
+			payload := []byte(fmt.Sprintf(`{"email":%q,"password":"wrong-password"}`, attempt.email))
+			req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth", bytes.NewBuffer(payload))
+			req.Header.Set("X-Forwarded-For", attempt.forwardedFor)
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, attempt.expectedStatus, w.Result().StatusCode, "attempt %d", i+1)
+		}
+
+		mockAuthStore.AssertExpectations(t)
+		mockUserStore.AssertNumberOfCalls(t, "GetByEmail", 1)
+	})
 }
 
 func TestHandleRefreshToken(t *testing.T) {
+	passwordHash, err := utils.HashPassword(context.Background(), "123mudar")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
 	setupTestServer := func() (*mocks.MockUserStore, *mocks.MockAuthStore, *mocks.MockUUIDGenerator, *httptest.Server, *mux.Router, config.Config) {
 		mockUUID := new(mocks.MockUUIDGenerator)
 		mockAuthStore := new(mocks.MockAuthStore)
 		mockUserStore := new(mocks.MockUserStore)
-		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID)
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, nil, nil)
 		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
 		ts := httptest.NewServer(router)
 		return mockUserStore, mockAuthStore, mockUUID, ts, router, apiServer.Config
 	}
 
+	createRefreshToken := func(t *testing.T, userID int, jti string, familyID string) string {
+		uuidGen := new(mocks.MockUUIDGenerator)
+		uuidGen.On("New").Return(jti)
+
+		token, err := utils.CreateRefreshJWT(userID, "JohnDoe", "johndoe@email.com", types.RoleReader, familyID, config.Envs.JWTSecret, 3600, uuidGen)
+		if err != nil {
+			t.Fatalf("Failed to create token: %v", err)
+		}
+		return token
+	}
+
 	t.Run("it should throw an error when body is not a valid JSON", func(t *testing.T) {
 		_, _, _, ts, router, _ := setupTestServer()
 		defer ts.Close()
@@ -394,7 +758,7 @@ func TestHandleRefreshToken(t *testing.T) {
 		assert.JSONEq(t, expectedResponse, string(responseBody))
 	})
 
-	t.Run("it should return error when the request context is canceled during the process of get refresh token by user id", func(t *testing.T) {
+	t.Run("it should return error when the request context is canceled during the process of get refresh token by jti", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
 		_, mockAuthStore, _, ts, router, _ := setupTestServer()
 		defer ts.Close()
@@ -402,9 +766,9 @@ func TestHandleRefreshToken(t *testing.T) {
 		canceledCtx, cancel := context.WithCancel(context.Background())
 		cancel()
 
-		mockAuthStore.On("GetRefreshTokenByUserID", mock.MatchedBy(func(ctx context.Context) bool {
+		mockAuthStore.On("GetRefreshTokenByJti", mock.MatchedBy(func(ctx context.Context) bool {
 			return ctx.Err() == context.Canceled
-		}), 1).Return((*types.RefreshToken)(nil), context.Canceled)
+		}), mock.Anything).Return((*types.RefreshToken)(nil), context.Canceled)
 
 		payload := types.RefreshTokenPayload{
 			RefreshToken: token,
@@ -435,18 +799,20 @@ func TestHandleRefreshToken(t *testing.T) {
 		mockUUID.On("New").Return("mocked-uuid")
 
 		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return(
-			&types.UserResponse{
-				ID:        1,
-				Username:  "JohnDoe",
-				Email:     "johndoe@email.com",
-				CreatedAt: time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
-				UpdatedAt: nil,
-				DeletedAt: nil,
+			&types.GetByEmailResponse{
+				ID:              1,
+				Username:        "JohnDoe",
+				Email:           "johndoe@email.com",
+				PasswordHash:    passwordHash,
+				EmailVerifiedAt: &emailVerifiedAt,
+				CreatedAt:       time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
+				UpdatedAt:       nil,
+				DeletedAt:       nil,
 			},
 			nil,
 		)
 
-		mockAuthStore.On("GetRefreshTokenByUserID", mock.Anything, mock.Anything).Return(
+		mockAuthStore.On("GetRefreshTokenByJti", mock.Anything, mock.Anything).Return(
 			&types.RefreshToken{
 				ID:        1,
 				UserID:    1,
@@ -457,7 +823,23 @@ func TestHandleRefreshToken(t *testing.T) {
 			nil,
 		)
 
-		mockAuthStore.On("UpsertRefreshToken", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return((*types.UserMFA)(nil), sql.ErrNoRows)
+		mockAuthStore.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("RotateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(
+			&types.UserResponse{
+				ID:              1,
+				Username:        "JohnDoe",
+				Email:           "johndoe@email.com",
+				Role:            types.RoleLibrarian,
+				EmailVerifiedAt: &emailVerifiedAt,
+				CreatedAt:       time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
+			},
+			nil,
+		)
 
 		userLoginPayload := types.UserLoginPayload{
 			Email:    "johndoe@email.com",
@@ -532,30 +914,59 @@ func TestHandleRefreshToken(t *testing.T) {
 		assert.Equal(t, "JohnDoe", access_token_claims.Username, "Username claim mismatch")
 		assert.Equal(t, 1, access_token_claims.UserID, "UserID claim mismatch")
 
+		assert.Equal(t, types.RoleLibrarian, access_token_claims.Role, "Role claim mismatch")
+
 		refresh_token_claims, err := utils.VerifyJWT(refresh_token, config.JWTSecret)
 		assert.NoError(t, err, "Failed to verify JWT token")
 		assert.Equal(t, 1, refresh_token_claims.UserID, "UserID claim mismatch")
 	})
 
-	t.Run("it should not be able refresh user with expired token", func(t *testing.T) {
+	t.Run("it should not refresh the token of a disabled user", func(t *testing.T) {
+		mockUserStore, mockAuthStore, _, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetRefreshTokenByJti", mock.Anything, "refresh-jti").Return(
+			&types.RefreshToken{ID: 1, UserID: 1, Jti: "refresh-jti", FamilyID: "family-id"},
+			nil,
+		)
+		mockUserStore.On("GetByID", mock.Anything, 1).Return((*types.UserResponse)(nil), sql.ErrNoRows)
+
+		payload, _ := json.Marshal(types.RefreshTokenPayload{RefreshToken: createRefreshToken(t, 1, "refresh-jti", "family-id")})
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/refresh", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		mockAuthStore.AssertNotCalled(t, "RotateRefreshToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should revoke the token family when an already rotated refresh token is reused", func(t *testing.T) {
 		mockUserStore, mockAuthStore, mockUUID, ts, router, _ := setupTestServer()
 		defer ts.Close()
 
 		mockUUID.On("New").Return("mocked-uuid")
 
 		mockUserStore.On("GetByEmail", mock.Anything, mock.Anything).Return(
-			&types.UserResponse{
-				ID:        1,
-				Username:  "JohnDoe",
-				Email:     "johndoe@email.com",
-				CreatedAt: time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
-				UpdatedAt: nil,
-				DeletedAt: nil,
+			&types.GetByEmailResponse{
+				ID:              1,
+				Username:        "JohnDoe",
+				Email:           "johndoe@email.com",
+				PasswordHash:    passwordHash,
+				EmailVerifiedAt: &emailVerifiedAt,
+				CreatedAt:       time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
+				UpdatedAt:       nil,
+				DeletedAt:       nil,
 			},
 			nil,
 		)
 
-		mockAuthStore.On("GetRefreshTokenByUserID", mock.Anything, mock.Anything).Return(
+		mockAuthStore.On("GetRefreshTokenByJti", mock.Anything, mock.Anything).Return(
 			&types.RefreshToken{
 				ID:        1,
 				UserID:    1,
@@ -566,7 +977,23 @@ func TestHandleRefreshToken(t *testing.T) {
 			nil,
 		).Once()
 
-		mockAuthStore.On("UpsertRefreshToken", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return((*types.UserMFA)(nil), sql.ErrNoRows)
+		mockAuthStore.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("RotateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(
+			&types.UserResponse{
+				ID:              1,
+				Username:        "JohnDoe",
+				Email:           "johndoe@email.com",
+				Role:            types.RoleLibrarian,
+				EmailVerifiedAt: &emailVerifiedAt,
+				CreatedAt:       time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
+			},
+			nil,
+		)
 
 		userLoginPayload := types.UserLoginPayload{
 			Email:    "johndoe@email.com",
@@ -613,16 +1040,14 @@ func TestHandleRefreshToken(t *testing.T) {
 
 		assert.Equal(t, http.StatusOK, resRefreshToken1.StatusCode)
 
-		mockAuthStore.On("GetRefreshTokenByUserID", mock.Anything, mock.Anything).Return(
-			&types.RefreshToken{
-				ID:        1,
-				UserID:    1,
-				CreatedAt: time.Now(),
-				ExpiresAt: time.Now().Add(24 * time.Hour),
-				Jti:       "mocked-uuid2",
-			},
-			nil,
+		mockAuthStore.On("GetRefreshTokenByJti", mock.Anything, mock.Anything).Return(
+			(*types.RefreshToken)(nil),
+			sql.ErrNoRows,
 		).Once()
+		mockAuthStore.On("DeleteRefreshTokenFamily", mock.Anything, 1, "mocked-uuid").Return(nil)
+		mockAuthStore.On("CreateSecurityEvent", mock.Anything, mock.MatchedBy(func(payload types.CreateSecurityEventPayload) bool {
+			return payload.UserID == 1 && payload.FamilyID == "mocked-uuid" && payload.EventType == types.SecurityEventRefreshTokenReuse
+		})).Return(nil)
 
 		reqRefreshToken2 := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/refresh", bytes.NewBuffer(userRefreshTokenMarshalled))
 		wRefreshToken2 := httptest.NewRecorder()
@@ -633,5 +1058,1604 @@ func TestHandleRefreshToken(t *testing.T) {
 		defer resRefreshToken2.Body.Close()
 
 		assert.Equal(t, http.StatusUnauthorized, resRefreshToken2.StatusCode)
+
+		mockAuthStore.AssertExpectations(t)
+	})
+
+	t.Run("it should not record a security event when the token family was already revoked", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetRefreshTokenByJti", mock.Anything, "refresh-jti").Return((*types.RefreshToken)(nil), sql.ErrNoRows)
+		mockAuthStore.On("DeleteRefreshTokenFamily", mock.Anything, 1, "family-id").Return(sql.ErrNoRows)
+
+		payload, _ := json.Marshal(types.RefreshTokenPayload{RefreshToken: createRefreshToken(t, 1, "refresh-jti", "family-id")})
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/refresh", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Refresh token is invalid or has been expired"}`
+		assert.JSONEq(t, expected, string(responseBody))
+
+		mockAuthStore.AssertNotCalled(t, "CreateSecurityEvent", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the token family cannot be revoked", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetRefreshTokenByJti", mock.Anything, "refresh-jti").Return((*types.RefreshToken)(nil), sql.ErrNoRows)
+		mockAuthStore.On("DeleteRefreshTokenFamily", mock.Anything, 1, "family-id").Return(fmt.Errorf("database error"))
+
+		payload, _ := json.Marshal(types.RefreshTokenPayload{RefreshToken: createRefreshToken(t, 1, "refresh-jti", "family-id")})
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/refresh", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+}
+
+func TestHandleLogout(t *testing.T) {
+	setupTestServer := func() (*mocks.MockAuthStore, *httptest.Server, *mux.Router) {
+		mockUUID := new(mocks.MockUUIDGenerator)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserStore := new(mocks.MockUserStore)
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockAuthStore, ts, router
+	}
+
+	createToken := func(t *testing.T, userID int, jti string) string {
+		uuidGen := new(mocks.MockUUIDGenerator)
+		uuidGen.On("New").Return(jti)
+
+		token, err := utils.CreateJWT(userID, "JohnDoe", "johndoe@email.com", types.RoleReader, config.Envs.JWTSecret, 3600, uuidGen)
+		if err != nil {
+			t.Fatalf("Failed to create token: %v", err)
+		}
+		return token
+	}
+
+	t.Run("it should throw an error when the refresh token is invalid", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload, _ := json.Marshal(types.RefreshTokenPayload{RefreshToken: "invalid-token"})
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/logout", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+createToken(t, 1, "access-jti"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Refresh token is invalid or has been expired"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the refresh token belongs to another user", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload, _ := json.Marshal(types.RefreshTokenPayload{RefreshToken: createToken(t, 2, "refresh-jti")})
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/logout", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+createToken(t, 1, "access-jti"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("it should throw an error when the refresh token cannot be deleted", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("DeleteRefreshToken", mock.Anything, 1, "refresh-jti").Return(fmt.Errorf("database error"))
+
+		payload, _ := json.Marshal(types.RefreshTokenPayload{RefreshToken: createToken(t, 1, "refresh-jti")})
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/logout", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+createToken(t, 1, "access-jti"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"An unexpected error occurred"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should revoke the refresh token and the current access token", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("DeleteRefreshToken", mock.Anything, 1, "refresh-jti").Return(nil)
+		mockAuthStore.On("RevokeAccessToken", mock.Anything, mock.MatchedBy(func(payload types.RevokeAccessTokenPayload) bool {
+			return payload.UserID == 1 && payload.Jti == "access-jti" && payload.ExpiresAt.After(time.Now())
+		})).Return(nil)
+
+		payload, _ := json.Marshal(types.RefreshTokenPayload{RefreshToken: createToken(t, 1, "refresh-jti")})
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/logout", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+createToken(t, 1, "access-jti"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		mockAuthStore.AssertExpectations(t)
+	})
+
+	t.Run("it should reject an access token that has been revoked", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		utils.SetTokenDenylist(mockAuthStore)
+		defer utils.SetTokenDenylist(nil)
+
+		mockAuthStore.On("IsAccessTokenRevoked", mock.Anything, "access-jti", mock.Anything, mock.Anything).Return(true, nil)
+
+		payload, _ := json.Marshal(types.RefreshTokenPayload{RefreshToken: createToken(t, 1, "refresh-jti")})
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/logout", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+createToken(t, 1, "access-jti"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Invalid or expired token"}`
+		assert.JSONEq(t, expected, string(responseBody))
+
+		mockAuthStore.AssertNotCalled(t, "DeleteRefreshToken", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandleLogoutAll(t *testing.T) {
+	setupTestServer := func() (*mocks.MockAuthStore, *httptest.Server, *mux.Router) {
+		mockUUID := new(mocks.MockUUIDGenerator)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserStore := new(mocks.MockUserStore)
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockAuthStore, ts, router
+	}
+
+	t.Run("it should throw an error when the authorization header is missing", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/logout-all", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("it should throw an error when the tokens cannot be revoked", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("RevokeTokensByUserID", mock.Anything, 1).Return(fmt.Errorf("database error"))
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/logout-all", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("it should revoke every token of the user", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("RevokeTokensByUserID", mock.Anything, 1).Return(nil)
+		mockAuthStore.On("RevokeAccessToken", mock.Anything, mock.MatchedBy(func(payload types.RevokeAccessTokenPayload) bool {
+			return payload.UserID == 1
+		})).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/logout-all", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		mockAuthStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleGetSessions(t *testing.T) {
+	setupTestServer := func() (*mocks.MockAuthStore, *httptest.Server, *mux.Router) {
+		mockUUID := new(mocks.MockUUIDGenerator)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserStore := new(mocks.MockUserStore)
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockAuthStore, ts, router
+	}
+
+	t.Run("it should throw an error when sessions cannot be retrieved", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetRefreshTokensByUserID", mock.Anything, 1).Return(([]*types.RefreshToken)(nil), fmt.Errorf("database error"))
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/sessions", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("it should list the active sessions of the user", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		createdAt := time.Date(2025, 2, 14, 10, 0, 0, 0, time.UTC)
+		lastUsedAt := time.Date(2025, 2, 14, 12, 0, 0, 0, time.UTC)
+		expiresAt := time.Date(2025, 2, 21, 10, 0, 0, 0, time.UTC)
+
+		mockAuthStore.On("GetRefreshTokensByUserID", mock.Anything, 1).Return(
+			[]*types.RefreshToken{
+				{
+					ID:         1,
+					UserID:     1,
+					Jti:        "jti-1",
+					UserAgent:  "Mozilla/5.0",
+					IPAddress:  "10.0.0.1",
+					CreatedAt:  createdAt,
+					LastUsedAt: &lastUsedAt,
+					ExpiresAt:  expiresAt,
+				},
+				{
+					ID:        2,
+					UserID:    1,
+					Jti:       "jti-2",
+					UserAgent: "curl/8.0",
+					IPAddress: "10.0.0.2",
+					CreatedAt: createdAt,
+					ExpiresAt: expiresAt,
+				},
+			},
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/sessions", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+
+		expected := `{
+			"sessions": [
+				{"id":1,"user_agent":"Mozilla/5.0","ip_address":"10.0.0.1","created_at":"2025-02-14T10:00:00Z","last_used_at":"2025-02-14T12:00:00Z","expires_at":"2025-02-21T10:00:00Z"},
+				{"id":2,"user_agent":"curl/8.0","ip_address":"10.0.0.2","created_at":"2025-02-14T10:00:00Z","last_used_at":null,"expires_at":"2025-02-21T10:00:00Z"}
+			]
+		}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleDeleteSession(t *testing.T) {
+	setupTestServer := func() (*mocks.MockAuthStore, *httptest.Server, *mux.Router) {
+		mockUUID := new(mocks.MockUUIDGenerator)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserStore := new(mocks.MockUserStore)
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockAuthStore, ts, router
+	}
+
+	t.Run("it should throw an error when session ID is not a positive integer", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/auth/sessions/abc", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Session ID must be a positive integer"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the session does not belong to the user", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("DeleteRefreshTokenByID", mock.Anything, 1, 42).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/auth/sessions/42", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No session found with ID 42"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the session cannot be deleted", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("DeleteRefreshTokenByID", mock.Anything, 1, 2).Return(fmt.Errorf("database error"))
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/auth/sessions/2", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+	})
+
+	t.Run("it should revoke the session", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("DeleteRefreshTokenByID", mock.Anything, 1, 2).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/auth/sessions/2", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		mockAuthStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleForgotPassword(t *testing.T) {
+	setupTestServer := func() (*mocks.MockUserStore, *mocks.MockAuthStore, *mailer.MemoryMailer, *httptest.Server, *mux.Router) {
+		mockUUID := new(mocks.MockUUIDGenerator)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserStore := new(mocks.MockUserStore)
+		memoryMailer := mailer.NewMemoryMailer()
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, memoryMailer, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, mockAuthStore, memoryMailer, ts, router
+	}
+
+	t.Run("it should throw an error when the email is invalid", func(t *testing.T) {
+		_, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload := []byte(`{"email":"johndoe"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/password/forgot", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'Email' is invalid: email"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should answer the same way when the email is not registered", func(t *testing.T) {
+		mockUserStore, mockAuthStore, memoryMailer, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("GetByEmail", mock.Anything, "unknown@email.com").Return((*types.GetByEmailResponse)(nil), sql.ErrNoRows)
+
+		payload := []byte(`{"email":"unknown@email.com"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/password/forgot", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"message":"If the email is registered, a password reset link has been sent"}`
+		assert.JSONEq(t, expected, string(responseBody))
+		assert.Empty(t, memoryMailer.Sent())
+		mockAuthStore.AssertNotCalled(t, "CreatePasswordResetToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the reset token cannot be stored", func(t *testing.T) {
+		mockUserStore, mockAuthStore, memoryMailer, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("GetByEmail", mock.Anything, "johndoe@email.com").Return(&types.GetByEmailResponse{ID: 1, Username: "JohnDoe", Email: "johndoe@email.com"}, nil)
+		mockAuthStore.On("CreatePasswordResetToken", mock.Anything, mock.Anything).Return(fmt.Errorf("database error"))
+
+		payload := []byte(`{"email":"johndoe@email.com"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/password/forgot", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Empty(t, memoryMailer.Sent())
+	})
+
+	t.Run("it should store a hashed token and email the reset link", func(t *testing.T) {
+		mockUserStore, mockAuthStore, memoryMailer, ts, router := setupTestServer()
+		defer ts.Close()
+
+		var storedPayload types.CreatePasswordResetTokenPayload
+		mockUserStore.On("GetByEmail", mock.Anything, "johndoe@email.com").Return(&types.GetByEmailResponse{ID: 1, Username: "JohnDoe", Email: "johndoe@email.com"}, nil)
+		mockAuthStore.On("CreatePasswordResetToken", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				storedPayload = args.Get(1).(types.CreatePasswordResetTokenPayload)
+			}).
+			Return(nil)
+
+		payload := []byte(`{"email":"johndoe@email.com"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/password/forgot", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+
+		sent := memoryMailer.Sent()
+		assert.Len(t, sent, 1)
+		assert.Equal(t, "johndoe@email.com", sent[0].To)
+
+		_, query, found := strings.Cut(sent[0].Body, config.Envs.PasswordResetURL+"?")
+		assert.True(t, found)
+		values, err := url.ParseQuery(strings.Fields(query)[0])
+		assert.NoError(t, err)
+
+		token := values.Get("token")
+		assert.NotEmpty(t, token)
+		assert.Equal(t, 1, storedPayload.UserID)
+		assert.Equal(t, utils.HashOpaqueToken(token), storedPayload.TokenHash)
+		assert.NotEqual(t, token, storedPayload.TokenHash)
+		assert.True(t, storedPayload.ExpiresAt.After(time.Now()))
+	})
+}
+
+func TestHandleResetPassword(t *testing.T) {
+	setupTestServer := func() (*mocks.MockUserStore, *mocks.MockAuthStore, *httptest.Server, *mux.Router) {
+		mockUUID := new(mocks.MockUUIDGenerator)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserStore := new(mocks.MockUserStore)
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, mailer.NewMemoryMailer(), nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, mockAuthStore, ts, router
+	}
+
+	t.Run("it should throw an error when the passwords do not match", func(t *testing.T) {
+		_, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload := []byte(`{"token":"reset-token","password":"newpassword","confirm_password":"otherpassword"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/password/reset", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'ConfirmPassword' is invalid: password_mismatch"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the token is invalid, expired or already used", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("ConsumePasswordResetToken", mock.Anything, utils.HashOpaqueToken("reset-token")).Return(0, sql.ErrNoRows)
+
+		payload := []byte(`{"token":"reset-token","password":"newpassword","confirm_password":"newpassword"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/password/reset", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Reset token is invalid or has expired"}`
+		assert.JSONEq(t, expected, string(responseBody))
+		mockUserStore.AssertNotCalled(t, "UpdatePasswordByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the password cannot be updated", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("ConsumePasswordResetToken", mock.Anything, utils.HashOpaqueToken("reset-token")).Return(1, nil)
+		mockUserStore.On("UpdatePasswordByID", mock.Anything, 1, mock.Anything).Return(fmt.Errorf("database error"))
+
+		payload := []byte(`{"token":"reset-token","password":"newpassword","confirm_password":"newpassword"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/password/reset", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		mockAuthStore.AssertNotCalled(t, "RevokeTokensByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should update the password and revoke every token", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("ConsumePasswordResetToken", mock.Anything, utils.HashOpaqueToken("reset-token")).Return(1, nil)
+		mockUserStore.On("UpdatePasswordByID", mock.Anything, 1, mock.MatchedBy(func(passwordHash string) bool {
+			_, err := utils.CheckPassword(context.Background(), passwordHash, "newpassword")
+			return err == nil
+		})).Return(nil)
+		mockAuthStore.On("RevokeTokensByUserID", mock.Anything, 1).Return(nil)
+
+		payload := []byte(`{"token":"reset-token","password":"newpassword","confirm_password":"newpassword"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/password/reset", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		mockUserStore.AssertExpectations(t)
+		mockAuthStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleRestoreAccount(t *testing.T) {
+	passwordHash, err := utils.HashPassword(context.Background(), "123mudar")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	setupTestServer := func() (*mocks.MockUserStore, *mocks.MockAuthStore, *httptest.Server, *mux.Router) {
+		mockUUID := new(mocks.MockUUIDGenerator)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserStore := new(mocks.MockUserStore)
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, mockAuthStore, ts, router
+	}
+
+	deletedAt := time.Now().Add(-time.Hour)
+	deletedUser := &types.GetByEmailResponse{
+		ID:           1,
+		Username:     "JohnDoe",
+		Email:        "johndoe@email.com",
+		PasswordHash: passwordHash,
+		Role:         types.RoleReader,
+		DeletedAt:    &deletedAt,
+	}
+
+	t.Run("it should throw an error when there is no deleted account for the email", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockUserStore.On("GetDeletedByEmail", mock.Anything, "johndoe@email.com", mock.AnythingOfType("time.Time")).Return((*types.GetByEmailResponse)(nil), sql.ErrNoRows)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+
+		payload := []byte(`{"email":"johndoe@email.com","password":"123mudar"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/restore", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Invalid email or password"}`
+		assert.JSONEq(t, expected, string(responseBody))
+		mockAuthStore.AssertNumberOfCalls(t, "RecordFailedLogin", 2)
+		mockUserStore.AssertNotCalled(t, "RestoreByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the password is wrong", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockUserStore.On("GetDeletedByEmail", mock.Anything, "johndoe@email.com", mock.AnythingOfType("time.Time")).Return(deletedUser, nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, mock.Anything, mock.Anything).Return(1, nil)
+
+		payload := []byte(`{"email":"johndoe@email.com","password":"wrongpassword"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/restore", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		mockUserStore.AssertNotCalled(t, "RestoreByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should refuse while the login is locked", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		lockedUntil := time.Now().Add(time.Minute)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return(&lockedUntil, nil)
+
+		payload := []byte(`{"email":"johndoe@email.com","password":"123mudar"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/restore", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		mockUserStore.AssertNotCalled(t, "GetDeletedByEmail", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should restore the account within the retention period", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockUserStore.On("GetDeletedByEmail", mock.Anything, "johndoe@email.com", mock.MatchedBy(func(deletedAfter time.Time) bool {
+			return deletedAfter.Before(time.Now()) && deletedAfter.After(time.Now().Add(-31*24*time.Hour))
+		})).Return(deletedUser, nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, "account:johndoe@email.com").Return(nil)
+		mockUserStore.On("RestoreByID", mock.Anything, 1).Return(nil)
+
+		payload := []byte(`{"email":"johndoe@email.com","password":"123mudar"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/restore", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"message":"Account restored, you can log in again"}`
+		assert.JSONEq(t, expected, string(responseBody))
+		mockUserStore.AssertExpectations(t)
+		mockAuthStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleGetJWKS(t *testing.T) {
+	setupTestServer := func() (*httptest.Server, *mux.Router) {
+		mockAuthHandler := auth.NewAuthHandler(new(mocks.MockUserStore), new(mocks.MockAuthStore), new(mocks.MockUUIDGenerator), nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return ts, router
+	}
+
+	t.Run("it should publish an empty key set when tokens are signed with the shared secret", func(t *testing.T) {
+		ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/.well-known/jwks.json", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"keys":[]}`, string(responseBody))
+	})
+
+	t.Run("it should publish every verification key and sign tokens with the active one", func(t *testing.T) {
+		ts, router := setupTestServer()
+		defer ts.Close()
+
+		rsaPrivateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("Failed to generate RSA key: %v", err)
+		}
+		edPublicKey, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			t.Fatalf("Failed to generate Ed25519 key: %v", err)
+		}
+
+		previousKey, err := utils.NewJWTKey("2024-11", &rsaPrivateKey.PublicKey)
+		assert.NoError(t, err)
+		currentKey, err := utils.NewJWTKey("2025-02", edPrivateKey)
+		assert.NoError(t, err)
+
+		keySet, err := utils.NewJWTKeySet("2025-02", previousKey, currentKey)
+		assert.NoError(t, err)
+
+		utils.SetJWTKeySet(keySet)
+		defer utils.SetJWTKeySet(nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/.well-known/jwks.json", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var jwks types.JWKSResponse
+		err = json.NewDecoder(res.Body).Decode(&jwks)
+		assert.NoError(t, err)
+		assert.Len(t, jwks.Keys, 2)
+		assert.Equal(t, "2024-11", jwks.Keys[0].Kid)
+		assert.Equal(t, "RSA", jwks.Keys[0].Kty)
+		assert.Equal(t, "RS256", jwks.Keys[0].Alg)
+		assert.Equal(t, "AQAB", jwks.Keys[0].E)
+		assert.Equal(t, "2025-02", jwks.Keys[1].Kid)
+		assert.Equal(t, "OKP", jwks.Keys[1].Kty)
+		assert.Equal(t, "EdDSA", jwks.Keys[1].Alg)
+		assert.Equal(t, base64.RawURLEncoding.EncodeToString(edPublicKey), jwks.Keys[1].X)
+
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com")
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &types.CustomClaims{})
+		assert.NoError(t, err)
+		assert.Equal(t, "2025-02", parsed.Header["kid"])
+		assert.Equal(t, "EdDSA", parsed.Header["alg"])
+
+		claims, err := utils.VerifyJWT(token, "")
+		assert.NoError(t, err)
+		assert.Equal(t, 1, claims.UserID)
+
+		hs256Token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, types.CustomClaims{UserID: 1}).SignedString([]byte(config.Envs.JWTSecret))
+		assert.NoError(t, err)
+		_, err = utils.VerifyJWT(hs256Token, config.Envs.JWTSecret)
+		assert.Error(t, err)
+	})
+}
+
+// testMFAEncryptionKey is 32 zero bytes.
+const testMFAEncryptionKey = "AAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAAA="
+
+func TestHandleEnrollMFA(t *testing.T) {
+	defer func(v string) { config.Envs.MFAEncryptionKey = v }(config.Envs.MFAEncryptionKey)
+	config.Envs.MFAEncryptionKey = testMFAEncryptionKey
+
+	setupTestServer := func() (*mocks.MockAuthStore, *httptest.Server, *mux.Router) {
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockAuthHandler := auth.NewAuthHandler(new(mocks.MockUserStore), mockAuthStore, new(mocks.MockUUIDGenerator), nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockAuthStore, ts, router
+	}
+
+	t.Run("it should refuse users that are neither admins nor librarians", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/enroll", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+	})
+
+	t.Run("it should throw an error when MFA is already enabled", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("UpsertPendingMFA", mock.Anything, 1, mock.Anything).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/enroll", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestTokenWithRole(1, "JohnDoe", "johndoe@email.com", types.RoleAdmin))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"MFA is already enabled"}`, string(responseBody))
+	})
+
+	t.Run("it should store an encrypted secret and return its provisioning URI", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		var storedSecret string
+		mockAuthStore.On("UpsertPendingMFA", mock.Anything, 1, mock.Anything).Run(func(args mock.Arguments) {
+			storedSecret = args.String(2)
+		}).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/enroll", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestTokenWithRole(1, "JohnDoe", "johndoe@email.com", types.RoleLibrarian))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.EnrollMFAResponse
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+
+		assert.NotEmpty(t, response.Secret)
+		assert.True(t, strings.HasPrefix(response.ProvisioningURI, "otpauth://totp/"))
+		assert.Contains(t, response.ProvisioningURI, "secret="+response.Secret)
+
+		assert.NotEqual(t, response.Secret, storedSecret)
+		decrypted, err := utils.DecryptSecret(storedSecret, config.Envs.MFAEncryptionKey)
+		assert.NoError(t, err)
+		assert.Equal(t, response.Secret, decrypted)
+	})
+}
+
+func TestHandleConfirmMFA(t *testing.T) {
+	defer func(v string) { config.Envs.MFAEncryptionKey = v }(config.Envs.MFAEncryptionKey)
+	config.Envs.MFAEncryptionKey = testMFAEncryptionKey
+
+	setupTestServer := func() (*mocks.MockAuthStore, *httptest.Server, *mux.Router) {
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockAuthHandler := auth.NewAuthHandler(new(mocks.MockUserStore), mockAuthStore, new(mocks.MockUUIDGenerator), nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockAuthStore, ts, router
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("Failed to generate TOTP secret: %v", err)
+	}
+
+	encryptedSecret, err := utils.EncryptSecret(secret, config.Envs.MFAEncryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to encrypt TOTP secret: %v", err)
+	}
+
+	adminToken := utils.GenerateTestTokenWithRole(1, "JohnDoe", "johndoe@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when the code is invalid", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return(&types.UserMFA{UserID: 1, Secret: encryptedSecret}, nil)
+
+		code, _ := utils.TOTPCode(secret, utils.TOTPStep(time.Now())+10)
+		payload := []byte(fmt.Sprintf(`{"code":"%s"}`, code))
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/confirm", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Invalid MFA code"}`, string(responseBody))
+		mockAuthStore.AssertNotCalled(t, "EnableMFA", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when there is no pending enrollment", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return((*types.UserMFA)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/confirm", bytes.NewBuffer([]byte(`{"code":"123456"}`)))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("it should enable MFA and return recovery codes", func(t *testing.T) {
+		mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		step := utils.TOTPStep(time.Now())
+		code, _ := utils.TOTPCode(secret, step)
+
+		var enablePayload types.EnableMFAPayload
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return(&types.UserMFA{UserID: 1, Secret: encryptedSecret}, nil)
+		mockAuthStore.On("EnableMFA", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			enablePayload = args.Get(1).(types.EnableMFAPayload)
+		}).Return(nil)
+
+		payload := []byte(fmt.Sprintf(`{"code":"%s"}`, code))
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/confirm", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.ConfirmMFAResponse
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+
+		assert.Len(t, response.RecoveryCodes, 10)
+		assert.Equal(t, 1, enablePayload.UserID)
+		assert.Len(t, enablePayload.RecoveryCodeHashes, 10)
+		assert.Equal(t, utils.HashOpaqueToken(utils.NormalizeRecoveryCode(response.RecoveryCodes[0])), enablePayload.RecoveryCodeHashes[0])
+	})
+}
+
+func TestHandleVerifyMFA(t *testing.T) {
+	defer func(v string) { config.Envs.MFAEncryptionKey = v }(config.Envs.MFAEncryptionKey)
+	config.Envs.MFAEncryptionKey = testMFAEncryptionKey
+
+	setupTestServer := func() (*mocks.MockUserStore, *mocks.MockAuthStore, *mocks.MockUUIDGenerator, *httptest.Server, *mux.Router) {
+		mockUUID := new(mocks.MockUUIDGenerator)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserStore := new(mocks.MockUserStore)
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, mockAuthStore, mockUUID, ts, router
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("Failed to generate TOTP secret: %v", err)
+	}
+
+	encryptedSecret, err := utils.EncryptSecret(secret, config.Envs.MFAEncryptionKey)
+	if err != nil {
+		t.Fatalf("Failed to encrypt TOTP secret: %v", err)
+	}
+
+	enabledMFA := &types.UserMFA{UserID: 1, Secret: encryptedSecret, EnabledAt: &emailVerifiedAt}
+
+	createMFAToken := func(t *testing.T, scope string) string {
+		uuidGen := new(mocks.MockUUIDGenerator)
+		uuidGen.On("New").Return("challenge-jti")
+
+		token, err := utils.CreateAccessJWT(1, "JohnDoe", "johndoe@email.com", types.RoleAdmin, scope, "", config.Envs.JWTSecret, 300, uuidGen)
+		if err != nil {
+			t.Fatalf("Failed to create MFA token: %v", err)
+		}
+		return token
+	}
+
+	verifiedUser := &types.UserResponse{
+		ID:              1,
+		Username:        "JohnDoe",
+		Email:           "johndoe@email.com",
+		Role:            types.RoleAdmin,
+		EmailVerifiedAt: &emailVerifiedAt,
+	}
+
+	t.Run("it should refuse a token that is not an MFA challenge", func(t *testing.T) {
+		_, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload := []byte(fmt.Sprintf(`{"mfa_token":"%s","code":"123456"}`, createMFAToken(t, "")))
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/verify", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"MFA token is invalid or has expired"}`, string(responseBody))
+	})
+
+	t.Run("it should refuse a challenge that was already used", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("IsAccessTokenRevoked", mock.Anything, "challenge-jti", mock.Anything, mock.Anything).Return(true, nil)
+
+		payload := []byte(fmt.Sprintf(`{"mfa_token":"%s","code":"123456"}`, createMFAToken(t, types.ScopeMFAChallenge)))
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/verify", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		mockAuthStore.AssertNotCalled(t, "GetMFAByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should refuse to check codes while MFA is locked", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		lockedUntil := time.Now().Add(90 * time.Second)
+		mockAuthStore.On("IsAccessTokenRevoked", mock.Anything, "challenge-jti", mock.Anything, mock.Anything).Return(false, nil)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, []string{"mfa:1"}).Return(&lockedUntil, nil)
+
+		payload := []byte(fmt.Sprintf(`{"mfa_token":"%s","code":"123456"}`, createMFAToken(t, types.ScopeMFAChallenge)))
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/verify", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		assert.Contains(t, []string{"89", "90"}, res.Header.Get("Retry-After"))
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Too many failed login attempts, try again later"}`, string(responseBody))
+		mockAuthStore.AssertNotCalled(t, "GetMFAByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should lock MFA after too many invalid codes", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("IsAccessTokenRevoked", mock.Anything, "challenge-jti", mock.Anything, mock.Anything).Return(false, nil)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, []string{"mfa:1"}).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RevokeAccessToken", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return(enabledMFA, nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "mfa:1", mock.Anything).Return(int(config.Envs.LoginMaxFailures), nil)
+		mockAuthStore.On("LockLogin", mock.Anything, "mfa:1", mock.Anything).Return(nil)
+		mockAuthStore.On("CreateSecurityEvent", mock.Anything, mock.MatchedBy(func(payload types.CreateSecurityEventPayload) bool {
+			return payload.UserID == 1 && payload.EventType == types.SecurityEventLoginLockout
+		})).Return(nil)
+
+		code, _ := utils.TOTPCode(secret, utils.TOTPStep(time.Now())-10)
+		payload := []byte(fmt.Sprintf(`{"mfa_token":"%s","code":"%s"}`, createMFAToken(t, types.ScopeMFAChallenge), code))
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/verify", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		mockAuthStore.AssertExpectations(t)
+	})
+
+	t.Run("it should spend the challenge and refuse an invalid code", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("IsAccessTokenRevoked", mock.Anything, "challenge-jti", mock.Anything, mock.Anything).Return(false, nil)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, []string{"mfa:1"}).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RevokeAccessToken", mock.Anything, mock.MatchedBy(func(payload types.RevokeAccessTokenPayload) bool {
+			return payload.Jti == "challenge-jti"
+		})).Return(nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return(enabledMFA, nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "mfa:1", mock.Anything).Return(1, nil)
+
+		code, _ := utils.TOTPCode(secret, utils.TOTPStep(time.Now())-10)
+		payload := []byte(fmt.Sprintf(`{"mfa_token":"%s","code":"%s"}`, createMFAToken(t, types.ScopeMFAChallenge), code))
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/verify", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Invalid MFA code"}`, string(responseBody))
+		mockAuthStore.AssertExpectations(t)
+		mockAuthStore.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
+		mockAuthStore.AssertNotCalled(t, "ResetLoginThrottle", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should refuse a TOTP code that was already used", func(t *testing.T) {
+		_, mockAuthStore, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("IsAccessTokenRevoked", mock.Anything, "challenge-jti", mock.Anything, mock.Anything).Return(false, nil)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, []string{"mfa:1"}).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RevokeAccessToken", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return(enabledMFA, nil)
+		mockAuthStore.On("UpdateMFALastUsedStep", mock.Anything, 1, mock.Anything).Return(sql.ErrNoRows)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "mfa:1", mock.Anything).Return(1, nil)
+
+		code, _ := utils.TOTPCode(secret, utils.TOTPStep(time.Now()))
+		payload := []byte(fmt.Sprintf(`{"mfa_token":"%s","code":"%s"}`, createMFAToken(t, types.ScopeMFAChallenge), code))
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/verify", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("it should exchange a valid TOTP code for the session tokens", func(t *testing.T) {
+		mockUserStore, mockAuthStore, mockUUID, ts, router := setupTestServer()
+		defer ts.Close()
+
+		step := utils.TOTPStep(time.Now())
+		code, _ := utils.TOTPCode(secret, step)
+
+		mockUUID.On("New").Return("mocked-uuid")
+		mockAuthStore.On("IsAccessTokenRevoked", mock.Anything, "challenge-jti", mock.Anything, mock.Anything).Return(false, nil)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, []string{"mfa:1"}).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RevokeAccessToken", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return(enabledMFA, nil)
+		mockAuthStore.On("UpdateMFALastUsedStep", mock.Anything, 1, mock.MatchedBy(func(usedStep int64) bool {
+			return usedStep >= step-1 && usedStep <= step+1
+		})).Return(nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, "mfa:1").Return(nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, "account:johndoe@email.com").Return(nil)
+		mockAuthStore.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(verifiedUser, nil)
+
+		payload := []byte(fmt.Sprintf(`{"mfa_token":"%s","code":"%s"}`, createMFAToken(t, types.ScopeMFAChallenge), code))
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/verify", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.UserLoginResponse
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+
+		claims, err := utils.VerifyJWT(response.AccessToken, config.Envs.JWTSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, "", claims.Scope)
+		assert.Equal(t, 1, claims.UserID)
+		assert.NotEmpty(t, response.RefreshToken)
+		mockAuthStore.AssertExpectations(t)
+	})
+
+	t.Run("it should exchange a recovery code for the session tokens", func(t *testing.T) {
+		mockUserStore, mockAuthStore, mockUUID, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		mockAuthStore.On("IsAccessTokenRevoked", mock.Anything, "challenge-jti", mock.Anything, mock.Anything).Return(false, nil)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, []string{"mfa:1"}).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RevokeAccessToken", mock.Anything, mock.Anything).Return(nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return(enabledMFA, nil)
+		mockAuthStore.On("ConsumeMFARecoveryCode", mock.Anything, 1, utils.HashOpaqueToken("abcdefghij")).Return(nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, "mfa:1").Return(nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, "account:johndoe@email.com").Return(nil)
+		mockAuthStore.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(verifiedUser, nil)
+
+		payload := []byte(fmt.Sprintf(`{"mfa_token":"%s","code":"ABCDE-fghij"}`, createMFAToken(t, types.ScopeMFAChallenge)))
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/auth/mfa/verify", bytes.NewBuffer(payload))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		mockAuthStore.AssertExpectations(t)
+		mockAuthStore.AssertNotCalled(t, "UpdateMFALastUsedStep", mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestHandleOIDCLogin(t *testing.T) {
+	setupTestServer := func(oidcProvider types.OIDCProvider) (*mocks.MockAuthStore, *httptest.Server, *mux.Router) {
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockAuthHandler := auth.NewAuthHandler(new(mocks.MockUserStore), mockAuthStore, new(mocks.MockUUIDGenerator), nil, oidcProvider)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockAuthStore, ts, router
+	}
+
+	t.Run("it should answer not found when OIDC login is not configured", func(t *testing.T) {
+		_, ts, router := setupTestServer(nil)
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/oidc/login", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"OIDC login is not enabled"}`, string(responseBody))
+	})
+
+	t.Run("it should store the login state and redirect to the provider", func(t *testing.T) {
+		mockOIDCProvider := new(mocks.MockOIDCProvider)
+		mockAuthStore, ts, router := setupTestServer(mockOIDCProvider)
+		defer ts.Close()
+
+		var storedState types.CreateOIDCLoginStatePayload
+		mockAuthStore.On("CreateOIDCLoginState", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			storedState = args.Get(1).(types.CreateOIDCLoginStatePayload)
+		}).Return(nil)
+		mockOIDCProvider.On("AuthCodeURL", mock.Anything, mock.Anything, mock.Anything).Return("https://accounts.example.com/authorize?state=abc")
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/oidc/login", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusFound, res.StatusCode)
+		assert.Equal(t, "https://accounts.example.com/authorize?state=abc", res.Header.Get("Location"))
+
+		state := mockOIDCProvider.Calls[0].Arguments.String(0)
+		nonce := mockOIDCProvider.Calls[0].Arguments.String(1)
+		codeChallenge := mockOIDCProvider.Calls[0].Arguments.String(2)
+
+		assert.Equal(t, utils.HashOpaqueToken(state), storedState.StateHash)
+		assert.Equal(t, nonce, storedState.Nonce)
+		assert.Equal(t, utils.PKCEChallenge(storedState.CodeVerifier), codeChallenge)
+		assert.True(t, storedState.ExpiresAt.After(time.Now()))
+	})
+}
+
+func TestHandleOIDCCallback(t *testing.T) {
+	setupTestServer := func() (*mocks.MockUserStore, *mocks.MockAuthStore, *mocks.MockOIDCProvider, *mocks.MockUUIDGenerator, *httptest.Server, *mux.Router) {
+		mockUUID := new(mocks.MockUUIDGenerator)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserStore := new(mocks.MockUserStore)
+		mockOIDCProvider := new(mocks.MockOIDCProvider)
+		mockAuthHandler := auth.NewAuthHandler(mockUserStore, mockAuthStore, mockUUID, nil, mockOIDCProvider)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, nil, mockAuthHandler, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, mockAuthStore, mockOIDCProvider, mockUUID, ts, router
+	}
+
+	loginState := &types.OIDCLoginState{Nonce: "nonce", CodeVerifier: "code-verifier"}
+	identity := &types.OIDCIdentity{
+		Issuer:        "https://accounts.example.com",
+		Subject:       "248289761001",
+		Email:         "johndoe@email.com",
+		EmailVerified: true,
+	}
+	verifiedUser := &types.UserResponse{
+		ID:              1,
+		Username:        "JohnDoe",
+		Email:           "johndoe@email.com",
+		Role:            types.RoleReader,
+		EmailVerifiedAt: &emailVerifiedAt,
+	}
+
+	t.Run("it should throw an error when the provider refused the login", func(t *testing.T) {
+		_, mockAuthStore, _, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/oidc/callback?error=access_denied&state=state", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Identity provider login failed"}`, string(responseBody))
+		mockAuthStore.AssertNotCalled(t, "ConsumeOIDCLoginState", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the state is invalid or has expired", func(t *testing.T) {
+		_, mockAuthStore, mockOIDCProvider, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("ConsumeOIDCLoginState", mock.Anything, utils.HashOpaqueToken("state")).Return((*types.OIDCLoginState)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/oidc/callback?code=code&state=state", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Login state is invalid or has expired"}`, string(responseBody))
+		mockOIDCProvider.AssertNotCalled(t, "Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the ID token cannot be verified", func(t *testing.T) {
+		_, mockAuthStore, mockOIDCProvider, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("ConsumeOIDCLoginState", mock.Anything, utils.HashOpaqueToken("state")).Return(loginState, nil)
+		mockOIDCProvider.On("Exchange", mock.Anything, "code", "code-verifier", "nonce").Return((*types.OIDCIdentity)(nil), fmt.Errorf("id token nonce does not match"))
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/oidc/callback?code=code&state=state", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("it should not link an identity whose email the provider did not verify", func(t *testing.T) {
+		mockUserStore, mockAuthStore, mockOIDCProvider, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		unverifiedIdentity := *identity
+		unverifiedIdentity.EmailVerified = false
+
+		mockAuthStore.On("ConsumeOIDCLoginState", mock.Anything, mock.Anything).Return(loginState, nil)
+		mockOIDCProvider.On("Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&unverifiedIdentity, nil)
+		mockAuthStore.On("GetUserIDByIdentity", mock.Anything, identity.Issuer, identity.Subject).Return(0, sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/oidc/callback?code=code&state=state", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Email address is not verified by the identity provider"}`, string(responseBody))
+		mockUserStore.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+		mockAuthStore.AssertNotCalled(t, "LinkIdentity", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should refuse an identity with no matching account", func(t *testing.T) {
+		mockUserStore, mockAuthStore, mockOIDCProvider, _, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("ConsumeOIDCLoginState", mock.Anything, mock.Anything).Return(loginState, nil)
+		mockOIDCProvider.On("Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(identity, nil)
+		mockAuthStore.On("GetUserIDByIdentity", mock.Anything, identity.Issuer, identity.Subject).Return(0, sql.ErrNoRows)
+		mockUserStore.On("GetByEmail", mock.Anything, "johndoe@email.com").Return((*types.GetByEmailResponse)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/oidc/callback?code=code&state=state", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"No account is associated with this email"}`, string(responseBody))
+	})
+
+	t.Run("it should link a new identity by verified email and issue the session tokens", func(t *testing.T) {
+		mockUserStore, mockAuthStore, mockOIDCProvider, mockUUID, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		mockAuthStore.On("ConsumeOIDCLoginState", mock.Anything, utils.HashOpaqueToken("state")).Return(loginState, nil)
+		mockOIDCProvider.On("Exchange", mock.Anything, "code", "code-verifier", "nonce").Return(identity, nil)
+		mockAuthStore.On("GetUserIDByIdentity", mock.Anything, identity.Issuer, identity.Subject).Return(0, sql.ErrNoRows)
+		mockUserStore.On("GetByEmail", mock.Anything, "johndoe@email.com").Return(&types.GetByEmailResponse{ID: 1, Email: "johndoe@email.com"}, nil)
+		mockAuthStore.On("LinkIdentity", mock.Anything, types.LinkIdentityPayload{
+			UserID:  1,
+			Issuer:  identity.Issuer,
+			Subject: identity.Subject,
+			Email:   identity.Email,
+		}).Return(nil)
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(verifiedUser, nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return((*types.UserMFA)(nil), sql.ErrNoRows)
+		mockAuthStore.On("CreateRefreshToken", mock.Anything, mock.Anything).Return(nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/oidc/callback?code=code&state=state", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.UserLoginResponse
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+
+		claims, err := utils.VerifyJWT(response.AccessToken, config.Envs.JWTSecret)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, claims.UserID)
+		assert.NotEmpty(t, response.RefreshToken)
+		mockAuthStore.AssertExpectations(t)
+	})
+
+	t.Run("it should return an MFA challenge for a linked user with MFA enabled", func(t *testing.T) {
+		mockUserStore, mockAuthStore, mockOIDCProvider, mockUUID, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUUID.On("New").Return("mocked-uuid")
+		mockAuthStore.On("ConsumeOIDCLoginState", mock.Anything, mock.Anything).Return(loginState, nil)
+		mockOIDCProvider.On("Exchange", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(identity, nil)
+		mockAuthStore.On("GetUserIDByIdentity", mock.Anything, identity.Issuer, identity.Subject).Return(1, nil)
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(verifiedUser, nil)
+		mockAuthStore.On("GetMFAByUserID", mock.Anything, 1).Return(&types.UserMFA{UserID: 1, EnabledAt: &emailVerifiedAt}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/auth/oidc/callback?code=code&state=state", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.UserLoginResponse
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+
+		assert.True(t, response.MFARequired)
+		assert.Empty(t, response.AccessToken)
+		mockUserStore.AssertNotCalled(t, "GetByEmail", mock.Anything, mock.Anything)
+		mockAuthStore.AssertNotCalled(t, "CreateRefreshToken", mock.Anything, mock.Anything)
 	})
 }
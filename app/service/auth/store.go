@@ -3,8 +3,10 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"time"
 
 	"github.com/hoyci/book-store-api/types"
+	"github.com/lib/pq"
 )
 
 type AuthStore struct {
@@ -15,49 +17,865 @@ func NewAuthStore(db *sql.DB) *AuthStore {
 	return &AuthStore{db: db}
 }
 
-func (s *AuthStore) GetRefreshTokenByUserID(ctx context.Context, userID int) (*types.RefreshToken, error) {
+func (s *AuthStore) GetRefreshTokenByJti(ctx context.Context, jti string) (*types.RefreshToken, error) {
 	token := &types.RefreshToken{}
 
-	err := s.db.QueryRowContext(ctx, "SELECT * FROM refresh_tokens WHERE user_id = $1", userID).
-		Scan(
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT id, user_id, jti, family_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), COALESCE(client_id, ''), COALESCE(scope, ''), expires_at, created_at, last_used_at
+         FROM refresh_tokens
+         WHERE jti = $1`,
+		jti,
+	).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Jti,
+		&token.FamilyID,
+		&token.UserAgent,
+		&token.IPAddress,
+		&token.ClientID,
+		&token.Scope,
+		&token.ExpiresAt,
+		&token.CreatedAt,
+		&token.LastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func (s *AuthStore) GetRefreshTokensByUserID(ctx context.Context, userID int) ([]*types.RefreshToken, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, user_id, jti, family_id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), expires_at, created_at, last_used_at
+         FROM refresh_tokens
+         WHERE user_id = $1 AND expires_at > NOW()
+         ORDER BY COALESCE(last_used_at, created_at) DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := []*types.RefreshToken{}
+
+	for rows.Next() {
+		token := &types.RefreshToken{}
+		err := rows.Scan(
 			&token.ID,
 			&token.UserID,
 			&token.Jti,
-			&token.CreatedAt,
+			&token.FamilyID,
+			&token.UserAgent,
+			&token.IPAddress,
 			&token.ExpiresAt,
+			&token.CreatedAt,
+			&token.LastUsedAt,
 		)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+func (s *AuthStore) CreateRefreshToken(ctx context.Context, payload types.CreateRefreshTokenPayload) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO refresh_tokens (user_id, jti, family_id, user_agent, ip_address, expires_at, client_id, scope, last_used_at)
+         VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NOW())`,
+		payload.UserID,
+		payload.Jti,
+		payload.FamilyID,
+		payload.UserAgent,
+		payload.IPAddress,
+		payload.ExpiresAt,
+		payload.ClientID,
+		payload.Scope,
+	)
+
+	return err
+}
+
+func (s *AuthStore) RotateRefreshToken(ctx context.Context, payload types.RotateRefreshTokenPayload) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE refresh_tokens SET
+             jti = $2,
+             user_agent = $3,
+             ip_address = $4,
+             expires_at = $5,
+             last_used_at = NOW()
+         WHERE jti = $1`,
+		payload.PreviousJti,
+		payload.Jti,
+		payload.UserAgent,
+		payload.IPAddress,
+		payload.ExpiresAt,
+	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	return token, nil
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
 }
 
-func (s *AuthStore) UpsertRefreshToken(ctx context.Context, payload types.UpdateRefreshTokenPayload) error {
-	token := &types.RefreshToken{}
+func (s *AuthStore) DeleteRefreshToken(ctx context.Context, userID int, jti string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		"DELETE FROM refresh_tokens WHERE user_id = $1 AND jti = $2",
+		userID,
+		jti,
+	)
+
+	return err
+}
+
+func (s *AuthStore) DeleteRefreshTokenByID(ctx context.Context, userID int, id int) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		"DELETE FROM refresh_tokens WHERE id = $1 AND user_id = $2",
+		id,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (s *AuthStore) DeleteRefreshTokensByUserID(ctx context.Context, userID int) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1", userID)
+
+	return err
+}
+
+// RevokeTokensByUserID ends every session of the user and also refuses the
+// access tokens issued so far, which would otherwise work until they expire.
+func (s *AuthStore) RevokeTokensByUserID(ctx context.Context, userID int) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET tokens_valid_after = $2 WHERE id = $1", userID, time.Now())
+
+	return err
+}
+
+// RevokeOtherTokens ends every session of the user except the one
+// identified by familyID and refuses the access tokens issued so far. The
+// spared session is refused too until it refreshes its access token.
+func (s *AuthStore) RevokeOtherTokens(ctx context.Context, userID int, familyID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1 AND family_id <> $2", userID, familyID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "UPDATE users SET tokens_valid_after = $2 WHERE id = $1", userID, time.Now())
+
+	return err
+}
+
+func (s *AuthStore) DeleteRefreshTokenFamily(ctx context.Context, userID int, familyID string) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		"DELETE FROM refresh_tokens WHERE user_id = $1 AND family_id = $2",
+		userID,
+		familyID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (s *AuthStore) RevokeAccessToken(ctx context.Context, payload types.RevokeAccessTokenPayload) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO revoked_tokens (jti, user_id, expires_at)
+         VALUES ($1, $2, $3)
+         ON CONFLICT (jti) DO NOTHING`,
+		payload.Jti,
+		payload.UserID,
+		payload.ExpiresAt,
+	)
+
+	return err
+}
+
+// IsAccessTokenRevoked tells whether the token was revoked on its own, or
+// along with every token of its user issued before tokens_valid_after. As
+// issuedAt only has second precision, tokens_valid_after is compared at that
+// precision too, so a session spared by the revocation can refresh right
+// away. A token issued in the same second before the revocation is accepted.
+func (s *AuthStore) IsAccessTokenRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error) {
+	var revoked bool
 
 	err := s.db.QueryRowContext(
 		ctx,
-		`INSERT INTO refresh_tokens (user_id, jti, expires_at) 
-         VALUES ($1, $2, $3) 
-         ON CONFLICT (user_id) 
-         DO UPDATE SET 
-             jti = EXCLUDED.jti, 
-             expires_at = EXCLUDED.expires_at 
-         RETURNING id, user_id, jti, created_at, expires_at`,
+		`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1 AND expires_at > NOW())
+             OR EXISTS (SELECT 1 FROM users WHERE id = $2 AND date_trunc('second', tokens_valid_after) > $3)`,
+		jti,
+		userID,
+		issuedAt,
+	).Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+func (s *AuthStore) CreateSecurityEvent(ctx context.Context, payload types.CreateSecurityEventPayload) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO security_events (user_id, event_type, family_id, user_agent, ip_address)
+         VALUES ($1, $2, $3, $4, $5)`,
 		payload.UserID,
-		payload.Jti,
+		payload.EventType,
+		payload.FamilyID,
+		payload.UserAgent,
+		payload.IPAddress,
+	)
+
+	return err
+}
+
+// CreatePasswordResetToken stores a new reset token and discards any unused
+// one the user still had, so only the most recent email link works.
+func (s *AuthStore) CreatePasswordResetToken(ctx context.Context, payload types.CreatePasswordResetTokenPayload) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM password_reset_tokens WHERE user_id = $1 AND used_at IS NULL",
+		payload.UserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+         VALUES ($1, $2, $3)`,
+		payload.UserID,
+		payload.TokenHash,
 		payload.ExpiresAt,
+	)
+
+	return err
+}
+
+// ConsumePasswordResetToken marks an unused, unexpired token as used and
+// returns its user. Doing both in one statement keeps tokens single-use even
+// under concurrent requests.
+func (s *AuthStore) ConsumePasswordResetToken(ctx context.Context, tokenHash string) (int, error) {
+	var userID int
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`UPDATE password_reset_tokens SET used_at = NOW()
+         WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+         RETURNING user_id`,
+		tokenHash,
+	).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (s *AuthStore) GetMFAByUserID(ctx context.Context, userID int) (*types.UserMFA, error) {
+	mfa := &types.UserMFA{}
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT user_id, secret, enabled_at, last_used_step, created_at
+         FROM user_mfa
+         WHERE user_id = $1`,
+		userID,
 	).Scan(
-		&token.ID,
-		&token.UserID,
-		&token.Jti,
-		&token.CreatedAt,
-		&token.ExpiresAt,
+		&mfa.UserID,
+		&mfa.Secret,
+		&mfa.EnabledAt,
+		&mfa.LastUsedStep,
+		&mfa.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return mfa, nil
+}
+
+// UpsertPendingMFA stores a new secret for a user that has not confirmed MFA
+// yet, replacing any previous pending enrollment. It returns sql.ErrNoRows
+// when MFA is already enabled.
+func (s *AuthStore) UpsertPendingMFA(ctx context.Context, userID int, encryptedSecret string) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO user_mfa (user_id, secret)
+         VALUES ($1, $2)
+         ON CONFLICT (user_id) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = NOW()
+         WHERE user_mfa.enabled_at IS NULL`,
+		userID,
+		encryptedSecret,
 	)
 	if err != nil {
 		return err
 	}
 
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
 	return nil
 }
+
+// EnableMFA confirms a pending enrollment and replaces the user's recovery
+// codes. It returns sql.ErrNoRows when there is no pending enrollment.
+func (s *AuthStore) EnableMFA(ctx context.Context, payload types.EnableMFAPayload) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	result, err := tx.ExecContext(
+		ctx,
+		`UPDATE user_mfa SET enabled_at = NOW(), last_used_step = $2
+         WHERE user_id = $1 AND enabled_at IS NULL`,
+		payload.UserID,
+		payload.Step,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		err = sql.ErrNoRows
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM mfa_recovery_codes WHERE user_id = $1", payload.UserID)
+	if err != nil {
+		return err
+	}
+
+	for _, codeHash := range payload.RecoveryCodeHashes {
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO mfa_recovery_codes (user_id, code_hash) VALUES ($1, $2)",
+			payload.UserID,
+			codeHash,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateMFALastUsedStep records the time step of an accepted TOTP code. It
+// returns sql.ErrNoRows when that step or a later one was already used, so a
+// code can't be replayed within its validity window.
+func (s *AuthStore) UpdateMFALastUsedStep(ctx context.Context, userID int, step int64) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		`UPDATE user_mfa SET last_used_step = $2
+         WHERE user_id = $1 AND enabled_at IS NOT NULL AND (last_used_step IS NULL OR last_used_step < $2)`,
+		userID,
+		step,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// ConsumeMFARecoveryCode marks one of the user's unused recovery codes as
+// used. It returns sql.ErrNoRows when the code doesn't match any of them.
+func (s *AuthStore) ConsumeMFARecoveryCode(ctx context.Context, userID int, codeHash string) error {
+	var id int
+
+	return s.db.QueryRowContext(
+		ctx,
+		`UPDATE mfa_recovery_codes SET used_at = NOW()
+         WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+         RETURNING id`,
+		userID,
+		codeHash,
+	).Scan(&id)
+}
+
+// GetLoginLockout returns when the latest active lockout among the given
+// throttle keys ends, or nil when none of them is locked.
+func (s *AuthStore) GetLoginLockout(ctx context.Context, keys []string) (*time.Time, error) {
+	var lockedUntil *time.Time
+
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT MAX(locked_until) FROM login_throttles WHERE throttle_key = ANY($1) AND locked_until > NOW()",
+		pq.Array(keys),
+	).Scan(&lockedUntil)
+	if err != nil {
+		return nil, err
+	}
+
+	return lockedUntil, nil
+}
+
+// RecordFailedLogin counts a failed attempt against key and returns how many
+// failures it has in a row. The count starts over once the key has had no
+// failure, and no lockout, for the whole window.
+func (s *AuthStore) RecordFailedLogin(ctx context.Context, key string, window time.Duration) (int, error) {
+	var failures int
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO login_throttles (throttle_key, failures, last_failure_at)
+         VALUES ($1, 1, NOW())
+         ON CONFLICT (throttle_key) DO UPDATE SET
+             failures = CASE
+                 WHEN GREATEST(login_throttles.last_failure_at, COALESCE(login_throttles.locked_until, login_throttles.last_failure_at)) < NOW() - $2 * INTERVAL '1 second'
+                 THEN 1
+                 ELSE login_throttles.failures + 1
+             END,
+             last_failure_at = NOW()
+         RETURNING failures`,
+		key,
+		int64(window.Seconds()),
+	).Scan(&failures)
+	if err != nil {
+		return 0, err
+	}
+
+	return failures, nil
+}
+
+func (s *AuthStore) LockLogin(ctx context.Context, key string, until time.Time) error {
+	_, err := s.db.ExecContext(ctx, "UPDATE login_throttles SET locked_until = $2 WHERE throttle_key = $1", key, until)
+
+	return err
+}
+
+func (s *AuthStore) ResetLoginThrottle(ctx context.Context, key string) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM login_throttles WHERE throttle_key = $1", key)
+
+	return err
+}
+
+// CreateOIDCLoginState stores what the callback of a new OIDC login needs
+// and drops the states of logins that were never completed.
+func (s *AuthStore) CreateOIDCLoginState(ctx context.Context, payload types.CreateOIDCLoginStatePayload) error {
+	_, err := s.db.ExecContext(ctx, "DELETE FROM oidc_login_states WHERE expires_at <= NOW()")
+	if err != nil {
+		return err
+	}
+
+	_, err = s.db.ExecContext(
+		ctx,
+		`INSERT INTO oidc_login_states (state_hash, nonce, code_verifier, expires_at)
+         VALUES ($1, $2, $3, $4)`,
+		payload.StateHash,
+		payload.Nonce,
+		payload.CodeVerifier,
+		payload.ExpiresAt,
+	)
+
+	return err
+}
+
+// ConsumeOIDCLoginState deletes an unexpired state and returns it, so every
+// state can complete a single login.
+func (s *AuthStore) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (*types.OIDCLoginState, error) {
+	state := &types.OIDCLoginState{}
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`DELETE FROM oidc_login_states
+         WHERE state_hash = $1 AND expires_at > NOW()
+         RETURNING nonce, code_verifier`,
+		stateHash,
+	).Scan(&state.Nonce, &state.CodeVerifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func (s *AuthStore) GetUserIDByIdentity(ctx context.Context, issuer string, subject string) (int, error) {
+	var userID int
+
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2",
+		issuer,
+		subject,
+	).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (s *AuthStore) LinkIdentity(ctx context.Context, payload types.LinkIdentityPayload) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO user_identities (user_id, issuer, subject, email)
+         VALUES ($1, $2, $3, $4)
+         ON CONFLICT (issuer, subject) DO NOTHING`,
+		payload.UserID,
+		payload.Issuer,
+		payload.Subject,
+		payload.Email,
+	)
+
+	return err
+}
+
+func (s *AuthStore) CreateOAuthClient(ctx context.Context, payload types.CreateOAuthClientDatabasePayload) (*types.OAuthClient, error) {
+	client := &types.OAuthClient{
+		ClientID:         payload.ClientID,
+		ClientSecretHash: payload.ClientSecretHash,
+		Name:             payload.Name,
+		RedirectURIs:     payload.RedirectURIs,
+		Scopes:           payload.Scopes,
+		GrantTypes:       payload.GrantTypes,
+		Confidential:     payload.ClientSecretHash != "",
+	}
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO oauth_clients (client_id, client_secret_hash, name, redirect_uris, scopes, grant_types, created_by)
+         VALUES ($1, NULLIF($2, ''), $3, $4, $5, $6, $7)
+         RETURNING id, created_at`,
+		payload.ClientID,
+		payload.ClientSecretHash,
+		payload.Name,
+		pq.Array(payload.RedirectURIs),
+		pq.Array(payload.Scopes),
+		pq.Array(payload.GrantTypes),
+		payload.CreatedBy,
+	).Scan(&client.ID, &client.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
+func (s *AuthStore) GetOAuthClientByClientID(ctx context.Context, clientID string) (*types.OAuthClient, error) {
+	client := &types.OAuthClient{}
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT id, client_id, COALESCE(client_secret_hash, ''), name, redirect_uris, scopes, grant_types, created_at
+         FROM oauth_clients
+         WHERE client_id = $1`,
+		clientID,
+	).Scan(
+		&client.ID,
+		&client.ClientID,
+		&client.ClientSecretHash,
+		&client.Name,
+		pq.Array(&client.RedirectURIs),
+		pq.Array(&client.Scopes),
+		pq.Array(&client.GrantTypes),
+		&client.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	client.Confidential = client.ClientSecretHash != ""
+
+	return client, nil
+}
+
+func (s *AuthStore) GetOAuthClients(ctx context.Context) ([]*types.OAuthClient, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, client_id, client_secret_hash IS NOT NULL, name, redirect_uris, scopes, grant_types, created_at
+         FROM oauth_clients
+         ORDER BY created_at DESC`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []*types.OAuthClient{}
+
+	for rows.Next() {
+		client := &types.OAuthClient{}
+		err := rows.Scan(
+			&client.ID,
+			&client.ClientID,
+			&client.Confidential,
+			&client.Name,
+			pq.Array(&client.RedirectURIs),
+			pq.Array(&client.Scopes),
+			pq.Array(&client.GrantTypes),
+			&client.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+
+	return clients, nil
+}
+
+// DeleteOAuthClient also drops, through the foreign keys, every code,
+// consent and refresh token issued to the client.
+func (s *AuthStore) DeleteOAuthClient(ctx context.Context, clientID string) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM oauth_clients WHERE client_id = $1", clientID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (s *AuthStore) CreateOAuthAuthorizationCode(ctx context.Context, payload types.CreateOAuthAuthorizationCodePayload) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO oauth_authorization_codes (code_hash, client_id, user_id, redirect_uri, scope, code_challenge, expires_at)
+         VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		payload.CodeHash,
+		payload.ClientID,
+		payload.UserID,
+		payload.RedirectURI,
+		payload.Scope,
+		payload.CodeChallenge,
+		payload.ExpiresAt,
+	)
+
+	return err
+}
+
+// ConsumeOAuthAuthorizationCode deletes an unexpired code and returns it, so
+// every code can be redeemed once.
+func (s *AuthStore) ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (*types.OAuthAuthorizationCode, error) {
+	code := &types.OAuthAuthorizationCode{}
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`DELETE FROM oauth_authorization_codes
+         WHERE code_hash = $1 AND expires_at > NOW()
+         RETURNING client_id, user_id, redirect_uri, scope, code_challenge`,
+		codeHash,
+	).Scan(&code.ClientID, &code.UserID, &code.RedirectURI, &code.Scope, &code.CodeChallenge)
+	if err != nil {
+		return nil, err
+	}
+
+	return code, nil
+}
+
+func (s *AuthStore) GetOAuthConsent(ctx context.Context, userID int, clientID string) (*types.OAuthConsent, error) {
+	consent := &types.OAuthConsent{}
+
+	err := s.db.QueryRowContext(
+		ctx,
+		`SELECT oauth_consents.client_id, oauth_clients.name, oauth_consents.scopes, oauth_consents.created_at, oauth_consents.updated_at
+         FROM oauth_consents
+         JOIN oauth_clients ON oauth_clients.client_id = oauth_consents.client_id
+         WHERE oauth_consents.user_id = $1 AND oauth_consents.client_id = $2`,
+		userID,
+		clientID,
+	).Scan(&consent.ClientID, &consent.ClientName, pq.Array(&consent.Scopes), &consent.CreatedAt, &consent.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return consent, nil
+}
+
+func (s *AuthStore) GetOAuthConsentsByUserID(ctx context.Context, userID int) ([]*types.OAuthConsent, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT oauth_consents.client_id, oauth_clients.name, oauth_consents.scopes, oauth_consents.created_at, oauth_consents.updated_at
+         FROM oauth_consents
+         JOIN oauth_clients ON oauth_clients.client_id = oauth_consents.client_id
+         WHERE oauth_consents.user_id = $1
+         ORDER BY oauth_consents.created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	consents := []*types.OAuthConsent{}
+
+	for rows.Next() {
+		consent := &types.OAuthConsent{}
+		err := rows.Scan(&consent.ClientID, &consent.ClientName, pq.Array(&consent.Scopes), &consent.CreatedAt, &consent.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		consents = append(consents, consent)
+	}
+
+	return consents, nil
+}
+
+// UpsertOAuthConsent adds scopes to what the user already granted the client.
+func (s *AuthStore) UpsertOAuthConsent(ctx context.Context, userID int, clientID string, scopes []string) error {
+	_, err := s.db.ExecContext(
+		ctx,
+		`INSERT INTO oauth_consents (user_id, client_id, scopes)
+         VALUES ($1, $2, $3)
+         ON CONFLICT (user_id, client_id) DO UPDATE SET
+             scopes = ARRAY(SELECT DISTINCT unnest(oauth_consents.scopes || EXCLUDED.scopes) ORDER BY 1),
+             updated_at = NOW()`,
+		userID,
+		clientID,
+		pq.Array(scopes),
+	)
+
+	return err
+}
+
+// DeleteOAuthConsent withdraws the consent and revokes the refresh tokens the
+// client holds for the user.
+func (s *AuthStore) DeleteOAuthConsent(ctx context.Context, userID int, clientID string) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM oauth_consents WHERE user_id = $1 AND client_id = $2", userID, clientID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		err = sql.ErrNoRows
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM refresh_tokens WHERE user_id = $1 AND client_id = $2", userID, clientID)
+
+	return err
+}
@@ -4,15 +4,17 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/hoyci/book-store-api/types"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
-func TestGetRefreshTokenByUserID(t *testing.T) {
+func TestGetRefreshTokenByJti(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -20,13 +22,16 @@ func TestGetRefreshTokenByUserID(t *testing.T) {
 	defer db.Close()
 
 	store := NewAuthStore(db)
+	query := `SELECT id, user_id, jti, family_id, COALESCE\(user_agent, ''\), COALESCE\(ip_address, ''\), COALESCE\(client_id, ''\), COALESCE\(scope, ''\), expires_at, created_at, last_used_at
+         FROM refresh_tokens
+         WHERE jti = \$1`
 
 	t.Run("database did not find any row", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT \* FROM refresh_tokens WHERE user_id = \$1`).
-			WithArgs(1).
+		mock.ExpectQuery(query).
+			WithArgs("31a0641b-e109-4467-b78c-13b72d0242a5").
 			WillReturnError(sql.ErrNoRows)
 
-		refreshToken, err := store.GetRefreshTokenByUserID(context.Background(), 1)
+		refreshToken, err := store.GetRefreshTokenByJti(context.Background(), "31a0641b-e109-4467-b78c-13b72d0242a5")
 
 		assert.Nil(t, refreshToken)
 		assert.Error(t, err)
@@ -38,11 +43,11 @@ func TestGetRefreshTokenByUserID(t *testing.T) {
 	})
 
 	t.Run("database unexpected error", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT \* FROM refresh_tokens WHERE user_id = \$1`).
-			WithArgs(1).
+		mock.ExpectQuery(query).
+			WithArgs("31a0641b-e109-4467-b78c-13b72d0242a5").
 			WillReturnError(fmt.Errorf("database connection error"))
 
-		refreshToken, err := store.GetRefreshTokenByUserID(context.Background(), 1)
+		refreshToken, err := store.GetRefreshTokenByJti(context.Background(), "31a0641b-e109-4467-b78c-13b72d0242a5")
 
 		assert.Error(t, err)
 		assert.Zero(t, refreshToken)
@@ -53,18 +58,71 @@ func TestGetRefreshTokenByUserID(t *testing.T) {
 		}
 	})
 
-	t.Run("successfully get user by ID", func(t *testing.T) {
-		mock.ExpectQuery(`SELECT \* FROM refresh_tokens WHERE user_id = \$1`).
-			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "jti", "expires_at", "created_at"}).
-				AddRow(1, 1, "31a0641b-e109-4467-b78c-13b72d0242a5", time.Date(0001, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(0001, 1, 1, 0, 0, 0, 0, time.UTC)))
+	t.Run("successfully get refresh token by JTI", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("31a0641b-e109-4467-b78c-13b72d0242a5").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "jti", "family_id", "user_agent", "ip_address", "client_id", "scope", "expires_at", "created_at", "last_used_at"}).
+				AddRow(1, 1, "31a0641b-e109-4467-b78c-13b72d0242a5", "8d3c1e2a-5b7f-4c9e-a1d2-6f0e9b8a7c54", "Mozilla/5.0", "127.0.0.1", "", "", time.Date(0001, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(0001, 1, 1, 0, 0, 0, 0, time.UTC), nil))
 
-		refreshToken, err := store.GetRefreshTokenByUserID(context.Background(), 1)
+		refreshToken, err := store.GetRefreshTokenByJti(context.Background(), "31a0641b-e109-4467-b78c-13b72d0242a5")
 
 		assert.NoError(t, err)
 		assert.NotNil(t, refreshToken)
 		assert.Equal(t, 1, refreshToken.UserID)
 		assert.Equal(t, "31a0641b-e109-4467-b78c-13b72d0242a5", refreshToken.Jti)
+		assert.Equal(t, "8d3c1e2a-5b7f-4c9e-a1d2-6f0e9b8a7c54", refreshToken.FamilyID)
+		assert.Equal(t, "Mozilla/5.0", refreshToken.UserAgent)
+		assert.Equal(t, "127.0.0.1", refreshToken.IPAddress)
+		assert.Nil(t, refreshToken.LastUsedAt)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetRefreshTokensByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := `FROM refresh_tokens
+         WHERE user_id = \$1 AND expires_at > NOW\(\)
+         ORDER BY COALESCE\(last_used_at, created_at\) DESC`
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(1).
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		refreshTokens, err := store.GetRefreshTokensByUserID(context.Background(), 1)
+
+		assert.Error(t, err)
+		assert.Nil(t, refreshTokens)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully list every session of the user", func(t *testing.T) {
+		lastUsedAt := time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(query).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "jti", "family_id", "user_agent", "ip_address", "expires_at", "created_at", "last_used_at"}).
+				AddRow(2, 1, "jti-phone", "family-phone", "Phone", "10.0.0.2", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), lastUsedAt).
+				AddRow(1, 1, "jti-laptop", "family-laptop", "Laptop", "10.0.0.1", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), nil))
+
+		refreshTokens, err := store.GetRefreshTokensByUserID(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, refreshTokens, 2)
+		assert.Equal(t, "jti-phone", refreshTokens[0].Jti)
+		assert.Equal(t, lastUsedAt, *refreshTokens[0].LastUsedAt)
+		assert.Equal(t, "Laptop", refreshTokens[1].UserAgent)
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("unmet expectations: %v", err)
@@ -72,7 +130,7 @@ func TestGetRefreshTokenByUserID(t *testing.T) {
 	})
 }
 
-func TestUpdateRefreshTokenByUserID(t *testing.T) {
+func TestCreateRefreshToken(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
 		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
@@ -80,25 +138,75 @@ func TestUpdateRefreshTokenByUserID(t *testing.T) {
 	defer db.Close()
 
 	store := NewAuthStore(db)
-	payload := types.UpdateRefreshTokenPayload{
+	payload := types.CreateRefreshTokenPayload{
 		UserID:    1,
 		Jti:       "31a0641b-e109-4467-b78c-13b72d0242a5",
+		FamilyID:  "8d3c1e2a-5b7f-4c9e-a1d2-6f0e9b8a7c54",
+		UserAgent: "Mozilla/5.0",
+		IPAddress: "127.0.0.1",
 		ExpiresAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
 	}
+	query := `INSERT INTO refresh_tokens \(user_id, jti, family_id, user_agent, ip_address, expires_at, client_id, scope, last_used_at\)
+         VALUES \(\$1, \$2, \$3, \$4, \$5, \$6, NULLIF\(\$7, ''\), NULLIF\(\$8, ''\), NOW\(\)\)`
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(payload.UserID, payload.Jti, payload.FamilyID, payload.UserAgent, payload.IPAddress, payload.ExpiresAt, payload.ClientID, payload.Scope).
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		err := store.CreateRefreshToken(context.Background(), payload)
+
+		assert.Error(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully create refresh token", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(payload.UserID, payload.Jti, payload.FamilyID, payload.UserAgent, payload.IPAddress, payload.ExpiresAt, payload.ClientID, payload.Scope).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := store.CreateRefreshToken(context.Background(), payload)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	payload := types.RotateRefreshTokenPayload{
+		PreviousJti: "31a0641b-e109-4467-b78c-13b72d0242a5",
+		Jti:         "7b1f3c8e-5a2d-4e6f-9c0b-1d2e3f4a5b6c",
+		UserAgent:   "Mozilla/5.0",
+		IPAddress:   "127.0.0.1",
+		ExpiresAt:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	query := `UPDATE refresh_tokens SET
+             jti = \$2,
+             user_agent = \$3,
+             ip_address = \$4,
+             expires_at = \$5,
+             last_used_at = NOW\(\)
+         WHERE jti = \$1`
 
 	t.Run("database did not find any row to update", func(t *testing.T) {
-		mock.ExpectQuery(`
-				INSERT INTO refresh_tokens \(user_id, jti, expires_at\) 
-				VALUES \(\$1, \$2, \$3\) 
-				ON CONFLICT \(user_id\) 
-				DO UPDATE 
-				SET jti = EXCLUDED\.jti, expires_at = EXCLUDED\.expires_at 
-				RETURNING id, user_id, jti, created_at, expires_at
-			`).
-			WithArgs(payload.UserID, payload.Jti, payload.ExpiresAt).
-			WillReturnError(sql.ErrNoRows)
+		mock.ExpectExec(query).
+			WithArgs(payload.PreviousJti, payload.Jti, payload.UserAgent, payload.IPAddress, payload.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(0, 0))
 
-		err := store.UpsertRefreshToken(context.Background(), payload)
+		err := store.RotateRefreshToken(context.Background(), payload)
 
 		assert.Error(t, err)
 		assert.Equal(t, err, sql.ErrNoRows)
@@ -109,18 +217,11 @@ func TestUpdateRefreshTokenByUserID(t *testing.T) {
 	})
 
 	t.Run("database unexpected error", func(t *testing.T) {
-		mock.ExpectQuery(`
-				INSERT INTO refresh_tokens \(user_id, jti, expires_at\) 
-				VALUES \(\$1, \$2, \$3\) 
-				ON CONFLICT \(user_id\) 
-				DO UPDATE 
-				SET jti = EXCLUDED\.jti, expires_at = EXCLUDED\.expires_at 
-				RETURNING id, user_id, jti, created_at, expires_at
-			`).
-			WithArgs(payload.UserID, payload.Jti, payload.ExpiresAt).
+		mock.ExpectExec(query).
+			WithArgs(payload.PreviousJti, payload.Jti, payload.UserAgent, payload.IPAddress, payload.ExpiresAt).
 			WillReturnError(fmt.Errorf("database connection error"))
 
-		err := store.UpsertRefreshToken(context.Background(), payload)
+		err := store.RotateRefreshToken(context.Background(), payload)
 
 		assert.Error(t, err)
 		assert.NotEqual(t, err, sql.ErrNoRows)
@@ -130,42 +231,1264 @@ func TestUpdateRefreshTokenByUserID(t *testing.T) {
 		}
 	})
 
-	t.Run("conflict error on upsert", func(t *testing.T) {
-		mock.ExpectQuery(`
-				INSERT INTO refresh_tokens \(user_id, jti, expires_at\) 
-				VALUES \(\$1, \$2, \$3\) 
-				ON CONFLICT \(user_id\) 
-				DO UPDATE 
-				SET jti = EXCLUDED\.jti, expires_at = EXCLUDED\.expires_at 
-				RETURNING id, user_id, jti, created_at, expires_at
-			`).
-			WithArgs(payload.UserID, payload.Jti, payload.ExpiresAt).
-			WillReturnError(fmt.Errorf("unique constraint violation"))
+	t.Run("successfully rotate refresh token", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(payload.PreviousJti, payload.Jti, payload.UserAgent, payload.IPAddress, payload.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.RotateRefreshToken(context.Background(), payload)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDeleteRefreshToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1 AND jti = \$2`).
+			WithArgs(1, "refresh-jti").
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		err := store.DeleteRefreshToken(context.Background(), 1, "refresh-jti")
+
+		assert.Error(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully delete refresh token", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1 AND jti = \$2`).
+			WithArgs(1, "refresh-jti").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.DeleteRefreshToken(context.Background(), 1, "refresh-jti")
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDeleteRefreshTokenByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+
+	t.Run("database did not find any row to delete", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(5, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.DeleteRefreshTokenByID(context.Background(), 1, 5)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully delete session", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE id = \$1 AND user_id = \$2`).
+			WithArgs(5, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.DeleteRefreshTokenByID(context.Background(), 1, 5)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDeleteRefreshTokenFamily(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+
+	t.Run("database did not find any row to delete", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1 AND family_id = \$2`).
+			WithArgs(1, "8d3c1e2a-5b7f-4c9e-a1d2-6f0e9b8a7c54").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.DeleteRefreshTokenFamily(context.Background(), 1, "8d3c1e2a-5b7f-4c9e-a1d2-6f0e9b8a7c54")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1 AND family_id = \$2`).
+			WithArgs(1, "8d3c1e2a-5b7f-4c9e-a1d2-6f0e9b8a7c54").
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		err := store.DeleteRefreshTokenFamily(context.Background(), 1, "8d3c1e2a-5b7f-4c9e-a1d2-6f0e9b8a7c54")
+
+		assert.Error(t, err)
+		assert.NotEqual(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully delete token family", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1 AND family_id = \$2`).
+			WithArgs(1, "8d3c1e2a-5b7f-4c9e-a1d2-6f0e9b8a7c54").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.DeleteRefreshTokenFamily(context.Background(), 1, "8d3c1e2a-5b7f-4c9e-a1d2-6f0e9b8a7c54")
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDeleteRefreshTokensByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+
+	t.Run("successfully delete every refresh token of the user", func(t *testing.T) {
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+
+		err := store.DeleteRefreshTokensByUserID(context.Background(), 1)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRevokeTokensByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+
+	t.Run("it should roll back when the access tokens cannot be revoked", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`UPDATE users SET tokens_valid_after = \$2 WHERE id = \$1`).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnError(fmt.Errorf("database connection error"))
+		mock.ExpectRollback()
+
+		err := store.RevokeTokensByUserID(context.Background(), 1)
+
+		assert.Error(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully revoke every token of the user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1`).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 3))
+		mock.ExpectExec(`UPDATE users SET tokens_valid_after = \$2 WHERE id = \$1`).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := store.RevokeTokensByUserID(context.Background(), 1)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRevokeOtherTokens(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+
+	t.Run("it should roll back when the access tokens cannot be revoked", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1 AND family_id <> \$2`).
+			WithArgs(1, "current-family").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE users SET tokens_valid_after = \$2 WHERE id = \$1`).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnError(fmt.Errorf("database connection error"))
+		mock.ExpectRollback()
+
+		err := store.RevokeOtherTokens(context.Background(), 1, "current-family")
+
+		assert.Error(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully revoke every token but the current family", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1 AND family_id <> \$2`).
+			WithArgs(1, "current-family").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectExec(`UPDATE users SET tokens_valid_after = \$2 WHERE id = \$1`).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := store.RevokeOtherTokens(context.Background(), 1, "current-family")
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	payload := types.RevokeAccessTokenPayload{
+		UserID:    1,
+		Jti:       "access-jti",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO revoked_tokens \(jti, user_id, expires_at\)`).
+			WithArgs(payload.Jti, payload.UserID, payload.ExpiresAt).
+			WillReturnError(fmt.Errorf("database connection error"))
 
-		err := store.UpsertRefreshToken(context.Background(), payload)
+		err := store.RevokeAccessToken(context.Background(), payload)
 
 		assert.Error(t, err)
-		assert.Contains(t, err.Error(), "unique constraint violation")
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("unmet expectations: %v", err)
 		}
 	})
 
-	t.Run("successfully update refresh token", func(t *testing.T) {
-		mock.ExpectQuery(`
-				INSERT INTO refresh_tokens \(user_id, jti, expires_at\) 
-				VALUES \(\$1, \$2, \$3\) 
-				ON CONFLICT \(user_id\) 
-				DO UPDATE 
-				SET jti = EXCLUDED\.jti, expires_at = EXCLUDED\.expires_at 
-				RETURNING id, user_id, jti, created_at, expires_at
-			`).
-			WithArgs(payload.UserID, payload.Jti, payload.ExpiresAt).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "jti", "created_at", "expires_at"}).
-				AddRow(1, payload.UserID, payload.Jti, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), payload.ExpiresAt))
+	t.Run("successfully revoke access token", func(t *testing.T) {
+		mock.ExpectExec(`INSERT INTO revoked_tokens \(jti, user_id, expires_at\)`).
+			WithArgs(payload.Jti, payload.UserID, payload.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.RevokeAccessToken(context.Background(), payload)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestIsAccessTokenRevoked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := regexp.QuoteMeta(`SELECT EXISTS (SELECT 1 FROM revoked_tokens WHERE jti = $1 AND expires_at > NOW())
+             OR EXISTS (SELECT 1 FROM users WHERE id = $2 AND date_trunc('second', tokens_valid_after) > $3)`)
+	issuedAt := time.Now().Add(-time.Minute)
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("access-jti", 1, issuedAt).
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		revoked, err := store.IsAccessTokenRevoked(context.Background(), "access-jti", 1, issuedAt)
+
+		assert.Error(t, err)
+		assert.False(t, revoked)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("token is on the denylist or was issued before the user's tokens were revoked", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("access-jti", 1, issuedAt).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+
+		revoked, err := store.IsAccessTokenRevoked(context.Background(), "access-jti", 1, issuedAt)
+
+		assert.NoError(t, err)
+		assert.True(t, revoked)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestCreateSecurityEvent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	payload := types.CreateSecurityEventPayload{
+		UserID:    1,
+		EventType: types.SecurityEventRefreshTokenReuse,
+		FamilyID:  "8d3c1e2a-5b7f-4c9e-a1d2-6f0e9b8a7c54",
+		UserAgent: "Mozilla/5.0",
+		IPAddress: "127.0.0.1",
+	}
+	query := `INSERT INTO security_events \(user_id, event_type, family_id, user_agent, ip_address\)
+         VALUES \(\$1, \$2, \$3, \$4, \$5\)`
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(payload.UserID, payload.EventType, payload.FamilyID, payload.UserAgent, payload.IPAddress).
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		err := store.CreateSecurityEvent(context.Background(), payload)
+
+		assert.Error(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully create security event", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(payload.UserID, payload.EventType, payload.FamilyID, payload.UserAgent, payload.IPAddress).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		err := store.CreateSecurityEvent(context.Background(), payload)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestCreatePasswordResetToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	payload := types.CreatePasswordResetTokenPayload{
+		UserID:    1,
+		TokenHash: "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8",
+		ExpiresAt: time.Now().Add(time.Hour),
+	}
+	deleteQuery := `DELETE FROM password_reset_tokens WHERE user_id = \$1 AND used_at IS NULL`
+	insertQuery := `INSERT INTO password_reset_tokens \(user_id, token_hash, expires_at\)
+         VALUES \(\$1, \$2, \$3\)`
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).
+			WithArgs(payload.UserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertQuery).
+			WithArgs(payload.UserID, payload.TokenHash, payload.ExpiresAt).
+			WillReturnError(fmt.Errorf("database connection error"))
+		mock.ExpectRollback()
+
+		err := store.CreatePasswordResetToken(context.Background(), payload)
+
+		assert.Error(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully replace previous unused tokens", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).
+			WithArgs(payload.UserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertQuery).
+			WithArgs(payload.UserID, payload.TokenHash, payload.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := store.CreatePasswordResetToken(context.Background(), payload)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestConsumePasswordResetToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	tokenHash := "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"
+	query := `UPDATE password_reset_tokens SET used_at = NOW\(\)
+         WHERE token_hash = \$1 AND used_at IS NULL AND expires_at > NOW\(\)
+         RETURNING user_id`
+
+	t.Run("token is invalid, expired or already used", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(tokenHash).
+			WillReturnError(sql.ErrNoRows)
+
+		userID, err := store.ConsumePasswordResetToken(context.Background(), tokenHash)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Zero(t, userID)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully consume token", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(tokenHash).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+
+		userID, err := store.ConsumePasswordResetToken(context.Background(), tokenHash)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, userID)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetMFAByUserID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := `SELECT user_id, secret, enabled_at, last_used_step, created_at
+         FROM user_mfa
+         WHERE user_id = \$1`
+
+	t.Run("user has no MFA enrollment", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(1).
+			WillReturnError(sql.ErrNoRows)
+
+		mfa, err := store.GetMFAByUserID(context.Background(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Nil(t, mfa)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get MFA enrollment", func(t *testing.T) {
+		enabledAt := time.Now()
+		createdAt := enabledAt.Add(-time.Minute)
+
+		mock.ExpectQuery(query).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"user_id", "secret", "enabled_at", "last_used_step", "created_at"}).
+				AddRow(1, "encrypted-secret", enabledAt, int64(58000000), createdAt))
+
+		mfa, err := store.GetMFAByUserID(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "encrypted-secret", mfa.Secret)
+		assert.Equal(t, enabledAt, *mfa.EnabledAt)
+		assert.Equal(t, int64(58000000), *mfa.LastUsedStep)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestUpsertPendingMFA(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := `INSERT INTO user_mfa \(user_id, secret\)
+         VALUES \(\$1, \$2\)
+         ON CONFLICT \(user_id\) DO UPDATE SET secret = EXCLUDED.secret, last_used_step = NULL, created_at = NOW\(\)
+         WHERE user_mfa.enabled_at IS NULL`
+
+	t.Run("MFA is already enabled", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, "encrypted-secret").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.UpsertPendingMFA(context.Background(), 1, "encrypted-secret")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully store pending enrollment", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, "encrypted-secret").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.UpsertPendingMFA(context.Background(), 1, "encrypted-secret")
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestEnableMFA(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	payload := types.EnableMFAPayload{
+		UserID:             1,
+		Step:               58000000,
+		RecoveryCodeHashes: []string{"hash-1", "hash-2"},
+	}
+	enableQuery := `UPDATE user_mfa SET enabled_at = NOW\(\), last_used_step = \$2
+         WHERE user_id = \$1 AND enabled_at IS NULL`
+	deleteQuery := `DELETE FROM mfa_recovery_codes WHERE user_id = \$1`
+	insertQuery := `INSERT INTO mfa_recovery_codes \(user_id, code_hash\) VALUES \(\$1, \$2\)`
+
+	t.Run("no pending enrollment", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(enableQuery).
+			WithArgs(payload.UserID, payload.Step).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := store.EnableMFA(context.Background(), payload)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully enable MFA and store recovery codes", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(enableQuery).
+			WithArgs(payload.UserID, payload.Step).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(deleteQuery).
+			WithArgs(payload.UserID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(insertQuery).
+			WithArgs(payload.UserID, "hash-1").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(insertQuery).
+			WithArgs(payload.UserID, "hash-2").
+			WillReturnResult(sqlmock.NewResult(2, 1))
+		mock.ExpectCommit()
+
+		err := store.EnableMFA(context.Background(), payload)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestUpdateMFALastUsedStep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := `UPDATE user_mfa SET last_used_step = \$2
+         WHERE user_id = \$1 AND enabled_at IS NOT NULL AND \(last_used_step IS NULL OR last_used_step < \$2\)`
+
+	t.Run("step was already used", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, int64(58000000)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.UpdateMFALastUsedStep(context.Background(), 1, 58000000)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully record step", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, int64(58000001)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.UpdateMFALastUsedStep(context.Background(), 1, 58000001)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestConsumeMFARecoveryCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := `UPDATE mfa_recovery_codes SET used_at = NOW\(\)
+         WHERE user_id = \$1 AND code_hash = \$2 AND used_at IS NULL
+         RETURNING id`
+
+	t.Run("code is unknown or already used", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(1, "hash-1").
+			WillReturnError(sql.ErrNoRows)
+
+		err := store.ConsumeMFARecoveryCode(context.Background(), 1, "hash-1")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully consume code", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(1, "hash-1").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3))
+
+		err := store.ConsumeMFARecoveryCode(context.Background(), 1, "hash-1")
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetLoginLockout(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	keys := []string{"account:johndoe@email.com", "ip:192.0.2.1"}
+	query := `SELECT MAX\(locked_until\) FROM login_throttles WHERE throttle_key = ANY\(\$1\) AND locked_until > NOW\(\)`
+
+	t.Run("no active lockout", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(pq.Array(keys)).
+			WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(nil))
+
+		lockedUntil, err := store.GetLoginLockout(context.Background(), keys)
+
+		assert.NoError(t, err)
+		assert.Nil(t, lockedUntil)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get active lockout", func(t *testing.T) {
+		until := time.Now().Add(time.Minute)
+
+		mock.ExpectQuery(query).
+			WithArgs(pq.Array(keys)).
+			WillReturnRows(sqlmock.NewRows([]string{"max"}).AddRow(until))
+
+		lockedUntil, err := store.GetLoginLockout(context.Background(), keys)
+
+		assert.NoError(t, err)
+		assert.Equal(t, until, *lockedUntil)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRecordFailedLogin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := `INSERT INTO login_throttles \(throttle_key, failures, last_failure_at\)`
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("ip:192.0.2.1", int64(900)).
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		failures, err := store.RecordFailedLogin(context.Background(), "ip:192.0.2.1", 15*time.Minute)
+
+		assert.Error(t, err)
+		assert.Zero(t, failures)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully record failure", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("ip:192.0.2.1", int64(900)).
+			WillReturnRows(sqlmock.NewRows([]string{"failures"}).AddRow(3))
+
+		failures, err := store.RecordFailedLogin(context.Background(), "ip:192.0.2.1", 15*time.Minute)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, failures)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestLockLogin(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	until := time.Now().Add(time.Minute)
+
+	mock.ExpectExec(`UPDATE login_throttles SET locked_until = \$2 WHERE throttle_key = \$1`).
+		WithArgs("account:johndoe@email.com", until).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = store.LockLogin(context.Background(), "account:johndoe@email.com", until)
+
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestResetLoginThrottle(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+
+	mock.ExpectExec(`DELETE FROM login_throttles WHERE throttle_key = \$1`).
+		WithArgs("account:johndoe@email.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = store.ResetLoginThrottle(context.Background(), "account:johndoe@email.com")
+
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreateOIDCLoginState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	payload := types.CreateOIDCLoginStatePayload{
+		StateHash:    "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8",
+		Nonce:        "nonce",
+		CodeVerifier: "code-verifier",
+		ExpiresAt:    time.Now().Add(10 * time.Minute),
+	}
+
+	mock.ExpectExec(`DELETE FROM oidc_login_states WHERE expires_at <= NOW\(\)`).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectExec(`INSERT INTO oidc_login_states \(state_hash, nonce, code_verifier, expires_at\)
+         VALUES \(\$1, \$2, \$3, \$4\)`).
+		WithArgs(payload.StateHash, payload.Nonce, payload.CodeVerifier, payload.ExpiresAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = store.CreateOIDCLoginState(context.Background(), payload)
+
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestConsumeOIDCLoginState(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	stateHash := "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"
+	query := `DELETE FROM oidc_login_states
+         WHERE state_hash = \$1 AND expires_at > NOW\(\)
+         RETURNING nonce, code_verifier`
+
+	t.Run("state is unknown, expired or already used", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(stateHash).
+			WillReturnError(sql.ErrNoRows)
+
+		state, err := store.ConsumeOIDCLoginState(context.Background(), stateHash)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Nil(t, state)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully consume state", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(stateHash).
+			WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier"}).AddRow("nonce", "code-verifier"))
+
+		state, err := store.ConsumeOIDCLoginState(context.Background(), stateHash)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.OIDCLoginState{Nonce: "nonce", CodeVerifier: "code-verifier"}, state)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetUserIDByIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := `SELECT user_id FROM user_identities WHERE issuer = \$1 AND subject = \$2`
+
+	t.Run("identity is not linked", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("https://accounts.example.com", "248289761001").
+			WillReturnError(sql.ErrNoRows)
+
+		userID, err := store.GetUserIDByIdentity(context.Background(), "https://accounts.example.com", "248289761001")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Zero(t, userID)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get linked user", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("https://accounts.example.com", "248289761001").
+			WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+
+		userID, err := store.GetUserIDByIdentity(context.Background(), "https://accounts.example.com", "248289761001")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, userID)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestLinkIdentity(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+
+	mock.ExpectExec(`INSERT INTO user_identities \(user_id, issuer, subject, email\)
+         VALUES \(\$1, \$2, \$3, \$4\)
+         ON CONFLICT \(issuer, subject\) DO NOTHING`).
+		WithArgs(1, "https://accounts.example.com", "248289761001", "johndoe@email.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = store.LinkIdentity(context.Background(), types.LinkIdentityPayload{
+		UserID:  1,
+		Issuer:  "https://accounts.example.com",
+		Subject: "248289761001",
+		Email:   "johndoe@email.com",
+	})
+
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestCreateOAuthClient(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	createdAt := time.Now()
+	payload := types.CreateOAuthClientDatabasePayload{
+		ClientID:         "client-1",
+		ClientSecretHash: "secret-hash",
+		Name:             "Reading Tracker",
+		RedirectURIs:     []string{"https://app.example.com/callback"},
+		Scopes:           []string{"books:read"},
+		GrantTypes:       []string{"authorization_code"},
+		CreatedBy:        1,
+	}
+
+	mock.ExpectQuery(`INSERT INTO oauth_clients \(client_id, client_secret_hash, name, redirect_uris, scopes, grant_types, created_by\)
+         VALUES \(\$1, NULLIF\(\$2, ''\), \$3, \$4, \$5, \$6, \$7\)
+         RETURNING id, created_at`).
+		WithArgs("client-1", "secret-hash", "Reading Tracker", pq.Array(payload.RedirectURIs), pq.Array(payload.Scopes), pq.Array(payload.GrantTypes), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow(1, createdAt))
+
+	client, err := store.CreateOAuthClient(context.Background(), payload)
+
+	assert.NoError(t, err)
+	assert.Equal(t, &types.OAuthClient{
+		ID:               1,
+		ClientID:         "client-1",
+		ClientSecretHash: "secret-hash",
+		Name:             "Reading Tracker",
+		RedirectURIs:     payload.RedirectURIs,
+		Scopes:           payload.Scopes,
+		GrantTypes:       payload.GrantTypes,
+		Confidential:     true,
+		CreatedAt:        createdAt,
+	}, client)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestGetOAuthClientByClientID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := `SELECT id, client_id, COALESCE\(client_secret_hash, ''\), name, redirect_uris, scopes, grant_types, created_at
+         FROM oauth_clients
+         WHERE client_id = \$1`
+
+	t.Run("client does not exist", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("client-1").
+			WillReturnError(sql.ErrNoRows)
+
+		client, err := store.GetOAuthClientByClientID(context.Background(), "client-1")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Nil(t, client)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get public client", func(t *testing.T) {
+		createdAt := time.Now()
+
+		mock.ExpectQuery(query).
+			WithArgs("client-1").
+			WillReturnRows(
+				sqlmock.NewRows([]string{"id", "client_id", "client_secret_hash", "name", "redirect_uris", "scopes", "grant_types", "created_at"}).
+					AddRow(1, "client-1", "", "Reading Tracker", "{https://app.example.com/callback}", "{books:read}", "{authorization_code,refresh_token}", createdAt),
+			)
+
+		client, err := store.GetOAuthClientByClientID(context.Background(), "client-1")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.OAuthClient{
+			ID:           1,
+			ClientID:     "client-1",
+			Name:         "Reading Tracker",
+			RedirectURIs: []string{"https://app.example.com/callback"},
+			Scopes:       []string{"books:read"},
+			GrantTypes:   []string{"authorization_code", "refresh_token"},
+			Confidential: false,
+			CreatedAt:    createdAt,
+		}, client)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDeleteOAuthClient(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := `DELETE FROM oauth_clients WHERE client_id = \$1`
+
+	t.Run("client does not exist", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs("client-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.DeleteOAuthClient(context.Background(), "client-1")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully delete client", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs("client-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.DeleteOAuthClient(context.Background(), "client-1")
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestConsumeOAuthAuthorizationCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	query := `DELETE FROM oauth_authorization_codes
+         WHERE code_hash = \$1 AND expires_at > NOW\(\)
+         RETURNING client_id, user_id, redirect_uri, scope, code_challenge`
+
+	t.Run("code is unknown, expired or already used", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("code-hash").
+			WillReturnError(sql.ErrNoRows)
+
+		code, err := store.ConsumeOAuthAuthorizationCode(context.Background(), "code-hash")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Nil(t, code)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully consume code", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("code-hash").
+			WillReturnRows(
+				sqlmock.NewRows([]string{"client_id", "user_id", "redirect_uri", "scope", "code_challenge"}).
+					AddRow("client-1", 1, "https://app.example.com/callback", "books:read", "challenge"),
+			)
+
+		code, err := store.ConsumeOAuthAuthorizationCode(context.Background(), "code-hash")
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.OAuthAuthorizationCode{
+			ClientID:      "client-1",
+			UserID:        1,
+			RedirectURI:   "https://app.example.com/callback",
+			Scope:         "books:read",
+			CodeChallenge: "challenge",
+		}, code)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestUpsertOAuthConsent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+	scopes := []string{"books:read"}
+
+	mock.ExpectExec(`INSERT INTO oauth_consents \(user_id, client_id, scopes\)
+         VALUES \(\$1, \$2, \$3\)
+         ON CONFLICT \(user_id, client_id\) DO UPDATE SET`).
+		WithArgs(1, "client-1", pq.Array(scopes)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = store.UpsertOAuthConsent(context.Background(), 1, "client-1", scopes)
+
+	assert.NoError(t, err)
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("unmet expectations: %v", err)
+	}
+}
+
+func TestDeleteOAuthConsent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthStore(db)
+
+	t.Run("consent does not exist", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM oauth_consents WHERE user_id = \$1 AND client_id = \$2`).
+			WithArgs(1, "client-1").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := store.DeleteOAuthConsent(context.Background(), 1, "client-1")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully delete consent and the client refresh tokens", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(`DELETE FROM oauth_consents WHERE user_id = \$1 AND client_id = \$2`).
+			WithArgs(1, "client-1").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(`DELETE FROM refresh_tokens WHERE user_id = \$1 AND client_id = \$2`).
+			WithArgs(1, "client-1").
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectCommit()
 
-		err := store.UpsertRefreshToken(context.Background(), payload)
+		err := store.DeleteOAuthConsent(context.Background(), 1, "client-1")
 
 		assert.NoError(t, err)
 
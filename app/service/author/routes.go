@@ -0,0 +1,309 @@
+package author
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+)
+
+var validate = validator.New()
+
+const (
+	defaultAuthorsPage  = 1
+	defaultAuthorsLimit = 20
+)
+
+type AuthorHandler struct {
+	authorStore types.AuthorStore
+}
+
+func NewAuthorHandler(authorStore types.AuthorStore) *AuthorHandler {
+	return &AuthorHandler{authorStore: authorStore}
+}
+
+func parseID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, fmt.Errorf("id must be positive, got %d", id)
+	}
+
+	return id, nil
+}
+
+func parsePagination(query url.Values) (page int, limit int, err error) {
+	page, limit = defaultAuthorsPage, defaultAuthorsLimit
+
+	if value := query.Get("page"); value != "" {
+		page, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("'page' must be an integer")
+		}
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil {
+			return 0, 0, fmt.Errorf("'limit' must be an integer")
+		}
+	}
+
+	return page, limit, nil
+}
+
+func writeValidationError(w http.ResponseWriter, err error, context string) {
+	var errorMessages []string
+	for _, e := range err.(validator.ValidationErrors) {
+		errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+	}
+
+	utils.WriteError(w, http.StatusBadRequest, err, context, types.BadRequestStructResponse{Error: errorMessages})
+}
+
+// @Summary Criar autor
+// @Description Cadastra um autor no catálogo compartilhado. Nomes que só diferem em maiúsculas, espaços ou pontuação são considerados o mesmo autor.
+// @Tags Authors
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.CreateAuthorPayload true "Dados do autor"
+// @Success 201 {object} types.CreateAuthorResponse "ID do novo autor"
+// @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 409 {object} types.ConflictResponse "An author with this name already exists"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /authors [post]
+func (h *AuthorHandler) HandleCreateAuthor(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateAuthorPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateAuthor", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+	if err := validate.Struct(payload); err != nil {
+		writeValidationError(w, err, "HandleCreateAuthor")
+		return
+	}
+
+	id, err := h.authorStore.Create(r.Context(), payload)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleCreateAuthor", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if errors.Is(err, ErrAuthorExists) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleCreateAuthor", types.ConflictResponse{Error: "An author with this name already exists"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleCreateAuthor", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.CreateAuthorResponse{ID: id})
+}
+
+// @Summary Listar autores
+// @Tags Authors
+// @Security BearerAuth
+// @Produce json
+// @Param search query string false "Trecho do nome do autor"
+// @Param page query int false "Página (padrão 1)"
+// @Param limit query int false "Itens por página (padrão 20, máximo 100)"
+// @Success 200 {object} types.GetAuthorsResponse "Lista de autores"
+// @Failure 400 {object} types.BadRequestResponse "Query parameter is not a valid integer"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for query parameters"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /authors [get]
+func (h *AuthorHandler) HandleGetAuthors(w http.ResponseWriter, r *http.Request) {
+	page, limit, err := parsePagination(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetAuthors", types.BadRequestResponse{Error: fmt.Sprintf("Query parameter %s", err.Error())})
+		return
+	}
+
+	options := types.GetAuthorsOptions{Page: page, Limit: limit, Search: strings.TrimSpace(r.URL.Query().Get("search"))}
+	if err := validate.Struct(options); err != nil {
+		writeValidationError(w, err, "HandleGetAuthors")
+		return
+	}
+
+	authors, total, err := h.authorStore.GetMany(r.Context(), options)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetAuthors", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetAuthors", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetAuthorsResponse{
+		Authors:    authors,
+		Total:      total,
+		Page:       options.Page,
+		Limit:      options.Limit,
+		TotalPages: (total + options.Limit - 1) / options.Limit,
+	})
+}
+
+// @Summary Obter autor por ID
+// @Tags Authors
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do autor"
+// @Success 200 {object} types.Author "Detalhes do autor"
+// @Failure 400 {object} types.BadRequestResponse "Author ID must be a positive integer"
+// @Failure 404 {object} types.NotFoundResponse "No author found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /authors/{id} [get]
+func (h *AuthorHandler) HandleGetAuthorByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetAuthorByID", types.BadRequestResponse{Error: "Author ID must be a positive integer"})
+		return
+	}
+
+	author, err := h.authorStore.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetAuthorByID", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleGetAuthorByID", types.NotFoundResponse{Error: fmt.Sprintf("No author found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetAuthorByID", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, author)
+}
+
+// @Summary Atualizar autor por ID
+// @Description Atualiza o nome e a biografia de um autor. Apenas administradores e bibliotecários.
+// @Tags Authors
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do autor"
+// @Param request body types.UpdateAuthorPayload true "Dados do autor"
+// @Success 200 {object} types.Author "Autor atualizado"
+// @Failure 400 {object} types.BadRequestResponse "Author ID must be a positive integer ou Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No author found with given ID"
+// @Failure 409 {object} types.ConflictResponse "An author with this name already exists"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /authors/{id} [put]
+func (h *AuthorHandler) HandleUpdateAuthorByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleUpdateAuthorByID", types.BadRequestResponse{Error: "Author ID must be a positive integer"})
+		return
+	}
+
+	var payload types.UpdateAuthorPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleUpdateAuthorByID", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	payload.Name = strings.TrimSpace(payload.Name)
+	if err := validate.Struct(payload); err != nil {
+		writeValidationError(w, err, "HandleUpdateAuthorByID")
+		return
+	}
+
+	author, err := h.authorStore.UpdateByID(r.Context(), id, payload)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleUpdateAuthorByID", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleUpdateAuthorByID", types.NotFoundResponse{Error: fmt.Sprintf("No author found with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, ErrAuthorExists) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleUpdateAuthorByID", types.ConflictResponse{Error: "An author with this name already exists"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUpdateAuthorByID", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, author)
+}
+
+// @Summary Excluir autor por ID
+// @Description Exclui um autor que não está creditado em nenhum livro. Apenas administradores e bibliotecários.
+// @Tags Authors
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do autor"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Author ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No author found with given ID"
+// @Failure 409 {object} types.ConflictResponse "The author is still credited on books"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /authors/{id} [delete]
+func (h *AuthorHandler) HandleDeleteAuthorByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleDeleteAuthorByID", types.BadRequestResponse{Error: "Author ID must be a positive integer"})
+		return
+	}
+
+	err = h.authorStore.DeleteByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleDeleteAuthorByID", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleDeleteAuthorByID", types.NotFoundResponse{Error: fmt.Sprintf("No author found with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, ErrAuthorHasBooks) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleDeleteAuthorByID", types.ConflictResponse{Error: "The author is still credited on books"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDeleteAuthorByID", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,313 @@
+package author_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/cmd/api"
+	"github.com/hoyci/book-store-api/mocks"
+	"github.com/hoyci/book-store-api/service/author"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTestServer() (*mocks.MockAuthorStore, *httptest.Server, *mux.Router) {
+	mockAuthorStore := new(mocks.MockAuthorStore)
+	mockAuthorHandler := author.NewAuthorHandler(mockAuthorStore)
+	apiServer := api.NewApiServer(":8080", nil)
+	router := apiServer.SetupRouter(nil, nil, nil, nil, nil, mockAuthorHandler, nil)
+	ts := httptest.NewServer(router)
+	return mockAuthorStore, ts, router
+}
+
+func TestHandleCreateAuthor(t *testing.T) {
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com")
+
+	t.Run("it should throw an error when the name is too short", func(t *testing.T) {
+		mockAuthorStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/authors", bytes.NewBufferString(`{"name":"  J  "}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'Name' is invalid: min"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+		mockAuthorStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the author already exists", func(t *testing.T) {
+		mockAuthorStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthorStore.On("Create", mock.Anything, types.CreateAuthorPayload{Name: "Neil Gaiman"}).Return(0, author.ErrAuthorExists)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/authors", bytes.NewBufferString(`{"name":" Neil Gaiman "}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"An author with this name already exists"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should successfully create an author", func(t *testing.T) {
+		mockAuthorStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthorStore.On("Create", mock.Anything, types.CreateAuthorPayload{Name: "Neil Gaiman", Bio: "English author"}).Return(4, nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/authors", bytes.NewBufferString(`{"name":"Neil Gaiman","bio":"English author"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"id":4}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleGetAuthors(t *testing.T) {
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com")
+
+	t.Run("it should throw an error when page is not an integer", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/authors?page=first", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Query parameter 'page' must be an integer"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should return error when the request context is canceled", func(t *testing.T) {
+		mockAuthorStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		canceledCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		mockAuthorStore.On("GetMany", mock.Anything, mock.Anything).Return(([]*types.Author)(nil), 0, context.Canceled)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/authors", nil).WithContext(canceledCtx)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+
+	t.Run("it should successfully search authors", func(t *testing.T) {
+		mockAuthorStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthorStore.On("GetMany", mock.Anything, types.GetAuthorsOptions{Page: 1, Limit: 20, Search: "gaiman"}).Return(
+			[]*types.Author{{ID: 4, Name: "Neil Gaiman", CreatedAt: time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC)}},
+			1,
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/authors?search=gaiman", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{
+			"authors": [{"id":4,"name":"Neil Gaiman","bio":"","created_at":"2025-03-21T00:00:00Z","updated_at":null}],
+			"total": 1,
+			"page": 1,
+			"limit": 20,
+			"total_pages": 1
+		}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleGetAuthorByID(t *testing.T) {
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com")
+
+	t.Run("it should throw an error when the ID is not positive", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/authors/0", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Author ID must be a positive integer"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the author does not exist", func(t *testing.T) {
+		mockAuthorStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthorStore.On("GetByID", mock.Anything, 4).Return((*types.Author)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/authors/4", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No author found with ID 4"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleUpdateAuthorByID(t *testing.T) {
+	librarianToken := utils.GenerateTestTokenWithRole(1, "librarian", "librarian@email.com", types.RoleLibrarian)
+
+	t.Run("it should forbid readers", func(t *testing.T) {
+		mockAuthorStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/authors/4", bytes.NewBufferString(`{"name":"Neil Gaiman"}`))
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(2, "JohnDoe", "johndoe@email.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		mockAuthorStore.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should successfully update the author", func(t *testing.T) {
+		mockAuthorStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		updatedAt := time.Date(2025, 3, 22, 0, 0, 0, 0, time.UTC)
+		mockAuthorStore.On("UpdateByID", mock.Anything, 4, types.UpdateAuthorPayload{Name: "Neil Gaiman", Bio: "English author"}).Return(
+			&types.Author{ID: 4, Name: "Neil Gaiman", Bio: "English author", CreatedAt: time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC), UpdatedAt: &updatedAt},
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/authors/4", bytes.NewBufferString(`{"name":"Neil Gaiman","bio":"English author"}`))
+		req.Header.Set("Authorization", "Bearer "+librarianToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"id":4,"name":"Neil Gaiman","bio":"English author","created_at":"2025-03-21T00:00:00Z","updated_at":"2025-03-22T00:00:00Z"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleDeleteAuthorByID(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when the author is still credited on books", func(t *testing.T) {
+		mockAuthorStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthorStore.On("DeleteByID", mock.Anything, 4).Return(author.ErrAuthorHasBooks)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/authors/4", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"The author is still credited on books"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should successfully delete the author", func(t *testing.T) {
+		mockAuthorStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthorStore.On("DeleteByID", mock.Anything, 4).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/authors/4", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
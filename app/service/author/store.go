@@ -0,0 +1,196 @@
+package author
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+)
+
+var (
+	ErrAuthorExists   = errors.New("author already exists")
+	ErrAuthorHasBooks = errors.New("author is credited on books")
+)
+
+type AuthorStore struct {
+	db *sql.DB
+}
+
+func NewAuthorStore(db *sql.DB) *AuthorStore {
+	return &AuthorStore{db: db}
+}
+
+func (s *AuthorStore) Create(ctx context.Context, author types.CreateAuthorPayload) (int, error) {
+	var authorID int
+	err := s.db.QueryRowContext(
+		ctx,
+		`
+		INSERT INTO authors (name, name_key, bio)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (name_key) DO NOTHING
+		RETURNING id;
+		`,
+		author.Name,
+		utils.AuthorNameKey(author.Name),
+		author.Bio,
+	).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return 0, ErrAuthorExists
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return authorID, nil
+}
+
+func (s *AuthorStore) GetByID(ctx context.Context, authorID int) (*types.Author, error) {
+	author := &types.Author{}
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT id, name, bio, created_at, updated_at FROM authors WHERE id = $1",
+		authorID,
+	).Scan(
+		&author.ID,
+		&author.Name,
+		&author.Bio,
+		&author.CreatedAt,
+		&author.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return author, nil
+}
+
+// GetMany lists authors by name. Search matches anywhere in the name and
+// ignores case, spacing and punctuation, like author names are compared.
+func (s *AuthorStore) GetMany(ctx context.Context, options types.GetAuthorsOptions) ([]*types.Author, int, error) {
+	const filter = "WHERE ($1 = '' OR name_key LIKE '%' || $1 || '%')"
+	searchKey := utils.AuthorNameKey(options.Search)
+
+	var total int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM authors "+filter, searchKey).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, name, bio, created_at, updated_at
+         FROM authors `+filter+`
+         ORDER BY name, id
+         LIMIT $2 OFFSET $3`,
+		searchKey,
+		options.Limit,
+		(options.Page-1)*options.Limit,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	authors := []*types.Author{}
+
+	for rows.Next() {
+		author := &types.Author{}
+		err := rows.Scan(
+			&author.ID,
+			&author.Name,
+			&author.Bio,
+			&author.CreatedAt,
+			&author.UpdatedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		authors = append(authors, author)
+	}
+
+	return authors, total, rows.Err()
+}
+
+// UpdateByID renames the author everywhere they are credited, rewriting the
+// books.author credit line of the books that credit them as an author.
+func (s *AuthorStore) UpdateByID(ctx context.Context, authorID int, newAuthor types.UpdateAuthorPayload) (*types.Author, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	author := &types.Author{}
+	err = tx.QueryRowContext(
+		ctx,
+		`
+		UPDATE authors
+		SET name = $2, name_key = $3, bio = $4, updated_at = $5
+		WHERE id = $1
+		RETURNING id, name, bio, created_at, updated_at;
+		`,
+		authorID,
+		newAuthor.Name,
+		utils.AuthorNameKey(newAuthor.Name),
+		newAuthor.Bio,
+		time.Now(),
+	).Scan(
+		&author.ID,
+		&author.Name,
+		&author.Bio,
+		&author.CreatedAt,
+		&author.UpdatedAt,
+	)
+	if utils.IsPQError(err, utils.PQUniqueViolation) {
+		return nil, ErrAuthorExists
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`
+		UPDATE books b SET author = `+utils.BookAuthorCreditSQL+`
+		WHERE b.id IN (SELECT book_id FROM book_authors WHERE author_id = $1 AND role = $2)
+		`,
+		authorID,
+		types.AuthorRoleAuthor,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return author, nil
+}
+
+// DeleteByID only removes authors that are no longer credited on any book.
+func (s *AuthorStore) DeleteByID(ctx context.Context, authorID int) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM authors WHERE id = $1", authorID)
+	if utils.IsPQError(err, utils.PQForeignKeyViolation) {
+		return ErrAuthorHasBooks
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
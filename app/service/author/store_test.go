@@ -0,0 +1,202 @@
+package author
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthorStore(db)
+	query := regexp.QuoteMeta("INSERT INTO authors (name, name_key, bio)")
+	payload := types.CreateAuthorPayload{Name: "J. R. R. Tolkien", Bio: "English writer"}
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("J. R. R. Tolkien", "jrrtolkien", "English writer").
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		id, err := store.Create(context.Background(), payload)
+
+		assert.Zero(t, id)
+		assert.Error(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("author with the same name key already exists", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("J. R. R. Tolkien", "jrrtolkien", "English writer").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+		id, err := store.Create(context.Background(), payload)
+
+		assert.Zero(t, id)
+		assert.ErrorIs(t, err, ErrAuthorExists)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully create author", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("J. R. R. Tolkien", "jrrtolkien", "English writer").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		id, err := store.Create(context.Background(), payload)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, id)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetMany(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthorStore(db)
+
+	t.Run("successfully search authors ignoring case and punctuation", func(t *testing.T) {
+		createdAt := time.Date(2025, 3, 21, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM authors WHERE")).
+			WithArgs("tolkien").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, bio, created_at, updated_at")).
+			WithArgs("tolkien", 20, 20).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "bio", "created_at", "updated_at"}).
+				AddRow(1, "J. R. R. Tolkien", "", createdAt, nil))
+
+		authors, total, err := store.GetMany(context.Background(), types.GetAuthorsOptions{Page: 2, Limit: 20, Search: " Tolkien!"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, []*types.Author{{ID: 1, Name: "J. R. R. Tolkien", CreatedAt: createdAt}}, authors)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestUpdateByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthorStore(db)
+	query := regexp.QuoteMeta("UPDATE authors")
+
+	t.Run("author not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(query).
+			WithArgs(1, "Ursula K. Le Guin", "ursulakleguin", "", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		author, err := store.UpdateByID(context.Background(), 1, types.UpdateAuthorPayload{Name: "Ursula K. Le Guin"})
+
+		assert.Nil(t, author)
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("name taken by another author", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(query).
+			WithArgs(1, "Ursula K. Le Guin", "ursulakleguin", "", sqlmock.AnyArg()).
+			WillReturnError(&pq.Error{Code: utils.PQUniqueViolation})
+		mock.ExpectRollback()
+
+		author, err := store.UpdateByID(context.Background(), 1, types.UpdateAuthorPayload{Name: "Ursula K. Le Guin"})
+
+		assert.Nil(t, author)
+		assert.ErrorIs(t, err, ErrAuthorExists)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDeleteByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewAuthorStore(db)
+	query := regexp.QuoteMeta("DELETE FROM authors WHERE id = $1")
+
+	t.Run("author still credited on books", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1).
+			WillReturnError(&pq.Error{Code: utils.PQForeignKeyViolation})
+
+		err := store.DeleteByID(context.Background(), 1)
+
+		assert.ErrorIs(t, err, ErrAuthorHasBooks)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("author not found", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.DeleteByID(context.Background(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully delete author", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.DeleteByID(context.Background(), 1)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
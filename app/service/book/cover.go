@@ -0,0 +1,195 @@
+package book
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+)
+
+// Covers are stored as JPEGs in two sizes, each fitting its box with the
+// aspect ratio kept. Images smaller than a box are not enlarged.
+const (
+	coverFullWidth       = 1200
+	coverFullHeight      = 1800
+	coverThumbnailWidth  = 200
+	coverThumbnailHeight = 300
+	coverJPEGQuality     = 85
+
+	// coverMaxPixels bounds the memory decoding an upload takes, since a few
+	// kilobytes of PNG can claim to be a gigapixel image. The decoded image
+	// and its flattened copy take up to 8 bytes a pixel, about 100 MB here,
+	// which still fits a phone photo of a cover.
+	coverMaxPixels = 12_000_000
+)
+
+var (
+	ErrCoverMissing         = errors.New("no cover file in the request")
+	ErrCoverTooLarge        = errors.New("cover is too large")
+	ErrCoverUnsupportedType = errors.New("cover is not a JPEG, PNG or GIF image")
+	ErrCoverInvalid         = errors.New("cover could not be decoded")
+)
+
+var coverContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// readCoverPart returns the content of the "cover" file of a multipart body,
+// failing with ErrCoverTooLarge past maxBytes.
+func readCoverPart(reader *multipart.Reader, maxBytes int64) ([]byte, error) {
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return nil, ErrCoverMissing
+		}
+		if err != nil {
+			return nil, coverReadError(err)
+		}
+
+		if part.FormName() != "cover" {
+			part.Close()
+			continue
+		}
+		defer part.Close()
+
+		data, err := io.ReadAll(io.LimitReader(part, maxBytes+1))
+		if err != nil {
+			return nil, coverReadError(err)
+		}
+		if int64(len(data)) > maxBytes {
+			return nil, fmt.Errorf("%w: more than %d bytes", ErrCoverTooLarge, maxBytes)
+		}
+
+		return data, nil
+	}
+}
+
+func coverReadError(err error) error {
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return fmt.Errorf("%w: %w", ErrCoverTooLarge, err)
+	}
+
+	return err
+}
+
+// processCover checks what an uploaded cover really is, whatever the client
+// said, and encodes it in the full and thumbnail sizes.
+func processCover(data []byte) (full []byte, thumbnail []byte, err error) {
+	contentType := http.DetectContentType(data)
+	if !coverContentTypes[contentType] {
+		return nil, nil, fmt.Errorf("%w: got %s", ErrCoverUnsupportedType, contentType)
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrCoverInvalid, err)
+	}
+	if config.Width <= 0 || config.Height <= 0 {
+		return nil, nil, fmt.Errorf("%w: image is %dx%d", ErrCoverInvalid, config.Width, config.Height)
+	}
+	if config.Width*config.Height > coverMaxPixels {
+		return nil, nil, fmt.Errorf("%w: image is %dx%d", ErrCoverTooLarge, config.Width, config.Height)
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %w", ErrCoverInvalid, err)
+	}
+
+	// JPEG has no transparency, so transparent PNGs and GIFs get a white
+	// background rather than a black one.
+	flat := image.NewRGBA(image.Rect(0, 0, src.Bounds().Dx(), src.Bounds().Dy()))
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), src, src.Bounds().Min, draw.Over)
+
+	fullImage := fitCover(flat, coverFullWidth, coverFullHeight)
+	full, err = encodeCover(fullImage)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	thumbnail, err = encodeCover(fitCover(fullImage, coverThumbnailWidth, coverThumbnailHeight))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return full, thumbnail, nil
+}
+
+// fitCover scales img down to fit in maxWidth x maxHeight. Each pixel of the
+// result is the average of the source pixels it covers, which keeps text on
+// covers readable where sampling a single pixel would not.
+func fitCover(img *image.RGBA, maxWidth, maxHeight int) *image.RGBA {
+	srcWidth, srcHeight := img.Bounds().Dx(), img.Bounds().Dy()
+	if srcWidth <= maxWidth && srcHeight <= maxHeight {
+		return img
+	}
+
+	width, height := maxWidth, srcHeight*maxWidth/srcWidth
+	if height > maxHeight {
+		width, height = srcWidth*maxHeight/srcHeight, maxHeight
+	}
+	width, height = max(width, 1), max(height, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*srcHeight/height, max((y+1)*srcHeight/height, y*srcHeight/height+1)
+		for x := 0; x < width; x++ {
+			x0, x1 := x*srcWidth/width, max((x+1)*srcWidth/width, x*srcWidth/width+1)
+
+			var r, g, b, a, n int
+			for sy := y0; sy < y1; sy++ {
+				row := img.Pix[sy*img.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					r += int(row[sx*4])
+					g += int(row[sx*4+1])
+					b += int(row[sx*4+2])
+					a += int(row[sx*4+3])
+					n++
+				}
+			}
+
+			i := y*dst.Stride + x*4
+			dst.Pix[i] = uint8(r / n)
+			dst.Pix[i+1] = uint8(g / n)
+			dst.Pix[i+2] = uint8(b / n)
+			dst.Pix[i+3] = uint8(a / n)
+		}
+	}
+
+	return dst
+}
+
+func encodeCover(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: coverJPEGQuality}); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// coverKeys returns where the sizes of a new cover of the book are stored,
+// as keys utils.IsCoverKey accepts. Every upload gets its own keys, so a
+// stored cover never changes and can be cached forever.
+func coverKeys(bookID int) (full string, thumbnail string, err error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", "", err
+	}
+
+	prefix := fmt.Sprintf("books/%d/%s", bookID, hex.EncodeToString(b))
+	return prefix + "/full.jpg", prefix + "/thumbnail.jpg", nil
+}
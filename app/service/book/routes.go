@@ -2,29 +2,56 @@ package book
 
 import (
 	"context"
+	"crypto/sha256"
 	"database/sql"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/config"
 	"github.com/hoyci/book-store-api/types"
 	"github.com/hoyci/book-store-api/utils"
+	"github.com/sirupsen/logrus"
 )
 
 var validate = validator.New()
 
+// isbnValidator replaces the built-in isbn tag, which rejects hyphenated
+// ISBNs. The param, when set, is the kind of ISBN the field takes: 10 or 13.
+func isbnValidator(fl validator.FieldLevel) bool {
+	isbn := utils.CleanISBN(fl.Field().String())
+	if param := fl.Param(); param != "" && strconv.Itoa(len(isbn)) != param {
+		return false
+	}
+
+	return utils.ValidISBN(isbn)
+}
+
 type BookHandler struct {
 	bookStore types.BookStore
+	metadata  types.MetadataProvider
+	covers    types.BlobStore
 }
 
-func NewBookHandler(bookStore types.BookStore) *BookHandler {
-	return &BookHandler{bookStore: bookStore}
+// NewBookHandler takes the catalog books are imported from and the storage
+// uploaded covers are kept in. With a nil metadataProvider, importing is
+// disabled; with a nil coverStore, uploading covers is.
+func NewBookHandler(bookStore types.BookStore, metadataProvider types.MetadataProvider, coverStore types.BlobStore) *BookHandler {
+	_ = validate.RegisterValidation("isbn", isbnValidator)
+
+	return &BookHandler{bookStore: bookStore, metadata: metadataProvider, covers: coverStore}
 }
 
 // @Summary Criar novo livro
+// @Description image_url é opcional: a capa pode ser enviada depois em PUT /books/{id}/cover.
 // @Tags Books
 // @Security BearerAuth
 // @Accept json
@@ -33,12 +60,16 @@ func NewBookHandler(bookStore types.BookStore) *BookHandler {
 // @Success 201 {object} types.CreateBookResponse "ID do novo livro"
 // @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
 // @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 400 {object} types.BadRequestResponse "One or more genres do not exist"
+// @Failure 400 {object} types.BadRequestResponse "ISBN-10 and ISBN-13 do not match"
+// @Failure 409 {object} types.ConflictResponse "A book with this ISBN is already in your library"
 // @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
 // @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
 // @Router /books [post]
 func (h *BookHandler) HandleCreateBook(w http.ResponseWriter, r *http.Request) {
 	var payload types.CreateBookPayload
-	if err := utils.ParseJSON(r, &payload); err != nil {
+	err := utils.ParseJSON(r, &payload)
+	if err != nil {
 		utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateBook", types.BadRequestResponse{Error: "Body is not a valid json"})
 		return
 	}
@@ -53,6 +84,12 @@ func (h *BookHandler) HandleCreateBook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	payload.ISBN10, payload.ISBN13, err = utils.ResolveISBNs(payload.ISBN10, payload.ISBN13)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateBook", types.BadRequestResponse{Error: "ISBN-10 and ISBN-13 do not match"})
+		return
+	}
+
 	id, err := h.bookStore.Create(r.Context(), payload)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
@@ -65,6 +102,16 @@ func (h *BookHandler) HandleCreateBook(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if errors.Is(err, ErrGenreNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateBook", types.BadRequestResponse{Error: "One or more genres do not exist"})
+			return
+		}
+
+		if errors.Is(err, types.ErrBookISBNExists) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleCreateBook", types.ConflictResponse{Error: "A book with this ISBN is already in your library"})
+			return
+		}
+
 		utils.WriteError(w, http.StatusInternalServerError, err, "HandleCreateBook", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
 		return
 	}
@@ -72,6 +119,129 @@ func (h *BookHandler) HandleCreateBook(w http.ResponseWriter, r *http.Request) {
 	utils.WriteJSON(w, http.StatusCreated, types.CreateBookResponse{ID: id})
 }
 
+// @Summary Importar livro por ISBN
+// @Description Cria um livro com os dados do catálogo externo para o ISBN informado. Os demais campos, quando enviados, substituem os do catálogo ou completam os que ele não tem.
+// @Tags Books
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.ImportBookByISBNPayload true "ISBN, gêneros e campos a substituir"
+// @Success 201 {object} types.CreateBookResponse "ID do novo livro"
+// @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload or for the fields missing from the catalog"
+// @Failure 400 {object} types.BadRequestResponse "One or more genres do not exist"
+// @Failure 404 {object} types.NotFoundResponse "No catalog entry found for given ISBN"
+// @Failure 409 {object} types.ConflictResponse "A book with this ISBN is already in your library"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 502 {object} types.BadGatewayResponse "The book catalog is unavailable"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/import/isbn [post]
+func (h *BookHandler) HandleImportBookByISBN(w http.ResponseWriter, r *http.Request) {
+	if h.metadata == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("metadata provider is not configured"), "HandleImportBookByISBN", types.NotFoundResponse{Error: "Importing books is not enabled"})
+		return
+	}
+
+	var importPayload types.ImportBookByISBNPayload
+	if err := utils.ParseJSON(r, &importPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleImportBookByISBN", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(importPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleImportBookByISBN", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	isbn13, _ := utils.NormalizeISBN(importPayload.ISBN)
+
+	metadata, err := h.metadata.LookupISBN(r.Context(), isbn13)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleImportBookByISBN", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if errors.Is(err, types.ErrMetadataNotFound) {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleImportBookByISBN", types.NotFoundResponse{Error: fmt.Sprintf("No catalog entry found for ISBN %s", importPayload.ISBN)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusBadGateway, err, "HandleImportBookByISBN", types.BadGatewayResponse{Error: "The book catalog is unavailable"})
+		return
+	}
+
+	payload := types.CreateBookPayload{
+		Name:          firstNonZero(importPayload.Name, metadata.Name),
+		Description:   firstNonZero(importPayload.Description, metadata.Description),
+		Author:        importPayload.Author,
+		GenreIDs:      importPayload.GenreIDs,
+		ReleaseYear:   firstNonZero(importPayload.ReleaseYear, metadata.ReleaseYear),
+		NumberOfPages: firstNonZero(importPayload.NumberOfPages, metadata.NumberOfPages),
+		ImageUrl:      firstNonZero(importPayload.ImageUrl, metadata.ImageUrl),
+		ISBN10:        utils.ISBN10(isbn13),
+		ISBN13:        isbn13,
+	}
+
+	// Each author in the catalog is credited on its own, unless the client
+	// sent the author to credit instead.
+	if payload.Author == "" {
+		payload.Author = strings.Join(metadata.Authors, ", ")
+		payload.Authors = metadata.Authors
+	}
+
+	// The catalog may lack fields a book requires. They are reported like
+	// any other validation error, so the client can send them and retry.
+	if err := validate.Struct(payload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleImportBookByISBN", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	id, err := h.bookStore.Create(r.Context(), payload)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleImportBookByISBN", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if errors.Is(err, ErrGenreNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleImportBookByISBN", types.BadRequestResponse{Error: "One or more genres do not exist"})
+			return
+		}
+
+		if errors.Is(err, types.ErrBookISBNExists) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleImportBookByISBN", types.ConflictResponse{Error: "A book with this ISBN is already in your library"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleImportBookByISBN", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.CreateBookResponse{ID: id})
+}
+
+func firstNonZero[T comparable](values ...T) T {
+	var zero T
+	for _, value := range values {
+		if value != zero {
+			return value
+		}
+	}
+
+	return zero
+}
+
 // @Summary Obter livro por ID
 // @Tags Books
 // @Security BearerAuth
@@ -124,23 +294,175 @@ func (h *BookHandler) HandleGetBookByID(w http.ResponseWriter, r *http.Request)
 		ReleaseYear:   book.ReleaseYear,
 		NumberOfPages: book.NumberOfPages,
 		ImageUrl:      book.ImageUrl,
+		ThumbnailUrl:  book.ThumbnailUrl,
+		ISBN10:        book.ISBN10,
+		ISBN13:        book.ISBN13,
 		CreatedAt:     book.CreatedAt,
 		DeletedAt:     book.DeletedAt,
 		UpdatedAt:     book.UpdatedAt,
 	})
 }
 
+// @Summary Obter livro por ISBN
+// @Description Busca o livro com o ISBN informado entre os livros do usuário e os compartilhados com ele, priorizando os do próprio usuário. Aceita ISBN-10 ou ISBN-13, com ou sem hífens.
+// @Tags Books
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param isbn path string true "ISBN-10 ou ISBN-13 do livro"
+// @Success 200 {object} types.Book "Detalhes do livro"
+// @Failure 400 {object} types.BadRequestResponse "ISBN is not valid"
+// @Failure 404 {object} types.NotFoundResponse "No book found with given ISBN"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/isbn/{isbn} [get]
+func (h *BookHandler) HandleGetBookByISBN(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	isbn, ok := utils.NormalizeISBN(vars["isbn"])
+	if !ok {
+		utils.WriteError(w, http.StatusBadRequest, utils.ErrInvalidISBN, "HandleGetBookByISBN", types.BadRequestResponse{Error: "ISBN is not valid"})
+		return
+	}
+
+	book, err := h.bookStore.GetByISBN(r.Context(), isbn)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetBookByISBN", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleGetBookByISBN", types.NotFoundResponse{Error: fmt.Sprintf("No book found with ISBN %s", vars["isbn"])})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetBookByISBN", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, book)
+}
+
+const (
+	defaultBooksPage  = 1
+	defaultBooksLimit = 20
+)
+
+type intQueryParam struct {
+	name   string
+	target *int
+}
+
+func parseIntQueryParams(query url.Values, params ...intQueryParam) error {
+	for _, param := range params {
+		value := query.Get(param.name)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("'%s' must be an integer", param.name)
+		}
+		*param.target = parsed
+	}
+
+	return nil
+}
+
+func parseGetBooksOptions(query url.Values) (types.GetBooksOptions, error) {
+	options := types.GetBooksOptions{
+		Page:   defaultBooksPage,
+		Limit:  defaultBooksLimit,
+		Author: query.Get("author"),
+		Genre:  query.Get("genre"),
+		Sort:   "created_at",
+		Order:  "asc",
+	}
+
+	err := parseIntQueryParams(
+		query,
+		intQueryParam{"page", &options.Page},
+		intQueryParam{"limit", &options.Limit},
+		intQueryParam{"author_id", &options.AuthorID},
+		intQueryParam{"release_year_min", &options.MinReleaseYear},
+		intQueryParam{"release_year_max", &options.MaxReleaseYear},
+		intQueryParam{"pages_min", &options.MinPages},
+		intQueryParam{"pages_max", &options.MaxPages},
+	)
+	if err != nil {
+		return options, err
+	}
+
+	if sort := query.Get("sort"); sort != "" {
+		options.Sort = sort
+	}
+	if order := query.Get("order"); order != "" {
+		options.Order = order
+	}
+
+	return options, nil
+}
+
+func buildBooksPageLink(r *http.Request, page int) *string {
+	query := r.URL.Query()
+	query.Set("page", strconv.Itoa(page))
+	link := fmt.Sprintf("%s?%s", r.URL.Path, query.Encode())
+	return &link
+}
+
+func buildBooksPageLinks(r *http.Request, page, totalPages int) (next *string, prev *string) {
+	if page < totalPages {
+		next = buildBooksPageLink(r, page+1)
+	}
+	if page > 1 {
+		prev = buildBooksPageLink(r, min(page-1, max(totalPages, 1)))
+	}
+
+	return next, prev
+}
+
 // @Summary Listar livros
 // @Tags Books
 // @Security BearerAuth
 // @Accept json
 // @Produce json
+// @Param page query int false "Página (padrão 1)"
+// @Param limit query int false "Itens por página (padrão 20, máximo 100)"
+// @Param author query string false "Filtrar por autor (busca parcial)"
+// @Param author_id query int false "Filtrar pelo ID de um autor creditado no livro"
+// @Param genre query string false "Filtrar por gênero (slug ou nome), incluindo seus subgêneros"
+// @Param release_year_min query int false "Ano de lançamento mínimo"
+// @Param release_year_max query int false "Ano de lançamento máximo"
+// @Param pages_min query int false "Número mínimo de páginas"
+// @Param pages_max query int false "Número máximo de páginas"
+// @Param sort query string false "Campo de ordenação" Enums(name, release_year, created_at)
+// @Param order query string false "Direção da ordenação" Enums(asc, desc)
 // @Success 200 {object} types.GetBooksResponse "Lista de livros"
+// @Failure 400 {object} types.BadRequestResponse "Query parameter is not a valid integer"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for query parameters"
 // @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
 // @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
 // @Router /books [get]
 func (h *BookHandler) HandleGetBooks(w http.ResponseWriter, r *http.Request) {
-	books, err := h.bookStore.GetMany(r.Context())
+	options, err := parseGetBooksOptions(r.URL.Query())
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetBooks", types.BadRequestResponse{Error: fmt.Sprintf("Query parameter %s", err.Error())})
+		return
+	}
+
+	if err := validate.Struct(options); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetBooks", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	books, total, err := h.bookStore.GetMany(r.Context(), options)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetBooks", types.ContextCanceledResponse{Error: "Request canceled"})
@@ -156,7 +478,86 @@ func (h *BookHandler) HandleGetBooks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusOK, types.GetBooksResponse{Books: books})
+	totalPages := (total + options.Limit - 1) / options.Limit
+	next, prev := buildBooksPageLinks(r, options.Page, totalPages)
+
+	utils.WriteJSON(w, http.StatusOK, types.GetBooksResponse{
+		Books:      books,
+		Total:      total,
+		Page:       options.Page,
+		Limit:      options.Limit,
+		TotalPages: totalPages,
+		Next:       next,
+		Prev:       prev,
+	})
+}
+
+// @Summary Buscar livros por texto
+// @Description Busca textual (com correspondência por prefixo) no nome, descrição e autor dos livros do usuário, ordenada por relevância. O snippet é HTML escapado, com os termos encontrados entre tags <mark>.
+// @Tags Books
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param q query string true "Texto a ser buscado"
+// @Param page query int false "Página (padrão 1)"
+// @Param limit query int false "Itens por página (padrão 20, máximo 100)"
+// @Success 200 {object} types.SearchBooksResponse "Livros encontrados"
+// @Failure 400 {object} types.BadRequestResponse "Query parameter is not a valid integer"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for query parameters"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/search [get]
+func (h *BookHandler) HandleSearchBooks(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	options := types.SearchBooksOptions{
+		Query: query.Get("q"),
+		Page:  defaultBooksPage,
+		Limit: defaultBooksLimit,
+	}
+
+	err := parseIntQueryParams(
+		query,
+		intQueryParam{"page", &options.Page},
+		intQueryParam{"limit", &options.Limit},
+	)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleSearchBooks", types.BadRequestResponse{Error: fmt.Sprintf("Query parameter %s", err.Error())})
+		return
+	}
+
+	if err := validate.Struct(options); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleSearchBooks", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	results, total, err := h.bookStore.Search(r.Context(), options)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleSearchBooks", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleSearchBooks", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	totalPages := (total + options.Limit - 1) / options.Limit
+	next, prev := buildBooksPageLinks(r, options.Page, totalPages)
+
+	utils.WriteJSON(w, http.StatusOK, types.SearchBooksResponse{
+		Results:    results,
+		Total:      total,
+		Page:       options.Page,
+		Limit:      options.Limit,
+		TotalPages: totalPages,
+		Next:       next,
+		Prev:       prev,
+	})
 }
 
 // @Summary Atualizar livro por ID
@@ -169,7 +570,11 @@ func (h *BookHandler) HandleGetBooks(w http.ResponseWriter, r *http.Request) {
 // @Success 200 {object} types.Book "Livro atualizado"
 // @Failure 400 {object} types.BadRequestResponse "Book ID must be a positive integer ou Body is not a valid json"
 // @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 400 {object} types.BadRequestResponse "One or more genres do not exist"
+// @Failure 400 {object} types.BadRequestResponse "ISBN-10 and ISBN-13 do not match"
+// @Failure 403 {object} types.ForbiddenResponse "The book is shared with the user as viewer"
 // @Failure 404 {object} types.NotFoundResponse "No book found with given ID"
+// @Failure 409 {object} types.ConflictResponse "Another book with this ISBN is already in the owner's library"
 // @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
 // @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
 // @Router /books/{id} [put]
@@ -199,6 +604,12 @@ func (h *BookHandler) HandleUpdateBookByID(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	payload.ISBN10, payload.ISBN13, err = utils.ResolveISBNs(payload.ISBN10, payload.ISBN13)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleUpdateBookByID", types.BadRequestResponse{Error: "ISBN-10 and ISBN-13 do not match"})
+		return
+	}
+
 	book, err := h.bookStore.UpdateByID(r.Context(), id, payload)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
@@ -215,6 +626,22 @@ func (h *BookHandler) HandleUpdateBookByID(w http.ResponseWriter, r *http.Reques
 			utils.WriteError(w, http.StatusNotFound, err, "HandleGetBookByID", types.NotFoundResponse{Error: fmt.Sprintf("No book found with ID %d", id)})
 			return
 		}
+
+		if errors.Is(err, ErrBookPermissionDenied) {
+			utils.WriteError(w, http.StatusForbidden, err, "HandleUpdateBookByID", types.ForbiddenResponse{Error: fmt.Sprintf("You are not allowed to edit book with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, ErrGenreNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleUpdateBookByID", types.BadRequestResponse{Error: "One or more genres do not exist"})
+			return
+		}
+
+		if errors.Is(err, types.ErrBookISBNExists) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleUpdateBookByID", types.ConflictResponse{Error: "Another book with this ISBN is already in the owner's library"})
+			return
+		}
+
 		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUpdateBookByID", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
 		return
 	}
@@ -228,6 +655,9 @@ func (h *BookHandler) HandleUpdateBookByID(w http.ResponseWriter, r *http.Reques
 		ReleaseYear:   book.ReleaseYear,
 		NumberOfPages: book.NumberOfPages,
 		ImageUrl:      book.ImageUrl,
+		ThumbnailUrl:  book.ThumbnailUrl,
+		ISBN10:        book.ISBN10,
+		ISBN13:        book.ISBN13,
 		CreatedAt:     book.CreatedAt,
 		DeletedAt:     book.DeletedAt,
 		UpdatedAt:     book.UpdatedAt,
@@ -242,6 +672,7 @@ func (h *BookHandler) HandleUpdateBookByID(w http.ResponseWriter, r *http.Reques
 // @Param id path int true "ID do livro a ser excluído"
 // @Success 204 "No Content"
 // @Failure 400 {object} types.BadRequestResponse "Book ID must be a positive integer"
+// @Failure 403 {object} types.ForbiddenResponse "Only the owner can delete the book"
 // @Failure 404 {object} types.NotFoundResponse "No book found with given ID"
 // @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
 // @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
@@ -268,14 +699,642 @@ func (h *BookHandler) HandleDeleteBookByID(w http.ResponseWriter, r *http.Reques
 			return
 		}
 
-		if err == sql.ErrNoRows {
+		if err == sql.ErrNoRows || errors.Is(err, ErrBookNotFound) {
 			utils.WriteError(w, http.StatusNotFound, err, "HandleDeleteBookByID", types.NotFoundResponse{Error: fmt.Sprintf("No book found with ID %d", id)})
 			return
 		}
 
+		if errors.Is(err, ErrBookPermissionDenied) {
+			utils.WriteError(w, http.StatusForbidden, err, "HandleDeleteBookByID", types.ForbiddenResponse{Error: fmt.Sprintf("Only the owner can delete book with ID %d", id)})
+			return
+		}
+
 		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDeleteBookByID", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
 		return
 	}
 
 	utils.WriteJSON(w, http.StatusNoContent, nil)
 }
+
+// trashCutoff is the oldest deletion that can still be undone. Anything
+// deleted before it is about to be purged.
+func trashCutoff() time.Time {
+	return time.Now().Add(-time.Duration(config.Envs.TrashRetention) * time.Second)
+}
+
+// @Summary Listar livros na lixeira
+// @Description Lista os livros excluídos pelo usuário que ainda podem ser restaurados, dos mais recentes para os mais antigos. Após o período de retenção eles são removidos definitivamente.
+// @Tags Books
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param page query int false "Página (padrão 1)"
+// @Param limit query int false "Itens por página (padrão 20, máximo 100)"
+// @Success 200 {object} types.GetBooksResponse "Livros na lixeira"
+// @Failure 400 {object} types.BadRequestResponse "Query parameter is not a valid integer"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for query parameters"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/trash [get]
+func (h *BookHandler) HandleGetDeletedBooks(w http.ResponseWriter, r *http.Request) {
+	options := types.GetDeletedBooksOptions{
+		Page:         defaultBooksPage,
+		Limit:        defaultBooksLimit,
+		DeletedAfter: trashCutoff(),
+	}
+
+	err := parseIntQueryParams(
+		r.URL.Query(),
+		intQueryParam{"page", &options.Page},
+		intQueryParam{"limit", &options.Limit},
+	)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetDeletedBooks", types.BadRequestResponse{Error: fmt.Sprintf("Query parameter %s", err.Error())})
+		return
+	}
+
+	if err := validate.Struct(options); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetDeletedBooks", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	books, total, err := h.bookStore.GetDeleted(r.Context(), options)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetDeletedBooks", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetDeletedBooks", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	totalPages := (total + options.Limit - 1) / options.Limit
+	next, prev := buildBooksPageLinks(r, options.Page, totalPages)
+
+	utils.WriteJSON(w, http.StatusOK, types.GetBooksResponse{
+		Books:      books,
+		Total:      total,
+		Page:       options.Page,
+		Limit:      options.Limit,
+		TotalPages: totalPages,
+		Next:       next,
+		Prev:       prev,
+	})
+}
+
+// @Summary Restaurar livro da lixeira
+// @Description Restaura um livro excluído pelo usuário dentro do período de retenção. Livros desativados por um administrador não podem ser restaurados por aqui.
+// @Tags Books
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do livro a ser restaurado"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Book ID must be a positive integer"
+// @Failure 404 {object} types.NotFoundResponse "No deleted book found with given ID"
+// @Failure 409 {object} types.ConflictResponse "Another book with this ISBN is already in your library"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/{id}/restore [post]
+func (h *BookHandler) HandleRestoreBook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleRestoreBook", types.BadRequestResponse{Error: "Book ID must be a positive integer"})
+		return
+	}
+
+	err = h.bookStore.RestoreDeletedByID(r.Context(), id, trashCutoff())
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleRestoreBook", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleRestoreBook", types.NotFoundResponse{Error: fmt.Sprintf("No deleted book found with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, types.ErrBookISBNExists) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleRestoreBook", types.ConflictResponse{Error: "Another book with this ISBN is already in your library"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRestoreBook", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Compartilhar livro
+// @Description Compartilha um livro do usuário com outro usuário, identificado pelo email, como editor ou leitor. Compartilhar de novo com o mesmo usuário altera a permissão dele. Editores podem atualizar o livro, leitores apenas visualizá-lo; só o dono pode excluí-lo.
+// @Tags Books
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do livro a ser compartilhado"
+// @Param request body types.ShareBookPayload true "Email do usuário e permissão"
+// @Success 200 {object} types.BookShare "Compartilhamento criado ou atualizado"
+// @Failure 400 {object} types.BadRequestResponse "Book ID must be a positive integer ou Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 403 {object} types.ForbiddenResponse "Only the owner can share the book"
+// @Failure 404 {object} types.NotFoundResponse "No book or user found"
+// @Failure 409 {object} types.ConflictResponse "The book can't be shared with its owner"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/{id}/shares [post]
+func (h *BookHandler) HandleShareBook(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleShareBook", types.BadRequestResponse{Error: "Book ID must be a positive integer"})
+		return
+	}
+
+	var payload types.ShareBookPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleShareBook", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleShareBook", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	share, err := h.bookStore.ShareByID(r.Context(), id, payload)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleShareBook", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleShareBook", types.NotFoundResponse{Error: fmt.Sprintf("No book found with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, ErrShareUserNotFound) {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleShareBook", types.NotFoundResponse{Error: fmt.Sprintf("No user found with email %s", payload.Email)})
+			return
+		}
+
+		if errors.Is(err, ErrBookPermissionDenied) {
+			utils.WriteError(w, http.StatusForbidden, err, "HandleShareBook", types.ForbiddenResponse{Error: fmt.Sprintf("Only the owner can share book with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, ErrShareWithOwner) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleShareBook", types.ConflictResponse{Error: "The book can't be shared with its owner"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleShareBook", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, share)
+}
+
+// @Summary Listar compartilhamentos do livro
+// @Description Lista quem tem acesso a um livro do usuário, incluindo o próprio dono.
+// @Tags Books
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do livro"
+// @Success 200 {object} types.GetBookSharesResponse "Usuários com acesso ao livro"
+// @Failure 400 {object} types.BadRequestResponse "Book ID must be a positive integer"
+// @Failure 403 {object} types.ForbiddenResponse "Only the owner can list the shares"
+// @Failure 404 {object} types.NotFoundResponse "No book found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/{id}/shares [get]
+func (h *BookHandler) HandleGetBookShares(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetBookShares", types.BadRequestResponse{Error: "Book ID must be a positive integer"})
+		return
+	}
+
+	shares, err := h.bookStore.GetSharesByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetBookShares", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleGetBookShares", types.NotFoundResponse{Error: fmt.Sprintf("No book found with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, ErrBookPermissionDenied) {
+			utils.WriteError(w, http.StatusForbidden, err, "HandleGetBookShares", types.ForbiddenResponse{Error: fmt.Sprintf("Only the owner can list the shares of book with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetBookShares", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetBookSharesResponse{Shares: shares})
+}
+
+// @Summary Revogar compartilhamento do livro
+// @Description Remove o acesso de um usuário a um livro. O dono pode revogar qualquer compartilhamento, e um usuário pode remover o próprio acesso a um livro compartilhado com ele.
+// @Tags Books
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do livro"
+// @Param userId path int true "ID do usuário que perderá o acesso"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Book ID or user ID must be a positive integer"
+// @Failure 404 {object} types.NotFoundResponse "No share found for the given book and user"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/{id}/shares/{userId} [delete]
+func (h *BookHandler) HandleRevokeBookShare(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleRevokeBookShare", types.BadRequestResponse{Error: "Book ID must be a positive integer"})
+		return
+	}
+
+	userID, err := strconv.Atoi(vars["userId"])
+	if err != nil || userID <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleRevokeBookShare", types.BadRequestResponse{Error: "User ID must be a positive integer"})
+		return
+	}
+
+	err = h.bookStore.RevokeShareByID(r.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleRevokeBookShare", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleRevokeBookShare", types.NotFoundResponse{Error: fmt.Sprintf("No share of book %d found for user %d", id, userID)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleRevokeBookShare", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Listar autores do livro
+// @Description Lista os autores creditados no livro, com o papel de cada um, na ordem em que são creditados.
+// @Tags Books
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do livro"
+// @Success 200 {object} types.GetBookAuthorsResponse "Autores do livro"
+// @Failure 400 {object} types.BadRequestResponse "Book ID must be a positive integer"
+// @Failure 404 {object} types.NotFoundResponse "No book found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/{id}/authors [get]
+func (h *BookHandler) HandleGetBookAuthors(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetBookAuthors", types.BadRequestResponse{Error: "Book ID must be a positive integer"})
+		return
+	}
+
+	authors, err := h.bookStore.GetAuthorsByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetBookAuthors", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleGetBookAuthors", types.NotFoundResponse{Error: fmt.Sprintf("No book found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetBookAuthors", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetBookAuthorsResponse{Authors: authors})
+}
+
+// @Summary Definir autores do livro
+// @Description Substitui os autores creditados no livro, na ordem enviada. O campo author do livro passa a listar os nomes dos que têm o papel author. Donos e editores do livro podem alterá-los.
+// @Tags Books
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do livro"
+// @Param request body types.SetBookAuthorsPayload true "Autores e seus papéis"
+// @Success 200 {object} types.GetBookAuthorsResponse "Autores do livro"
+// @Failure 400 {object} types.BadRequestResponse "Book ID must be a positive integer ou Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 403 {object} types.ForbiddenResponse "The book is shared with the user as viewer"
+// @Failure 404 {object} types.NotFoundResponse "No book or author found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/{id}/authors [put]
+func (h *BookHandler) HandleSetBookAuthors(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleSetBookAuthors", types.BadRequestResponse{Error: "Book ID must be a positive integer"})
+		return
+	}
+
+	var payload types.SetBookAuthorsPayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleSetBookAuthors", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(payload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleSetBookAuthors", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	authors, err := h.bookStore.SetAuthorsByID(r.Context(), id, payload.Authors)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleSetBookAuthors", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleSetBookAuthors", types.NotFoundResponse{Error: fmt.Sprintf("No book found with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, ErrAuthorNotFound) {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleSetBookAuthors", types.NotFoundResponse{Error: "One or more authors were not found"})
+			return
+		}
+
+		if errors.Is(err, ErrBookPermissionDenied) {
+			utils.WriteError(w, http.StatusForbidden, err, "HandleSetBookAuthors", types.ForbiddenResponse{Error: fmt.Sprintf("You are not allowed to edit book with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleSetBookAuthors", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetBookAuthorsResponse{Authors: authors})
+}
+
+// @Summary Enviar capa do livro
+// @Description Envia a imagem da capa do livro (JPEG, PNG ou GIF), que é convertida em JPEG em tamanho completo (até 1200x1800) e em miniatura (até 200x300). O tipo da imagem é detectado pelo conteúdo, não pelo nome ou cabeçalho do arquivo. image_url e thumbnail_url do livro passam a apontar para as capas enviadas. Donos e editores do livro podem alterá-la.
+// @Tags Books
+// @Security BearerAuth
+// @Accept multipart/form-data
+// @Produce json
+// @Param id path int true "ID do livro"
+// @Param cover formData file true "Imagem da capa"
+// @Success 200 {object} types.BookCover "URLs da capa"
+// @Failure 400 {object} types.BadRequestResponse "Book ID must be a positive integer, body is not multipart or has no cover file, or the image could not be read"
+// @Failure 403 {object} types.ForbiddenResponse "The book is shared with the user as viewer"
+// @Failure 404 {object} types.NotFoundResponse "No book found with given ID or cover uploads are not enabled"
+// @Failure 413 {object} types.PayloadTooLargeResponse "The cover is too large"
+// @Failure 415 {object} types.UnsupportedMediaTypeResponse "The cover must be a JPEG, PNG or GIF image"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /books/{id}/cover [put]
+func (h *BookHandler) HandleUploadBookCover(w http.ResponseWriter, r *http.Request) {
+	if h.covers == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("cover storage is not configured"), "HandleUploadBookCover", types.NotFoundResponse{Error: "Cover uploads are not enabled"})
+		return
+	}
+
+	vars := mux.Vars(r)
+	idStr := vars["id"]
+
+	id, err := strconv.Atoi(idStr)
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleUploadBookCover", types.BadRequestResponse{Error: "Book ID must be a positive integer"})
+		return
+	}
+
+	// Decoding an image is costly, so only those who may change the cover
+	// get their upload read. SetCoverByID checks again when storing it.
+	if err := h.bookStore.CheckCanEditByID(r.Context(), id); err != nil {
+		writeUploadCoverError(w, id, err)
+		return
+	}
+
+	// The multipart envelope around the file takes a few more bytes.
+	r.Body = http.MaxBytesReader(w, r.Body, config.Envs.CoverMaxBytes+64*1024)
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleUploadBookCover", types.BadRequestResponse{Error: "Body must be multipart/form-data"})
+		return
+	}
+
+	data, err := readCoverPart(reader, config.Envs.CoverMaxBytes)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleUploadBookCover", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if errors.Is(err, ErrCoverTooLarge) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, err, "HandleUploadBookCover", types.PayloadTooLargeResponse{Error: fmt.Sprintf("The cover must be at most %d bytes", config.Envs.CoverMaxBytes)})
+			return
+		}
+
+		if errors.Is(err, ErrCoverMissing) {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleUploadBookCover", types.BadRequestResponse{Error: "The cover field with the image file is missing"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleUploadBookCover", types.BadRequestResponse{Error: "Body must be multipart/form-data"})
+		return
+	}
+
+	full, thumbnail, err := processCover(data)
+	if err != nil {
+		if errors.Is(err, ErrCoverUnsupportedType) {
+			utils.WriteError(w, http.StatusUnsupportedMediaType, err, "HandleUploadBookCover", types.UnsupportedMediaTypeResponse{Error: "The cover must be a JPEG, PNG or GIF image"})
+			return
+		}
+
+		if errors.Is(err, ErrCoverTooLarge) {
+			utils.WriteError(w, http.StatusRequestEntityTooLarge, err, "HandleUploadBookCover", types.PayloadTooLargeResponse{Error: fmt.Sprintf("The cover must be at most %d megapixels", coverMaxPixels/1_000_000)})
+			return
+		}
+
+		if errors.Is(err, ErrCoverInvalid) {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleUploadBookCover", types.BadRequestResponse{Error: "The cover could not be read as an image"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUploadBookCover", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	cover, err := h.storeCover(r.Context(), id, full, thumbnail)
+	if err != nil {
+		writeUploadCoverError(w, id, err)
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, cover)
+}
+
+func writeUploadCoverError(w http.ResponseWriter, id int, err error) {
+	if errors.Is(err, context.Canceled) {
+		utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleUploadBookCover", types.ContextCanceledResponse{Error: "Request canceled"})
+		return
+	}
+
+	if err == sql.ErrNoRows {
+		utils.WriteError(w, http.StatusNotFound, err, "HandleUploadBookCover", types.NotFoundResponse{Error: fmt.Sprintf("No book found with ID %d", id)})
+		return
+	}
+
+	if errors.Is(err, ErrBookPermissionDenied) {
+		utils.WriteError(w, http.StatusForbidden, err, "HandleUploadBookCover", types.ForbiddenResponse{Error: fmt.Sprintf("You are not allowed to edit book with ID %d", id)})
+		return
+	}
+
+	utils.WriteError(w, http.StatusInternalServerError, err, "HandleUploadBookCover", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+}
+
+// storeCover saves both sizes of a new cover and points the book at them. The
+// blobs of the cover it replaces are removed; so are the new ones when the
+// book can't be updated.
+func (h *BookHandler) storeCover(ctx context.Context, bookID int, full []byte, thumbnail []byte) (*types.BookCover, error) {
+	fullKey, thumbnailKey, err := coverKeys(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := h.covers.Put(ctx, fullKey, full, "image/jpeg"); err != nil {
+		return nil, err
+	}
+	if err := h.covers.Put(ctx, thumbnailKey, thumbnail, "image/jpeg"); err != nil {
+		utils.DeleteCoverBlobs(ctx, h.covers, fullKey)
+		return nil, err
+	}
+
+	cover := types.BookCover{ImageUrl: utils.CoverURL(fullKey), ThumbnailUrl: utils.CoverURL(thumbnailKey)}
+	previous, err := h.bookStore.SetCoverByID(ctx, bookID, cover)
+	if err != nil {
+		utils.DeleteCoverBlobs(ctx, h.covers, fullKey, thumbnailKey)
+		return nil, err
+	}
+
+	utils.DeleteCoverBlobs(ctx, h.covers, utils.CoverKeys(bookID, previous.ImageUrl, previous.ThumbnailUrl)...)
+
+	return &cover, nil
+}
+
+// @Summary Obter capa de livro
+// @Description Devolve uma capa enviada por PUT /books/{id}/cover. Cada envio gera uma URL nova, então a resposta pode ser guardada em cache indefinidamente.
+// @Tags Books
+// @Produce jpeg
+// @Param key path string true "Caminho da capa, como aparece em image_url e thumbnail_url"
+// @Success 200 {file} binary "Imagem da capa"
+// @Success 304 "A capa não mudou desde a versão em cache (If-None-Match)"
+// @Failure 404 {object} types.NotFoundResponse "No cover found"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /covers/{key} [get]
+func (h *BookHandler) HandleGetCover(w http.ResponseWriter, r *http.Request) {
+	if h.covers == nil {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("cover storage is not configured"), "HandleGetCover", types.NotFoundResponse{Error: "No cover found"})
+		return
+	}
+
+	key := mux.Vars(r)["key"]
+	if !utils.IsCoverKey(key) {
+		utils.WriteError(w, http.StatusNotFound, fmt.Errorf("%q is not a cover key", key), "HandleGetCover", types.NotFoundResponse{Error: "No cover found"})
+		return
+	}
+
+	// Blobs are never rewritten under the same key, so the key alone
+	// identifies the content.
+	sum := sha256.Sum256([]byte(key))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if r.Header.Get("If-None-Match") == etag {
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	body, info, err := h.covers.Get(r.Context(), key)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetCover", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if errors.Is(err, types.ErrBlobNotFound) {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleGetCover", types.NotFoundResponse{Error: "No cover found"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetCover", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", info.ContentType)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	if info.Size > 0 {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size, 10))
+	}
+	if !info.LastModified.IsZero() {
+		w.Header().Set("Last-Modified", info.LastModified.UTC().Format(http.TimeFormat))
+	}
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, body); err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"key":   key,
+			"error": err.Error(),
+		}).Error("Failed to send a book cover")
+	}
+}
@@ -6,14 +6,26 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
 	"io"
+	"io/fs"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/blobstore"
 	"github.com/hoyci/book-store-api/cmd/api"
+	"github.com/hoyci/book-store-api/config"
+	"github.com/hoyci/book-store-api/metadata"
 	"github.com/hoyci/book-store-api/mocks"
 	"github.com/hoyci/book-store-api/service/book"
 	"github.com/hoyci/book-store-api/types"
@@ -25,9 +37,9 @@ import (
 func TestHandleCreateBook(t *testing.T) {
 	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
 		mockBookStore := new(mocks.MockBookStore)
-		mockBookHandler := book.NewBookHandler(mockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
 		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
 		ts := httptest.NewServer(router)
 		return mockBookStore, ts, router
 	}
@@ -67,7 +79,7 @@ func TestHandleCreateBook(t *testing.T) {
 		payload := types.CreateBookPayload{
 			Description:   "A book about Go programming",
 			Author:        "John Doe",
-			Genres:        []string{"Programming"},
+			GenreIDs:      []int{3},
 			ReleaseYear:   2024,
 			NumberOfPages: 300,
 			ImageUrl:      "http://example.com/go.jpg",
@@ -110,7 +122,7 @@ func TestHandleCreateBook(t *testing.T) {
 			Name:          "Go Programming",
 			Description:   "A book about Go programming",
 			Author:        "John Doe",
-			Genres:        []string{"Programming"},
+			GenreIDs:      []int{3},
 			ReleaseYear:   2024,
 			NumberOfPages: 300,
 			ImageUrl:      "http://example.com/go.jpg",
@@ -146,7 +158,7 @@ func TestHandleCreateBook(t *testing.T) {
 			Name:          "Go Programming",
 			Description:   "A book about Go programming",
 			Author:        "John Doe",
-			Genres:        []string{"Programming"},
+			GenreIDs:      []int{3},
 			ReleaseYear:   2024,
 			NumberOfPages: 300,
 			ImageUrl:      "http://example.com/go.jpg",
@@ -180,7 +192,7 @@ func TestHandleCreateBook(t *testing.T) {
 			Name:          "Go Programming",
 			Description:   "A book about Go programming",
 			Author:        "John Doe",
-			Genres:        []string{"Programming"},
+			GenreIDs:      []int{3},
 			ReleaseYear:   2024,
 			NumberOfPages: 300,
 			ImageUrl:      "http://example.com/go.jpg",
@@ -218,7 +230,7 @@ func TestHandleCreateBook(t *testing.T) {
 			Name:          "Go Programming",
 			Description:   "A book about Go programming",
 			Author:        "John Doe",
-			Genres:        []string{"Programming"},
+			GenreIDs:      []int{3},
 			ReleaseYear:   2024,
 			NumberOfPages: 300,
 			ImageUrl:      "http://example.com/go.jpg",
@@ -244,14 +256,230 @@ func TestHandleCreateBook(t *testing.T) {
 		expectedResponse := `{"id":1}`
 		assert.JSONEq(t, expectedResponse, string(responseBody))
 	})
+
+	t.Run("it should create a book without a cover", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("Create", mock.Anything, mock.MatchedBy(func(payload types.CreateBookPayload) bool {
+			return payload.ImageUrl == ""
+		})).Return(int(1), nil)
+
+		payload := `{"name":"Go Programming","description":"A book about Go programming","author":"John Doe","genre_ids":[3],"release_year":2024,"number_of_pages":300}`
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		mockBookStore.AssertExpectations(t)
+	})
+
+	t.Run("it should throw an error when the ISBN check digit is wrong", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload := types.CreateBookPayload{
+			Name:          "Dune",
+			Description:   "A desert planet",
+			Author:        "Frank Herbert",
+			GenreIDs:      []int{2},
+			ReleaseYear:   1965,
+			NumberOfPages: 412,
+			ImageUrl:      "http://example.com/dune.jpg",
+			ISBN10:        "0-441-01359-8",
+			ISBN13:        "9780441013594",
+		}
+		marshalled, _ := json.Marshal(payload)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books", bytes.NewBuffer(marshalled))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"error":["Field 'ISBN10' is invalid: isbn","Field 'ISBN13' is invalid: isbn"]}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+		mockBookStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the ISBN-10 and ISBN-13 are different books", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload := types.CreateBookPayload{
+			Name:          "Dune",
+			Description:   "A desert planet",
+			Author:        "Frank Herbert",
+			GenreIDs:      []int{2},
+			ReleaseYear:   1965,
+			NumberOfPages: 412,
+			ImageUrl:      "http://example.com/dune.jpg",
+			ISBN10:        "0306406152",
+			ISBN13:        "9780441013593",
+		}
+		marshalled, _ := json.Marshal(payload)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books", bytes.NewBuffer(marshalled))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"error":"ISBN-10 and ISBN-13 do not match"}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+		mockBookStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should store the ISBN normalized to ISBN-13", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload := types.CreateBookPayload{
+			Name:          "Dune",
+			Description:   "A desert planet",
+			Author:        "Frank Herbert",
+			GenreIDs:      []int{2},
+			ReleaseYear:   1965,
+			NumberOfPages: 412,
+			ImageUrl:      "http://example.com/dune.jpg",
+			ISBN10:        "0-441-01359-7",
+		}
+		normalized := payload
+		normalized.ISBN10 = "0441013597"
+		normalized.ISBN13 = "9780441013593"
+		mockBookStore.On("Create", mock.Anything, normalized).Return(int(2), nil)
+
+		marshalled, _ := json.Marshal(payload)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books", bytes.NewBuffer(marshalled))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		mockBookStore.AssertExpectations(t)
+	})
+
+	t.Run("it should throw an error when the ISBN is already in the library", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("Create", mock.Anything, mock.Anything).Return(int(0), types.ErrBookISBNExists)
+
+		payload := types.CreateBookPayload{
+			Name:          "Dune",
+			Description:   "A desert planet",
+			Author:        "Frank Herbert",
+			GenreIDs:      []int{2},
+			ReleaseYear:   1965,
+			NumberOfPages: 412,
+			ImageUrl:      "http://example.com/dune.jpg",
+			ISBN13:        "978-0-441-01359-3",
+		}
+		marshalled, _ := json.Marshal(payload)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books", bytes.NewBuffer(marshalled))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"error":"A book with this ISBN is already in your library"}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+	})
+
+	t.Run("it should let an API key without scopes create books", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore := new(mocks.MockUserStore)
+		mockUserStore.On("AuthenticateAPIKey", mock.Anything, mock.Anything).Return(
+			&types.APIKeyOwner{KeyID: 1, UserID: 1, Role: types.RoleLibrarian, Scopes: []string{}},
+			nil,
+		)
+		utils.SetAPIKeyAuthenticator(mockUserStore)
+		defer utils.SetAPIKeyAuthenticator(nil)
+
+		mockBookStore.On("Create", mock.Anything, mock.Anything).Return(int(1), nil)
+
+		payload := `{"name":"Go Programming","description":"A book about Go programming","author":"John Doe","genre_ids":[3],"release_year":2024,"number_of_pages":300}`
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+types.APIKeyPrefix+"secret")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		mockBookStore.AssertExpectations(t)
+	})
+
+	t.Run("it should refuse an API key limited to books:read", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore := new(mocks.MockUserStore)
+		mockUserStore.On("AuthenticateAPIKey", mock.Anything, mock.Anything).Return(
+			&types.APIKeyOwner{KeyID: 1, UserID: 1, Role: types.RoleLibrarian, Scopes: []string{types.ScopeBooksRead}},
+			nil,
+		)
+		utils.SetAPIKeyAuthenticator(mockUserStore)
+		defer utils.SetAPIKeyAuthenticator(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books", bytes.NewBuffer([]byte(`{}`)))
+		req.Header.Set("Authorization", "Bearer "+types.APIKeyPrefix+"secret")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		mockBookStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
 }
 
 func TestHandleGetBookByID(t *testing.T) {
 	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
 		mockBookStore := new(mocks.MockBookStore)
-		mockBookHandler := book.NewBookHandler(mockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
 		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
 		ts := httptest.NewServer(router)
 		return mockBookStore, ts, router
 	}
@@ -398,7 +626,7 @@ func TestHandleGetBookByID(t *testing.T) {
 			Name:          "Go Programming",
 			Description:   "A book about Go programming",
 			Author:        "John Doe",
-			Genres:        []string{"Programming"},
+			Genres:        []*types.BookGenre{{ID: 3, Name: "Programming", Slug: "programming"}},
 			ReleaseYear:   2024,
 			NumberOfPages: 300,
 			ImageUrl:      "http://example.com/go.jpg",
@@ -430,10 +658,13 @@ func TestHandleGetBookByID(t *testing.T) {
 			"name": "Go Programming",
 			"description": "A book about Go programming",
 			"author": "John Doe",
-			"genres": ["Programming"],
+			"genres":[{"id": 3, "name": "Programming", "slug": "programming"}],
 			"release_year": 2024,
 			"number_of_pages": 300,
 			"image_url": "http://example.com/go.jpg",
+			"thumbnail_url": null,
+			"isbn_10": null,
+			"isbn_13": null,
 			"created_at": "0001-01-01T00:00:00Z",
 			"deleted_at": null,
 			"updated_at": null
@@ -442,30 +673,58 @@ func TestHandleGetBookByID(t *testing.T) {
 	})
 }
 
-func TestHandleGetManyBooks(t *testing.T) {
-	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+func TestHandleImportBookByISBN(t *testing.T) {
+	setupTestServer := func(metadataProvider types.MetadataProvider) (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
 		mockBookStore := new(mocks.MockBookStore)
-		mockBookHandler := book.NewBookHandler(mockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, metadataProvider, nil)
 		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
 		ts := httptest.NewServer(router)
 		return mockBookStore, ts, router
 	}
 
-	t.Run("it should return error when the request context is canceled", func(t *testing.T) {
-		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		mockBookStore, ts, router := setupTestServer()
+	catalog := metadata.NewFixtureProvider(map[string]types.BookMetadata{
+		"9780441013593": {
+			ISBN13:        "9780441013593",
+			Name:          "Dune",
+			Description:   "A desert planet",
+			Authors:       []string{"Frank Herbert"},
+			ReleaseYear:   2005,
+			NumberOfPages: 528,
+			ImageUrl:      "https://covers.example.com/dune.jpg",
+		},
+		"9780306406157": {
+			ISBN13:        "9780306406157",
+			Name:          "Modern Physics",
+			Authors:       []string{"Hans C. Ohanian"},
+			ReleaseYear:   1987,
+			NumberOfPages: 615,
+		},
+	})
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+
+	t.Run("it should throw an error when importing is not enabled", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer(nil)
 		defer ts.Close()
 
-		canceledCtx, cancel := context.WithCancel(context.Background())
-		cancel()
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/import/isbn", bytes.NewBufferString(`{"isbn":"9780441013593","genre_ids":[1]}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
 
-		mockBookStore.On("GetMany", mock.MatchedBy(func(ctx context.Context) bool {
+		router.ServeHTTP(w, req)
 
-			return ctx.Err() == context.Canceled
-		})).Return([]*types.Book{}, context.Canceled)
+		res := w.Result()
+		defer res.Body.Close()
 
-		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books", nil).WithContext(canceledCtx)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		mockBookStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the catalog has no entry for the ISBN", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer(catalog)
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/import/isbn", bytes.NewBufferString(`{"isbn":"979-12-345-6789-6","genre_ids":[1]}`))
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -474,23 +733,19 @@ func TestHandleGetManyBooks(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
-
-		responseBody, err := io.ReadAll(res.Body)
-		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
 
-		expected := `{"error":"Request canceled"}`
-		assert.JSONEq(t, expected, string(responseBody))
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"error":"No catalog entry found for ISBN 979-12-345-6789-6"}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+		mockBookStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
 	})
 
-	t.Run("it should return error when database is not available", func(t *testing.T) {
-		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		mockBookStore, ts, router := setupTestServer()
+	t.Run("it should report the fields missing from the catalog", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer(catalog)
 		defer ts.Close()
 
-		mockBookStore.On("GetMany", mock.Anything).Return([]*types.Book{}, sql.ErrConnDone)
-
-		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books", nil)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/import/isbn", bytes.NewBufferString(`{"isbn":"0-306-40615-2","genre_ids":[1]}`))
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -499,25 +754,32 @@ func TestHandleGetManyBooks(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
-
-		responseBody, err := io.ReadAll(res.Body)
-		if err != nil {
-			t.Fatalf("Failed to read response body: %v", err)
-		}
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 
-		expected := `{"error":"An unexpected error occurred"}`
-		assert.JSONEq(t, expected, string(responseBody))
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"error":["Field 'Description' is invalid: required"]}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+		mockBookStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
 	})
 
-	t.Run("it should return error when a generic database error occur", func(t *testing.T) {
-		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		mockBookStore, ts, router := setupTestServer()
+	t.Run("it should create the book from the catalog entry", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer(catalog)
 		defer ts.Close()
 
-		mockBookStore.On("GetMany", mock.Anything).Return([]*types.Book{}, errors.New("generic database error"))
-
-		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books", nil)
+		mockBookStore.On("Create", mock.Anything, types.CreateBookPayload{
+			Name:          "Dune",
+			Description:   "A desert planet",
+			Author:        "Frank Herbert",
+			Authors:       []string{"Frank Herbert"},
+			GenreIDs:      []int{2},
+			ReleaseYear:   1965,
+			NumberOfPages: 528,
+			ImageUrl:      "https://covers.example.com/dune.jpg",
+			ISBN10:        "0441013597",
+			ISBN13:        "9780441013593",
+		}).Return(int(5), nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/import/isbn", bytes.NewBufferString(`{"isbn":"0441013597","genre_ids":[2],"release_year":1965}`))
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -526,25 +788,31 @@ func TestHandleGetManyBooks(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
-
-		responseBody, err := io.ReadAll(res.Body)
-		if err != nil {
-			t.Fatalf("Failed to read response body: %v", err)
-		}
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
 
-		expected := `{"error":"An unexpected error occurred"}`
-		assert.JSONEq(t, expected, string(responseBody))
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"id":5}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+		mockBookStore.AssertExpectations(t)
 	})
 
-	t.Run("it should return an empty array of books", func(t *testing.T) {
-		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		mockBookStore, ts, router := setupTestServer()
+	t.Run("it should credit the author sent instead of the catalog's", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer(catalog)
 		defer ts.Close()
 
-		mockBookStore.On("GetMany", mock.Anything).Return([]*types.Book{}, nil)
-
-		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books", nil)
+		mockBookStore.On("Create", mock.Anything, types.CreateBookPayload{
+			Name:          "Dune",
+			Description:   "A desert planet",
+			Author:        "F. Herbert",
+			GenreIDs:      []int{2},
+			ReleaseYear:   2005,
+			NumberOfPages: 528,
+			ImageUrl:      "https://covers.example.com/dune.jpg",
+			ISBN10:        "0441013597",
+			ISBN13:        "9780441013593",
+		}).Return(int(5), nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/import/isbn", bytes.NewBufferString(`{"isbn":"0441013597","genre_ids":[2],"author":"F. Herbert"}`))
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -553,123 +821,45 @@ func TestHandleGetManyBooks(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusOK, res.StatusCode)
-
-		responseBody, err := io.ReadAll(res.Body)
-		if err != nil {
-			t.Fatalf("Failed to read response body: %v", err)
-		}
-
-		expected := `{"books":[]}`
-		assert.JSONEq(t, expected, string(responseBody))
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		mockBookStore.AssertExpectations(t)
 	})
 
-	t.Run("it should return an array of books", func(t *testing.T) {
-		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		mockBookStore, ts, router := setupTestServer()
+	t.Run("it should throw an error when the ISBN is already in the library", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer(catalog)
 		defer ts.Close()
 
-		expectedCreatedAt := time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC)
-
-		expectedBooks := []*types.Book{
-			{
-				ID:            1,
-				Name:          "Go Programming",
-				Description:   "A book about Go programming",
-				Author:        "John Doe",
-				Genres:        []string{"Programming"},
-				ReleaseYear:   2024,
-				NumberOfPages: 300,
-				ImageUrl:      "http://example.com/go.jpg",
-				CreatedAt:     expectedCreatedAt,
-				UpdatedAt:     nil,
-				DeletedAt:     nil,
-			},
-			{
-				ID:            2,
-				Name:          "Clean Code",
-				Description:   "A book about writing clean code",
-				Author:        "Robert C. Martin",
-				Genres:        []string{"Programming", "Best Practices"},
-				ReleaseYear:   2008,
-				NumberOfPages: 464,
-				ImageUrl:      "http://example.com/clean-code.jpg",
-				CreatedAt:     expectedCreatedAt,
-				UpdatedAt:     nil,
-				DeletedAt:     nil,
-			},
-		}
-
-		mockBookStore.On("GetMany", mock.Anything).Return(expectedBooks, nil)
+		mockBookStore.On("Create", mock.Anything, mock.Anything).Return(int(0), types.ErrBookISBNExists)
 
-		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books", nil)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/import/isbn", bytes.NewBufferString(`{"isbn":"9780441013593","genre_ids":[2]}`))
 		req.Header.Set("Authorization", "Bearer "+token)
-
 		w := httptest.NewRecorder()
+
 		router.ServeHTTP(w, req)
 
 		res := w.Result()
 		defer res.Body.Close()
-		assert.Equal(t, http.StatusOK, res.StatusCode)
 
-		responseBody, err := io.ReadAll(res.Body)
-		if err != nil {
-			t.Fatalf("Failed to read response body: %v", err)
-		}
-
-		expectedJSON := `{
-			"books": [
-				{
-					"id": 1,
-					"name": "Go Programming",
-					"description": "A book about Go programming",
-					"author": "John Doe",
-					"genres": ["Programming"],
-					"release_year": 2024,
-					"number_of_pages": 300,
-					"image_url": "http://example.com/go.jpg",
-					"created_at": "0001-01-01T00:00:00Z",
-					"deleted_at": null,
-            		"updated_at": null
-				},
-				{
-					"id": 2,
-					"name": "Clean Code",
-					"description": "A book about writing clean code",
-					"author": "Robert C. Martin",
-					"genres": ["Programming", "Best Practices"],
-					"release_year": 2008,
-					"number_of_pages": 464,
-					"image_url": "http://example.com/clean-code.jpg",
-					"created_at": "0001-01-01T00:00:00Z",
-					"deleted_at": null,
-            		"updated_at": null
-				}
-			]
-		}`
-
-		assert.JSONEq(t, expectedJSON, string(responseBody))
-
-		mockBookStore.AssertExpectations(t)
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
 	})
 }
 
-func TestHandleUpdateBookByID(t *testing.T) {
+func TestHandleGetBookByISBN(t *testing.T) {
 	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
 		mockBookStore := new(mocks.MockBookStore)
-		mockBookHandler := book.NewBookHandler(mockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
 		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
 		ts := httptest.NewServer(router)
 		return mockBookStore, ts, router
 	}
 
-	t.Run("it should throw an error when call endpoint with wrong book ID", func(t *testing.T) {
+	t.Run("it should throw an error when the ISBN is not valid", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		_, ts, router := setupTestServer()
+		mockBookStore, ts, router := setupTestServer()
 		defer ts.Close()
 
-		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/johndoe", nil)
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/isbn/9780441013594", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -680,24 +870,21 @@ func TestHandleUpdateBookByID(t *testing.T) {
 
 		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 
-		responseBody, err := io.ReadAll(res.Body)
-		if err != nil {
-			t.Fatalf("Failed to read response body: %v", err)
-		}
-
-		expectedResponse := `{"error":"Book ID must be a positive integer"}`
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"error": "ISBN is not valid"}`
 		assert.JSONEq(t, expectedResponse, string(responseBody))
+		mockBookStore.AssertNotCalled(t, "GetByISBN", mock.Anything, mock.Anything)
 	})
 
-	t.Run("it should throw an error when no fields are provided for update", func(t *testing.T) {
+	t.Run("it should throw an error when no book has the ISBN", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		_, ts, router := setupTestServer()
+		mockBookStore, ts, router := setupTestServer()
 		defer ts.Close()
 
-		emptyPayload := `{}`
-		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(emptyPayload))
+		mockBookStore.On("GetByISBN", mock.Anything, "9780306406157").Return((*types.Book)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/isbn/0-306-40615-2", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
-		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 
 		router.ServeHTTP(w, req)
@@ -705,34 +892,35 @@ func TestHandleUpdateBookByID(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
-
-		responseBody, err := io.ReadAll(res.Body)
-		if err != nil {
-			t.Fatalf("Failed to read response body: %v", err)
-		}
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
 
-		expectedResponse := `{"error":["Field validation for 'Name' failed on the 'required' tag", "Field validation for 'Description' failed on the 'required' tag", "Field validation for 'Author' failed on the 'required' tag", "Field validation for 'Genres' failed on the 'required' tag", "Field validation for 'ReleaseYear' failed on the 'required' tag", "Field validation for 'NumberOfPages' failed on the 'required' tag", "Field validation for 'ImageUrl' failed on the 'required' tag"]}`
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"error": "No book found with ISBN 0-306-40615-2"}`
 		assert.JSONEq(t, expectedResponse, string(responseBody))
 	})
 
-	t.Run("it should throw an error when body is invalid", func(t *testing.T) {
+	t.Run("it should look the book up by its ISBN-13", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		_, ts, router := setupTestServer()
+		mockBookStore, ts, router := setupTestServer()
 		defer ts.Close()
 
-		invalidPayload := `{
-			"name": "aa",
-			"description": "aaa",
-			"author": "aa",
-			"genres": ["a", "a"],
-			"release_year": 2100,
-			"number_of_pages": 1,
-			"image_url": "random_text"
-		}`
-		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(invalidPayload))
+		isbn10, isbn13 := "0441013597", "9780441013593"
+		mockBookStore.On("GetByISBN", mock.Anything, isbn13).Return(&types.Book{
+			ID:            2,
+			Name:          "Dune",
+			Description:   "A desert planet",
+			Author:        "Frank Herbert",
+			Genres:        []*types.BookGenre{},
+			ReleaseYear:   1965,
+			NumberOfPages: 412,
+			ImageUrl:      "http://example.com/dune.jpg",
+			ISBN10:        &isbn10,
+			ISBN13:        &isbn13,
+			CreatedAt:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/isbn/0441013597", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
-		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 
 		router.ServeHTTP(w, req)
@@ -740,16 +928,23 @@ func TestHandleUpdateBookByID(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
-
-		responseBody, err := io.ReadAll(res.Body)
-		if err != nil {
-			t.Fatalf("Failed to read response body: %v", err)
-		}
+		assert.Equal(t, http.StatusOK, res.StatusCode)
 
-		expectedResponse := `{"error":["Field validation for 'Name' failed on the 'min' tag", "Field validation for 'Description' failed on the 'min' tag", "Field validation for 'Author' failed on the 'min' tag", "Field validation for 'Genres[0]' failed on the 'min' tag", "Field validation for 'Genres[1]' failed on the 'min' tag", "Field validation for 'ReleaseYear' failed on the 'lte' tag", "Field validation for 'ImageUrl' failed on the 'url' tag"]}`
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"id":2,"name":"Dune","description":"A desert planet","author":"Frank Herbert","genres":[],"release_year":1965,"number_of_pages":412,"image_url":"http://example.com/dune.jpg","thumbnail_url":null,"isbn_10":"0441013597","isbn_13":"9780441013593","created_at":"2025-01-01T00:00:00Z","deleted_at":null,"updated_at":null}`
 		assert.JSONEq(t, expectedResponse, string(responseBody))
 	})
+}
+
+func TestHandleGetManyBooks(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
 
 	t.Run("it should return error when the request context is canceled", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
@@ -759,21 +954,12 @@ func TestHandleUpdateBookByID(t *testing.T) {
 		canceledCtx, cancel := context.WithCancel(context.Background())
 		cancel()
 
-		mockBookStore.On("UpdateByID", mock.MatchedBy(func(ctx context.Context) bool {
-			return ctx.Err() == context.Canceled
-		}), 1, mock.Anything).Return(&types.Book{}, context.Canceled)
+		mockBookStore.On("GetMany", mock.MatchedBy(func(ctx context.Context) bool {
 
-		validPayload := `{
-			"name": "book",
-			"description": "a description",
-			"author": "john doe",
-			"genres": ["fiction"],
-			"release_year": 2005,
-			"number_of_pages": 199,
-			"image_url": "http://google.com/randomimage.jpg"
-		}`
+			return ctx.Err() == context.Canceled
+		}), mock.Anything).Return([]*types.Book{}, 0, context.Canceled)
 
-		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(validPayload)).WithContext(canceledCtx)
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books", nil).WithContext(canceledCtx)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -791,24 +977,14 @@ func TestHandleUpdateBookByID(t *testing.T) {
 		assert.JSONEq(t, expected, string(responseBody))
 	})
 
-	t.Run("it should throw a database connection error", func(t *testing.T) {
+	t.Run("it should return error when database is not available", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
 		mockBookStore, ts, router := setupTestServer()
 		defer ts.Close()
 
-		mockBookStore.On("UpdateByID", mock.Anything, mock.Anything, mock.Anything).Return(&types.Book{}, sql.ErrConnDone)
-
-		validPayload := `{
-			"name": "book",
-			"description": "a description",
-			"author": "john doe",
-			"genres": ["fiction"],
-			"release_year": 2005,
-			"number_of_pages": 199,
-			"image_url": "http://google.com/randomimage.jpg"
-		}`
+		mockBookStore.On("GetMany", mock.Anything, mock.Anything).Return([]*types.Book{}, 0, sql.ErrConnDone)
 
-		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(validPayload))
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -819,30 +995,24 @@ func TestHandleUpdateBookByID(t *testing.T) {
 
 		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 
-		responseBody, _ := io.ReadAll(res.Body)
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
 		expected := `{"error":"An unexpected error occurred"}`
 		assert.JSONEq(t, expected, string(responseBody))
 	})
 
-	t.Run("it should throw an error when call endpoint with a non-existent user ID", func(t *testing.T) {
+	t.Run("it should return error when a generic database error occur", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
 		mockBookStore, ts, router := setupTestServer()
 		defer ts.Close()
 
-		mockBookStore.On("UpdateByID", mock.Anything, 1, mock.Anything).Return(&types.Book{}, sql.ErrNoRows)
+		mockBookStore.On("GetMany", mock.Anything, mock.Anything).Return([]*types.Book{}, 0, errors.New("generic database error"))
 
-		validPayload := `{
-			"name": "book",
-			"description": "a description",
-			"author": "john doe",
-			"genres": ["fiction"],
-			"release_year": 2005,
-			"number_of_pages": 199,
-			"image_url": "http://google.com/randomimage.jpg"
-		}`
-		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(validPayload))
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
-		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 
 		router.ServeHTTP(w, req)
@@ -850,50 +1020,26 @@ func TestHandleUpdateBookByID(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
 
 		responseBody, err := io.ReadAll(res.Body)
 		if err != nil {
 			t.Fatalf("Failed to read response body: %v", err)
 		}
 
-		expectedResponse := `{"error": "No book found with ID 1"}`
-		assert.JSONEq(t, expectedResponse, string(responseBody))
+		expected := `{"error":"An unexpected error occurred"}`
+		assert.JSONEq(t, expected, string(responseBody))
 	})
 
-	t.Run("it should return successfully status and body when the book is updated", func(t *testing.T) {
+	t.Run("it should return an empty array of books", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
 		mockBookStore, ts, router := setupTestServer()
 		defer ts.Close()
 
-		mockedDate := time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC)
-
-		mockBookStore.On("UpdateByID", mock.Anything, 1, mock.Anything).Return(&types.Book{
-			ID:            1,
-			Name:          "Go Programming - Updated",
-			Description:   "Updated description",
-			Author:        "John Doe",
-			Genres:        []string{"Programming", "Go"},
-			ReleaseYear:   2024,
-			NumberOfPages: 350,
-			ImageUrl:      "http://example.com/go_updated.jpg",
-			CreatedAt:     time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
-			DeletedAt:     nil,
-			UpdatedAt:     &mockedDate,
-		}, nil)
+		mockBookStore.On("GetMany", mock.Anything, mock.Anything).Return([]*types.Book{}, 0, nil)
 
-		validPayload := `{
-			"name": "Go Programming - Updated",
-			"description": "Updated description",
-			"author": "John Doe",
-			"genres": ["Programming", "Go"],
-			"release_year": 2024,
-			"number_of_pages": 350,
-			"image_url": "http://example.com/go_updated.jpg"
-		}`
-		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(validPayload))
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
-		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 
 		router.ServeHTTP(w, req)
@@ -908,86 +1054,146 @@ func TestHandleUpdateBookByID(t *testing.T) {
 			t.Fatalf("Failed to read response body: %v", err)
 		}
 
-		expectedResponse := `{
-			"id": 1,
-			"name": "Go Programming - Updated",
-			"description": "Updated description",
-			"author": "John Doe",
-			"genres": ["Programming", "Go"],
-			"release_year": 2024,
-			"number_of_pages": 350,
-			"image_url": "http://example.com/go_updated.jpg",
-			"created_at": "0001-01-01T00:00:00Z",
-			"updated_at": "0001-01-01T00:00:00Z",
-			"deleted_at": null 
-		}`
-		assert.JSONEq(t, expectedResponse, string(responseBody))
+		expected := `{"books":[],"total":0,"page":1,"limit":20,"total_pages":0,"next":null,"prev":null}`
+		assert.JSONEq(t, expected, string(responseBody))
 	})
-}
-
-func TestHandleDeleteBookByID(t *testing.T) {
-	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
-		mockBookStore := new(mocks.MockBookStore)
-		mockBookHandler := book.NewBookHandler(mockBookStore)
-		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil)
-		ts := httptest.NewServer(router)
-		return mockBookStore, ts, router
-	}
 
-	t.Run("it should throw an error when call endpoint without book ID", func(t *testing.T) {
-		_, ts, router := setupTestServer()
+	t.Run("it should return an array of books", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
 		defer ts.Close()
 
-		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books", nil)
-		w := httptest.NewRecorder()
-
-		router.ServeHTTP(w, req)
-
-		res := w.Result()
-		defer res.Body.Close()
+		expectedCreatedAt := time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC)
 
-		assert.Equal(t, http.StatusNotFound, res.StatusCode)
-	})
+		expectedBooks := []*types.Book{
+			{
+				ID:            1,
+				Name:          "Go Programming",
+				Description:   "A book about Go programming",
+				Author:        "John Doe",
+				Genres:        []*types.BookGenre{{ID: 3, Name: "Programming", Slug: "programming"}},
+				ReleaseYear:   2024,
+				NumberOfPages: 300,
+				ImageUrl:      "http://example.com/go.jpg",
+				CreatedAt:     expectedCreatedAt,
+				UpdatedAt:     nil,
+				DeletedAt:     nil,
+			},
+			{
+				ID:            2,
+				Name:          "Clean Code",
+				Description:   "A book about writing clean code",
+				Author:        "Robert C. Martin",
+				Genres:        []*types.BookGenre{{ID: 3, Name: "Programming", Slug: "programming"}, {ID: 5, Name: "Best Practices", Slug: "best-practices"}},
+				ReleaseYear:   2008,
+				NumberOfPages: 464,
+				ImageUrl:      "http://example.com/clean-code.jpg",
+				CreatedAt:     expectedCreatedAt,
+				UpdatedAt:     nil,
+				DeletedAt:     nil,
+			},
+		}
 
-	t.Run("it should throw an error when call endpoint with wrong ID", func(t *testing.T) {
-		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		_, ts, router := setupTestServer()
-		defer ts.Close()
+		mockBookStore.On("GetMany", mock.Anything, types.GetBooksOptions{
+			Page:  1,
+			Limit: 20,
+			Sort:  "created_at",
+			Order: "asc",
+		}).Return(expectedBooks, 2, nil)
 
-		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/johndoe", nil)
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
-		w := httptest.NewRecorder()
 
+		w := httptest.NewRecorder()
 		router.ServeHTTP(w, req)
 
 		res := w.Result()
 		defer res.Body.Close()
-
-		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
 
 		responseBody, err := io.ReadAll(res.Body)
 		if err != nil {
 			t.Fatalf("Failed to read response body: %v", err)
 		}
 
-		expectedResponse := `{"error":"Book ID must be a positive integer"}`
-		assert.JSONEq(t, expectedResponse, string(responseBody))
-	})
-
-	t.Run("it should return error when the request context is canceled", func(t *testing.T) {
-		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		mockBookStore, ts, router := setupTestServer()
+		expectedJSON := `{
+			"books": [
+				{
+					"id": 1,
+					"name": "Go Programming",
+					"description": "A book about Go programming",
+					"author": "John Doe",
+					"genres":[{"id": 3, "name": "Programming", "slug": "programming"}],
+					"release_year": 2024,
+					"number_of_pages": 300,
+					"image_url": "http://example.com/go.jpg",
+					"thumbnail_url": null,
+					"isbn_10": null,
+					"isbn_13": null,
+					"created_at": "0001-01-01T00:00:00Z",
+					"deleted_at": null,
+            		"updated_at": null
+				},
+				{
+					"id": 2,
+					"name": "Clean Code",
+					"description": "A book about writing clean code",
+					"author": "Robert C. Martin",
+					"genres":[{"id": 3, "name": "Programming", "slug": "programming"}, {"id": 5, "name": "Best Practices", "slug": "best-practices"}],
+					"release_year": 2008,
+					"number_of_pages": 464,
+					"image_url": "http://example.com/clean-code.jpg",
+					"thumbnail_url": null,
+					"isbn_10": null,
+					"isbn_13": null,
+					"created_at": "0001-01-01T00:00:00Z",
+					"deleted_at": null,
+            		"updated_at": null
+				}
+			],
+			"total": 2,
+			"page": 1,
+			"limit": 20,
+			"total_pages": 1,
+			"next": null,
+			"prev": null
+		}`
+
+		assert.JSONEq(t, expectedJSON, string(responseBody))
+
+		mockBookStore.AssertExpectations(t)
+	})
+	t.Run("it should throw an error when a query parameter is not an integer", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		_, ts, router := setupTestServer()
 		defer ts.Close()
 
-		canceledCtx, cancel := context.WithCancel(context.Background())
-		cancel()
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books?page=abc", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
 
-		mockBookStore.On("DeleteByID", mock.MatchedBy(func(ctx context.Context) bool {
-			return ctx.Err() == context.Canceled
-		}), mock.Anything).Return(context.Canceled)
+		router.ServeHTTP(w, req)
 
-		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil).WithContext(canceledCtx)
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		expected := `{"error":"Query parameter 'page' must be an integer"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when query parameters are out of range", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books?limit=500&sort=author", nil)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -996,23 +1202,55 @@ func TestHandleDeleteBookByID(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 
 		responseBody, err := io.ReadAll(res.Body)
-		assert.NoError(t, err)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
 
-		expected := `{"error":"Request canceled"}`
+		expected := `{"error":["Field 'Limit' is invalid: lte","Field 'Sort' is invalid: oneof"]}`
 		assert.JSONEq(t, expected, string(responseBody))
 	})
 
-	t.Run("it should throw a database connection error", func(t *testing.T) {
+	t.Run("it should forward filters to the store and return pagination links", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
 		mockBookStore, ts, router := setupTestServer()
 		defer ts.Close()
 
-		mockBookStore.On("DeleteByID", mock.Anything, mock.Anything).Return(sql.ErrConnDone)
+		expectedOptions := types.GetBooksOptions{
+			Page:           2,
+			Limit:          1,
+			Author:         "Martin",
+			Genre:          "Programming",
+			MinReleaseYear: 2000,
+			MaxReleaseYear: 2010,
+			MinPages:       100,
+			MaxPages:       500,
+			Sort:           "release_year",
+			Order:          "desc",
+		}
+		expectedBooks := []*types.Book{
+			{
+				ID:            2,
+				Name:          "Clean Code",
+				Description:   "A book about writing clean code",
+				Author:        "Robert C. Martin",
+				Genres:        []*types.BookGenre{{ID: 3, Name: "Programming", Slug: "programming"}, {ID: 5, Name: "Best Practices", Slug: "best-practices"}},
+				ReleaseYear:   2008,
+				NumberOfPages: 464,
+				ImageUrl:      "http://example.com/clean-code.jpg",
+				CreatedAt:     time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
+			},
+		}
 
-		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil)
+		mockBookStore.On("GetMany", mock.Anything, expectedOptions).Return(expectedBooks, 3, nil)
+
+		req := httptest.NewRequest(
+			http.MethodGet,
+			ts.URL+"/api/v1/books?page=2&limit=1&author=Martin&genre=Programming&release_year_min=2000&release_year_max=2010&pages_min=100&pages_max=500&sort=release_year&order=desc",
+			nil,
+		)
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -1021,22 +1259,68 @@ func TestHandleDeleteBookByID(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+		assert.Equal(t, http.StatusOK, res.StatusCode)
 
-		responseBody, _ := io.ReadAll(res.Body)
-		expected := `{"error":"An unexpected error occurred"}`
-		assert.JSONEq(t, expected, string(responseBody))
+		var response types.GetBooksResponse
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+
+		assert.Equal(t, 3, response.Total)
+		assert.Equal(t, 2, response.Page)
+		assert.Equal(t, 1, response.Limit)
+		assert.Equal(t, 3, response.TotalPages)
+		assert.Len(t, response.Books, 1)
+		assert.Equal(t, "/api/v1/books?author=Martin&genre=Programming&limit=1&order=desc&page=3&pages_max=500&pages_min=100&release_year_max=2010&release_year_min=2000&sort=release_year", *response.Next)
+		assert.Equal(t, "/api/v1/books?author=Martin&genre=Programming&limit=1&order=desc&page=1&pages_max=500&pages_min=100&release_year_max=2010&release_year_min=2000&sort=release_year", *response.Prev)
+
+		mockBookStore.AssertExpectations(t)
 	})
+}
 
-	t.Run("it should throw an error when call endpoint with a non-existent user ID", func(t *testing.T) {
+func TestHandleUpdateBookByID(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	t.Run("it should throw an error when call endpoint with wrong book ID", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
-		mockBookStore, ts, router := setupTestServer()
+		_, ts, router := setupTestServer()
 		defer ts.Close()
 
-		mockBookStore.On("DeleteByID", mock.Anything, mock.Anything).Return(sql.ErrNoRows)
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/johndoe", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
 
-		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil)
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		expectedResponse := `{"error":"Book ID must be a positive integer"}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+	})
+
+	t.Run("it should throw an error when no fields are provided for update", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		emptyPayload := `{}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(emptyPayload))
 		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 
 		router.ServeHTTP(w, req)
@@ -1044,25 +1328,110 @@ func TestHandleDeleteBookByID(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
 
 		responseBody, err := io.ReadAll(res.Body)
 		if err != nil {
 			t.Fatalf("Failed to read response body: %v", err)
 		}
 
-		expectedResponse := `{"error": "No book found with ID 1"}`
+		expectedResponse := `{"error":["Field validation for 'Name' failed on the 'required' tag", "Field validation for 'Description' failed on the 'required' tag", "Field validation for 'Author' failed on the 'required' tag", "Field validation for 'GenreIDs' failed on the 'required' tag", "Field validation for 'ReleaseYear' failed on the 'required' tag", "Field validation for 'NumberOfPages' failed on the 'required' tag"]}`
 		assert.JSONEq(t, expectedResponse, string(responseBody))
 	})
 
-	t.Run("it should return error when a generic database error occur", func(t *testing.T) {
+	t.Run("it should throw an error when body is invalid", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		invalidPayload := `{
+			"name": "aa",
+			"description": "aaa",
+			"author": "aa",
+			"genre_ids": [0, -1],
+			"release_year": 2100,
+			"number_of_pages": 1,
+			"image_url": "random_text"
+		}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(invalidPayload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		expectedResponse := `{"error":["Field validation for 'Name' failed on the 'min' tag", "Field validation for 'Description' failed on the 'min' tag", "Field validation for 'Author' failed on the 'min' tag", "Field validation for 'GenreIDs[0]' failed on the 'gte' tag", "Field validation for 'GenreIDs[1]' failed on the 'gte' tag", "Field validation for 'ReleaseYear' failed on the 'lte' tag", "Field validation for 'ImageUrl' failed on the 'url' tag"]}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+	})
+
+	t.Run("it should return error when the request context is canceled", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
 		mockBookStore, ts, router := setupTestServer()
 		defer ts.Close()
 
-		mockBookStore.On("DeleteByID", mock.Anything, mock.Anything).Return(errors.New("generic database error"))
+		canceledCtx, cancel := context.WithCancel(context.Background())
+		cancel()
 
-		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil)
+		mockBookStore.On("UpdateByID", mock.MatchedBy(func(ctx context.Context) bool {
+			return ctx.Err() == context.Canceled
+		}), 1, mock.Anything).Return(&types.Book{}, context.Canceled)
+
+		validPayload := `{
+			"name": "book",
+			"description": "a description",
+			"author": "john doe",
+			"genre_ids": [1],
+			"release_year": 2005,
+			"number_of_pages": 199,
+			"image_url": "http://google.com/randomimage.jpg"
+		}`
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(validPayload)).WithContext(canceledCtx)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+
+		expected := `{"error":"Request canceled"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw a database connection error", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("UpdateByID", mock.Anything, mock.Anything, mock.Anything).Return(&types.Book{}, sql.ErrConnDone)
+
+		validPayload := `{
+			"name": "book",
+			"description": "a description",
+			"author": "john doe",
+			"genre_ids": [1],
+			"release_year": 2005,
+			"number_of_pages": 199,
+			"image_url": "http://google.com/randomimage.jpg"
+		}`
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(validPayload))
 		req.Header.Set("Authorization", "Bearer "+token)
 		w := httptest.NewRecorder()
 
@@ -1078,15 +1447,25 @@ func TestHandleDeleteBookByID(t *testing.T) {
 		assert.JSONEq(t, expected, string(responseBody))
 	})
 
-	t.Run("it should return the correct status", func(t *testing.T) {
+	t.Run("it should throw an error when call endpoint with a non-existent user ID", func(t *testing.T) {
 		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
 		mockBookStore, ts, router := setupTestServer()
 		defer ts.Close()
 
-		mockBookStore.On("DeleteByID", mock.Anything, mock.Anything).Return(nil)
+		mockBookStore.On("UpdateByID", mock.Anything, 1, mock.Anything).Return(&types.Book{}, sql.ErrNoRows)
 
-		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil)
+		validPayload := `{
+			"name": "book",
+			"description": "a description",
+			"author": "john doe",
+			"genre_ids": [1],
+			"release_year": 2005,
+			"number_of_pages": 199,
+			"image_url": "http://google.com/randomimage.jpg"
+		}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(validPayload))
 		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
 		w := httptest.NewRecorder()
 
 		router.ServeHTTP(w, req)
@@ -1094,6 +1473,1387 @@ func TestHandleDeleteBookByID(t *testing.T) {
 		res := w.Result()
 		defer res.Body.Close()
 
-		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		expectedResponse := `{"error": "No book found with ID 1"}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+	})
+
+	t.Run("it should return successfully status and body when the book is updated", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockedDate := time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC)
+
+		mockBookStore.On("UpdateByID", mock.Anything, 1, mock.Anything).Return(&types.Book{
+			ID:            1,
+			Name:          "Go Programming - Updated",
+			Description:   "Updated description",
+			Author:        "John Doe",
+			Genres:        []*types.BookGenre{{ID: 3, Name: "Programming", Slug: "programming"}, {ID: 8, Name: "Go", Slug: "go"}},
+			ReleaseYear:   2024,
+			NumberOfPages: 350,
+			ImageUrl:      "http://example.com/go_updated.jpg",
+			CreatedAt:     time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
+			DeletedAt:     nil,
+			UpdatedAt:     &mockedDate,
+		}, nil)
+
+		validPayload := `{
+			"name": "Go Programming - Updated",
+			"description": "Updated description",
+			"author": "John Doe",
+			"genre_ids": [3, 8],
+			"release_year": 2024,
+			"number_of_pages": 350,
+			"image_url": "http://example.com/go_updated.jpg"
+		}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1", bytes.NewBufferString(validPayload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		expectedResponse := `{
+			"id": 1,
+			"name": "Go Programming - Updated",
+			"description": "Updated description",
+			"author": "John Doe",
+			"genres":[{"id": 3, "name": "Programming", "slug": "programming"}, {"id": 8, "name": "Go", "slug": "go"}],
+			"release_year": 2024,
+			"number_of_pages": 350,
+			"image_url": "http://example.com/go_updated.jpg",
+			"thumbnail_url": null,
+			"isbn_10": null,
+			"isbn_13": null,
+			"created_at": "0001-01-01T00:00:00Z",
+			"updated_at": "0001-01-01T00:00:00Z",
+			"deleted_at": null 
+		}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+	})
+}
+
+func TestHandleDeleteBookByID(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	t.Run("it should throw an error when call endpoint without book ID", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("it should throw an error when call endpoint with wrong ID", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/johndoe", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		expectedResponse := `{"error":"Book ID must be a positive integer"}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+	})
+
+	t.Run("it should return error when the request context is canceled", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		canceledCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		mockBookStore.On("DeleteByID", mock.MatchedBy(func(ctx context.Context) bool {
+			return ctx.Err() == context.Canceled
+		}), mock.Anything).Return(context.Canceled)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil).WithContext(canceledCtx)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+
+		expected := `{"error":"Request canceled"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw a database connection error", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("DeleteByID", mock.Anything, mock.Anything).Return(sql.ErrConnDone)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"An unexpected error occurred"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when call endpoint with a non-existent user ID", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("DeleteByID", mock.Anything, mock.Anything).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		expectedResponse := `{"error": "No book found with ID 1"}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+	})
+
+	t.Run("it should return error when a generic database error occur", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("DeleteByID", mock.Anything, mock.Anything).Return(errors.New("generic database error"))
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusInternalServerError, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"An unexpected error occurred"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should return the correct status", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("DeleteByID", mock.Anything, mock.Anything).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+
+	t.Run("it should forbid deleting a book shared with the user", func(t *testing.T) {
+		token := utils.GenerateTestToken(2, "JaneDoe", "janedoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("DeleteByID", mock.Anything, 1).Return(fmt.Errorf("%w: user 2 is editor of book 1", book.ErrBookPermissionDenied))
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Only the owner can delete book with ID 1"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the book is not found", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("DeleteByID", mock.Anything, 1).Return(fmt.Errorf("%w: %d", book.ErrBookNotFound, 1))
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No book found with ID 1"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleSearchBooks(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	t.Run("it should throw an error when the search text is missing", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/search", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		expected := `{"error":["Field 'Query' is invalid: required"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should return error when the request context is canceled", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		canceledCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		mockBookStore.On("Search", mock.MatchedBy(func(ctx context.Context) bool {
+			return ctx.Err() == context.Canceled
+		}), mock.Anything).Return([]*types.BookSearchResult{}, 0, context.Canceled)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/search?q=go", nil).WithContext(canceledCtx)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		assert.NoError(t, err)
+
+		expected := `{"error":"Request canceled"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should return ranked results with snippets", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		expectedResults := []*types.BookSearchResult{
+			{
+				Book: types.Book{
+					ID:            1,
+					Name:          "Go Programming",
+					Description:   "A book about Go programming",
+					Author:        "John Doe",
+					Genres:        []*types.BookGenre{{ID: 3, Name: "Programming", Slug: "programming"}},
+					ReleaseYear:   2024,
+					NumberOfPages: 300,
+					ImageUrl:      "http://example.com/go.jpg",
+					CreatedAt:     time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
+				},
+				Rank:    0.6,
+				Snippet: "A book about <mark>Go</mark> programming",
+			},
+		}
+
+		mockBookStore.On("Search", mock.Anything, types.SearchBooksOptions{Query: "go prog", Page: 1, Limit: 20}).Return(expectedResults, 1, nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/search?q=go+prog", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		expectedJSON := `{
+			"results": [
+				{
+					"id": 1,
+					"name": "Go Programming",
+					"description": "A book about Go programming",
+					"author": "John Doe",
+					"genres":[{"id": 3, "name": "Programming", "slug": "programming"}],
+					"release_year": 2024,
+					"number_of_pages": 300,
+					"image_url": "http://example.com/go.jpg",
+					"thumbnail_url": null,
+					"isbn_10": null,
+					"isbn_13": null,
+					"created_at": "0001-01-01T00:00:00Z",
+					"deleted_at": null,
+					"updated_at": null,
+					"rank": 0.6,
+					"snippet": "A book about <mark>Go</mark> programming"
+				}
+			],
+			"total": 1,
+			"page": 1,
+			"limit": 20,
+			"total_pages": 1,
+			"next": null,
+			"prev": null
+		}`
+		assert.JSONEq(t, expectedJSON, string(responseBody))
+
+		mockBookStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleGetDeletedBooks(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	t.Run("it should throw an error when limit is out of range", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/trash?limit=500", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'Limit' is invalid: lte"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should list the books deleted within the retention period", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		deletedAt := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+		mockBookStore.On("GetDeleted", mock.Anything, mock.MatchedBy(func(options types.GetDeletedBooksOptions) bool {
+			return options.Page == 1 &&
+				options.Limit == 20 &&
+				options.DeletedAfter.Before(time.Now()) &&
+				options.DeletedAfter.After(time.Now().Add(-31*24*time.Hour))
+		})).Return(
+			[]*types.Book{
+				{
+					ID:            1,
+					Name:          "Dune",
+					Description:   "A desert planet",
+					Author:        "Frank Herbert",
+					Genres:        []*types.BookGenre{{ID: 2, Name: "Sci-Fi", Slug: "sci-fi"}},
+					ReleaseYear:   1965,
+					NumberOfPages: 412,
+					ImageUrl:      "http://example.com/dune.jpg",
+					CreatedAt:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+					DeletedAt:     &deletedAt,
+				},
+			},
+			1,
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/trash", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{
+			"books": [
+				{"id":1,"name":"Dune","description":"A desert planet","author":"Frank Herbert","genres":[{"id": 2, "name": "Sci-Fi", "slug": "sci-fi"}],"release_year":1965,"number_of_pages":412,"image_url":"http://example.com/dune.jpg","thumbnail_url":null,"isbn_10":null,"isbn_13":null,"created_at":"2025-01-01T00:00:00Z","deleted_at":"2025-02-10T00:00:00Z","updated_at":null}
+			],
+			"total": 1,
+			"page": 1,
+			"limit": 20,
+			"total_pages": 1,
+			"next": null,
+			"prev": null
+		}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleRestoreBook(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	t.Run("it should throw an error when call endpoint with wrong ID", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/johndoe/restore", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Book ID must be a positive integer"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the book is not in the trash", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("RestoreDeletedByID", mock.Anything, 7, mock.AnythingOfType("time.Time")).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/7/restore", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No deleted book found with ID 7"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should restore the book", func(t *testing.T) {
+		token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("RestoreDeletedByID", mock.Anything, 7, mock.AnythingOfType("time.Time")).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/7/restore", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		mockBookStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleShareBook(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+
+	t.Run("it should throw an error when the permission is invalid", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload := `{"email":"janedoe@example.com","permission":"owner"}`
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/1/shares", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'Permission' is invalid: oneof"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the user to share with does not exist", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		sharePayload := types.ShareBookPayload{Email: "janedoe@example.com", Permission: types.BookPermissionViewer}
+		mockBookStore.On("ShareByID", mock.Anything, 1, sharePayload).Return((*types.BookShare)(nil), fmt.Errorf("%w: janedoe@example.com", book.ErrShareUserNotFound))
+
+		payload, _ := json.Marshal(sharePayload)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/1/shares", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No user found with email janedoe@example.com"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should forbid sharing a book the user does not own", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("ShareByID", mock.Anything, 1, mock.Anything).Return((*types.BookShare)(nil), book.ErrBookPermissionDenied)
+
+		payload := `{"email":"janedoe@example.com","permission":"editor"}`
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/1/shares", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Only the owner can share book with ID 1"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when sharing with the owner", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("ShareByID", mock.Anything, 1, mock.Anything).Return((*types.BookShare)(nil), book.ErrShareWithOwner)
+
+		payload := `{"email":"johndoe@example.com","permission":"editor"}`
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/1/shares", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"The book can't be shared with its owner"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should share the book", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		createdAt := time.Date(2025, 3, 19, 10, 0, 0, 0, time.UTC)
+		mockBookStore.On("ShareByID", mock.Anything, 1, types.ShareBookPayload{Email: "janedoe@example.com", Permission: types.BookPermissionEditor}).
+			Return(&types.BookShare{UserID: 2, Username: "JaneDoe", Email: "janedoe@example.com", Permission: types.BookPermissionEditor, CreatedAt: createdAt}, nil)
+
+		payload := `{"email":"janedoe@example.com","permission":"editor"}`
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/books/1/shares", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"user_id":2,"username":"JaneDoe","email":"janedoe@example.com","permission":"editor","created_at":"2025-03-19T10:00:00Z"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleGetBookShares(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+
+	t.Run("it should throw an error when the book is not found", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("GetSharesByID", mock.Anything, 1).Return(([]*types.BookShare)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/1/shares", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No book found with ID 1"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should list the shares", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		createdAt := time.Date(2025, 3, 19, 10, 0, 0, 0, time.UTC)
+		mockBookStore.On("GetSharesByID", mock.Anything, 1).Return([]*types.BookShare{
+			{UserID: 1, Username: "JohnDoe", Email: "johndoe@example.com", Permission: types.BookPermissionOwner, CreatedAt: createdAt},
+			{UserID: 2, Username: "JaneDoe", Email: "janedoe@example.com", Permission: types.BookPermissionViewer, CreatedAt: createdAt},
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/1/shares", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var responseBody types.GetBookSharesResponse
+		err := json.NewDecoder(res.Body).Decode(&responseBody)
+		assert.NoError(t, err)
+		assert.Len(t, responseBody.Shares, 2)
+		assert.Equal(t, types.BookPermissionViewer, responseBody.Shares[1].Permission)
+	})
+}
+
+func TestHandleRevokeBookShare(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+
+	t.Run("it should throw an error when call endpoint with wrong user ID", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1/shares/janedoe", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"User ID must be a positive integer"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the share does not exist", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("RevokeShareByID", mock.Anything, 1, 2).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1/shares/2", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No share of book 1 found for user 2"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should revoke the share", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("RevokeShareByID", mock.Anything, 1, 2).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/books/1/shares/2", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		mockBookStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleGetBookAuthors(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+
+	t.Run("it should throw an error when the book is not found", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("GetAuthorsByID", mock.Anything, 1).Return(([]*types.BookAuthor)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/1/authors", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No book found with ID 1"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should list the book authors", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("GetAuthorsByID", mock.Anything, 1).Return([]*types.BookAuthor{
+			{ID: 4, Name: "Neil Gaiman", Role: types.AuthorRoleAuthor},
+			{ID: 6, Name: "Josh Kirby", Role: types.AuthorRoleIllustrator},
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/books/1/authors", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"authors":[{"id":4,"name":"Neil Gaiman","role":"author"},{"id":6,"name":"Josh Kirby","role":"illustrator"}]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleSetBookAuthors(t *testing.T) {
+	setupTestServer := func() (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, nil)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+
+	t.Run("it should throw an error when the role is unknown", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1/authors", bytes.NewBufferString(`{"authors":[{"author_id":4,"role":"editor"}]}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'Role' is invalid: oneof"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+		mockBookStore.AssertNotCalled(t, "SetAuthorsByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when an author does not exist", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("SetAuthorsByID", mock.Anything, 1, mock.Anything).Return(([]*types.BookAuthor)(nil), book.ErrAuthorNotFound)
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1/authors", bytes.NewBufferString(`{"authors":[{"author_id":99,"role":"author"}]}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"One or more authors were not found"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should forbid users who can only view the book", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("SetAuthorsByID", mock.Anything, 1, mock.Anything).Return(([]*types.BookAuthor)(nil), book.ErrBookPermissionDenied)
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1/authors", bytes.NewBufferString(`{"authors":[{"author_id":4,"role":"author"}]}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"You are not allowed to edit book with ID 1"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should replace the book authors", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockBookStore.On("SetAuthorsByID", mock.Anything, 1, []types.BookAuthorPayload{
+			{AuthorID: 4, Role: types.AuthorRoleAuthor},
+			{AuthorID: 6, Role: types.AuthorRoleIllustrator},
+		}).Return([]*types.BookAuthor{
+			{ID: 4, Name: "Neil Gaiman", Role: types.AuthorRoleAuthor},
+			{ID: 6, Name: "Josh Kirby", Role: types.AuthorRoleIllustrator},
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/books/1/authors", bytes.NewBufferString(`{"authors":[{"author_id":4,"role":"author"},{"author_id":6,"role":"illustrator"}]}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"authors":[{"id":4,"name":"Neil Gaiman","role":"author"},{"id":6,"name":"Josh Kirby","role":"illustrator"}]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleUploadBookCover(t *testing.T) {
+	setupTestServer := func(coverStore types.BlobStore) (*mocks.MockBookStore, *httptest.Server, *mux.Router) {
+		mockBookStore := new(mocks.MockBookStore)
+		mockBookHandler := book.NewBookHandler(mockBookStore, nil, coverStore)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockBookStore, ts, router
+	}
+
+	newCoverRequest := func(url, field string, data []byte) *http.Request {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		part, _ := writer.CreateFormFile(field, "cover.png")
+		part.Write(data)
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPut, url, body)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com"))
+		return req
+	}
+
+	// A 600x1200 cover, half transparent so that the white background shows.
+	cover := image.NewNRGBA(image.Rect(0, 0, 600, 1200))
+	for y := 0; y < 600; y++ {
+		for x := 0; x < 600; x++ {
+			cover.Set(x, y, color.NRGBA{R: 200, G: 30, B: 30, A: 255})
+		}
+	}
+	coverPNG := &bytes.Buffer{}
+	png.Encode(coverPNG, cover)
+
+	blobKeys := func(dir string) []string {
+		var keys []string
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				key, _ := filepath.Rel(dir, path)
+				keys = append(keys, filepath.ToSlash(key))
+			}
+			return nil
+		})
+		return keys
+	}
+
+	t.Run("it should throw an error when uploading covers is not enabled", func(t *testing.T) {
+		mockBookStore, ts, router := setupTestServer(nil)
+		defer ts.Close()
+
+		req := newCoverRequest(ts.URL+"/api/v1/books/1/cover", "cover", coverPNG.Bytes())
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		mockBookStore.AssertNotCalled(t, "SetCoverByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the cover field is missing", func(t *testing.T) {
+		dir := t.TempDir()
+		mockBookStore, ts, router := setupTestServer(blobstore.NewLocalBlobStore(dir))
+		defer ts.Close()
+
+		mockBookStore.On("CheckCanEditByID", mock.Anything, 1).Return(nil)
+
+		req := newCoverRequest(ts.URL+"/api/v1/books/1/cover", "image", coverPNG.Bytes())
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"error":"The cover field with the image file is missing"}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+		mockBookStore.AssertNotCalled(t, "SetCoverByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the file is not an image, whatever its name says", func(t *testing.T) {
+		dir := t.TempDir()
+		mockBookStore, ts, router := setupTestServer(blobstore.NewLocalBlobStore(dir))
+		defer ts.Close()
+
+		mockBookStore.On("CheckCanEditByID", mock.Anything, 1).Return(nil)
+
+		req := newCoverRequest(ts.URL+"/api/v1/books/1/cover", "cover", []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnsupportedMediaType, res.StatusCode)
+		assert.Empty(t, blobKeys(dir))
+		mockBookStore.AssertNotCalled(t, "SetCoverByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the image is truncated", func(t *testing.T) {
+		dir := t.TempDir()
+		mockBookStore, ts, router := setupTestServer(blobstore.NewLocalBlobStore(dir))
+		defer ts.Close()
+
+		mockBookStore.On("CheckCanEditByID", mock.Anything, 1).Return(nil)
+
+		req := newCoverRequest(ts.URL+"/api/v1/books/1/cover", "cover", coverPNG.Bytes()[:coverPNG.Len()/2])
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		mockBookStore.AssertNotCalled(t, "SetCoverByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the file is too large", func(t *testing.T) {
+		defer func(maxBytes int64) { config.Envs.CoverMaxBytes = maxBytes }(config.Envs.CoverMaxBytes)
+		config.Envs.CoverMaxBytes = 1024
+
+		dir := t.TempDir()
+		mockBookStore, ts, router := setupTestServer(blobstore.NewLocalBlobStore(dir))
+		defer ts.Close()
+
+		mockBookStore.On("CheckCanEditByID", mock.Anything, 1).Return(nil)
+
+		req := newCoverRequest(ts.URL+"/api/v1/books/1/cover", "cover", coverPNG.Bytes())
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusRequestEntityTooLarge, res.StatusCode)
+		mockBookStore.AssertNotCalled(t, "SetCoverByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error before reading the upload when the user can't edit the book", func(t *testing.T) {
+		dir := t.TempDir()
+		mockBookStore, ts, router := setupTestServer(blobstore.NewLocalBlobStore(dir))
+		defer ts.Close()
+
+		mockBookStore.On("CheckCanEditByID", mock.Anything, 1).Return(book.ErrBookPermissionDenied)
+
+		req := newCoverRequest(ts.URL+"/api/v1/books/1/cover", "cover", coverPNG.Bytes())
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		assert.Empty(t, blobKeys(dir))
+		mockBookStore.AssertNotCalled(t, "SetCoverByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error and remove the uploaded files when the user can no longer edit the book", func(t *testing.T) {
+		dir := t.TempDir()
+		mockBookStore, ts, router := setupTestServer(blobstore.NewLocalBlobStore(dir))
+		defer ts.Close()
+
+		mockBookStore.On("CheckCanEditByID", mock.Anything, 1).Return(nil)
+		mockBookStore.On("SetCoverByID", mock.Anything, 1, mock.Anything).Return((*types.BookCover)(nil), book.ErrBookPermissionDenied)
+
+		req := newCoverRequest(ts.URL+"/api/v1/books/1/cover", "cover", coverPNG.Bytes())
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		assert.Empty(t, blobKeys(dir))
+	})
+
+	t.Run("it should throw an error when the book does not exist", func(t *testing.T) {
+		dir := t.TempDir()
+		mockBookStore, ts, router := setupTestServer(blobstore.NewLocalBlobStore(dir))
+		defer ts.Close()
+
+		mockBookStore.On("CheckCanEditByID", mock.Anything, 42).Return(sql.ErrNoRows)
+
+		req := newCoverRequest(ts.URL+"/api/v1/books/42/cover", "cover", coverPNG.Bytes())
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expectedResponse := `{"error":"No book found with ID 42"}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+		assert.Empty(t, blobKeys(dir))
+		mockBookStore.AssertNotCalled(t, "SetCoverByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should store the resized cover and remove the one it replaces", func(t *testing.T) {
+		dir := t.TempDir()
+		coverStore := blobstore.NewLocalBlobStore(dir)
+		mockBookStore, ts, router := setupTestServer(coverStore)
+		defer ts.Close()
+
+		mockBookStore.On("CheckCanEditByID", mock.Anything, 1).Return(nil)
+
+		coverStore.Put(context.Background(), "books/1/00000000000000aa/full.jpg", []byte("old"), "image/jpeg")
+		coverStore.Put(context.Background(), "books/1/00000000000000aa/thumbnail.jpg", []byte("old"), "image/jpeg")
+
+		baseURL := config.Envs.CoverBaseURL
+		mockBookStore.On("SetCoverByID", mock.Anything, 1, mock.MatchedBy(func(cover types.BookCover) bool {
+			return strings.HasPrefix(cover.ImageUrl, baseURL+"/books/1/") && strings.HasSuffix(cover.ImageUrl, "/full.jpg") &&
+				strings.HasPrefix(cover.ThumbnailUrl, baseURL+"/books/1/") && strings.HasSuffix(cover.ThumbnailUrl, "/thumbnail.jpg")
+		})).Return(&types.BookCover{
+			ImageUrl:     baseURL + "/books/1/00000000000000aa/full.jpg",
+			ThumbnailUrl: baseURL + "/books/1/00000000000000aa/thumbnail.jpg",
+		}, nil)
+
+		req := newCoverRequest(ts.URL+"/api/v1/books/1/cover", "cover", coverPNG.Bytes())
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.BookCover
+		json.NewDecoder(res.Body).Decode(&response)
+
+		fullKey := strings.TrimPrefix(response.ImageUrl, baseURL+"/")
+		thumbnailKey := strings.TrimPrefix(response.ThumbnailUrl, baseURL+"/")
+		assert.ElementsMatch(t, []string{fullKey, thumbnailKey}, blobKeys(dir))
+
+		for key, size := range map[string]image.Point{fullKey: {600, 1200}, thumbnailKey: {150, 300}} {
+			body, info, err := coverStore.Get(context.Background(), key)
+			assert.NoError(t, err)
+
+			img, err := jpeg.Decode(body)
+			body.Close()
+			assert.NoError(t, err)
+			assert.Equal(t, "image/jpeg", info.ContentType)
+			assert.Equal(t, size, img.Bounds().Size(), key)
+
+			r, g, b, _ := img.At(img.Bounds().Dx()/2, img.Bounds().Dy()*3/4).RGBA()
+			assert.Greater(t, r>>8+g>>8+b>>8, uint32(3*240), "transparent pixels should be white in %s", key)
+		}
+	})
+}
+
+func TestHandleGetCover(t *testing.T) {
+	setupTestServer := func(coverStore types.BlobStore) (*httptest.Server, *mux.Router) {
+		mockBookHandler := book.NewBookHandler(new(mocks.MockBookStore), nil, coverStore)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, mockBookHandler, nil, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return ts, router
+	}
+
+	coverStore := blobstore.NewLocalBlobStore(t.TempDir())
+	coverStore.Put(context.Background(), "books/1/0123456789abcdef/full.jpg", []byte("jpeg data"), "image/jpeg")
+	coverStore.Put(context.Background(), "books/1/0123456789abcdef/notes.txt", []byte("not a cover"), "text/plain")
+
+	t.Run("it should throw an error when the cover does not exist", func(t *testing.T) {
+		ts, router := setupTestServer(coverStore)
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/covers/books/1/fedcba9876543210/full.jpg", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("it should not serve blobs other than covers", func(t *testing.T) {
+		ts, router := setupTestServer(coverStore)
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/covers/books/1/0123456789abcdef/notes.txt", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+	})
+
+	t.Run("it should serve the cover with caching headers, without authentication", func(t *testing.T) {
+		ts, router := setupTestServer(coverStore)
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/covers/books/1/0123456789abcdef/full.jpg", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, "image/jpeg", res.Header.Get("Content-Type"))
+		assert.Equal(t, "public, max-age=31536000, immutable", res.Header.Get("Cache-Control"))
+		assert.NotEmpty(t, res.Header.Get("ETag"))
+		assert.NotEmpty(t, res.Header.Get("Last-Modified"))
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.Equal(t, "jpeg data", string(responseBody))
+	})
+
+	t.Run("it should answer not modified to a request with the current ETag", func(t *testing.T) {
+		ts, router := setupTestServer(coverStore)
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/covers/books/1/0123456789abcdef/full.jpg", nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		etag := w.Result().Header.Get("ETag")
+
+		req = httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/covers/books/1/0123456789abcdef/full.jpg", nil)
+		req.Header.Set("If-None-Match", etag)
+		w = httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotModified, res.StatusCode)
+		assert.Equal(t, etag, res.Header.Get("ETag"))
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.Empty(t, responseBody)
 	})
 }
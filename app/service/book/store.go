@@ -5,7 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"html"
+	"strings"
 	"time"
+	"unicode"
 
 	"github.com/hoyci/book-store-api/types"
 	"github.com/hoyci/book-store-api/utils"
@@ -13,11 +16,14 @@ import (
 )
 
 type BookStore struct {
-	db *sql.DB
+	db     *sql.DB
+	covers types.BlobStore
 }
 
-func NewBookStore(db *sql.DB) *BookStore {
-	return &BookStore{db: db}
+// NewBookStore removes the uploaded covers of the books it purges from
+// covers, which is nil when cover uploads are not enabled.
+func NewBookStore(db *sql.DB, covers types.BlobStore) *BookStore {
+	return &BookStore{db: db, covers: covers}
 }
 
 func (s *BookStore) Create(ctx context.Context, book types.CreateBookPayload) (int, error) {
@@ -44,18 +50,24 @@ func (s *BookStore) Create(ctx context.Context, book types.CreateBookPayload) (i
 	err = tx.QueryRowContext(
 		ctx,
 		`
-        INSERT INTO books (name, description, author, genres, release_year, number_of_pages, image_url) 
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        INSERT INTO books (name, description, author, release_year, number_of_pages, image_url, isbn_10, isbn_13, owner_id) 
+        VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), $9)
         RETURNING id
         `,
 		book.Name,
 		book.Description,
 		book.Author,
-		pq.Array(book.Genres),
 		book.ReleaseYear,
 		book.NumberOfPages,
 		book.ImageUrl,
+		book.ISBN10,
+		book.ISBN13,
+		userID,
 	).Scan(&bookID)
+	if utils.IsPQError(err, utils.PQUniqueViolation) {
+		err = fmt.Errorf("%w: %s", types.ErrBookISBNExists, book.ISBN13)
+		return 0, err
+	}
 	if err != nil {
 		return 0, err
 	}
@@ -63,19 +75,99 @@ func (s *BookStore) Create(ctx context.Context, book types.CreateBookPayload) (i
 	_, err = tx.ExecContext(
 		ctx,
 		`
-        INSERT INTO users_books (user_id, book_id) 
-        VALUES ($1, $2)
+        INSERT INTO users_books (user_id, book_id, permission) 
+        VALUES ($1, $2, $3)
         `,
 		userID,
 		bookID,
+		types.BookPermissionOwner,
 	)
 	if err != nil {
 		return 0, err
 	}
 
+	err = addBookGenres(ctx, tx, bookID, book.GenreIDs)
+	if err != nil {
+		return 0, err
+	}
+
+	// Without a list of authors, the free-text author becomes the one
+	// credited on the book.
+	authors := book.Authors
+	if len(authors) == 0 {
+		authors = []string{book.Author}
+	}
+
+	for position, name := range authors {
+		err = creditAuthorByName(ctx, tx, bookID, name, position)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	_, err = setAuthorCredit(ctx, tx, bookID)
+	if err != nil {
+		return 0, err
+	}
+
 	return bookID, nil
 }
 
+// creditAuthorByName credits the author of that name on the book, found by
+// name or created, the same way the add-authors-tables migration linked the
+// books that existed before it. A name without letters or digits credits no
+// one.
+func creditAuthorByName(ctx context.Context, tx *sql.Tx, bookID int, name string, position int) error {
+	nameKey := utils.AuthorNameKey(name)
+	if nameKey == "" {
+		return nil
+	}
+
+	var authorID int
+	err := tx.QueryRowContext(
+		ctx,
+		`
+        INSERT INTO authors (name, name_key) 
+        VALUES ($1, $2)
+        ON CONFLICT (name_key) DO UPDATE SET name_key = EXCLUDED.name_key
+        RETURNING id
+        `,
+		strings.TrimSpace(name),
+		nameKey,
+	).Scan(&authorID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		`
+        INSERT INTO book_authors (book_id, author_id, role, position) 
+        VALUES ($1, $2, $3, $4)
+        ON CONFLICT DO NOTHING
+        `,
+		bookID,
+		authorID,
+		types.AuthorRoleAuthor,
+		position,
+	)
+
+	return err
+}
+
+// setAuthorCredit rewrites the books.author credit line of the book from its
+// credited authors and returns it.
+func setAuthorCredit(ctx context.Context, tx *sql.Tx, bookID int) (string, error) {
+	var credit string
+	err := tx.QueryRowContext(
+		ctx,
+		"UPDATE books b SET author = "+utils.BookAuthorCreditSQL+" WHERE b.id = $1 RETURNING b.author",
+		bookID,
+	).Scan(&credit)
+
+	return credit, err
+}
+
 func (s *BookStore) GetByID(ctx context.Context, bookID int) (*types.Book, error) {
 	book := &types.Book{}
 	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
@@ -87,8 +179,20 @@ func (s *BookStore) GetByID(ctx context.Context, bookID int) (*types.Book, error
 	err := s.db.QueryRowContext(
 		ctx,
 		`
-		SELECT 
-		b.* 
+		SELECT
+		b.id,
+		b.name,
+		b.description,
+		b.author,
+		b.release_year,
+		b.number_of_pages,
+		b.image_url,
+		b.thumbnail_url,
+		b.isbn_10,
+		b.isbn_13,
+		b.created_at,
+		b.updated_at,
+		b.deleted_at
 		FROM books b
 		INNER JOIN users_books ub ON ub.book_id = b.id
 		WHERE b.id = $1
@@ -102,10 +206,12 @@ func (s *BookStore) GetByID(ctx context.Context, bookID int) (*types.Book, error
 		&book.Name,
 		&book.Description,
 		&book.Author,
-		pq.Array(&book.Genres),
 		&book.ReleaseYear,
 		&book.NumberOfPages,
 		&book.ImageUrl,
+		&book.ThumbnailUrl,
+		&book.ISBN10,
+		&book.ISBN13,
 		&book.CreatedAt,
 		&book.UpdatedAt,
 		&book.DeletedAt,
@@ -114,32 +220,186 @@ func (s *BookStore) GetByID(ctx context.Context, bookID int) (*types.Book, error
 		return nil, err
 	}
 
+	if err := loadBookGenres(ctx, s.db, book); err != nil {
+		return nil, err
+	}
+
 	return book, nil
 }
 
-func (s *BookStore) GetMany(ctx context.Context) ([]*types.Book, error) {
+// GetByISBN finds the book with the ISBN-13 among the ones the caller can
+// see. Their own copy wins over the ones shared with them.
+func (s *BookStore) GetByISBN(ctx context.Context, isbn13 string) (*types.Book, error) {
+	book := &types.Book{}
 	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
 	if !ok {
 		return nil, fmt.Errorf("failed to retrieve userID from context")
 	}
 	userID := claimsCtx.UserID
 
-	rows, err := s.db.QueryContext(
+	err := s.db.QueryRowContext(
 		ctx,
 		`
-		SELECT b.*
+		SELECT
+		b.id,
+		b.name,
+		b.description,
+		b.author,
+		b.release_year,
+		b.number_of_pages,
+		b.image_url,
+		b.thumbnail_url,
+		b.isbn_10,
+		b.isbn_13,
+		b.created_at,
+		b.updated_at,
+		b.deleted_at
 		FROM books b
-		INNER JOIN users_books ub  ON
-		ub.book_id = b.id
-		WHERE ub.user_id = $1 
-		AND b.deleted_at IS NULL;
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE b.isbn_13 = $1
+		AND ub.user_id = $2
+		AND b.deleted_at IS NULL
+		ORDER BY ub.permission = 'owner' DESC, b.id
+		LIMIT 1;
 		`,
+		isbn13,
 		userID,
+	).Scan(
+		&book.ID,
+		&book.Name,
+		&book.Description,
+		&book.Author,
+		&book.ReleaseYear,
+		&book.NumberOfPages,
+		&book.ImageUrl,
+		&book.ThumbnailUrl,
+		&book.ISBN10,
+		&book.ISBN13,
+		&book.CreatedAt,
+		&book.UpdatedAt,
+		&book.DeletedAt,
 	)
-
 	if err != nil {
 		return nil, err
 	}
+
+	if err := loadBookGenres(ctx, s.db, book); err != nil {
+		return nil, err
+	}
+
+	return book, nil
+}
+
+var bookSortColumns = map[string]string{
+	"name":         "b.name",
+	"release_year": "b.release_year",
+	"created_at":   "b.created_at",
+}
+
+func buildBooksFilter(userID int, options types.GetBooksOptions) (string, []any) {
+	conditions := []string{"ub.user_id = $1", "b.deleted_at IS NULL"}
+	args := []any{userID}
+
+	addCondition := func(condition string, value any) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+
+	if options.Author != "" {
+		addCondition("b.author ILIKE '%%' || $%d || '%%'", utils.EscapeLike(options.Author))
+	}
+	if options.AuthorID != 0 {
+		addCondition("EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = b.id AND ba.author_id = $%d)", options.AuthorID)
+	}
+	// A genre matches the books in any of its subgenres too, however deep.
+	if options.Genre != "" {
+		addCondition(`EXISTS (
+			WITH RECURSIVE subgenres AS (
+				SELECT id FROM genres WHERE slug = $%d
+				UNION
+				SELECT g.id FROM genres g INNER JOIN subgenres sg ON g.parent_id = sg.id
+			)
+			SELECT 1 FROM book_genres bg WHERE bg.book_id = b.id AND bg.genre_id IN (SELECT id FROM subgenres)
+		)`, utils.GenreSlug(options.Genre))
+	}
+	if options.MinReleaseYear != 0 {
+		addCondition("b.release_year >= $%d", options.MinReleaseYear)
+	}
+	if options.MaxReleaseYear != 0 {
+		addCondition("b.release_year <= $%d", options.MaxReleaseYear)
+	}
+	if options.MinPages != 0 {
+		addCondition("b.number_of_pages >= $%d", options.MinPages)
+	}
+	if options.MaxPages != 0 {
+		addCondition("b.number_of_pages <= $%d", options.MaxPages)
+	}
+
+	return strings.Join(conditions, "\n\t\tAND "), args
+}
+
+func (s *BookStore) GetMany(ctx context.Context, options types.GetBooksOptions) ([]*types.Book, int, error) {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return nil, 0, fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	sortColumn, ok := bookSortColumns[options.Sort]
+	if !ok {
+		return nil, 0, fmt.Errorf("invalid sort field: %s", options.Sort)
+	}
+	order := "ASC"
+	if options.Order == "desc" {
+		order = "DESC"
+	}
+
+	where, args := buildBooksFilter(userID, options)
+
+	var total int
+	err := s.db.QueryRowContext(
+		ctx,
+		fmt.Sprintf(`
+		SELECT COUNT(*)
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE %s;
+		`, where),
+		args...,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, options.Limit, (options.Page-1)*options.Limit)
+	rows, err := s.db.QueryContext(
+		ctx,
+		fmt.Sprintf(`
+		SELECT
+		b.id,
+		b.name,
+		b.description,
+		b.author,
+		b.release_year,
+		b.number_of_pages,
+		b.image_url,
+		b.thumbnail_url,
+		b.isbn_10,
+		b.isbn_13,
+		b.created_at,
+		b.updated_at,
+		b.deleted_at
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE %s
+		ORDER BY %s %s, b.id %s
+		LIMIT $%d OFFSET $%d;
+		`, where, sortColumn, order, order, len(args)-1, len(args)),
+		args...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
 	defer rows.Close()
 
 	books := []*types.Book{}
@@ -151,96 +411,384 @@ func (s *BookStore) GetMany(ctx context.Context) ([]*types.Book, error) {
 			&book.Name,
 			&book.Description,
 			&book.Author,
-			pq.Array(&book.Genres),
 			&book.ReleaseYear,
 			&book.NumberOfPages,
 			&book.ImageUrl,
+			&book.ThumbnailUrl,
+			&book.ISBN10,
+			&book.ISBN13,
 			&book.CreatedAt,
 			&book.UpdatedAt,
 			&book.DeletedAt,
 		)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		books = append(books, book)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := loadBookGenres(ctx, s.db, books...); err != nil {
+		return nil, 0, err
+	}
+
+	return books, total, nil
+}
+
+// buildPrefixTSQuery turns free text into a tsquery where every term must
+// match as a prefix, e.g. "lord ring" becomes "lord:* & ring:*".
+func buildPrefixTSQuery(text string) string {
+	terms := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for i, term := range terms {
+		terms[i] = term + ":*"
+	}
+
+	return strings.Join(terms, " & ")
+}
+
+// ts_headline marks the matches with these control characters, which can't
+// be mistaken for HTML, so that the description can be escaped before the
+// marks become <mark> tags. Book descriptions are user input.
+const (
+	snippetStartSel = "\x02"
+	snippetStopSel  = "\x03"
+)
+
+var snippetHeadlineOptions = fmt.Sprintf("StartSel=%s, StopSel=%s, MaxFragments=2", snippetStartSel, snippetStopSel)
+
+var snippetHighlighter = strings.NewReplacer(snippetStartSel, "<mark>", snippetStopSel, "</mark>")
+
+// highlightSnippet turns a ts_headline snippet into HTML safe to render.
+func highlightSnippet(snippet string) string {
+	return snippetHighlighter.Replace(html.EscapeString(snippet))
+}
+
+func (s *BookStore) Search(ctx context.Context, options types.SearchBooksOptions) ([]*types.BookSearchResult, int, error) {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return nil, 0, fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	results := []*types.BookSearchResult{}
+
+	tsQuery := buildPrefixTSQuery(options.Query)
+	if tsQuery == "" {
+		return results, 0, nil
+	}
+
+	var total int
+	err := s.db.QueryRowContext(
+		ctx,
+		`
+		SELECT COUNT(*)
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE ub.user_id = $1
+		AND b.deleted_at IS NULL
+		AND b.search_vector @@ to_tsquery('simple', $2);
+		`,
+		userID,
+		tsQuery,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+		SELECT
+		b.id,
+		b.name,
+		b.description,
+		b.author,
+		b.release_year,
+		b.number_of_pages,
+		b.image_url,
+		b.thumbnail_url,
+		b.isbn_10,
+		b.isbn_13,
+		b.created_at,
+		b.updated_at,
+		b.deleted_at,
+		ts_rank(b.search_vector, query) AS rank,
+		ts_headline('simple', coalesce(b.description, ''), query, $5) AS snippet
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id,
+		to_tsquery('simple', $2) query
+		WHERE ub.user_id = $1
+		AND b.deleted_at IS NULL
+		AND b.search_vector @@ query
+		ORDER BY rank DESC, b.id ASC
+		LIMIT $3 OFFSET $4;
+		`,
+		userID,
+		tsQuery,
+		options.Limit,
+		(options.Page-1)*options.Limit,
+		snippetHeadlineOptions,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		result := &types.BookSearchResult{}
+		err := rows.Scan(
+			&result.ID,
+			&result.Name,
+			&result.Description,
+			&result.Author,
+			&result.ReleaseYear,
+			&result.NumberOfPages,
+			&result.ImageUrl,
+			&result.ThumbnailUrl,
+			&result.ISBN10,
+			&result.ISBN13,
+			&result.CreatedAt,
+			&result.UpdatedAt,
+			&result.DeletedAt,
+			&result.Rank,
+			&result.Snippet,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		result.Snippet = highlightSnippet(result.Snippet)
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	books := make([]*types.Book, len(results))
+	for i, result := range results {
+		books[i] = &result.Book
+	}
+	if err := loadBookGenres(ctx, s.db, books...); err != nil {
+		return nil, 0, err
+	}
 
-	return books, nil
+	return results, total, nil
 }
 
+// UpdateByID also deletes the uploaded cover the book no longer points at
+// when image_url changes.
 func (s *BookStore) UpdateByID(ctx context.Context, bookID int, newBook types.UpdateBookPayload) (*types.Book, error) {
+	updatedBook, replacedCoverKeys, err := s.updateByID(ctx, bookID, newBook)
+	if err != nil {
+		return nil, err
+	}
+
+	utils.DeleteCoverBlobs(ctx, s.covers, replacedCoverKeys...)
+
+	return updatedBook, nil
+}
+
+func (s *BookStore) updateByID(ctx context.Context, bookID int, newBook types.UpdateBookPayload) (*types.Book, []string, error) {
 	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
 	if !ok {
-		return nil, fmt.Errorf("failed to retrieve userID from context")
+		return nil, nil, fmt.Errorf("failed to retrieve userID from context")
 	}
 	userID := claimsCtx.UserID
 
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
 	query := `
 			UPDATE books SET 
 			name = $3, 
 			description = $4,
-			author = $5,
-			genres = $6,
-			release_year = $7,
-			number_of_pages = $8,
-			image_url = $9,
+			release_year = $5,
+			number_of_pages = $6,
+			image_url = $7,
+			-- The thumbnail of an uploaded cover goes away with it.
+			thumbnail_url = CASE WHEN image_url = $7 THEN thumbnail_url END,
+			isbn_10 = NULLIF($8, ''),
+			isbn_13 = NULLIF($9, ''),
 			updated_at = $10
-			WHERE id IN (
-				SELECT b.id
+			FROM (
+				SELECT b.id AS book_id, b.image_url AS previous_image_url, COALESCE(b.thumbnail_url, '') AS previous_thumbnail_url
 				FROM books b
 				INNER JOIN users_books ub ON ub.book_id = b.id
 				WHERE b.id = $1 AND ub.user_id = $2
-			)
+				AND ub.permission IN ('owner', 'editor')
+				AND b.deleted_at IS NULL
+				FOR UPDATE OF b
+			) previous
+			WHERE id = previous.book_id
 			RETURNING 
 				id, 
 				name, 
 				description, 
 				author, 
-				genres, 
 				release_year, 
 				number_of_pages, 
 				image_url, 
+				thumbnail_url, 
+				isbn_10, 
+				isbn_13, 
 				created_at, 
 				deleted_at,
-				updated_at;
+				updated_at,
+				previous.previous_image_url,
+				previous.previous_thumbnail_url;
 			`
 
 	updatedBook := &types.Book{}
-	err := s.db.QueryRowContext(
+	previous := types.BookCover{}
+	err = tx.QueryRowContext(
 		ctx,
 		query,
 		bookID,
 		userID,
 		newBook.Name,
 		newBook.Description,
-		newBook.Author,
-		pq.Array(newBook.Genres),
 		newBook.ReleaseYear,
 		newBook.NumberOfPages,
 		newBook.ImageUrl,
+		newBook.ISBN10,
+		newBook.ISBN13,
 		time.Now(),
 	).Scan(
 		&updatedBook.ID,
 		&updatedBook.Name,
 		&updatedBook.Description,
 		&updatedBook.Author,
-		pq.Array(&updatedBook.Genres),
 		&updatedBook.ReleaseYear,
 		&updatedBook.NumberOfPages,
 		&updatedBook.ImageUrl,
+		&updatedBook.ThumbnailUrl,
+		&updatedBook.ISBN10,
+		&updatedBook.ISBN13,
 		&updatedBook.CreatedAt,
 		&updatedBook.DeletedAt,
 		&updatedBook.UpdatedAt,
+		&previous.ImageUrl,
+		&previous.ThumbnailUrl,
 	)
 
+	if err == sql.ErrNoRows {
+		err = s.permissionDeniedOr(ctx, bookID, userID, err)
+		return nil, nil, err
+	}
+	if utils.IsPQError(err, utils.PQUniqueViolation) {
+		err = fmt.Errorf("%w: %s", types.ErrBookISBNExists, newBook.ISBN13)
+		return nil, nil, err
+	}
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 
-	return updatedBook, nil
+	// books.author is only written from the credited authors. A different
+	// credit line in the payload replaces the credited authors, keeping the
+	// translators and illustrators; the same one, as sent back by clients
+	// that update whole books, leaves them alone.
+	if strings.TrimSpace(newBook.Author) != updatedBook.Author {
+		_, err = tx.ExecContext(ctx, "DELETE FROM book_authors WHERE book_id = $1 AND role = $2", bookID, types.AuthorRoleAuthor)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = creditAuthorByName(ctx, tx, bookID, newBook.Author, 0)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		updatedBook.Author, err = setAuthorCredit(ctx, tx, bookID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM book_genres WHERE book_id = $1", bookID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = addBookGenres(ctx, tx, bookID, newBook.GenreIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = loadBookGenres(ctx, tx, updatedBook)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if previous.ImageUrl == updatedBook.ImageUrl {
+		return updatedBook, nil, nil
+	}
+
+	// The new image_url may well be the thumbnail of the previous cover,
+	// which then stays.
+	var replacedURLs []string
+	for _, url := range []string{previous.ImageUrl, previous.ThumbnailUrl} {
+		if url != updatedBook.ImageUrl {
+			replacedURLs = append(replacedURLs, url)
+		}
+	}
+
+	return updatedBook, utils.CoverKeys(bookID, replacedURLs...), nil
+}
+
+var (
+	ErrBookNotFound         = errors.New("book not found")
+	ErrBookPermissionDenied = errors.New("book permission denied")
+)
+
+// permissionOf returns the user's permission on a book that isn't deleted, or
+// sql.ErrNoRows when the book isn't theirs nor shared with them.
+func (s *BookStore) permissionOf(ctx context.Context, bookID int, userID int) (string, error) {
+	var permission string
+	err := s.db.QueryRowContext(
+		ctx,
+		`
+		SELECT ub.permission
+		FROM users_books ub
+		INNER JOIN books b ON b.id = ub.book_id
+		WHERE ub.book_id = $1
+		AND ub.user_id = $2
+		AND b.deleted_at IS NULL;
+		`,
+		bookID,
+		userID,
+	).Scan(&permission)
+
+	return permission, err
 }
 
-var ErrBookNotFound = errors.New("book not found")
+// permissionDeniedOr tells apart a book the user can't see from a book
+// shared with them at a permission too low for what they tried. The first
+// gets notFoundErr back, the second ErrBookPermissionDenied.
+func (s *BookStore) permissionDeniedOr(ctx context.Context, bookID int, userID int, notFoundErr error) error {
+	permission, err := s.permissionOf(ctx, bookID, userID)
+	if err == sql.ErrNoRows {
+		return notFoundErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return fmt.Errorf("%w: user %d is %s of book %d", ErrBookPermissionDenied, userID, permission, bookID)
+}
 
 func (s *BookStore) DeleteByID(ctx context.Context, bookID int) error {
 	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
@@ -260,6 +808,8 @@ func (s *BookStore) DeleteByID(ctx context.Context, bookID int) error {
 			INNER JOIN users_books ub ON ub.book_id = b.id
 			WHERE b.id = $1
 			AND ub.user_id = $2
+			AND ub.permission = 'owner'
+			AND b.deleted_at IS NULL
 		)
 		RETURNING id;
 		`,
@@ -276,8 +826,833 @@ func (s *BookStore) DeleteByID(ctx context.Context, bookID int) error {
 		return err
 	}
 	if rowsAffected == 0 {
-		return fmt.Errorf("%w: %d", ErrBookNotFound, bookID)
+		return s.permissionDeniedOr(ctx, bookID, userID, fmt.Errorf("%w: %d", ErrBookNotFound, bookID))
 	}
 
 	return nil
 }
+
+// GetAll lists every book regardless of users_books ownership, disabled ones
+// included. It backs the admin endpoints only.
+func (s *BookStore) GetAll(ctx context.Context, options types.GetAllBooksOptions) ([]*types.Book, int, error) {
+	var total int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM books").Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+		SELECT
+		id,
+		name,
+		description,
+		author,
+		release_year,
+		number_of_pages,
+		image_url,
+		thumbnail_url,
+		isbn_10,
+		isbn_13,
+		created_at,
+		updated_at,
+		deleted_at
+		FROM books
+		ORDER BY id
+		LIMIT $1 OFFSET $2;
+		`,
+		options.Limit,
+		(options.Page-1)*options.Limit,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	books := []*types.Book{}
+
+	for rows.Next() {
+		book := &types.Book{}
+		err := rows.Scan(
+			&book.ID,
+			&book.Name,
+			&book.Description,
+			&book.Author,
+			&book.ReleaseYear,
+			&book.NumberOfPages,
+			&book.ImageUrl,
+			&book.ThumbnailUrl,
+			&book.ISBN10,
+			&book.ISBN13,
+			&book.CreatedAt,
+			&book.UpdatedAt,
+			&book.DeletedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := loadBookGenres(ctx, s.db, books...); err != nil {
+		return nil, 0, err
+	}
+
+	return books, total, nil
+}
+
+// DisableByID hides the book on behalf of an admin or librarian. Unlike
+// DeleteByID, its owners can't restore it and the purger won't remove it.
+func (s *BookStore) DisableByID(ctx context.Context, bookID int) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		"UPDATE books SET deleted_at = COALESCE(deleted_at, $2), disabled_by_admin = TRUE WHERE id = $1 AND NOT disabled_by_admin",
+		bookID,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (s *BookStore) RestoreByID(ctx context.Context, bookID int) error {
+	result, err := s.db.ExecContext(
+		ctx,
+		"UPDATE books SET deleted_at = NULL, disabled_by_admin = FALSE, updated_at = $2 WHERE id = $1 AND deleted_at IS NOT NULL",
+		bookID,
+		time.Now(),
+	)
+	if utils.IsPQError(err, utils.PQUniqueViolation) {
+		return fmt.Errorf("%w: book %d", types.ErrBookISBNExists, bookID)
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetDeleted lists the caller's trash: the books they own and deleted after
+// options.DeletedAfter, most recently deleted first.
+func (s *BookStore) GetDeleted(ctx context.Context, options types.GetDeletedBooksOptions) ([]*types.Book, int, error) {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return nil, 0, fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	var total int
+	err := s.db.QueryRowContext(
+		ctx,
+		`
+		SELECT COUNT(*)
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE ub.user_id = $1
+		AND ub.permission = 'owner'
+		AND b.deleted_at > $2
+		AND NOT b.disabled_by_admin;
+		`,
+		userID,
+		options.DeletedAfter,
+	).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+		SELECT
+		b.id,
+		b.name,
+		b.description,
+		b.author,
+		b.release_year,
+		b.number_of_pages,
+		b.image_url,
+		b.thumbnail_url,
+		b.isbn_10,
+		b.isbn_13,
+		b.created_at,
+		b.updated_at,
+		b.deleted_at
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE ub.user_id = $1
+		AND ub.permission = 'owner'
+		AND b.deleted_at > $2
+		AND NOT b.disabled_by_admin
+		ORDER BY b.deleted_at DESC, b.id DESC
+		LIMIT $3 OFFSET $4;
+		`,
+		userID,
+		options.DeletedAfter,
+		options.Limit,
+		(options.Page-1)*options.Limit,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	books := []*types.Book{}
+
+	for rows.Next() {
+		book := &types.Book{}
+		err := rows.Scan(
+			&book.ID,
+			&book.Name,
+			&book.Description,
+			&book.Author,
+			&book.ReleaseYear,
+			&book.NumberOfPages,
+			&book.ImageUrl,
+			&book.ThumbnailUrl,
+			&book.ISBN10,
+			&book.ISBN13,
+			&book.CreatedAt,
+			&book.UpdatedAt,
+			&book.DeletedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if err := loadBookGenres(ctx, s.db, books...); err != nil {
+		return nil, 0, err
+	}
+
+	return books, total, nil
+}
+
+// RestoreDeletedByID takes a book the caller owns out of the trash, as long
+// as it was deleted after deletedAfter and not disabled by an admin.
+func (s *BookStore) RestoreDeletedByID(ctx context.Context, bookID int, deletedAfter time.Time) error {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`
+		UPDATE books
+		SET deleted_at = NULL, updated_at = $4
+		WHERE id IN (
+			SELECT b.id
+			FROM books b
+			INNER JOIN users_books ub ON ub.book_id = b.id
+			WHERE b.id = $1
+			AND ub.user_id = $2
+			AND ub.permission = 'owner'
+			AND b.deleted_at > $3
+			AND NOT b.disabled_by_admin
+		);
+		`,
+		bookID,
+		userID,
+		deletedAfter,
+		time.Now(),
+	)
+	if utils.IsPQError(err, utils.PQUniqueViolation) {
+		return fmt.Errorf("%w: book %d", types.ErrBookISBNExists, bookID)
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// PurgeDeleted hard deletes the books deleted by their owners before
+// deletedBefore, along with their users_books rows and uploaded covers.
+func (s *BookStore) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	purged, coverKeys, err := s.purgeDeleted(ctx, deletedBefore)
+	if err != nil {
+		return 0, err
+	}
+
+	// Covers go once the books are gone for good, so none is left pointing
+	// at a missing blob if the transaction fails.
+	utils.DeleteCoverBlobs(ctx, s.covers, coverKeys...)
+
+	return purged, nil
+}
+
+func (s *BookStore) purgeDeleted(ctx context.Context, deletedBefore time.Time) (int, []string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	_, err = tx.ExecContext(
+		ctx,
+		`
+		DELETE FROM users_books
+		WHERE book_id IN (
+			SELECT id
+			FROM books
+			WHERE deleted_at < $1
+			AND NOT disabled_by_admin
+		);
+		`,
+		deletedBefore,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		"DELETE FROM books WHERE deleted_at < $1 AND NOT disabled_by_admin RETURNING id, image_url, COALESCE(thumbnail_url, '')",
+		deletedBefore,
+	)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	purged, coverKeys, err := utils.ScanCoverKeys(rows)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	return purged, coverKeys, nil
+}
+
+var (
+	ErrShareUserNotFound = errors.New("user to share with not found")
+	ErrShareWithOwner    = errors.New("book cannot be shared with its owner")
+)
+
+// ShareByID gives the user registered with share.Email access to a book the
+// caller owns. Sharing it again with the same user changes their permission.
+func (s *BookStore) ShareByID(ctx context.Context, bookID int, share types.ShareBookPayload) (*types.BookShare, error) {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	var owned int
+	err = tx.QueryRowContext(
+		ctx,
+		`
+		SELECT ub.book_id
+		FROM users_books ub
+		INNER JOIN books b ON b.id = ub.book_id
+		WHERE ub.book_id = $1
+		AND ub.user_id = $2
+		AND ub.permission = 'owner'
+		AND b.deleted_at IS NULL;
+		`,
+		bookID,
+		userID,
+	).Scan(&owned)
+	if err == sql.ErrNoRows {
+		err = s.permissionDeniedOr(ctx, bookID, userID, err)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bookShare := &types.BookShare{Permission: share.Permission}
+	err = tx.QueryRowContext(
+		ctx,
+		"SELECT id, username, email FROM users WHERE email = $1 AND deleted_at IS NULL",
+		share.Email,
+	).Scan(&bookShare.UserID, &bookShare.Username, &bookShare.Email)
+	if err == sql.ErrNoRows {
+		err = fmt.Errorf("%w: %s", ErrShareUserNotFound, share.Email)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if bookShare.UserID == userID {
+		err = ErrShareWithOwner
+		return nil, err
+	}
+
+	err = tx.QueryRowContext(
+		ctx,
+		`
+		INSERT INTO users_books (user_id, book_id, permission)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, book_id)
+		DO UPDATE SET permission = EXCLUDED.permission, updated_at = $4
+		RETURNING created_at;
+		`,
+		bookShare.UserID,
+		bookID,
+		share.Permission,
+		time.Now(),
+	).Scan(&bookShare.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return bookShare, nil
+}
+
+// GetSharesByID lists everyone with access to a book the caller owns, the
+// owner included.
+func (s *BookStore) GetSharesByID(ctx context.Context, bookID int) ([]*types.BookShare, error) {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`
+		SELECT ub.user_id, u.username, u.email, ub.permission, ub.created_at
+		FROM users_books ub
+		INNER JOIN users u ON u.id = ub.user_id
+		WHERE ub.book_id = $1
+		AND EXISTS (
+			SELECT 1
+			FROM users_books o
+			INNER JOIN books b ON b.id = o.book_id
+			WHERE o.book_id = $1
+			AND o.user_id = $2
+			AND o.permission = 'owner'
+			AND b.deleted_at IS NULL
+		)
+		ORDER BY ub.created_at, ub.user_id;
+		`,
+		bookID,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	shares := []*types.BookShare{}
+
+	for rows.Next() {
+		share := &types.BookShare{}
+		err := rows.Scan(
+			&share.UserID,
+			&share.Username,
+			&share.Email,
+			&share.Permission,
+			&share.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, share)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// The owner's own row is always listed, so no rows means the caller
+	// doesn't own the book.
+	if len(shares) == 0 {
+		return nil, s.permissionDeniedOr(ctx, bookID, userID, sql.ErrNoRows)
+	}
+
+	return shares, nil
+}
+
+// RevokeShareByID removes the access of shareUserID to the book. The owner
+// can revoke anyone's share, and a shared user can remove their own.
+func (s *BookStore) RevokeShareByID(ctx context.Context, bookID int, shareUserID int) error {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	result, err := s.db.ExecContext(
+		ctx,
+		`
+		DELETE FROM users_books
+		WHERE book_id = $1
+		AND user_id = $3
+		AND permission <> 'owner'
+		AND (
+			user_id = $2
+			OR EXISTS (
+				SELECT 1
+				FROM users_books o
+				WHERE o.book_id = $1
+				AND o.user_id = $2
+				AND o.permission = 'owner'
+			)
+		);
+		`,
+		bookID,
+		userID,
+		shareUserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+var ErrAuthorNotFound = errors.New("author not found")
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func getBookAuthors(ctx context.Context, q queryer, bookID int) ([]*types.BookAuthor, error) {
+	rows, err := q.QueryContext(
+		ctx,
+		`
+		SELECT a.id, a.name, ba.role
+		FROM book_authors ba
+		INNER JOIN authors a ON a.id = ba.author_id
+		WHERE ba.book_id = $1
+		ORDER BY ba.position, a.name;
+		`,
+		bookID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	authors := []*types.BookAuthor{}
+
+	for rows.Next() {
+		author := &types.BookAuthor{}
+		err := rows.Scan(&author.ID, &author.Name, &author.Role)
+		if err != nil {
+			return nil, err
+		}
+		authors = append(authors, author)
+	}
+
+	return authors, rows.Err()
+}
+
+// GetAuthorsByID lists the authors credited on a book the caller can see.
+func (s *BookStore) GetAuthorsByID(ctx context.Context, bookID int) ([]*types.BookAuthor, error) {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	if _, err := s.permissionOf(ctx, bookID, userID); err != nil {
+		return nil, err
+	}
+
+	return getBookAuthors(ctx, s.db, bookID)
+}
+
+// SetAuthorsByID replaces the authors credited on a book the caller can edit,
+// in the given order, and rewrites the books.author credit line from them.
+func (s *BookStore) SetAuthorsByID(ctx context.Context, bookID int, authors []types.BookAuthorPayload) ([]*types.BookAuthor, error) {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	var editable int
+	err = tx.QueryRowContext(
+		ctx,
+		`
+		SELECT b.id
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE b.id = $1
+		AND ub.user_id = $2
+		AND ub.permission IN ('owner', 'editor')
+		AND b.deleted_at IS NULL
+		FOR UPDATE OF b;
+		`,
+		bookID,
+		userID,
+	).Scan(&editable)
+	if err == sql.ErrNoRows {
+		err = s.permissionDeniedOr(ctx, bookID, userID, err)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(ctx, "DELETE FROM book_authors WHERE book_id = $1", bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	for position, author := range authors {
+		_, err = tx.ExecContext(
+			ctx,
+			`
+			INSERT INTO book_authors (book_id, author_id, role, position)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING;
+			`,
+			bookID,
+			author.AuthorID,
+			author.Role,
+			position,
+		)
+		if utils.IsPQError(err, utils.PQForeignKeyViolation) {
+			err = fmt.Errorf("%w: %d", ErrAuthorNotFound, author.AuthorID)
+			return nil, err
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	bookAuthors, err := getBookAuthors(ctx, tx, bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE books b SET author = "+utils.BookAuthorCreditSQL+", updated_at = $2 WHERE b.id = $1",
+		bookID,
+		time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return bookAuthors, nil
+}
+
+// CheckCanEditByID fails unless the caller owns or edits the book, with
+// sql.ErrNoRows when they can't see it and ErrBookPermissionDenied when it is
+// shared with them as viewer. Uploads check it before reading the file.
+func (s *BookStore) CheckCanEditByID(ctx context.Context, bookID int) error {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	permission, err := s.permissionOf(ctx, bookID, userID)
+	if err != nil {
+		return err
+	}
+	if permission != types.BookPermissionOwner && permission != types.BookPermissionEditor {
+		return fmt.Errorf("%w: user %d is %s of book %d", ErrBookPermissionDenied, userID, permission, bookID)
+	}
+
+	return nil
+}
+
+// SetCoverByID points the book at an uploaded cover and returns the cover it
+// had before, so that the caller can remove it from storage.
+func (s *BookStore) SetCoverByID(ctx context.Context, bookID int, cover types.BookCover) (*types.BookCover, error) {
+	claimsCtx, ok := utils.GetClaimsFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("failed to retrieve userID from context")
+	}
+	userID := claimsCtx.UserID
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	previous := &types.BookCover{}
+	err = tx.QueryRowContext(
+		ctx,
+		`
+		SELECT b.image_url, COALESCE(b.thumbnail_url, '')
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE b.id = $1
+		AND ub.user_id = $2
+		AND ub.permission IN ('owner', 'editor')
+		AND b.deleted_at IS NULL
+		FOR UPDATE OF b;
+		`,
+		bookID,
+		userID,
+	).Scan(&previous.ImageUrl, &previous.ThumbnailUrl)
+	if err == sql.ErrNoRows {
+		err = s.permissionDeniedOr(ctx, bookID, userID, err)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"UPDATE books SET image_url = $2, thumbnail_url = $3, updated_at = $4 WHERE id = $1",
+		bookID,
+		cover.ImageUrl,
+		cover.ThumbnailUrl,
+		time.Now(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return previous, nil
+}
+
+var ErrGenreNotFound = errors.New("genre not found")
+
+// loadBookGenres fills in the genres of the books, with a single query however
+// many books there are.
+func loadBookGenres(ctx context.Context, q queryer, books ...*types.Book) error {
+	if len(books) == 0 {
+		return nil
+	}
+
+	bookIDs := make([]int, len(books))
+	booksByID := make(map[int]*types.Book, len(books))
+	for i, book := range books {
+		book.Genres = []*types.BookGenre{}
+		bookIDs[i] = book.ID
+		booksByID[book.ID] = book
+	}
+
+	rows, err := q.QueryContext(
+		ctx,
+		`
+		SELECT bg.book_id, g.id, g.name, g.slug
+		FROM book_genres bg
+		INNER JOIN genres g ON g.id = bg.genre_id
+		WHERE bg.book_id = ANY($1)
+		ORDER BY g.name, g.id;
+		`,
+		pq.Array(bookIDs),
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		genre := &types.BookGenre{}
+		err := rows.Scan(&bookID, &genre.ID, &genre.Name, &genre.Slug)
+		if err != nil {
+			return err
+		}
+
+		book := booksByID[bookID]
+		book.Genres = append(book.Genres, genre)
+	}
+
+	return rows.Err()
+}
+
+// addBookGenres files a book under the genres. An ID that isn't a genre fails
+// with ErrGenreNotFound.
+func addBookGenres(ctx context.Context, tx *sql.Tx, bookID int, genreIDs []int) error {
+	_, err := tx.ExecContext(
+		ctx,
+		`
+		INSERT INTO book_genres (book_id, genre_id)
+		SELECT $1, UNNEST($2::INT[])
+		ON CONFLICT DO NOTHING;
+		`,
+		bookID,
+		pq.Array(genreIDs),
+	)
+	if utils.IsPQError(err, utils.PQForeignKeyViolation) {
+		return fmt.Errorf("%w: %v", ErrGenreNotFound, genreIDs)
+	}
+
+	return err
+}
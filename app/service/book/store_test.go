@@ -11,12 +11,21 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/hoyci/book-store-api/blobstore"
 	"github.com/hoyci/book-store-api/types"
 	"github.com/hoyci/book-store-api/utils"
 	"github.com/lib/pq"
 	"github.com/stretchr/testify/assert"
 )
 
+var bookGenresQuery = regexp.QuoteMeta(`
+		SELECT bg.book_id, g.id, g.name, g.slug
+		FROM book_genres bg
+		INNER JOIN genres g ON g.id = bg.genre_id
+		WHERE bg.book_id = ANY($1)
+		ORDER BY g.name, g.id;
+		`)
+
 func TestCreateBook(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -24,15 +33,17 @@ func TestCreateBook(t *testing.T) {
 	}
 	defer db.Close()
 
-	store := NewBookStore(db)
+	store := NewBookStore(db, nil)
 	book := types.CreateBookPayload{
 		Name:          "Go Programming",
 		Description:   "A book about Go programming",
 		Author:        "John Doe",
-		Genres:        []string{"Programming"},
+		GenreIDs:      []int{3},
 		ReleaseYear:   2024,
 		NumberOfPages: 300,
 		ImageUrl:      "http://example.com/go.jpg",
+		ISBN13:        "9780306406157",
+		ISBN10:        "0306406152",
 	}
 
 	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
@@ -70,7 +81,7 @@ func TestCreateBook(t *testing.T) {
 	t.Run("database unexpected error", func(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectQuery("INSERT INTO books").
-			WithArgs(book.Name, book.Description, book.Author, pq.Array(book.Genres), book.ReleaseYear, book.NumberOfPages, book.ImageUrl).
+			WithArgs(book.Name, book.Description, book.Author, book.ReleaseYear, book.NumberOfPages, book.ImageUrl, book.ISBN10, book.ISBN13, 1).
 			WillReturnError(fmt.Errorf("database connection error"))
 		mock.ExpectRollback()
 
@@ -84,13 +95,30 @@ func TestCreateBook(t *testing.T) {
 		}
 	})
 
+	t.Run("ISBN already in the user's library", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO books").
+			WithArgs(book.Name, book.Description, book.Author, book.ReleaseYear, book.NumberOfPages, book.ImageUrl, book.ISBN10, book.ISBN13, 1).
+			WillReturnError(&pq.Error{Code: utils.PQUniqueViolation})
+		mock.ExpectRollback()
+
+		id, err := store.Create(ctx, book)
+
+		assert.ErrorIs(t, err, types.ErrBookISBNExists)
+		assert.Zero(t, id)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
 	t.Run("fail to insert into users_books", func(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectQuery("INSERT INTO books").
-			WithArgs(book.Name, book.Description, book.Author, pq.Array(book.Genres), book.ReleaseYear, book.NumberOfPages, book.ImageUrl).
+			WithArgs(book.Name, book.Description, book.Author, book.ReleaseYear, book.NumberOfPages, book.ImageUrl, book.ISBN10, book.ISBN13, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectExec("INSERT INTO users_books").
-			WithArgs(1, 1).
+			WithArgs(1, 1, types.BookPermissionOwner).
 			WillReturnError(fmt.Errorf("failed to insert into users_books"))
 		mock.ExpectRollback()
 
@@ -127,10 +155,10 @@ func TestCreateBook(t *testing.T) {
 	t.Run("rollback on intermediate failure", func(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectQuery("INSERT INTO books").
-			WithArgs(book.Name, book.Description, book.Author, pq.Array(book.Genres), book.ReleaseYear, book.NumberOfPages, book.ImageUrl).
+			WithArgs(book.Name, book.Description, book.Author, book.ReleaseYear, book.NumberOfPages, book.ImageUrl, book.ISBN10, book.ISBN13, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectExec("INSERT INTO users_books").
-			WithArgs(1, 1).
+			WithArgs(1, 1, types.BookPermissionOwner).
 			WillReturnError(fmt.Errorf("database error"))
 		mock.ExpectRollback()
 
@@ -144,14 +172,91 @@ func TestCreateBook(t *testing.T) {
 		}
 	})
 
+	t.Run("genre does not exist", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO books").
+			WithArgs(book.Name, book.Description, book.Author, book.ReleaseYear, book.NumberOfPages, book.ImageUrl, book.ISBN10, book.ISBN13, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec("INSERT INTO users_books").
+			WithArgs(1, 1, types.BookPermissionOwner).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO book_genres").
+			WithArgs(1, pq.Array([]int{3})).
+			WillReturnError(&pq.Error{Code: utils.PQForeignKeyViolation})
+		mock.ExpectRollback()
+
+		id, err := store.Create(ctx, book)
+
+		assert.ErrorIs(t, err, ErrGenreNotFound)
+		assert.Zero(t, id)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
 	t.Run("successfully create book", func(t *testing.T) {
 		mock.ExpectBegin()
 		mock.ExpectQuery("INSERT INTO books").
-			WithArgs(book.Name, book.Description, book.Author, pq.Array(book.Genres), book.ReleaseYear, book.NumberOfPages, book.ImageUrl).
+			WithArgs(book.Name, book.Description, book.Author, book.ReleaseYear, book.NumberOfPages, book.ImageUrl, book.ISBN10, book.ISBN13, 1).
 			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
 		mock.ExpectExec("INSERT INTO users_books").
-			WithArgs(1, 1).
+			WithArgs(1, 1, types.BookPermissionOwner).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO book_genres").
+			WithArgs(1, pq.Array([]int{3})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO authors").
+			WithArgs("John Doe", "johndoe").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectExec("INSERT INTO book_authors").
+			WithArgs(1, 4, types.AuthorRoleAuthor, 0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("UPDATE books b SET author").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"author"}).AddRow("John Doe"))
+		mock.ExpectCommit()
+
+		id, err := store.Create(ctx, book)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, id)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("credit each author of the list", func(t *testing.T) {
+		book := book
+		book.Author = "Neil Gaiman, Terry Pratchett"
+		book.Authors = []string{"Neil Gaiman", "Terry Pratchett"}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery("INSERT INTO books").
+			WithArgs(book.Name, book.Description, book.Author, book.ReleaseYear, book.NumberOfPages, book.ImageUrl, book.ISBN10, book.ISBN13, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec("INSERT INTO users_books").
+			WithArgs(1, 1, types.BookPermissionOwner).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("INSERT INTO book_genres").
+			WithArgs(1, pq.Array([]int{3})).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO authors").
+			WithArgs("Neil Gaiman", "neilgaiman").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectExec("INSERT INTO book_authors").
+			WithArgs(1, 4, types.AuthorRoleAuthor, 0).
 			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO authors").
+			WithArgs("Terry Pratchett", "terrypratchett").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(5))
+		mock.ExpectExec("INSERT INTO book_authors").
+			WithArgs(1, 5, types.AuthorRoleAuthor, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("UPDATE books b SET author").
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"author"}).AddRow("Neil Gaiman, Terry Pratchett"))
 		mock.ExpectCommit()
 
 		id, err := store.Create(ctx, book)
@@ -172,7 +277,7 @@ func TestGetBookByID(t *testing.T) {
 	}
 	defer db.Close()
 
-	store := NewBookStore(db)
+	store := NewBookStore(db, nil)
 	expectedCreatedAt := time.Now()
 
 	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
@@ -210,8 +315,20 @@ func TestGetBookByID(t *testing.T) {
 
 	t.Run("database did not find any row", func(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(`
-		SELECT 
-		b.* 
+		SELECT
+		b.id,
+		b.name,
+		b.description,
+		b.author,
+		b.release_year,
+		b.number_of_pages,
+		b.image_url,
+		b.thumbnail_url,
+		b.isbn_10,
+		b.isbn_13,
+		b.created_at,
+		b.updated_at,
+		b.deleted_at
 		FROM books b
 		INNER JOIN users_books ub ON ub.book_id = b.id
 		WHERE b.id = $1
@@ -234,8 +351,20 @@ func TestGetBookByID(t *testing.T) {
 
 	t.Run("database connection error", func(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(`
-				SELECT 
-				b.* 
+				SELECT
+				b.id,
+				b.name,
+				b.description,
+				b.author,
+				b.release_year,
+				b.number_of_pages,
+				b.image_url,
+				b.thumbnail_url,
+				b.isbn_10,
+				b.isbn_13,
+				b.created_at,
+				b.updated_at,
+				b.deleted_at
 				FROM books b
 				INNER JOIN users_books ub ON ub.book_id = b.id
 				WHERE b.id = $1
@@ -258,8 +387,20 @@ func TestGetBookByID(t *testing.T) {
 
 	t.Run("successfully get book by ID", func(t *testing.T) {
 		mock.ExpectQuery(regexp.QuoteMeta(`
-				SELECT 
-				b.* 
+				SELECT
+				b.id,
+				b.name,
+				b.description,
+				b.author,
+				b.release_year,
+				b.number_of_pages,
+				b.image_url,
+				b.thumbnail_url,
+				b.isbn_10,
+				b.isbn_13,
+				b.created_at,
+				b.updated_at,
+				b.deleted_at
 				FROM books b
 				INNER JOIN users_books ub ON ub.book_id = b.id
 				WHERE b.id = $1
@@ -267,8 +408,12 @@ func TestGetBookByID(t *testing.T) {
 				AND b.deleted_at IS NULL;
 			`)).
 			WithArgs(1, 1).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "author", "genres", "release_year", "number_of_pages", "image_url", "created_at", "updated_at", "deleted_at"}).
-				AddRow(1, "Go Programming", "A book about Go programming", "John Doe", pq.Array([]string{"Programming"}), 2024, 300, "http://example.com/go.jpg", expectedCreatedAt, nil, nil))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "author", "release_year", "number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "updated_at", "deleted_at"}).
+				AddRow(1, "Go Programming", "A book about Go programming", "John Doe", 2024, 300, "http://example.com/go.jpg", nil, nil, nil, expectedCreatedAt, nil, nil))
+		mock.ExpectQuery(bookGenresQuery).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "slug"}).
+				AddRow(1, 3, "Programming", "programming"))
 
 		expectedID := 1
 
@@ -279,6 +424,97 @@ func TestGetBookByID(t *testing.T) {
 		assert.Equal(t, expectedID, book.ID)
 		assert.Equal(t, "Go Programming", book.Name)
 		assert.Equal(t, expectedCreatedAt, book.CreatedAt)
+		assert.Equal(t, []*types.BookGenre{{ID: 3, Name: "Programming", Slug: "programming"}}, book.Genres)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetBookByISBN(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+	expectedCreatedAt := time.Now()
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		ID:               "ID-CRAZY",
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+
+	query := regexp.QuoteMeta(`
+		SELECT
+		b.id,
+		b.name,
+		b.description,
+		b.author,
+		b.release_year,
+		b.number_of_pages,
+		b.image_url,
+		b.thumbnail_url,
+		b.isbn_10,
+		b.isbn_13,
+		b.created_at,
+		b.updated_at,
+		b.deleted_at
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE b.isbn_13 = $1
+		AND ub.user_id = $2
+		AND b.deleted_at IS NULL
+		ORDER BY ub.permission = 'owner' DESC, b.id
+		LIMIT 1;
+		`)
+
+	t.Run("missing userID in context", func(t *testing.T) {
+		ctx := context.Background()
+
+		book, err := store.GetByISBN(ctx, "9780441013593")
+
+		assert.Error(t, err)
+		assert.Equal(t, "failed to retrieve userID from context", err.Error())
+		assert.Nil(t, book)
+	})
+
+	t.Run("database did not find any row", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("9780441013593", 1).
+			WillReturnError(sql.ErrNoRows)
+
+		book, err := store.GetByISBN(ctx, "9780441013593")
+
+		assert.Nil(t, book)
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get book by ISBN", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("9780441013593", 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "author", "release_year", "number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "updated_at", "deleted_at"}).
+				AddRow(2, "Dune", "A desert planet", "Frank Herbert", 1965, 412, "http://example.com/dune.jpg", nil, "0441013597", "9780441013593", expectedCreatedAt, nil, nil))
+		mock.ExpectQuery(bookGenresQuery).
+			WithArgs(pq.Array([]int{2})).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "slug"}))
+
+		book, err := store.GetByISBN(ctx, "9780441013593")
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, book.ID)
+		assert.Equal(t, "0441013597", *book.ISBN10)
+		assert.Equal(t, "9780441013593", *book.ISBN13)
+		assert.Equal(t, []*types.BookGenre{}, book.Genres)
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("unmet expectations: %v", err)
@@ -293,7 +529,7 @@ func TestGetManyBooks(t *testing.T) {
 	}
 	defer db.Close()
 
-	store := NewBookStore(db)
+	store := NewBookStore(db, nil)
 	expectedCreatedAt := time.Now()
 
 	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
@@ -304,24 +540,67 @@ func TestGetManyBooks(t *testing.T) {
 		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
 	})
 
+	defaultOptions := types.GetBooksOptions{Page: 1, Limit: 20, Sort: "created_at", Order: "asc"}
+
+	countQuery := regexp.QuoteMeta(`
+		SELECT COUNT(*)
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE ub.user_id = $1
+		AND b.deleted_at IS NULL;
+	`)
+	selectQuery := regexp.QuoteMeta(`
+		SELECT
+		b.id,
+		b.name,
+		b.description,
+		b.author,
+		b.release_year,
+		b.number_of_pages,
+		b.image_url,
+		b.thumbnail_url,
+		b.isbn_10,
+		b.isbn_13,
+		b.created_at,
+		b.updated_at,
+		b.deleted_at
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE ub.user_id = $1
+		AND b.deleted_at IS NULL
+		ORDER BY b.created_at ASC, b.id ASC
+		LIMIT $2 OFFSET $3;
+	`)
+
 	t.Run("missing userID in context", func(t *testing.T) {
 		ctx := context.Background()
 
-		id, err := store.GetMany(ctx)
+		books, total, err := store.GetMany(ctx, defaultOptions)
 
 		assert.Error(t, err)
 		assert.Equal(t, "failed to retrieve userID from context", err.Error())
-		assert.Zero(t, id)
+		assert.Nil(t, books)
+		assert.Zero(t, total)
+	})
+
+	t.Run("invalid sort field", func(t *testing.T) {
+		books, total, err := store.GetMany(ctx, types.GetBooksOptions{Page: 1, Limit: 20, Sort: "password", Order: "asc"})
+
+		assert.Error(t, err)
+		assert.Equal(t, "invalid sort field: password", err.Error())
+		assert.Nil(t, books)
+		assert.Zero(t, total)
 	})
 
 	t.Run("context cancellation", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(ctx)
 		cancel()
 
-		books, err := store.GetMany(ctx)
+		books, total, err := store.GetMany(ctx, defaultOptions)
 
 		assert.Error(t, err)
 		assert.Nil(t, books)
+		assert.Zero(t, total)
 		assert.True(t, errors.Is(err, context.Canceled))
 
 		if err := mock.ExpectationsWereMet(); err != nil {
@@ -330,21 +609,15 @@ func TestGetManyBooks(t *testing.T) {
 	})
 
 	t.Run("database connection error", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`
-				SELECT b.*
-				FROM books b
-				INNER JOIN users_books ub  ON
-				ub.book_id = b.id
-				WHERE ub.user_id = $1 
-				AND b.deleted_at IS NULL;
-			`)).
+		mock.ExpectQuery(countQuery).
 			WithArgs(1).
 			WillReturnError(sql.ErrConnDone)
 
-		book, err := store.GetMany(ctx)
+		books, total, err := store.GetMany(ctx, defaultOptions)
 
 		assert.Error(t, err)
-		assert.Zero(t, book)
+		assert.Nil(t, books)
+		assert.Zero(t, total)
 		assert.True(t, errors.Is(err, sql.ErrConnDone))
 
 		if err := mock.ExpectationsWereMet(); err != nil {
@@ -353,24 +626,21 @@ func TestGetManyBooks(t *testing.T) {
 	})
 
 	t.Run("empty result set (no rows)", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`
-				SELECT b.*
-				FROM books b
-				INNER JOIN users_books ub  ON
-				ub.book_id = b.id
-				WHERE ub.user_id = $1 
-				AND b.deleted_at IS NULL;
-			`)).
+		mock.ExpectQuery(countQuery).
 			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(selectQuery).
+			WithArgs(1, 20, 0).
 			WillReturnRows(sqlmock.NewRows([]string{
-				"id", "name", "description", "author", "genres", "release_year", "number_of_pages", "image_url", "created_at", "updated_at", "deleted_at",
+				"id", "name", "description", "author", "release_year", "number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "updated_at", "deleted_at",
 			}))
 
-		books, err := store.GetMany(ctx)
+		books, total, err := store.GetMany(ctx, defaultOptions)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, books)
 		assert.Equal(t, 0, len(books))
+		assert.Equal(t, 0, total)
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("unmet expectations: %v", err)
@@ -378,27 +648,31 @@ func TestGetManyBooks(t *testing.T) {
 	})
 
 	t.Run("successfully get user books", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`
-			SELECT b.*
-			FROM books b
-			INNER JOIN users_books ub ON ub.book_id = b.id
-			WHERE ub.user_id = $1 
-			AND b.deleted_at IS NULL;
-		`)).
+		mock.ExpectQuery(countQuery).
 			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+		mock.ExpectQuery(selectQuery).
+			WithArgs(1, 20, 0).
 			WillReturnRows(
 				sqlmock.NewRows([]string{
-					"id", "name", "description", "author", "genres", "release_year", "number_of_pages", "image_url", "created_at", "updated_at", "deleted_at",
+					"id", "name", "description", "author", "release_year", "number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "updated_at", "deleted_at",
 				}).
-					AddRow(1, "Go Programming", "A book about Go programming", "John Doe", pq.Array([]string{"Programming"}), 2024, 300, "http://example.com/go.jpg", expectedCreatedAt, nil, nil).
-					AddRow(2, "Clean Code", "A book about writing clean code", "Robert C. Martin", pq.Array([]string{"Programming", "Best Practices"}), 2008, 464, "http://example.com/clean-code.jpg", expectedCreatedAt, nil, nil),
+					AddRow(1, "Go Programming", "A book about Go programming", "John Doe", 2024, 300, "http://example.com/go.jpg", nil, nil, nil, expectedCreatedAt, nil, nil).
+					AddRow(2, "Clean Code", "A book about writing clean code", "Robert C. Martin", 2008, 464, "http://example.com/clean-code.jpg", nil, nil, nil, expectedCreatedAt, nil, nil),
 			)
+		mock.ExpectQuery(bookGenresQuery).
+			WithArgs(pq.Array([]int{1, 2})).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "slug"}).
+				AddRow(2, 5, "Best Practices", "best-practices").
+				AddRow(1, 3, "Programming", "programming").
+				AddRow(2, 3, "Programming", "programming"))
 
-		books, err := store.GetMany(ctx)
+		books, total, err := store.GetMany(ctx, defaultOptions)
 
 		assert.NoError(t, err)
 		assert.NotNil(t, books)
 		assert.Equal(t, 2, len(books))
+		assert.Equal(t, 2, total)
 
 		assert.Equal(t, 1, books[0].ID)
 		assert.Equal(t, "Go Programming", books[0].Name)
@@ -408,6 +682,111 @@ func TestGetManyBooks(t *testing.T) {
 		assert.Equal(t, "Clean Code", books[1].Name)
 		assert.Equal(t, "Robert C. Martin", books[1].Author)
 
+		assert.Equal(t, []*types.BookGenre{{ID: 3, Name: "Programming", Slug: "programming"}}, books[0].Genres)
+		assert.Equal(t, []*types.BookGenre{
+			{ID: 5, Name: "Best Practices", Slug: "best-practices"},
+			{ID: 3, Name: "Programming", Slug: "programming"},
+		}, books[1].Genres)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully apply filters, sorting and pagination", func(t *testing.T) {
+		options := types.GetBooksOptions{
+			Page:           3,
+			Limit:          10,
+			Author:         "Martin_",
+			Genre:          "Programming",
+			MinReleaseYear: 2000,
+			MaxReleaseYear: 2010,
+			MinPages:       100,
+			MaxPages:       500,
+			Sort:           "release_year",
+			Order:          "desc",
+		}
+		where := `
+		WHERE ub.user_id = $1
+		AND b.deleted_at IS NULL
+		AND b.author ILIKE '%' || $2 || '%'
+		AND EXISTS (
+			WITH RECURSIVE subgenres AS (
+				SELECT id FROM genres WHERE slug = $3
+				UNION
+				SELECT g.id FROM genres g INNER JOIN subgenres sg ON g.parent_id = sg.id
+			)
+			SELECT 1 FROM book_genres bg WHERE bg.book_id = b.id AND bg.genre_id IN (SELECT id FROM subgenres)
+		)
+		AND b.release_year >= $4
+		AND b.release_year <= $5
+		AND b.number_of_pages >= $6
+		AND b.number_of_pages <= $7`
+
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT COUNT(*)
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id`+where+`;`)).
+			WithArgs(1, `Martin\_`, "programming", 2000, 2010, 100, 500).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id`+where+`
+		ORDER BY b.release_year DESC, b.id DESC
+		LIMIT $8 OFFSET $9;`)).
+			WithArgs(1, `Martin\_`, "programming", 2000, 2010, 100, 500, 10, 20).
+			WillReturnRows(
+				sqlmock.NewRows([]string{
+					"id", "name", "description", "author", "release_year", "number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "updated_at", "deleted_at",
+				}).
+					AddRow(2, "Clean Code", "A book about writing clean code", "Robert C. Martin", 2008, 464, "http://example.com/clean-code.jpg", nil, nil, nil, expectedCreatedAt, nil, nil),
+			)
+		mock.ExpectQuery(bookGenresQuery).
+			WithArgs(pq.Array([]int{2})).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "slug"}).
+				AddRow(2, 7, "Software Engineering", "software-engineering"))
+
+		books, total, err := store.GetMany(ctx, options)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, len(books))
+		assert.Equal(t, 21, total)
+		assert.Equal(t, "Clean Code", books[0].Name)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully filter by author ID", func(t *testing.T) {
+		options := types.GetBooksOptions{Page: 1, Limit: 10, AuthorID: 4, Sort: "name", Order: "asc"}
+		where := `
+		WHERE ub.user_id = $1
+		AND b.deleted_at IS NULL
+		AND EXISTS (SELECT 1 FROM book_authors ba WHERE ba.book_id = b.id AND ba.author_id = $2)`
+
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT COUNT(*)
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id`+where+`;`)).
+			WithArgs(1, 4).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id`+where+`
+		ORDER BY b.name ASC, b.id ASC
+		LIMIT $3 OFFSET $4;`)).
+			WithArgs(1, 4, 10, 0).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "name", "description", "author", "release_year", "number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "updated_at", "deleted_at",
+			}))
+
+		books, total, err := store.GetMany(ctx, options)
+
+		assert.NoError(t, err)
+		assert.Empty(t, books)
+		assert.Zero(t, total)
+
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("unmet expectations: %v", err)
 		}
@@ -421,7 +800,7 @@ func TestUpdateByID(t *testing.T) {
 	}
 	defer db.Close()
 
-	store := NewBookStore(db)
+	store := NewBookStore(db, nil)
 
 	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
 		ID:               "ID-CRAZY",
@@ -441,59 +820,79 @@ func TestUpdateByID(t *testing.T) {
 		assert.Nil(t, book)
 	})
 
-	t.Run("database did not find any row", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta(`
+	updateQuery := regexp.QuoteMeta(`
 			UPDATE books SET 
 			name = $3, 
 			description = $4,
-			author = $5,
-			genres = $6,
-			release_year = $7,
-			number_of_pages = $8,
-			image_url = $9,
+			release_year = $5,
+			number_of_pages = $6,
+			image_url = $7,
+			-- The thumbnail of an uploaded cover goes away with it.
+			thumbnail_url = CASE WHEN image_url = $7 THEN thumbnail_url END,
+			isbn_10 = NULLIF($8, ''),
+			isbn_13 = NULLIF($9, ''),
 			updated_at = $10
-			WHERE id IN (
-				SELECT b.id
+			FROM (
+				SELECT b.id AS book_id, b.image_url AS previous_image_url, COALESCE(b.thumbnail_url, '') AS previous_thumbnail_url
 				FROM books b
 				INNER JOIN users_books ub ON ub.book_id = b.id
 				WHERE b.id = $1 AND ub.user_id = $2
-			)
+				AND ub.permission IN ('owner', 'editor')
+				AND b.deleted_at IS NULL
+				FOR UPDATE OF b
+			) previous
+			WHERE id = previous.book_id
 			RETURNING 
 				id, 
 				name, 
 				description, 
 				author, 
-				genres, 
 				release_year, 
 				number_of_pages, 
 				image_url, 
+				thumbnail_url, 
+				isbn_10, 
+				isbn_13, 
 				created_at, 
 				deleted_at,
-				updated_at;
-			`)).
+				updated_at,
+				previous.previous_image_url,
+				previous.previous_thumbnail_url;
+			`)
+	payload := types.UpdateBookPayload{
+		Name:          "Updated Book Name",
+		Description:   "Updated Description",
+		Author:        "John Doe",
+		GenreIDs:      []int{3, 5},
+		ReleaseYear:   2025,
+		NumberOfPages: 199,
+		ImageUrl:      "http://google.com/somerandomimage.jpg",
+		ISBN10:        "0441013597",
+		ISBN13:        "9780441013593",
+	}
+
+	t.Run("database did not find any row", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
 			WithArgs(
 				1, // bookID
 				1, // userID
 				"Updated Book Name",
 				"Updated Description",
-				"John Doe",
-				pq.Array([]string{"Genre1", "Genre2"}),
 				2025,
 				199,
 				"http://google.com/somerandomimage.jpg",
+				"0441013597",
+				"9780441013593",
 				sqlmock.AnyArg(),
 			).
 			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT ub.permission")).
+			WithArgs(1, 1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
 
-		book, err := store.UpdateByID(ctx, 1, types.UpdateBookPayload{
-			Name:          "Updated Book Name",
-			Description:   "Updated Description",
-			Author:        "John Doe",
-			Genres:        []string{"Genre1", "Genre2"},
-			ReleaseYear:   2025,
-			NumberOfPages: 199,
-			ImageUrl:      "http://google.com/somerandomimage.jpg",
-		})
+		book, err := store.UpdateByID(ctx, 1, payload)
 
 		assert.Nil(t, book)
 		assert.Error(t, err)
@@ -504,91 +903,232 @@ func TestUpdateByID(t *testing.T) {
 		}
 	})
 
-	t.Run("successfully update book", func(t *testing.T) {
-		mockDate := time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC)
-
+	t.Run("book in the trash or disabled by an admin", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WillReturnError(sql.ErrNoRows)
 		mock.ExpectQuery(regexp.QuoteMeta(`
-			UPDATE books SET 
-			name = $3, 
-			description = $4,
-			author = $5,
-			genres = $6,
-			release_year = $7,
-			number_of_pages = $8,
-			image_url = $9,
-			updated_at = $10
-			WHERE id IN (
-				SELECT b.id
-				FROM books b
-				INNER JOIN users_books ub ON ub.book_id = b.id
-				WHERE b.id = $1 AND ub.user_id = $2
-			)
-			RETURNING 
-				id, 
-				name, 
-				description, 
-				author, 
-				genres, 
-				release_year, 
-				number_of_pages, 
-				image_url, 
-				created_at, 
-				deleted_at,
-				updated_at;
-			`)).
-			WithArgs(
-				1, 1,
-				"Updated Book Name",
-				"Updated Description",
-				"John Doe",
-				pq.Array([]string{"Genre1", "Genre2"}),
-				2025,
-				199,
-				"http://google.com/somerandomimage.jpg",
-				sqlmock.AnyArg(),
-			).
-			WillReturnRows(sqlmock.NewRows([]string{
-				"id", "name", "description", "author", "genres", "release_year",
-				"number_of_pages", "image_url", "created_at", "deleted_at", "updated_at",
-			}).AddRow(
-				1,
-				"Updated Book Name",
-				"Updated Description",
-				"Author Name",
-				pq.Array([]string{"Genre1", "Genre2"}),
-				2025,
-				300,
-				"http://example.com/image.jpg",
-				mockDate,
-				&mockDate,
-				&mockDate,
-			))
+		SELECT ub.permission
+		FROM users_books ub
+		INNER JOIN books b ON b.id = ub.book_id
+		WHERE ub.book_id = $1
+		AND ub.user_id = $2
+		AND b.deleted_at IS NULL;
+		`)).
+			WithArgs(1, 1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
 
-		updatedBook, err := store.UpdateByID(ctx, 1, types.UpdateBookPayload{
-			Name:          "Updated Book Name",
-			Description:   "Updated Description",
-			Author:        "John Doe",
-			Genres:        []string{"Genre1", "Genre2"},
-			ReleaseYear:   2025,
-			NumberOfPages: 199,
-			ImageUrl:      "http://google.com/somerandomimage.jpg",
-		})
+		book, err := store.UpdateByID(ctx, 1, payload)
 
-		assert.NoError(t, err)
-		assert.NotNil(t, updatedBook)
-		assert.Equal(t, 1, updatedBook.ID)
-		assert.Equal(t, "Updated Book Name", updatedBook.Name)
-		assert.Equal(t, "Updated Description", updatedBook.Description)
-		assert.Equal(t, []string{"Genre1", "Genre2"}, updatedBook.Genres)
-		assert.Equal(t, 2025, updatedBook.ReleaseYear)
-		assert.Equal(t, 300, updatedBook.NumberOfPages)
-		assert.Equal(t, "http://example.com/image.jpg", updatedBook.ImageUrl)
-		assert.Equal(t, mockDate, updatedBook.CreatedAt)
-		assert.Equal(t, &mockDate, updatedBook.DeletedAt)
-		assert.Equal(t, &mockDate, updatedBook.UpdatedAt)
+		assert.Nil(t, book)
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("book shared with the user as viewer", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE books SET")).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT ub.permission
+		FROM users_books ub
+		INNER JOIN books b ON b.id = ub.book_id
+		WHERE ub.book_id = $1
+		AND ub.user_id = $2
+		AND b.deleted_at IS NULL;
+		`)).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(types.BookPermissionViewer))
+		mock.ExpectRollback()
+
+		book, err := store.UpdateByID(ctx, 1, payload)
+
+		assert.Nil(t, book)
+		assert.ErrorIs(t, err, ErrBookPermissionDenied)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("ISBN already in the owner's library", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WillReturnError(&pq.Error{Code: utils.PQUniqueViolation})
+		mock.ExpectRollback()
+
+		book, err := store.UpdateByID(ctx, 1, payload)
+
+		assert.Nil(t, book)
+		assert.ErrorIs(t, err, types.ErrBookISBNExists)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("genre does not exist", func(t *testing.T) {
+		mockDate := time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "name", "description", "author", "release_year",
+				"number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "deleted_at", "updated_at",
+				"previous_image_url", "previous_thumbnail_url",
+			}).AddRow(1, "Updated Book Name", "Updated Description", "John Doe", 2025, 199, "http://google.com/somerandomimage.jpg", nil, nil, nil, mockDate, nil, &mockDate, "http://google.com/somerandomimage.jpg", ""))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM book_genres WHERE book_id = $1")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO book_genres (book_id, genre_id)")).
+			WithArgs(1, pq.Array([]int{3, 5})).
+			WillReturnError(&pq.Error{Code: utils.PQForeignKeyViolation})
+		mock.ExpectRollback()
+
+		book, err := store.UpdateByID(ctx, 1, payload)
+
+		assert.Nil(t, book)
+		assert.ErrorIs(t, err, ErrGenreNotFound)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully update book", func(t *testing.T) {
+		mockDate := time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WithArgs(
+				1, 1,
+				"Updated Book Name",
+				"Updated Description",
+				2025,
+				199,
+				"http://google.com/somerandomimage.jpg",
+				"0441013597",
+				"9780441013593",
+				sqlmock.AnyArg(),
+			).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "name", "description", "author", "release_year",
+				"number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "deleted_at", "updated_at",
+				"previous_image_url", "previous_thumbnail_url",
+			}).AddRow(
+				1,
+				"Updated Book Name",
+				"Updated Description",
+				"Author Name",
+				2025,
+				300,
+				"http://example.com/image.jpg",
+				nil,
+				"0441013597",
+				"9780441013593",
+				mockDate,
+				&mockDate,
+				&mockDate,
+				"http://example.com/image.jpg",
+				"",
+			))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM book_authors WHERE book_id = $1 AND role = $2")).
+			WithArgs(1, types.AuthorRoleAuthor).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("INSERT INTO authors").
+			WithArgs("John Doe", "johndoe").
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(4))
+		mock.ExpectExec("INSERT INTO book_authors").
+			WithArgs(1, 4, types.AuthorRoleAuthor, 0).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery(regexp.QuoteMeta("UPDATE books b SET author")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"author"}).AddRow("John Doe"))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM book_genres WHERE book_id = $1")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO book_genres (book_id, genre_id)")).
+			WithArgs(1, pq.Array([]int{3, 5})).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(bookGenresQuery).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "slug"}).
+				AddRow(1, 5, "Epic", "epic").
+				AddRow(1, 3, "Fantasy", "fantasy"))
+		mock.ExpectCommit()
+
+		updatedBook, err := store.UpdateByID(ctx, 1, payload)
+
+		assert.NoError(t, err)
+		assert.NotNil(t, updatedBook)
+		assert.Equal(t, 1, updatedBook.ID)
+		assert.Equal(t, "Updated Book Name", updatedBook.Name)
+		assert.Equal(t, "Updated Description", updatedBook.Description)
+		assert.Equal(t, "John Doe", updatedBook.Author)
+		assert.Equal(t, []*types.BookGenre{
+			{ID: 5, Name: "Epic", Slug: "epic"},
+			{ID: 3, Name: "Fantasy", Slug: "fantasy"},
+		}, updatedBook.Genres)
+		assert.Equal(t, 2025, updatedBook.ReleaseYear)
+		assert.Equal(t, 300, updatedBook.NumberOfPages)
+		assert.Equal(t, "http://example.com/image.jpg", updatedBook.ImageUrl)
+		assert.Equal(t, "0441013597", *updatedBook.ISBN10)
+		assert.Equal(t, "9780441013593", *updatedBook.ISBN13)
+		assert.Equal(t, mockDate, updatedBook.CreatedAt)
+		assert.Equal(t, &mockDate, updatedBook.DeletedAt)
+		assert.Equal(t, &mockDate, updatedBook.UpdatedAt)
+
+		err = mock.ExpectationsWereMet()
+		assert.NoError(t, err)
+	})
+
+	t.Run("successfully replace an uploaded cover", func(t *testing.T) {
+		mockDate := time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC)
+		covers := blobstore.NewLocalBlobStore(t.TempDir())
+		store := NewBookStore(db, covers)
+
+		coverKeys := []string{"books/1/0123456789abcdef/full.jpg", "books/1/0123456789abcdef/thumbnail.jpg"}
+		for _, key := range coverKeys {
+			if err := covers.Put(ctx, key, []byte("jpeg"), "image/jpeg"); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WillReturnRows(sqlmock.NewRows([]string{
+				"id", "name", "description", "author", "release_year",
+				"number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "deleted_at", "updated_at",
+				"previous_image_url", "previous_thumbnail_url",
+			}).AddRow(1, "Updated Book Name", "Updated Description", "John Doe", 2025, 199, "http://google.com/somerandomimage.jpg", nil, nil, nil, mockDate, nil, &mockDate, utils.CoverURL(coverKeys[0]), utils.CoverURL(coverKeys[1])))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM book_genres WHERE book_id = $1")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO book_genres (book_id, genre_id)")).
+			WithArgs(1, pq.Array([]int{3, 5})).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(bookGenresQuery).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "slug"}))
+		mock.ExpectCommit()
+
+		updatedBook, err := store.UpdateByID(ctx, 1, payload)
 
-		err = mock.ExpectationsWereMet()
 		assert.NoError(t, err)
+		assert.Equal(t, "http://google.com/somerandomimage.jpg", updatedBook.ImageUrl)
+
+		for _, key := range coverKeys {
+			_, _, err := covers.Get(ctx, key)
+			assert.ErrorIs(t, err, types.ErrBlobNotFound)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
 	})
 }
 
@@ -599,7 +1139,7 @@ func TestDeleteByID(t *testing.T) {
 	}
 	defer db.Close()
 
-	store := NewBookStore(db)
+	store := NewBookStore(db, nil)
 
 	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
 		ID:               "ID-CRAZY",
@@ -649,6 +1189,8 @@ func TestDeleteByID(t *testing.T) {
 				INNER JOIN users_books ub ON ub.book_id = b.id
 				WHERE b.id = $1
 				AND ub.user_id = $2
+				AND ub.permission = 'owner'
+				AND b.deleted_at IS NULL
 			)
 			RETURNING id;
 		`)).
@@ -676,6 +1218,8 @@ func TestDeleteByID(t *testing.T) {
 				INNER JOIN users_books ub ON ub.book_id = b.id
 				WHERE b.id = $1
 				AND ub.user_id = $2
+				AND ub.permission = 'owner'
+				AND b.deleted_at IS NULL
 			)
 			RETURNING id;
 		`)).
@@ -702,6 +1246,8 @@ func TestDeleteByID(t *testing.T) {
 				INNER JOIN users_books ub ON ub.book_id = b.id
 				WHERE b.id = $1
 				AND ub.user_id = $2
+				AND ub.permission = 'owner'
+				AND b.deleted_at IS NULL
 			)
 			RETURNING id;
 		`)).
@@ -716,4 +1262,1071 @@ func TestDeleteByID(t *testing.T) {
 			t.Errorf("unmet expectations: %v", err)
 		}
 	})
+
+	t.Run("book shared with the user as editor", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE books")).
+			WithArgs(1, 1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT ub.permission")).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(types.BookPermissionEditor))
+
+		err := store.DeleteByID(ctx, 1)
+
+		assert.ErrorIs(t, err, ErrBookPermissionDenied)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("book not shared with the user", func(t *testing.T) {
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE books")).
+			WithArgs(1, 1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT ub.permission")).
+			WithArgs(1, 1).
+			WillReturnError(sql.ErrNoRows)
+
+		err := store.DeleteByID(ctx, 1)
+
+		assert.ErrorIs(t, err, ErrBookNotFound)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestSearchBooks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+	expectedCreatedAt := time.Now()
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		ID:               "ID-CRAZY",
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+
+	countQuery := regexp.QuoteMeta(`
+		SELECT COUNT(*)
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE ub.user_id = $1
+		AND b.deleted_at IS NULL
+		AND b.search_vector @@ to_tsquery('simple', $2);
+	`)
+	selectQuery := regexp.QuoteMeta(`
+		ts_rank(b.search_vector, query) AS rank,
+		ts_headline('simple', coalesce(b.description, ''), query, $5) AS snippet
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id,
+		to_tsquery('simple', $2) query
+		WHERE ub.user_id = $1
+		AND b.deleted_at IS NULL
+		AND b.search_vector @@ query
+		ORDER BY rank DESC, b.id ASC
+		LIMIT $3 OFFSET $4;
+	`)
+
+	t.Run("missing userID in context", func(t *testing.T) {
+		results, total, err := store.Search(context.Background(), types.SearchBooksOptions{Query: "go", Page: 1, Limit: 20})
+
+		assert.Error(t, err)
+		assert.Equal(t, "failed to retrieve userID from context", err.Error())
+		assert.Nil(t, results)
+		assert.Zero(t, total)
+	})
+
+	t.Run("query without searchable terms", func(t *testing.T) {
+		results, total, err := store.Search(ctx, types.SearchBooksOptions{Query: "!?&", Page: 1, Limit: 20})
+
+		assert.NoError(t, err)
+		assert.Empty(t, results)
+		assert.Zero(t, total)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("database connection error", func(t *testing.T) {
+		mock.ExpectQuery(countQuery).
+			WithArgs(1, "go:*").
+			WillReturnError(sql.ErrConnDone)
+
+		results, total, err := store.Search(ctx, types.SearchBooksOptions{Query: "go", Page: 1, Limit: 20})
+
+		assert.Error(t, err)
+		assert.Nil(t, results)
+		assert.Zero(t, total)
+		assert.True(t, errors.Is(err, sql.ErrConnDone))
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully search user books with prefix terms", func(t *testing.T) {
+		mock.ExpectQuery(countQuery).
+			WithArgs(1, "lord:* & ring:*").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(selectQuery).
+			WithArgs(1, "lord:* & ring:*", 10, 10, "StartSel=\x02, StopSel=\x03, MaxFragments=2").
+			WillReturnRows(
+				sqlmock.NewRows([]string{
+					"id", "name", "description", "author", "release_year", "number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "updated_at", "deleted_at", "rank", "snippet",
+				}).
+					AddRow(1, "The Lord of the Rings", "One <b>ring</b> to rule them all", "J. R. R. Tolkien", 1954, 1178, "http://example.com/lotr.jpg", nil, nil, nil, expectedCreatedAt, nil, nil, 0.75, "One <b>\x02ring\x03</b> to <script>rule</script> them all"),
+			)
+		mock.ExpectQuery(bookGenresQuery).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "slug"}).
+				AddRow(1, 4, "Fantasy", "fantasy"))
+
+		results, total, err := store.Search(ctx, types.SearchBooksOptions{Query: "Lord, Ring!", Page: 2, Limit: 10})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Len(t, results, 1)
+		assert.Equal(t, "The Lord of the Rings", results[0].Name)
+		assert.Equal(t, 0.75, results[0].Rank)
+		assert.Equal(t, "One &lt;b&gt;<mark>ring</mark>&lt;/b&gt; to &lt;script&gt;rule&lt;/script&gt; them all", results[0].Snippet)
+		assert.Equal(t, []*types.BookGenre{{ID: 4, Name: "Fantasy", Slug: "fantasy"}}, results[0].Genres)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetAllBooks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+	options := types.GetAllBooksOptions{Page: 1, Limit: 20}
+
+	t.Run("database connection error", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM books")).
+			WillReturnError(sql.ErrConnDone)
+
+		books, total, err := store.GetAll(context.Background(), options)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+		assert.Nil(t, books)
+		assert.Zero(t, total)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully list every book", func(t *testing.T) {
+		deletedAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM books")).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM books")).
+			WithArgs(20, 0).
+			WillReturnRows(
+				sqlmock.NewRows([]string{
+					"id", "name", "description", "author", "release_year", "number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "updated_at", "deleted_at",
+				}).
+					AddRow(1, "The Lord of the Rings", "One ring to rule them all", "J. R. R. Tolkien", 1954, 1178, "http://example.com/lotr.jpg", nil, nil, nil, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), nil, deletedAt),
+			)
+		mock.ExpectQuery(bookGenresQuery).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "slug"}).
+				AddRow(1, 4, "Fantasy", "fantasy"))
+
+		books, total, err := store.GetAll(context.Background(), options)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, total)
+		assert.Len(t, books, 1)
+		assert.Equal(t, &deletedAt, books[0].DeletedAt)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDisableAndRestoreBookByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+	disableQuery := regexp.QuoteMeta("UPDATE books SET deleted_at = COALESCE(deleted_at, $2), disabled_by_admin = TRUE WHERE id = $1 AND NOT disabled_by_admin")
+	restoreQuery := regexp.QuoteMeta("UPDATE books SET deleted_at = NULL, disabled_by_admin = FALSE, updated_at = $2 WHERE id = $1 AND deleted_at IS NOT NULL")
+
+	t.Run("disable did not find any active book", func(t *testing.T) {
+		mock.ExpectExec(disableQuery).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.DisableByID(context.Background(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully disable book", func(t *testing.T) {
+		mock.ExpectExec(disableQuery).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.DisableByID(context.Background(), 1)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("restore did not find any disabled book", func(t *testing.T) {
+		mock.ExpectExec(restoreQuery).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.RestoreByID(context.Background(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully restore book", func(t *testing.T) {
+		mock.ExpectExec(restoreQuery).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.RestoreByID(context.Background(), 1)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetDeletedBooks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		ID:               "ID-CRAZY",
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+	deletedAfter := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	options := types.GetDeletedBooksOptions{Page: 2, Limit: 10, DeletedAfter: deletedAfter}
+
+	t.Run("missing userID in context", func(t *testing.T) {
+		books, total, err := store.GetDeleted(context.Background(), options)
+
+		assert.Nil(t, books)
+		assert.Zero(t, total)
+		assert.Equal(t, "failed to retrieve userID from context", err.Error())
+	})
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*)")).
+			WithArgs(1, deletedAfter).
+			WillReturnError(sql.ErrConnDone)
+
+		books, total, err := store.GetDeleted(ctx, options)
+
+		assert.Nil(t, books)
+		assert.Zero(t, total)
+		assert.ErrorIs(t, err, sql.ErrConnDone)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get deleted books", func(t *testing.T) {
+		createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		deletedAt := time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		SELECT COUNT(*)
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE ub.user_id = $1
+		AND ub.permission = 'owner'
+		AND b.deleted_at > $2
+		AND NOT b.disabled_by_admin;
+		`)).
+			WithArgs(1, deletedAfter).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(11))
+
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		AND b.deleted_at > $2
+		AND NOT b.disabled_by_admin
+		ORDER BY b.deleted_at DESC, b.id DESC
+		LIMIT $3 OFFSET $4;
+		`)).
+			WithArgs(1, deletedAfter, 10, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "author", "release_year", "number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "updated_at", "deleted_at"}).
+				AddRow(1, "Dune", "A desert planet", "Frank Herbert", 1965, 412, "http://example.com/dune.jpg", nil, nil, nil, createdAt, nil, deletedAt))
+		mock.ExpectQuery(bookGenresQuery).
+			WithArgs(pq.Array([]int{1})).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "slug"}).
+				AddRow(1, 2, "Sci-Fi", "sci-fi"))
+
+		books, total, err := store.GetDeleted(ctx, options)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 11, total)
+		assert.Equal(t, []*types.Book{
+			{
+				ID:            1,
+				Name:          "Dune",
+				Description:   "A desert planet",
+				Author:        "Frank Herbert",
+				Genres:        []*types.BookGenre{{ID: 2, Name: "Sci-Fi", Slug: "sci-fi"}},
+				ReleaseYear:   1965,
+				NumberOfPages: 412,
+				ImageUrl:      "http://example.com/dune.jpg",
+				CreatedAt:     createdAt,
+				DeletedAt:     &deletedAt,
+			},
+		}, books)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRestoreDeletedBookByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		ID:               "ID-CRAZY",
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+	deletedAfter := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	query := regexp.QuoteMeta(`
+		UPDATE books
+		SET deleted_at = NULL, updated_at = $4
+		WHERE id IN (
+			SELECT b.id
+			FROM books b
+			INNER JOIN users_books ub ON ub.book_id = b.id
+			WHERE b.id = $1
+			AND ub.user_id = $2
+			AND ub.permission = 'owner'
+			AND b.deleted_at > $3
+			AND NOT b.disabled_by_admin
+		);
+	`)
+
+	t.Run("missing userID in context", func(t *testing.T) {
+		err := store.RestoreDeletedByID(context.Background(), 1, deletedAfter)
+
+		assert.Equal(t, "failed to retrieve userID from context", err.Error())
+	})
+
+	t.Run("database did not find any deleted book", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(7, 1, deletedAfter, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.RestoreDeletedByID(ctx, 7, deletedAfter)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully restore deleted book", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(7, 1, deletedAfter, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.RestoreDeletedByID(ctx, 7, deletedAfter)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestPurgeDeletedBooks(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	covers := blobstore.NewLocalBlobStore(t.TempDir())
+	store := NewBookStore(db, covers)
+	deletedBefore := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	usersBooksQuery := regexp.QuoteMeta("DELETE FROM users_books")
+	booksQuery := regexp.QuoteMeta("DELETE FROM books WHERE deleted_at < $1 AND NOT disabled_by_admin RETURNING id, image_url, COALESCE(thumbnail_url, '')")
+
+	ctx := context.Background()
+	coverKeys := []string{"books/1/0123456789abcdef/full.jpg", "books/1/0123456789abcdef/thumbnail.jpg"}
+	for _, key := range coverKeys {
+		if err := covers.Put(ctx, key, []byte("jpeg"), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(usersBooksQuery).
+			WithArgs(deletedBefore).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(booksQuery).
+			WithArgs(deletedBefore).
+			WillReturnError(fmt.Errorf("database connection error"))
+		mock.ExpectRollback()
+
+		purged, err := store.PurgeDeleted(ctx, deletedBefore)
+
+		assert.Error(t, err)
+		assert.Zero(t, purged)
+
+		for _, key := range coverKeys {
+			_, _, err := covers.Get(ctx, key)
+			assert.NoError(t, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully purge deleted books", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(usersBooksQuery).
+			WithArgs(deletedBefore).
+			WillReturnResult(sqlmock.NewResult(0, 2))
+		mock.ExpectQuery(booksQuery).
+			WithArgs(deletedBefore).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "image_url", "thumbnail_url"}).
+				AddRow(1, utils.CoverURL(coverKeys[0]), utils.CoverURL(coverKeys[1])).
+				AddRow(3, "http://google.com/somerandomimage.jpg", ""))
+		mock.ExpectCommit()
+
+		purged, err := store.PurgeDeleted(ctx, deletedBefore)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, purged)
+
+		for _, key := range coverKeys {
+			_, _, err := covers.Get(ctx, key)
+			assert.ErrorIs(t, err, types.ErrBlobNotFound)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestShareByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+	ownerQuery := regexp.QuoteMeta(`
+		SELECT ub.book_id
+		FROM users_books ub
+		INNER JOIN books b ON b.id = ub.book_id
+		WHERE ub.book_id = $1
+		AND ub.user_id = $2
+		AND ub.permission = 'owner'
+		AND b.deleted_at IS NULL;
+	`)
+	userQuery := regexp.QuoteMeta("SELECT id, username, email FROM users WHERE email = $1 AND deleted_at IS NULL")
+	payload := types.ShareBookPayload{Email: "janedoe@email.com", Permission: types.BookPermissionViewer}
+
+	t.Run("missing userID in context", func(t *testing.T) {
+		share, err := store.ShareByID(context.Background(), 1, payload)
+
+		assert.Nil(t, share)
+		assert.Equal(t, "failed to retrieve userID from context", err.Error())
+	})
+
+	t.Run("book shared with the caller as editor", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(ownerQuery).
+			WithArgs(1, 1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT ub.permission")).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(types.BookPermissionEditor))
+		mock.ExpectRollback()
+
+		share, err := store.ShareByID(ctx, 1, payload)
+
+		assert.Nil(t, share)
+		assert.ErrorIs(t, err, ErrBookPermissionDenied)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("user to share with not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(ownerQuery).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow(1))
+		mock.ExpectQuery(userQuery).
+			WithArgs("janedoe@email.com").
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		share, err := store.ShareByID(ctx, 1, payload)
+
+		assert.Nil(t, share)
+		assert.ErrorIs(t, err, ErrShareUserNotFound)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("sharing with the owner", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(ownerQuery).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow(1))
+		mock.ExpectQuery(userQuery).
+			WithArgs("johndoe@email.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).AddRow(1, "johndoe", "johndoe@email.com"))
+		mock.ExpectRollback()
+
+		share, err := store.ShareByID(ctx, 1, types.ShareBookPayload{Email: "johndoe@email.com", Permission: types.BookPermissionEditor})
+
+		assert.Nil(t, share)
+		assert.ErrorIs(t, err, ErrShareWithOwner)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully share book", func(t *testing.T) {
+		createdAt := time.Date(2025, 3, 19, 10, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(ownerQuery).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id"}).AddRow(1))
+		mock.ExpectQuery(userQuery).
+			WithArgs("janedoe@email.com").
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email"}).AddRow(2, "janedoe", "janedoe@email.com"))
+		mock.ExpectQuery(regexp.QuoteMeta(`
+		INSERT INTO users_books (user_id, book_id, permission)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, book_id)
+		DO UPDATE SET permission = EXCLUDED.permission, updated_at = $4
+		RETURNING created_at;
+		`)).
+			WithArgs(2, 1, types.BookPermissionViewer, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"created_at"}).AddRow(createdAt))
+		mock.ExpectCommit()
+
+		share, err := store.ShareByID(ctx, 1, payload)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.BookShare{
+			UserID:     2,
+			Username:   "janedoe",
+			Email:      "janedoe@email.com",
+			Permission: types.BookPermissionViewer,
+			CreatedAt:  createdAt,
+		}, share)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetSharesByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+	query := regexp.QuoteMeta("SELECT ub.user_id, u.username, u.email, ub.permission, ub.created_at")
+	columns := []string{"user_id", "username", "email", "permission", "created_at"}
+
+	t.Run("book not found", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows(columns))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT ub.permission")).
+			WithArgs(1, 1).
+			WillReturnError(sql.ErrNoRows)
+
+		shares, err := store.GetSharesByID(ctx, 1)
+
+		assert.Nil(t, shares)
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get shares", func(t *testing.T) {
+		createdAt := time.Date(2025, 3, 19, 10, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(query).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows(columns).
+				AddRow(1, "johndoe", "johndoe@email.com", types.BookPermissionOwner, createdAt).
+				AddRow(2, "janedoe", "janedoe@email.com", types.BookPermissionEditor, createdAt))
+
+		shares, err := store.GetSharesByID(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.Len(t, shares, 2)
+		assert.Equal(t, types.BookPermissionOwner, shares[0].Permission)
+		assert.Equal(t, 2, shares[1].UserID)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRevokeShareByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+	query := regexp.QuoteMeta("DELETE FROM users_books")
+
+	t.Run("share not found", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, 1, 2).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.RevokeShareByID(ctx, 1, 2)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully revoke share", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, 1, 2).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.RevokeShareByID(ctx, 1, 2)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetAuthorsByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+	authorsQuery := regexp.QuoteMeta(`
+		SELECT a.id, a.name, ba.role
+		FROM book_authors ba
+		INNER JOIN authors a ON a.id = ba.author_id
+		WHERE ba.book_id = $1
+		ORDER BY ba.position, a.name;
+	`)
+
+	t.Run("book not found", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT ub.permission")).
+			WithArgs(1, 1).
+			WillReturnError(sql.ErrNoRows)
+
+		authors, err := store.GetAuthorsByID(ctx, 1)
+
+		assert.Nil(t, authors)
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get authors", func(t *testing.T) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT ub.permission")).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(types.BookPermissionViewer))
+		mock.ExpectQuery(authorsQuery).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "role"}).
+				AddRow(4, "Neil Gaiman", types.AuthorRoleAuthor).
+				AddRow(5, "Terry Pratchett", types.AuthorRoleAuthor))
+
+		authors, err := store.GetAuthorsByID(ctx, 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, []*types.BookAuthor{
+			{ID: 4, Name: "Neil Gaiman", Role: types.AuthorRoleAuthor},
+			{ID: 5, Name: "Terry Pratchett", Role: types.AuthorRoleAuthor},
+		}, authors)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestSetAuthorsByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+	editableQuery := regexp.QuoteMeta(`
+		SELECT b.id
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE b.id = $1
+		AND ub.user_id = $2
+		AND ub.permission IN ('owner', 'editor')
+		AND b.deleted_at IS NULL
+		FOR UPDATE OF b;
+	`)
+	insertQuery := regexp.QuoteMeta("INSERT INTO book_authors (book_id, author_id, role, position)")
+	authors := []types.BookAuthorPayload{
+		{AuthorID: 4, Role: types.AuthorRoleAuthor},
+		{AuthorID: 5, Role: types.AuthorRoleAuthor},
+		{AuthorID: 6, Role: types.AuthorRoleIllustrator},
+	}
+
+	t.Run("book shared with the user as viewer", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(editableQuery).
+			WithArgs(1, 1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT ub.permission")).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(types.BookPermissionViewer))
+		mock.ExpectRollback()
+
+		bookAuthors, err := store.SetAuthorsByID(ctx, 1, authors)
+
+		assert.Nil(t, bookAuthors)
+		assert.ErrorIs(t, err, ErrBookPermissionDenied)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("author not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(editableQuery).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM book_authors WHERE book_id = $1")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertQuery).
+			WithArgs(1, 4, types.AuthorRoleAuthor, 0).
+			WillReturnError(&pq.Error{Code: utils.PQForeignKeyViolation})
+		mock.ExpectRollback()
+
+		bookAuthors, err := store.SetAuthorsByID(ctx, 1, authors)
+
+		assert.Nil(t, bookAuthors)
+		assert.ErrorIs(t, err, ErrAuthorNotFound)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully set authors", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(editableQuery).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM book_authors WHERE book_id = $1")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		for position, author := range authors {
+			mock.ExpectExec(insertQuery).
+				WithArgs(1, author.AuthorID, author.Role, position).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT a.id, a.name, ba.role")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "role"}).
+				AddRow(4, "Neil Gaiman", types.AuthorRoleAuthor).
+				AddRow(5, "Terry Pratchett", types.AuthorRoleAuthor).
+				AddRow(6, "Josh Kirby", types.AuthorRoleIllustrator))
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE books b SET author = "+utils.BookAuthorCreditSQL+", updated_at = $2 WHERE b.id = $1")).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		bookAuthors, err := store.SetAuthorsByID(ctx, 1, authors)
+
+		assert.NoError(t, err)
+		assert.Len(t, bookAuthors, 3)
+		assert.Equal(t, types.AuthorRoleIllustrator, bookAuthors[2].Role)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestCheckCanEditByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+	permissionQuery := regexp.QuoteMeta("SELECT ub.permission")
+
+	t.Run("book not found", func(t *testing.T) {
+		mock.ExpectQuery(permissionQuery).
+			WithArgs(42, 1).
+			WillReturnError(sql.ErrNoRows)
+
+		err := store.CheckCanEditByID(ctx, 42)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("book shared with the user as viewer", func(t *testing.T) {
+		mock.ExpectQuery(permissionQuery).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(types.BookPermissionViewer))
+
+		err := store.CheckCanEditByID(ctx, 1)
+
+		assert.ErrorIs(t, err, ErrBookPermissionDenied)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("book shared with the user as editor", func(t *testing.T) {
+		mock.ExpectQuery(permissionQuery).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(types.BookPermissionEditor))
+
+		err := store.CheckCanEditByID(ctx, 1)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestSetCoverByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewBookStore(db, nil)
+
+	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
+		UserID:           1,
+		Username:         "johndoe",
+		Email:            "johndoe@email.com",
+		RegisteredClaims: jwt.RegisteredClaims{ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour * 24))},
+	})
+	coverQuery := regexp.QuoteMeta(`
+		SELECT b.image_url, COALESCE(b.thumbnail_url, '')
+		FROM books b
+		INNER JOIN users_books ub ON ub.book_id = b.id
+		WHERE b.id = $1
+		AND ub.user_id = $2
+		AND ub.permission IN ('owner', 'editor')
+		AND b.deleted_at IS NULL
+		FOR UPDATE OF b;
+	`)
+	updateQuery := regexp.QuoteMeta("UPDATE books SET image_url = $2, thumbnail_url = $3, updated_at = $4 WHERE id = $1")
+	cover := types.BookCover{
+		ImageUrl:     "http://localhost:8182/api/v1/covers/books/1/abc/full.jpg",
+		ThumbnailUrl: "http://localhost:8182/api/v1/covers/books/1/abc/thumbnail.jpg",
+	}
+
+	t.Run("book shared with the user as viewer", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(coverQuery).
+			WithArgs(1, 1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT ub.permission")).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"permission"}).AddRow(types.BookPermissionViewer))
+		mock.ExpectRollback()
+
+		previous, err := store.SetCoverByID(ctx, 1, cover)
+
+		assert.Nil(t, previous)
+		assert.ErrorIs(t, err, ErrBookPermissionDenied)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("book not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(coverQuery).
+			WithArgs(42, 1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT ub.permission")).
+			WithArgs(42, 1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		previous, err := store.SetCoverByID(ctx, 42, cover)
+
+		assert.Nil(t, previous)
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully set cover", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(coverQuery).
+			WithArgs(1, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"image_url", "thumbnail_url"}).AddRow("http://example.com/dune.jpg", ""))
+		mock.ExpectExec(updateQuery).
+			WithArgs(1, cover.ImageUrl, cover.ThumbnailUrl, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		previous, err := store.SetCoverByID(ctx, 1, cover)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.BookCover{ImageUrl: "http://example.com/dune.jpg"}, previous)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
 }
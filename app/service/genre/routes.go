@@ -0,0 +1,288 @@
+package genre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+)
+
+var validate = validator.New()
+
+type GenreHandler struct {
+	genreStore types.GenreStore
+}
+
+func NewGenreHandler(genreStore types.GenreStore) *GenreHandler {
+	return &GenreHandler{genreStore: genreStore}
+}
+
+func parseID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		return 0, err
+	}
+	if id <= 0 {
+		return 0, fmt.Errorf("id must be positive, got %d", id)
+	}
+
+	return id, nil
+}
+
+// normalizeSlug trims the name and slugifies the slug, falling back to the
+// slug of the name when none is given.
+func normalizeSlug(name, slug *string) {
+	*name = strings.TrimSpace(*name)
+	if strings.TrimSpace(*slug) == "" {
+		*slug = *name
+	}
+	*slug = utils.GenreSlug(*slug)
+}
+
+func writeValidationError(w http.ResponseWriter, err error, context string) {
+	var errorMessages []string
+	for _, e := range err.(validator.ValidationErrors) {
+		errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+	}
+
+	utils.WriteError(w, http.StatusBadRequest, err, context, types.BadRequestStructResponse{Error: errorMessages})
+}
+
+// @Summary Criar gênero
+// @Description Cadastra um gênero, opcionalmente como subgênero de outro. O slug é gerado a partir do nome quando não informado. Apenas administradores.
+// @Tags Genres
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.CreateGenrePayload true "Dados do gênero"
+// @Success 201 {object} types.CreateGenreResponse "ID do novo gênero"
+// @Failure 400 {object} types.BadRequestResponse "Body is not a valid json ou Parent genre does not exist"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 409 {object} types.ConflictResponse "A genre with this slug already exists"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /genres [post]
+func (h *GenreHandler) HandleCreateGenre(w http.ResponseWriter, r *http.Request) {
+	var payload types.CreateGenrePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateGenre", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	normalizeSlug(&payload.Name, &payload.Slug)
+	if err := validate.Struct(payload); err != nil {
+		writeValidationError(w, err, "HandleCreateGenre")
+		return
+	}
+
+	id, err := h.genreStore.Create(r.Context(), payload)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleCreateGenre", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if errors.Is(err, ErrGenreExists) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleCreateGenre", types.ConflictResponse{Error: "A genre with this slug already exists"})
+			return
+		}
+
+		if errors.Is(err, ErrGenreParentNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateGenre", types.BadRequestResponse{Error: "Parent genre does not exist"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleCreateGenre", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.CreateGenreResponse{ID: id})
+}
+
+// @Summary Listar gêneros
+// @Description Lista todos os gêneros em ordem alfabética. A hierarquia é dada pelo parent_id de cada um.
+// @Tags Genres
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.GetGenresResponse "Lista de gêneros"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /genres [get]
+func (h *GenreHandler) HandleGetGenres(w http.ResponseWriter, r *http.Request) {
+	genres, err := h.genreStore.GetAll(r.Context())
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetGenres", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetGenres", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetGenresResponse{Genres: genres})
+}
+
+// @Summary Obter gênero por ID
+// @Tags Genres
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do gênero"
+// @Success 200 {object} types.Genre "Detalhes do gênero"
+// @Failure 400 {object} types.BadRequestResponse "Genre ID must be a positive integer"
+// @Failure 404 {object} types.NotFoundResponse "No genre found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /genres/{id} [get]
+func (h *GenreHandler) HandleGetGenreByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleGetGenreByID", types.BadRequestResponse{Error: "Genre ID must be a positive integer"})
+		return
+	}
+
+	genre, err := h.genreStore.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetGenreByID", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleGetGenreByID", types.NotFoundResponse{Error: fmt.Sprintf("No genre found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetGenreByID", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, genre)
+}
+
+// @Summary Atualizar gênero por ID
+// @Description Renomeia um gênero ou o move para outro gênero pai. Um parent_id nulo o leva para o topo da hierarquia. Apenas administradores.
+// @Tags Genres
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param id path int true "ID do gênero"
+// @Param request body types.UpdateGenrePayload true "Dados do gênero"
+// @Success 200 {object} types.Genre "Gênero atualizado"
+// @Failure 400 {object} types.BadRequestResponse "Genre ID must be a positive integer, Body is not a valid json ou Parent genre does not exist"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No genre found with given ID"
+// @Failure 409 {object} types.ConflictResponse "A genre with this slug already exists ou A genre can't be nested under itself or its subgenres"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /genres/{id} [put]
+func (h *GenreHandler) HandleUpdateGenreByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleUpdateGenreByID", types.BadRequestResponse{Error: "Genre ID must be a positive integer"})
+		return
+	}
+
+	var payload types.UpdateGenrePayload
+	if err := utils.ParseJSON(r, &payload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleUpdateGenreByID", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	normalizeSlug(&payload.Name, &payload.Slug)
+	if err := validate.Struct(payload); err != nil {
+		writeValidationError(w, err, "HandleUpdateGenreByID")
+		return
+	}
+
+	genre, err := h.genreStore.UpdateByID(r.Context(), id, payload)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleUpdateGenreByID", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleUpdateGenreByID", types.NotFoundResponse{Error: fmt.Sprintf("No genre found with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, ErrGenreExists) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleUpdateGenreByID", types.ConflictResponse{Error: "A genre with this slug already exists"})
+			return
+		}
+
+		if errors.Is(err, ErrGenreCycle) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleUpdateGenreByID", types.ConflictResponse{Error: "A genre can't be nested under itself or its subgenres"})
+			return
+		}
+
+		if errors.Is(err, ErrGenreParentNotFound) {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleUpdateGenreByID", types.BadRequestResponse{Error: "Parent genre does not exist"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUpdateGenreByID", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, genre)
+}
+
+// @Summary Excluir gênero por ID
+// @Description Exclui um gênero sem subgêneros e sem livros. Apenas administradores.
+// @Tags Genres
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID do gênero"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Genre ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 403 {object} types.ForbiddenResponse "Forbidden"
+// @Failure 404 {object} types.NotFoundResponse "No genre found with given ID"
+// @Failure 409 {object} types.ConflictResponse "The genre still has subgenres or books"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /genres/{id} [delete]
+func (h *GenreHandler) HandleDeleteGenreByID(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleDeleteGenreByID", types.BadRequestResponse{Error: "Genre ID must be a positive integer"})
+		return
+	}
+
+	err = h.genreStore.DeleteByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleDeleteGenreByID", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleDeleteGenreByID", types.NotFoundResponse{Error: fmt.Sprintf("No genre found with ID %d", id)})
+			return
+		}
+
+		if errors.Is(err, ErrGenreInUse) {
+			utils.WriteError(w, http.StatusConflict, err, "HandleDeleteGenreByID", types.ConflictResponse{Error: "The genre still has subgenres or books"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDeleteGenreByID", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
@@ -0,0 +1,320 @@
+package genre_test
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/cmd/api"
+	"github.com/hoyci/book-store-api/mocks"
+	"github.com/hoyci/book-store-api/service/genre"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func setupTestServer() (*mocks.MockGenreStore, *httptest.Server, *mux.Router) {
+	mockGenreStore := new(mocks.MockGenreStore)
+	mockGenreHandler := genre.NewGenreHandler(mockGenreStore)
+	apiServer := api.NewApiServer(":8080", nil)
+	router := apiServer.SetupRouter(nil, nil, nil, nil, nil, nil, mockGenreHandler)
+	ts := httptest.NewServer(router)
+	return mockGenreStore, ts, router
+}
+
+func TestHandleCreateGenre(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should forbid users that are not admins", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		for _, role := range []string{types.RoleReader, types.RoleLibrarian} {
+			req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/genres", bytes.NewBufferString(`{"name":"Fantasy"}`))
+			req.Header.Set("Authorization", "Bearer "+utils.GenerateTestTokenWithRole(2, "JohnDoe", "johndoe@email.com", role))
+			w := httptest.NewRecorder()
+
+			router.ServeHTTP(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		}
+
+		mockGenreStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the name has no letters or digits", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/genres", bytes.NewBufferString(`{"name":"--"}`))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'Slug' is invalid: required"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+		mockGenreStore.AssertNotCalled(t, "Create", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the parent genre does not exist", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockGenreStore.On("Create", mock.Anything, mock.Anything).Return(0, genre.ErrGenreParentNotFound)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/genres", bytes.NewBufferString(`{"name":"Epic","parent_id":99}`))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Parent genre does not exist"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should derive the slug from the name", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		parentID := 1
+		mockGenreStore.On("Create", mock.Anything, types.CreateGenrePayload{Name: "Science Fiction", Slug: "science-fiction", ParentID: &parentID}).Return(4, nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/genres", bytes.NewBufferString(`{"name":" Science Fiction ","parent_id":1}`))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"id":4}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the slug is taken", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockGenreStore.On("Create", mock.Anything, types.CreateGenrePayload{Name: "Epic", Slug: "epic-fantasy"}).Return(0, genre.ErrGenreExists)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/genres", bytes.NewBufferString(`{"name":"Epic","slug":"Epic Fantasy"}`))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"A genre with this slug already exists"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleGetGenres(t *testing.T) {
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com")
+
+	t.Run("it should return error when the request context is canceled", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		canceledCtx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		mockGenreStore.On("GetAll", mock.Anything).Return(([]*types.Genre)(nil), context.Canceled)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/genres", nil).WithContext(canceledCtx)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+	})
+
+	t.Run("it should list every genre", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		parentID := 1
+		createdAt := time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+		mockGenreStore.On("GetAll", mock.Anything).Return([]*types.Genre{
+			{ID: 2, Name: "Fantasy", Slug: "fantasy", ParentID: &parentID, CreatedAt: createdAt},
+			{ID: 1, Name: "Fiction", Slug: "fiction", CreatedAt: createdAt},
+		}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/genres", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"genres":[
+			{"id":2,"name":"Fantasy","slug":"fantasy","parent_id":1,"created_at":"2025-03-24T00:00:00Z","updated_at":null},
+			{"id":1,"name":"Fiction","slug":"fiction","parent_id":null,"created_at":"2025-03-24T00:00:00Z","updated_at":null}
+		]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleGetGenreByID(t *testing.T) {
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@email.com")
+
+	t.Run("it should throw an error when the genre does not exist", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockGenreStore.On("GetByID", mock.Anything, 9).Return((*types.Genre)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/genres/9", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No genre found with ID 9"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleUpdateGenreByID(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when the genre would be nested under itself", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockGenreStore.On("UpdateByID", mock.Anything, 1, mock.Anything).Return((*types.Genre)(nil), genre.ErrGenreCycle)
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/genres/1", bytes.NewBufferString(`{"name":"Fiction","parent_id":3}`))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"A genre can't be nested under itself or its subgenres"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should successfully move the genre", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		parentID := 2
+		createdAt := time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+		mockGenreStore.On("UpdateByID", mock.Anything, 3, types.UpdateGenrePayload{Name: "Epic", Slug: "epic", ParentID: &parentID}).Return(
+			&types.Genre{ID: 3, Name: "Epic", Slug: "epic", ParentID: &parentID, CreatedAt: createdAt, UpdatedAt: &createdAt},
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/genres/3", bytes.NewBufferString(`{"name":"Epic","parent_id":2}`))
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"id":3,"name":"Epic","slug":"epic","parent_id":2,"created_at":"2025-03-24T00:00:00Z","updated_at":"2025-03-24T00:00:00Z"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleDeleteGenreByID(t *testing.T) {
+	adminToken := utils.GenerateTestTokenWithRole(1, "admin", "admin@email.com", types.RoleAdmin)
+
+	t.Run("it should throw an error when the genre is still in use", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockGenreStore.On("DeleteByID", mock.Anything, 1).Return(genre.ErrGenreInUse)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/genres/1", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusConflict, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"The genre still has subgenres or books"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should successfully delete the genre", func(t *testing.T) {
+		mockGenreStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockGenreStore.On("DeleteByID", mock.Anything, 3).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/genres/3", nil)
+		req.Header.Set("Authorization", "Bearer "+adminToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
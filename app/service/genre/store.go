@@ -0,0 +1,213 @@
+package genre
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+)
+
+var (
+	ErrGenreExists         = errors.New("genre slug already exists")
+	ErrGenreParentNotFound = errors.New("parent genre not found")
+	ErrGenreCycle          = errors.New("genre cannot be nested under itself")
+	ErrGenreInUse          = errors.New("genre has subgenres or books")
+)
+
+type GenreStore struct {
+	db *sql.DB
+}
+
+func NewGenreStore(db *sql.DB) *GenreStore {
+	return &GenreStore{db: db}
+}
+
+func (s *GenreStore) Create(ctx context.Context, genre types.CreateGenrePayload) (int, error) {
+	var genreID int
+	err := s.db.QueryRowContext(
+		ctx,
+		`
+		INSERT INTO genres (name, slug, parent_id)
+		VALUES ($1, $2, $3)
+		RETURNING id;
+		`,
+		genre.Name,
+		genre.Slug,
+		genre.ParentID,
+	).Scan(&genreID)
+	if utils.IsPQError(err, utils.PQUniqueViolation) {
+		return 0, ErrGenreExists
+	}
+	if utils.IsPQError(err, utils.PQForeignKeyViolation) {
+		return 0, fmt.Errorf("%w: %d", ErrGenreParentNotFound, *genre.ParentID)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	return genreID, nil
+}
+
+func (s *GenreStore) GetByID(ctx context.Context, genreID int) (*types.Genre, error) {
+	genre := &types.Genre{}
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT id, name, slug, parent_id, created_at, updated_at FROM genres WHERE id = $1",
+		genreID,
+	).Scan(
+		&genre.ID,
+		&genre.Name,
+		&genre.Slug,
+		&genre.ParentID,
+		&genre.CreatedAt,
+		&genre.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return genre, nil
+}
+
+// GetAll lists every genre by name. The taxonomy is small enough to be sent
+// whole, and clients build the tree from ParentID.
+func (s *GenreStore) GetAll(ctx context.Context) ([]*types.Genre, error) {
+	rows, err := s.db.QueryContext(
+		ctx,
+		"SELECT id, name, slug, parent_id, created_at, updated_at FROM genres ORDER BY name, id",
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	genres := []*types.Genre{}
+
+	for rows.Next() {
+		genre := &types.Genre{}
+		err := rows.Scan(
+			&genre.ID,
+			&genre.Name,
+			&genre.Slug,
+			&genre.ParentID,
+			&genre.CreatedAt,
+			&genre.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		genres = append(genres, genre)
+	}
+
+	return genres, rows.Err()
+}
+
+// UpdateByID renames the genre and moves it under another parent. Moving a
+// genre under itself or one of its subgenres fails with ErrGenreCycle. The
+// table is locked while checking so that two concurrent moves can't close a
+// cycle between them.
+func (s *GenreStore) UpdateByID(ctx context.Context, genreID int, newGenre types.UpdateGenrePayload) (*types.Genre, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	if newGenre.ParentID != nil {
+		_, err = tx.ExecContext(ctx, "LOCK TABLE genres IN SHARE ROW EXCLUSIVE MODE")
+		if err != nil {
+			return nil, err
+		}
+
+		var cycle bool
+		err = tx.QueryRowContext(
+			ctx,
+			`
+			WITH RECURSIVE ancestors AS (
+				SELECT id, parent_id FROM genres WHERE id = $2
+				UNION
+				SELECT g.id, g.parent_id FROM genres g INNER JOIN ancestors a ON g.id = a.parent_id
+			)
+			SELECT EXISTS (SELECT 1 FROM ancestors WHERE id = $1);
+			`,
+			genreID,
+			*newGenre.ParentID,
+		).Scan(&cycle)
+		if err != nil {
+			return nil, err
+		}
+		if cycle {
+			err = fmt.Errorf("%w: %d under %d", ErrGenreCycle, genreID, *newGenre.ParentID)
+			return nil, err
+		}
+	}
+
+	genre := &types.Genre{}
+	err = tx.QueryRowContext(
+		ctx,
+		`
+		UPDATE genres
+		SET name = $2, slug = $3, parent_id = $4, updated_at = $5
+		WHERE id = $1
+		RETURNING id, name, slug, parent_id, created_at, updated_at;
+		`,
+		genreID,
+		newGenre.Name,
+		newGenre.Slug,
+		newGenre.ParentID,
+		time.Now(),
+	).Scan(
+		&genre.ID,
+		&genre.Name,
+		&genre.Slug,
+		&genre.ParentID,
+		&genre.CreatedAt,
+		&genre.UpdatedAt,
+	)
+	if utils.IsPQError(err, utils.PQUniqueViolation) {
+		err = ErrGenreExists
+		return nil, err
+	}
+	if utils.IsPQError(err, utils.PQForeignKeyViolation) {
+		err = fmt.Errorf("%w: %d", ErrGenreParentNotFound, *newGenre.ParentID)
+		return nil, err
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return genre, nil
+}
+
+// DeleteByID only removes genres without subgenres and that no book is
+// filed under.
+func (s *GenreStore) DeleteByID(ctx context.Context, genreID int) error {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM genres WHERE id = $1", genreID)
+	if utils.IsPQError(err, utils.PQForeignKeyViolation) {
+		return ErrGenreInUse
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
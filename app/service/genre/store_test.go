@@ -0,0 +1,219 @@
+package genre
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewGenreStore(db)
+	query := regexp.QuoteMeta("INSERT INTO genres (name, slug, parent_id)")
+	parentID := 1
+	payload := types.CreateGenrePayload{Name: "Fantasy", Slug: "fantasy", ParentID: &parentID}
+
+	t.Run("slug already taken", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("Fantasy", "fantasy", 1).
+			WillReturnError(&pq.Error{Code: utils.PQUniqueViolation})
+
+		id, err := store.Create(context.Background(), payload)
+
+		assert.Zero(t, id)
+		assert.ErrorIs(t, err, ErrGenreExists)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("parent genre does not exist", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("Fantasy", "fantasy", 1).
+			WillReturnError(&pq.Error{Code: utils.PQForeignKeyViolation})
+
+		id, err := store.Create(context.Background(), payload)
+
+		assert.Zero(t, id)
+		assert.ErrorIs(t, err, ErrGenreParentNotFound)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully create top level genre", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("Fiction", "fiction", nil).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		id, err := store.Create(context.Background(), types.CreateGenrePayload{Name: "Fiction", Slug: "fiction"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, id)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewGenreStore(db)
+
+	t.Run("successfully list genres", func(t *testing.T) {
+		createdAt := time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, name, slug, parent_id, created_at, updated_at FROM genres ORDER BY name, id")).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "parent_id", "created_at", "updated_at"}).
+				AddRow(2, "Fantasy", "fantasy", 1, createdAt, nil).
+				AddRow(1, "Fiction", "fiction", nil, createdAt, nil))
+
+		genres, err := store.GetAll(context.Background())
+
+		parentID := 1
+		assert.NoError(t, err)
+		assert.Equal(t, []*types.Genre{
+			{ID: 2, Name: "Fantasy", Slug: "fantasy", ParentID: &parentID, CreatedAt: createdAt},
+			{ID: 1, Name: "Fiction", Slug: "fiction", CreatedAt: createdAt},
+		}, genres)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestUpdateByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewGenreStore(db)
+	cycleQuery := regexp.QuoteMeta("WITH RECURSIVE ancestors AS")
+	updateQuery := regexp.QuoteMeta("UPDATE genres")
+
+	t.Run("moving a genre under one of its subgenres", func(t *testing.T) {
+		parentID := 3
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("LOCK TABLE genres IN SHARE ROW EXCLUSIVE MODE")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(cycleQuery).
+			WithArgs(1, 3).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+		mock.ExpectRollback()
+
+		genre, err := store.UpdateByID(context.Background(), 1, types.UpdateGenrePayload{Name: "Fiction", Slug: "fiction", ParentID: &parentID})
+
+		assert.Nil(t, genre)
+		assert.ErrorIs(t, err, ErrGenreCycle)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("genre not found", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(updateQuery).
+			WithArgs(1, "Fiction", "fiction", nil, sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		genre, err := store.UpdateByID(context.Background(), 1, types.UpdateGenrePayload{Name: "Fiction", Slug: "fiction"})
+
+		assert.Nil(t, genre)
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully move genre under another parent", func(t *testing.T) {
+		parentID := 1
+		createdAt := time.Date(2025, 3, 24, 0, 0, 0, 0, time.UTC)
+		updatedAt := time.Date(2025, 3, 25, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(regexp.QuoteMeta("LOCK TABLE genres IN SHARE ROW EXCLUSIVE MODE")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectQuery(cycleQuery).
+			WithArgs(2, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery(updateQuery).
+			WithArgs(2, "Fantasy", "fantasy", 1, sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "slug", "parent_id", "created_at", "updated_at"}).
+				AddRow(2, "Fantasy", "fantasy", 1, createdAt, updatedAt))
+		mock.ExpectCommit()
+
+		genre, err := store.UpdateByID(context.Background(), 2, types.UpdateGenrePayload{Name: "Fantasy", Slug: "fantasy", ParentID: &parentID})
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.Genre{ID: 2, Name: "Fantasy", Slug: "fantasy", ParentID: &parentID, CreatedAt: createdAt, UpdatedAt: &updatedAt}, genre)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDeleteByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewGenreStore(db)
+	query := regexp.QuoteMeta("DELETE FROM genres WHERE id = $1")
+
+	t.Run("genre still has subgenres or books", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1).
+			WillReturnError(&pq.Error{Code: utils.PQForeignKeyViolation})
+
+		err := store.DeleteByID(context.Background(), 1)
+
+		assert.ErrorIs(t, err, ErrGenreInUse)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("genre not found", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.DeleteByID(context.Background(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
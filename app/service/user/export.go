@@ -0,0 +1,293 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/hoyci/book-store-api/types"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+)
+
+// ExportByID gathers everything stored about the user. It reads inside a
+// single repeatable read transaction so the sections of the export agree
+// with each other.
+func (s *UserStore) ExportByID(ctx context.Context, userID int) (*types.UserDataExport, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.ExportByID")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	export := &types.UserDataExport{ExportedAt: time.Now().UTC()}
+
+	err = tx.QueryRowContext(ctx, "SELECT id, username, email, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE id = $1", userID).
+		Scan(
+			&export.Profile.ID,
+			&export.Profile.Username,
+			&export.Profile.Email,
+			&export.Profile.Role,
+			&export.Profile.EmailVerifiedAt,
+			&export.Profile.CreatedAt,
+			&export.Profile.UpdatedAt,
+			&export.Profile.DeletedAt,
+		)
+	if err != nil {
+		return nil, err
+	}
+
+	if export.Books, err = exportBooks(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if export.Sessions, err = exportSessions(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if export.APIKeys, err = exportAPIKeys(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if export.SecurityEvents, err = exportSecurityEvents(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+	if export.AuditLogs, err = exportAuditLogs(ctx, tx, userID); err != nil {
+		return nil, err
+	}
+
+	return export, nil
+}
+
+// exportBooks includes the books in the trash, they are still stored.
+func exportBooks(ctx context.Context, tx *sql.Tx, userID int) ([]*types.Book, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT b.id, b.name, b.description, b.author, b.release_year, b.number_of_pages, b.image_url, b.thumbnail_url, b.isbn_10, b.isbn_13, b.created_at, b.updated_at, b.deleted_at
+         FROM books b
+         INNER JOIN users_books ub ON ub.book_id = b.id
+         WHERE ub.user_id = $1
+         ORDER BY b.id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []*types.Book{}
+	for rows.Next() {
+		book := &types.Book{}
+		err := rows.Scan(
+			&book.ID,
+			&book.Name,
+			&book.Description,
+			&book.Author,
+			&book.ReleaseYear,
+			&book.NumberOfPages,
+			&book.ImageUrl,
+			&book.ThumbnailUrl,
+			&book.ISBN10,
+			&book.ISBN13,
+			&book.CreatedAt,
+			&book.UpdatedAt,
+			&book.DeletedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, book)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return books, exportBookGenres(ctx, tx, userID, books)
+}
+
+func exportBookGenres(ctx context.Context, tx *sql.Tx, userID int, books []*types.Book) error {
+	booksByID := make(map[int]*types.Book, len(books))
+	for _, book := range books {
+		book.Genres = []*types.BookGenre{}
+		booksByID[book.ID] = book
+	}
+
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT bg.book_id, g.id, g.name, g.slug
+         FROM book_genres bg
+         INNER JOIN genres g ON g.id = bg.genre_id
+         INNER JOIN users_books ub ON ub.book_id = bg.book_id
+         WHERE ub.user_id = $1
+         ORDER BY g.name, g.id`,
+		userID,
+	)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var bookID int
+		genre := &types.BookGenre{}
+		err := rows.Scan(&bookID, &genre.ID, &genre.Name, &genre.Slug)
+		if err != nil {
+			return err
+		}
+
+		if book, ok := booksByID[bookID]; ok {
+			book.Genres = append(book.Genres, genre)
+		}
+	}
+
+	return rows.Err()
+}
+
+// exportSessions includes expired sessions too, since their rows, user agent
+// and IP address included, are kept until the user is deleted.
+func exportSessions(ctx context.Context, tx *sql.Tx, userID int) ([]*types.SessionResponse, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at, last_used_at, expires_at
+         FROM refresh_tokens
+         WHERE user_id = $1
+         ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []*types.SessionResponse{}
+	for rows.Next() {
+		session := &types.SessionResponse{}
+		err := rows.Scan(
+			&session.ID,
+			&session.UserAgent,
+			&session.IPAddress,
+			&session.CreatedAt,
+			&session.LastUsedAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func exportAPIKeys(ctx context.Context, tx *sql.Tx, userID int) ([]*types.APIKey, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+         FROM api_keys
+         WHERE user_id = $1
+         ORDER BY id`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	apiKeys := []*types.APIKey{}
+	for rows.Next() {
+		apiKey := &types.APIKey{}
+		err := rows.Scan(
+			&apiKey.ID,
+			&apiKey.Name,
+			&apiKey.TokenPrefix,
+			pq.Array(&apiKey.Scopes),
+			&apiKey.ExpiresAt,
+			&apiKey.LastUsedAt,
+			&apiKey.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	return apiKeys, rows.Err()
+}
+
+func exportSecurityEvents(ctx context.Context, tx *sql.Tx, userID int) ([]*types.SecurityEvent, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, event_type, COALESCE(user_agent, ''), COALESCE(ip_address, ''), created_at
+         FROM security_events
+         WHERE user_id = $1
+         ORDER BY created_at`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events := []*types.SecurityEvent{}
+	for rows.Next() {
+		event := &types.SecurityEvent{}
+		err := rows.Scan(
+			&event.ID,
+			&event.EventType,
+			&event.UserAgent,
+			&event.IPAddress,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+// exportAuditLogs lists what admins did to the account. The IP address and
+// user agent recorded there belong to the admin, so they are left out.
+func exportAuditLogs(ctx context.Context, tx *sql.Tx, userID int) ([]*types.AuditLog, error) {
+	rows, err := tx.QueryContext(
+		ctx,
+		`SELECT id, actor_id, action, target_type, target_id, details, created_at
+         FROM admin_audit_logs
+         WHERE target_type = $1 AND target_id = $2
+         ORDER BY created_at`,
+		types.AuditTargetUser,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	auditLogs := []*types.AuditLog{}
+	for rows.Next() {
+		auditLog := &types.AuditLog{}
+		var details []byte
+		err := rows.Scan(
+			&auditLog.ID,
+			&auditLog.ActorID,
+			&auditLog.Action,
+			&auditLog.TargetType,
+			&auditLog.TargetID,
+			&details,
+			&auditLog.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal(details, &auditLog.Details); err != nil {
+			return nil, err
+		}
+		auditLogs = append(auditLogs, auditLog)
+	}
+
+	return auditLogs, rows.Err()
+}
@@ -0,0 +1,101 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	profileQuery := regexp.QuoteMeta("SELECT id, username, email, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE id = $1")
+
+	t.Run("database did not find any user", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(profileQuery).
+			WithArgs(1).
+			WillReturnError(sql.ErrNoRows)
+		mock.ExpectRollback()
+
+		export, err := store.ExportByID(context.Background(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Nil(t, export)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully export user data", func(t *testing.T) {
+		createdAt := time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(profileQuery).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role", "email_verified_at", "created_at", "updated_at", "deleted_at"}).
+				AddRow(1, "JohnDoe", "johndoe@example.com", types.RoleReader, createdAt, createdAt, nil, nil))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM books b")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "author", "release_year", "number_of_pages", "image_url", "thumbnail_url", "isbn_10", "isbn_13", "created_at", "updated_at", "deleted_at"}).
+				AddRow(1, "Dune", "Sci-fi classic", "Frank Herbert", 1965, 412, "https://example.com/dune.jpg", nil, "0441013597", "9780441013593", createdAt, nil, createdAt))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM book_genres bg")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"book_id", "id", "name", "slug"}).
+				AddRow(1, 2, "Sci-Fi", "sci-fi"))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM refresh_tokens")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "user_agent", "ip_address", "created_at", "last_used_at", "expires_at"}).
+				AddRow(7, "curl/8.0", "10.0.0.1", createdAt, createdAt, createdAt.Add(24*time.Hour)))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM api_keys")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "token_prefix", "scopes", "expires_at", "last_used_at", "created_at"}))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM security_events")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "event_type", "user_agent", "ip_address", "created_at"}).
+				AddRow(3, "password_changed", "curl/8.0", "10.0.0.1", createdAt))
+		mock.ExpectQuery(regexp.QuoteMeta("FROM admin_audit_logs")).
+			WithArgs(types.AuditTargetUser, 1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "actor_id", "action", "target_type", "target_id", "details", "created_at"}).
+				AddRow(5, 2, types.AuditActionUserRoleUpdated, types.AuditTargetUser, 1, []byte(`{"role":"librarian"}`), createdAt))
+		mock.ExpectRollback()
+
+		export, err := store.ExportByID(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "johndoe@example.com", export.Profile.Email)
+		assert.Len(t, export.Books, 1)
+		assert.NotNil(t, export.Books[0].DeletedAt)
+		assert.Equal(t, []*types.BookGenre{{ID: 2, Name: "Sci-Fi", Slug: "sci-fi"}}, export.Books[0].Genres)
+		assert.Len(t, export.Sessions, 1)
+		assert.Empty(t, export.APIKeys)
+		assert.Len(t, export.SecurityEvents, 1)
+		assert.Equal(t, []*types.AuditLog{
+			{
+				ID:         5,
+				ActorID:    2,
+				Action:     types.AuditActionUserRoleUpdated,
+				TargetType: types.AuditTargetUser,
+				TargetID:   1,
+				Details:    map[string]string{"role": types.RoleLibrarian},
+				CreatedAt:  createdAt,
+			},
+		}, export.AuditLogs)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
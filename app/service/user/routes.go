@@ -5,31 +5,48 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/gorilla/mux"
+	"github.com/hoyci/book-store-api/config"
 	"github.com/hoyci/book-store-api/types"
 	"github.com/hoyci/book-store-api/utils"
+	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
 )
 
 var validate = validator.New()
 
 func passwordValidator(sl validator.StructLevel) {
-	data := sl.Current().Interface().(types.CreateUserRequestPayload)
-	if data.Password != data.ConfirmPassword {
-		sl.ReportError(data.ConfirmPassword, "ConfirmPassword", "ConfirmPassword", "password_mismatch", "")
+	var password, confirmPassword string
+	switch data := sl.Current().Interface().(type) {
+	case types.CreateUserRequestPayload:
+		password, confirmPassword = data.Password, data.ConfirmPassword
+	case types.ChangePasswordPayload:
+		password, confirmPassword = data.Password, data.ConfirmPassword
+	}
+
+	if password != confirmPassword {
+		sl.ReportError(confirmPassword, "ConfirmPassword", "ConfirmPassword", "password_mismatch", "")
 	}
 }
 
 type UserHandler struct {
 	userStore types.UserStore
+	authStore types.AuthStore
+	mailer    types.Mailer
 }
 
-func NewUserHandler(userStore types.UserStore) *UserHandler {
-	validate.RegisterStructValidation(passwordValidator, types.CreateUserRequestPayload{})
+func NewUserHandler(userStore types.UserStore, authStore types.AuthStore, mailer types.Mailer) *UserHandler {
+	validate.RegisterStructValidation(passwordValidator, types.CreateUserRequestPayload{}, types.ChangePasswordPayload{})
 
-	return &UserHandler{userStore: userStore}
+	return &UserHandler{userStore: userStore, authStore: authStore, mailer: mailer}
 }
 
 // @Summary Criar um novo usuário
@@ -63,6 +80,11 @@ func (h *UserHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 		utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateUser", types.BadRequestStructResponse{Error: errorMessages})
 		return
 	}
+
+	if err := utils.CheckPasswordPolicy(requestPayload.Password); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateUser", types.BadRequestResponse{Error: err.Error()})
+		return
+	}
 	validationSpan.End()
 
 	user, _ := h.userStore.GetByEmail(r.Context(), requestPayload.Email)
@@ -75,6 +97,7 @@ func (h *UserHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 	hashedPassword, err := utils.HashPassword(ctx, requestPayload.Password)
 	if err != nil {
 		utils.WriteError(w, http.StatusInternalServerError, err, "HandleCreateUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
 	}
 
 	var databasePayload = types.CreateUserDatabasePayload{
@@ -83,7 +106,7 @@ func (h *UserHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 		PasswordHash: hashedPassword,
 	}
 
-	_, err = h.userStore.Create(r.Context(), databasePayload)
+	createdUser, err := h.userStore.Create(r.Context(), databasePayload)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
 			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleCreateUser", types.ContextCanceledResponse{Error: "Request canceled"})
@@ -99,7 +122,146 @@ func (h *UserHandler) HandleCreateUser(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.WriteJSON(w, http.StatusCreated, types.CreateUserResponse{Message: "User successfully created"})
+	// The account already exists at this point, so a failure here is only
+	// logged. The user can ask for a new email through the resend endpoint.
+	if err := h.sendVerificationEmail(ctx, createdUser.ID, createdUser.Username, createdUser.Email); err != nil {
+		utils.Log.WithFields(logrus.Fields{
+			"user_id": createdUser.ID,
+			"error":   err.Error(),
+		}).Error("Failed to send verification email")
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.CreateUserResponse{Message: "User successfully created, check your email to verify your account"})
+}
+
+func (h *UserHandler) sendVerificationEmail(ctx context.Context, userID int, username string, email string) error {
+	token, tokenHash, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+
+	err = h.userStore.CreateEmailVerificationToken(
+		ctx,
+		types.CreateEmailVerificationTokenPayload{
+			UserID:    userID,
+			TokenHash: tokenHash,
+			ExpiresAt: time.Now().Add(time.Duration(config.Envs.EmailVerificationTTL) * time.Second),
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	return h.mailer.Send(ctx, types.Email{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Hi %s,\n\nConfirm your email address by opening the link below. It expires in %d hours.\n\n%s?token=%s\n\nIf you didn't create an account, you can ignore this email.\n",
+			username,
+			config.Envs.EmailVerificationTTL/3600,
+			config.Envs.EmailVerificationURL,
+			url.QueryEscape(token),
+		),
+	})
+}
+
+// @Summary Confirmar email
+// @Description Confirma o email do usuário com o token enviado no cadastro.
+// @Tags Users
+// @Produce json
+// @Param token query string true "Token de verificação recebido por email"
+// @Success 200 {object} types.VerifyEmailResponse "Email successfully verified"
+// @Failure 400 {object} types.BadRequestResponse "Verification token is invalid or has expired"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /users/verify [get]
+func (h *UserHandler) HandleVerifyEmail(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("verification token is missing"), "HandleVerifyEmail", types.BadRequestResponse{Error: "Verification token is required"})
+		return
+	}
+
+	_, err := h.userStore.VerifyEmail(r.Context(), utils.HashOpaqueToken(token))
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleVerifyEmail", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusBadRequest, err, "HandleVerifyEmail", types.BadRequestResponse{Error: "Verification token is invalid or has expired"})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleVerifyEmail", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.VerifyEmailResponse{Message: "Email successfully verified"})
+}
+
+// @Summary Reenviar email de verificação
+// @Description Envia um novo link de verificação se o email estiver cadastrado e ainda não verificado. A resposta é sempre a mesma.
+// @Tags Users
+// @Accept json
+// @Produce json
+// @Param request body types.ResendVerificationEmailPayload true "Email da conta"
+// @Success 202 {object} types.ResendVerificationEmailResponse "Verification email sent if the account is pending verification"
+// @Failure 400 {object} types.BadRequestResponse "Body is not a valid json"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /users/verify/resend [post]
+func (h *UserHandler) HandleResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	var requestPayload types.ResendVerificationEmailPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleResendVerificationEmail", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleResendVerificationEmail", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	response := types.ResendVerificationEmailResponse{Message: "If the account is pending verification, a new verification email has been sent"}
+
+	user, err := h.userStore.GetByEmail(r.Context(), requestPayload.Email)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleResendVerificationEmail", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteJSON(w, http.StatusAccepted, response)
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleResendVerificationEmail", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if user.EmailVerifiedAt != nil {
+		utils.WriteJSON(w, http.StatusAccepted, response)
+		return
+	}
+
+	if err := h.sendVerificationEmail(r.Context(), user.ID, user.Username, user.Email); err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleResendVerificationEmail", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleResendVerificationEmail", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusAccepted, response)
 }
 
 // @Summary      Get user by ID
@@ -145,7 +307,7 @@ func (h *UserHandler) HandleGetUserByID(w http.ResponseWriter, r *http.Request)
 }
 
 // @Summary      Update user by ID
-// @Description  Updates user details based on the authenticated user's ID extracted from the request context.
+// @Description  Updates user details based on the authenticated user's ID extracted from the request context. A changed email is unverified until confirmed through the link sent to it.
 // @Tags         Users
 // @Accept       json
 // @Produce      json
@@ -180,6 +342,21 @@ func (h *UserHandler) HandleUpdateUserByID(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	previous, err := h.userStore.GetByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleUpdateUserByID", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleUpdateUserByID", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", userID)})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleUpdateUserByID", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
 	user, err := h.userStore.UpdateByID(r.Context(), userID, payload)
 	if err != nil {
 		if errors.Is(err, context.Canceled) {
@@ -200,9 +377,152 @@ func (h *UserHandler) HandleUpdateUserByID(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	// The store drops the verification of a changed email. As at sign up,
+	// the update went through, so a failure to send is only logged.
+	if !strings.EqualFold(previous.Email, user.Email) {
+		if err := h.sendVerificationEmail(r.Context(), user.ID, user.Username, user.Email); err != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"user_id": user.ID,
+				"error":   err.Error(),
+			}).Error("Failed to send verification email")
+		}
+	}
+
 	utils.WriteJSON(w, http.StatusOK, user)
 }
 
+// @Summary Alterar senha
+// @Description Altera a senha do usuário autenticado após conferir a senha atual. As demais sessões do usuário são encerradas e todos os access tokens já emitidos deixam de valer; a sessão atual continua e obtém um novo access token em /auth/refresh.
+// @Description Senhas atuais erradas contam para o mesmo limite de tentativas do login.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.ChangePasswordPayload true "Senha atual e nova senha"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Current password is incorrect"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 404 {object} types.NotFoundResponse "User not found"
+// @Failure 429 {object} types.TooManyRequestsResponse "Too many failed login attempts, try again later"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /users/password [put]
+func (h *UserHandler) HandleChangePassword(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetClaimsFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve claims from context"), "HandleChangePassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	var requestPayload types.ChangePasswordPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleChangePassword", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleChangePassword", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	if err := utils.CheckPasswordPolicy(requestPayload.Password); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleChangePassword", types.BadRequestResponse{Error: err.Error()})
+		return
+	}
+
+	throttles := utils.LoginThrottles(claims.Email, utils.ClientIP(r))
+
+	lockedUntil, err := h.authStore.GetLoginLockout(r.Context(), utils.LoginThrottleKeys(throttles))
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleChangePassword", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleChangePassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+	if lockedUntil != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(*lockedUntil).Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("password change attempt while locked out until %s", lockedUntil.Format(time.RFC3339)), "HandleChangePassword", types.TooManyRequestsResponse{Error: "Too many failed login attempts, try again later"})
+		return
+	}
+
+	currentPasswordHash, err := h.userStore.GetPasswordHashByID(r.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleChangePassword", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleChangePassword", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", claims.UserID)})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleChangePassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if _, err := utils.CheckPassword(r.Context(), currentPasswordHash, requestPayload.CurrentPassword); err != nil {
+		if _, err := utils.RecordFailedLogin(r, h.authStore, throttles, claims.UserID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err, "HandleChangePassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleChangePassword", types.BadRequestResponse{Error: "Current password is incorrect"})
+		return
+	}
+
+	err = h.authStore.ResetLoginThrottle(r.Context(), throttles[0].Key)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleChangePassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	passwordHash, err := utils.HashPassword(r.Context(), requestPayload.Password)
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleChangePassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	err = h.userStore.UpdatePasswordByID(r.Context(), claims.UserID, passwordHash)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleChangePassword", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleChangePassword", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", claims.UserID)})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleChangePassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	// Access tokens issued before sessions were tracked carry no session ID,
+	// in which case there is no way to spare the current one. The access
+	// tokens of every session are refused either way, so the spared one has
+	// to refresh.
+	if claims.SessionID != "" {
+		err = h.authStore.RevokeOtherTokens(r.Context(), claims.UserID, claims.SessionID)
+	} else {
+		err = h.authStore.RevokeTokensByUserID(r.Context(), claims.UserID)
+	}
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleChangePassword", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
 // @Summary      Delete user by ID
 // @Description  Deletes the user associated with the authenticated user's ID extracted from the request context.
 // @Tags         Users
@@ -241,3 +561,317 @@ func (h *UserHandler) HandleDeleteUserByID(w http.ResponseWriter, r *http.Reques
 
 	utils.WriteJSON(w, http.StatusNoContent, nil)
 }
+
+// @Summary Exportar dados pessoais
+// @Description Baixa um arquivo JSON com todos os dados guardados sobre o usuário: perfil, livros (inclusive os da lixeira), sessões, chaves de API, eventos de segurança e ações administrativas sobre a conta. Segredos como hashes de senha e de tokens não são incluídos.
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.UserDataExport "Dados do usuário"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 404 {object} types.NotFoundResponse "User not found"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /users/me/export [get]
+func (h *UserHandler) HandleExportUserData(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleExportUserData", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	export, err := h.userStore.ExportByID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleExportUserData", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleExportUserData", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", userID)})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleExportUserData", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="book-store-export-%d-%s.json"`, userID, export.ExportedAt.Format("20060102")))
+	w.Header().Set("Cache-Control", "no-store")
+	utils.WriteJSON(w, http.StatusOK, export)
+}
+
+// @Summary Apagar conta definitivamente
+// @Description Apaga de forma definitiva e imediata a conta e todos os dados do usuário: perfil, livros que só ele possui e suas capas enviadas, vínculos com livros compartilhados, sessões, chaves de API e eventos de segurança. Não há período de retenção nem como desfazer. Exige a senha atual, e senhas erradas contam para o mesmo limite de tentativas do login.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.EraseUserPayload true "Senha atual"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "Password is incorrect"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 404 {object} types.NotFoundResponse "User not found"
+// @Failure 429 {object} types.TooManyRequestsResponse "Too many failed login attempts, try again later"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /users/me/erase [post]
+func (h *UserHandler) HandleEraseUser(w http.ResponseWriter, r *http.Request) {
+	claims, ok := utils.GetClaimsFromContext(r.Context())
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve claims from context"), "HandleEraseUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	var requestPayload types.EraseUserPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleEraseUser", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleEraseUser", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	throttles := utils.LoginThrottles(claims.Email, utils.ClientIP(r))
+
+	lockedUntil, err := h.authStore.GetLoginLockout(r.Context(), utils.LoginThrottleKeys(throttles))
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleEraseUser", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleEraseUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+	if lockedUntil != nil {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(time.Until(*lockedUntil).Seconds()))))
+		utils.WriteError(w, http.StatusTooManyRequests, fmt.Errorf("account erasure attempt while locked out until %s", lockedUntil.Format(time.RFC3339)), "HandleEraseUser", types.TooManyRequestsResponse{Error: "Too many failed login attempts, try again later"})
+		return
+	}
+
+	passwordHash, err := h.userStore.GetPasswordHashByID(r.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleEraseUser", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleEraseUser", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", claims.UserID)})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleEraseUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if _, err := utils.CheckPassword(r.Context(), passwordHash, requestPayload.Password); err != nil {
+		if _, err := utils.RecordFailedLogin(r, h.authStore, throttles, claims.UserID); err != nil {
+			utils.WriteError(w, http.StatusInternalServerError, err, "HandleEraseUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleEraseUser", types.BadRequestResponse{Error: "Password is incorrect"})
+		return
+	}
+
+	err = h.userStore.HardDeleteByID(r.Context(), claims.UserID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleEraseUser", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleEraseUser", types.NotFoundResponse{Error: fmt.Sprintf("No user found with ID %d", claims.UserID)})
+			return
+		}
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleEraseUser", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	// The refresh tokens are gone with the user, but the access token used
+	// here would keep working until it expires. API keys carry no jti and
+	// were deleted along with the user anyway.
+	if claims.RegisteredClaims.ID != "" && claims.RegisteredClaims.ExpiresAt != nil {
+		err = h.authStore.RevokeAccessToken(
+			r.Context(),
+			types.RevokeAccessTokenPayload{
+				UserID:    claims.UserID,
+				Jti:       claims.RegisteredClaims.ID,
+				ExpiresAt: claims.RegisteredClaims.ExpiresAt.Time,
+			},
+		)
+		if err != nil {
+			utils.Log.WithFields(logrus.Fields{
+				"user_id": claims.UserID,
+				"error":   err.Error(),
+			}).Error("Failed to revoke the access token of an erased user")
+		}
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
+
+// @Summary Criar chave de API
+// @Description Cria uma chave de API para automações. A chave só é exibida nesta resposta. Sem escopos, ela recebe books:read e books:write. Nenhuma chave acessa as rotas da conta, de tokens ou de administração.
+// @Tags Users
+// @Security BearerAuth
+// @Accept json
+// @Produce json
+// @Param request body types.CreateAPIKeyPayload true "Nome, escopos e expiração da chave"
+// @Success 201 {object} types.CreateAPIKeyResponse "Chave de API criada"
+// @Failure 400 {object} types.BadRequestResponse "Expiration must be in the future"
+// @Failure 400 {object} types.BadRequestStructResponse "Validation errors for payload"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /users/tokens [post]
+func (h *UserHandler) HandleCreateAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleCreateAPIKey", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	var requestPayload types.CreateAPIKeyPayload
+	if err := utils.ParseJSON(r, &requestPayload); err != nil {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateAPIKey", types.BadRequestResponse{Error: "Body is not a valid json"})
+		return
+	}
+
+	if err := validate.Struct(requestPayload); err != nil {
+		var errorMessages []string
+		for _, e := range err.(validator.ValidationErrors) {
+			errorMessages = append(errorMessages, fmt.Sprintf("Field '%s' is invalid: %s", e.Field(), e.Tag()))
+		}
+
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleCreateAPIKey", types.BadRequestStructResponse{Error: errorMessages})
+		return
+	}
+
+	if requestPayload.ExpiresAt != nil && !requestPayload.ExpiresAt.After(time.Now()) {
+		utils.WriteError(w, http.StatusBadRequest, fmt.Errorf("user %d sent an API key expiration in the past", userID), "HandleCreateAPIKey", types.BadRequestResponse{Error: "Expiration must be in the future"})
+		return
+	}
+
+	secret, _, err := utils.GenerateOpaqueToken()
+	if err != nil {
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleCreateAPIKey", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+	token := types.APIKeyPrefix + secret
+
+	scopes := requestPayload.Scopes
+	if scopes == nil {
+		scopes = []string{}
+	}
+
+	apiKey, err := h.userStore.CreateAPIKey(
+		r.Context(),
+		types.CreateAPIKeyDatabasePayload{
+			UserID:      userID,
+			Name:        requestPayload.Name,
+			TokenPrefix: token[:len(types.APIKeyPrefix)+6],
+			TokenHash:   utils.HashOpaqueToken(token),
+			Scopes:      scopes,
+			ExpiresAt:   requestPayload.ExpiresAt,
+		},
+	)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleCreateAPIKey", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleCreateAPIKey", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusCreated, types.CreateAPIKeyResponse{APIKey: *apiKey, Token: token})
+}
+
+// @Summary Listar chaves de API
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Success 200 {object} types.GetAPIKeysResponse "Chaves de API do usuário"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /users/tokens [get]
+func (h *UserHandler) HandleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleGetAPIKeys", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	apiKeys, err := h.userStore.GetAPIKeysByUserID(r.Context(), userID)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleGetAPIKeys", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleGetAPIKeys", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	if apiKeys == nil {
+		apiKeys = []*types.APIKey{}
+	}
+
+	utils.WriteJSON(w, http.StatusOK, types.GetAPIKeysResponse{APIKeys: apiKeys})
+}
+
+// @Summary Revogar chave de API
+// @Tags Users
+// @Security BearerAuth
+// @Produce json
+// @Param id path int true "ID da chave de API"
+// @Success 204 "No Content"
+// @Failure 400 {object} types.BadRequestResponse "API key ID must be a positive integer"
+// @Failure 401 {object} types.UnauthorizedResponse "Unauthorized"
+// @Failure 404 {object} types.NotFoundResponse "No API key found with given ID"
+// @Failure 500 {object} types.InternalServerErrorResponse "An unexpected error occurred"
+// @Failure 503 {object} types.ContextCanceledResponse "Request canceled"
+// @Router /users/tokens/{id} [delete]
+func (h *UserHandler) HandleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utils.GetClaimFromContext[int](r, "UserID")
+	if !ok {
+		utils.WriteError(w, http.StatusInternalServerError, fmt.Errorf("failed to retrieve userID from context"), "HandleDeleteAPIKey", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil || id <= 0 {
+		utils.WriteError(w, http.StatusBadRequest, err, "HandleDeleteAPIKey", types.BadRequestResponse{Error: "API key ID must be a positive integer"})
+		return
+	}
+
+	err = h.userStore.DeleteAPIKey(r.Context(), userID, id)
+	if err != nil {
+		if errors.Is(err, context.Canceled) {
+			utils.WriteError(w, http.StatusServiceUnavailable, err, "HandleDeleteAPIKey", types.ContextCanceledResponse{Error: "Request canceled"})
+			return
+		}
+
+		if err == sql.ErrNoRows {
+			utils.WriteError(w, http.StatusNotFound, err, "HandleDeleteAPIKey", types.NotFoundResponse{Error: fmt.Sprintf("No API key found with ID %d", id)})
+			return
+		}
+
+		utils.WriteError(w, http.StatusInternalServerError, err, "HandleDeleteAPIKey", types.InternalServerErrorResponse{Error: "An unexpected error occurred"})
+		return
+	}
+
+	utils.WriteJSON(w, http.StatusNoContent, nil)
+}
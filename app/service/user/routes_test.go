@@ -8,12 +8,15 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/hoyci/book-store-api/cmd/api"
 	"github.com/hoyci/book-store-api/config"
+	"github.com/hoyci/book-store-api/mailer"
 	"github.com/hoyci/book-store-api/mocks"
 	"github.com/hoyci/book-store-api/service/user"
 	"github.com/hoyci/book-store-api/types"
@@ -25,9 +28,9 @@ import (
 func TestHandleCreateUser(t *testing.T) {
 	setupTestServer := func() (*mocks.MockUserStore, *httptest.Server, *mux.Router, config.Config) {
 		mockUserStore := new(mocks.MockUserStore)
-		mockUserHandler := user.NewUserHandler(mockUserStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), mailer.NewMemoryMailer())
 		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
 		ts := httptest.NewServer(router)
 		return mockUserStore, ts, router, apiServer.Config
 	}
@@ -145,6 +148,45 @@ func TestHandleCreateUser(t *testing.T) {
 		assert.JSONEq(t, expectedResponse, string(responseBody))
 	})
 
+	t.Run("it should refuse a password from the breached passwords blocklist", func(t *testing.T) {
+		_, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		policy := utils.GetPasswordPolicy()
+		defer utils.SetPasswordPolicy(policy)
+		utils.SetPasswordPolicy(&utils.PasswordPolicy{
+			MinLength: 8,
+			MaxLength: 64,
+			Blocked:   map[string]struct{}{"password123": {}},
+		})
+
+		payload := types.CreateUserRequestPayload{
+			Username:        "JohnDoe",
+			Email:           "johndoe@email.com",
+			Password:        "Password123",
+			ConfirmPassword: "Password123",
+		}
+		marshalled, _ := json.Marshal(payload)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users", bytes.NewBuffer(marshalled))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, err := io.ReadAll(res.Body)
+		if err != nil {
+			t.Fatalf("Failed to read response body: %v", err)
+		}
+
+		expectedResponse := `{"error":"Password is too common, choose a different one"}`
+		assert.JSONEq(t, expectedResponse, string(responseBody))
+	})
+
 	t.Run("it should return error when the request context is canceled", func(t *testing.T) {
 		mockUserStore, ts, router, _ := setupTestServer()
 		defer ts.Close()
@@ -152,6 +194,7 @@ func TestHandleCreateUser(t *testing.T) {
 		canceledCtx, cancel := context.WithCancel(context.Background())
 		cancel()
 
+		mockUserStore.On("GetByEmail", mock.Anything, "johndoe@email.com").Return((*types.GetByEmailResponse)(nil), sql.ErrNoRows)
 		mockUserStore.On("Create", mock.MatchedBy(func(ctx context.Context) bool {
 			return ctx.Err() == context.Canceled
 		}), mock.Anything).Return((*types.UserResponse)(nil), context.Canceled)
@@ -185,6 +228,7 @@ func TestHandleCreateUser(t *testing.T) {
 		mockUserStore, ts, router, _ := setupTestServer()
 		defer ts.Close()
 
+		mockUserStore.On("GetByEmail", mock.Anything, "johndoe@email.com").Return((*types.GetByEmailResponse)(nil), sql.ErrNoRows)
 		mockUserStore.On("Create", mock.Anything, mock.Anything).Return((*types.UserResponse)(nil), sql.ErrConnDone)
 
 		payload := types.CreateUserRequestPayload{
@@ -214,6 +258,7 @@ func TestHandleCreateUser(t *testing.T) {
 		mockUserStore, ts, router, _ := setupTestServer()
 		defer ts.Close()
 
+		mockUserStore.On("GetByEmail", mock.Anything, "johndoe@email.com").Return((*types.GetByEmailResponse)(nil), sql.ErrNoRows)
 		mockUserStore.On("Create", mock.Anything, mock.Anything).Return(
 			&types.UserResponse{
 				ID:        1,
@@ -225,6 +270,9 @@ func TestHandleCreateUser(t *testing.T) {
 			},
 			nil,
 		)
+		mockUserStore.On("CreateEmailVerificationToken", mock.Anything, mock.MatchedBy(func(payload types.CreateEmailVerificationTokenPayload) bool {
+			return payload.UserID == 1 && len(payload.TokenHash) == 64 && payload.ExpiresAt.After(time.Now())
+		})).Return(nil)
 
 		payload := types.CreateUserRequestPayload{
 			Username:        "JohnDoe",
@@ -243,6 +291,7 @@ func TestHandleCreateUser(t *testing.T) {
 		defer res.Body.Close()
 
 		assert.Equal(t, http.StatusCreated, res.StatusCode)
+		mockUserStore.AssertExpectations(t)
 
 		responseBody, err := io.ReadAll(res.Body)
 		if err != nil {
@@ -259,22 +308,43 @@ func TestHandleCreateUser(t *testing.T) {
 		if !ok {
 			t.Fatalf("Token not found or not a string")
 		}
-		assert.Equal(t, "User successfully created", responseMessage)
+		assert.Equal(t, "User successfully created, check your email to verify your account", responseMessage)
 	})
 }
 
 func TestHandleGetUser(t *testing.T) {
 	setupTestServer := func() (*mocks.MockUserStore, *httptest.Server, *mux.Router, config.Config) {
 		mockUserStore := new(mocks.MockUserStore)
-		mockUserHandler := user.NewUserHandler(mockUserStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), mailer.NewMemoryMailer())
 		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
 		ts := httptest.NewServer(router)
 		return mockUserStore, ts, router, apiServer.Config
 	}
 
 	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
 
+	t.Run("it should accept a token restricted to unverified emails", func(t *testing.T) {
+		mockUserStore, ts, router, config := setupTestServer()
+		defer ts.Close()
+
+		restrictedToken, err := utils.CreateAccessJWT(1, "JohnDoe", "johndoe@example.com", types.RoleReader, types.ScopeUnverifiedEmail, "", config.JWTSecret, 3600, &utils.UUIDGeneratorUtil{})
+		assert.NoError(t, err)
+
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(&types.UserResponse{ID: 1, Username: "JohnDoe", Email: "johndoe@example.com", Role: types.RoleReader}, nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/users", nil)
+		req.Header.Set("Authorization", "Bearer "+restrictedToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
 	t.Run("it should return error when context is canceled", func(t *testing.T) {
 		mockUserStore, ts, router, _ := setupTestServer()
 		defer ts.Close()
@@ -364,6 +434,7 @@ func TestHandleGetUser(t *testing.T) {
 			ID:        1,
 			Username:  "johndoe",
 			Email:     "johndoe@email.com",
+			Role:      types.RoleReader,
 			CreatedAt: time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
 			DeletedAt: nil,
 			UpdatedAt: nil,
@@ -389,6 +460,8 @@ func TestHandleGetUser(t *testing.T) {
 			"id": 1,
 			"username": "johndoe",
 			"email": "johndoe@email.com",
+			"role": "reader",
+			"emailVerifiedAt": null,
 			"createdAt": "0001-01-01T00:00:00Z",
 			"deletedAt": null,
 			"updatedAt": null
@@ -400,15 +473,39 @@ func TestHandleGetUser(t *testing.T) {
 func TestHandleUpdateUser(t *testing.T) {
 	setupTestServer := func() (*mocks.MockUserStore, *httptest.Server, *mux.Router, config.Config) {
 		mockUserStore := new(mocks.MockUserStore)
-		mockUserHandler := user.NewUserHandler(mockUserStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), mailer.NewMemoryMailer())
 		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
 		ts := httptest.NewServer(router)
 		return mockUserStore, ts, router, apiServer.Config
 	}
 
 	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
 
+	t.Run("it should refuse a token restricted to unverified emails", func(t *testing.T) {
+		mockUserStore, ts, router, config := setupTestServer()
+		defer ts.Close()
+
+		restrictedToken, err := utils.CreateAccessJWT(1, "JohnDoe", "johndoe@example.com", types.RoleReader, types.ScopeUnverifiedEmail, "", config.JWTSecret, 3600, &utils.UUIDGeneratorUtil{})
+		assert.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/users", bytes.NewBufferString(`{"username":"JohnDoe","email":"johndoe@example.com"}`))
+		req.Header.Set("Authorization", "Bearer "+restrictedToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Email address is not verified"}`
+		assert.JSONEq(t, expected, string(responseBody))
+		mockUserStore.AssertNotCalled(t, "UpdateByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
 	t.Run("it should throw an error when no fields are provided for update", func(t *testing.T) {
 		_, ts, router, _ := setupTestServer()
 		defer ts.Close()
@@ -466,6 +563,7 @@ func TestHandleUpdateUser(t *testing.T) {
 		canceledCtx, cancel := context.WithCancel(context.Background())
 		cancel()
 
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(&types.UserResponse{ID: 1, Username: "JohnDoe", Email: "johndoe@example.com"}, nil)
 		mockUserStore.On("UpdateByID", mock.MatchedBy(func(ctx context.Context) bool {
 			return ctx.Err() == context.Canceled
 		}), mock.Anything, mock.Anything).Return(&types.UserResponse{}, context.Canceled)
@@ -497,6 +595,7 @@ func TestHandleUpdateUser(t *testing.T) {
 		mockUserStore, ts, router, _ := setupTestServer()
 		defer ts.Close()
 
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(&types.UserResponse{ID: 1, Username: "JohnDoe", Email: "johndoe@example.com"}, nil)
 		mockUserStore.On("UpdateByID", mock.Anything, mock.Anything).Return(&types.UserResponse{}, sql.ErrConnDone)
 
 		validPayload := `{
@@ -524,6 +623,7 @@ func TestHandleUpdateUser(t *testing.T) {
 		mockUserStore, ts, router, _ := setupTestServer()
 		defer ts.Close()
 
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(&types.UserResponse{ID: 1, Username: "JohnDoe", Email: "johndoe@example.com"}, nil)
 		mockUserStore.On("UpdateByID", mock.Anything, mock.Anything).Return(&types.UserResponse{}, sql.ErrNoRows)
 
 		validPayload := `{
@@ -556,10 +656,12 @@ func TestHandleUpdateUser(t *testing.T) {
 
 		mockedDate := time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC)
 
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(&types.UserResponse{ID: 1, Username: "JohnDoe", Email: "johndoeupdated@email.com"}, nil)
 		mockUserStore.On("UpdateByID", mock.Anything, mock.Anything).Return(&types.UserResponse{
 			ID:        1,
 			Username:  "johndoe - updated",
 			Email:     "johndoeupdated@email.com",
+			Role:      types.RoleReader,
 			CreatedAt: time.Date(0001, 01, 01, 0, 0, 0, 0, time.UTC),
 			DeletedAt: nil,
 			UpdatedAt: &mockedDate,
@@ -589,20 +691,262 @@ func TestHandleUpdateUser(t *testing.T) {
 			"id": 1,
 			"username":  "johndoe - updated",
 			"email": "johndoeupdated@email.com",
+			"role": "reader",
+			"emailVerifiedAt": null,
 			"createdAt": "0001-01-01T00:00:00Z",
 			"updatedAt": "0001-01-01T00:00:00Z",
 			"deletedAt": null
 		}`
 		assert.JSONEq(t, expectedResponse, string(responseBody))
+		mockUserStore.AssertNotCalled(t, "CreateEmailVerificationToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should send a verification link to a changed email", func(t *testing.T) {
+		mockUserStore := new(mocks.MockUserStore)
+		memoryMailer := mailer.NewMemoryMailer()
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), memoryMailer)
+		router := api.NewApiServer(":8080", nil).SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
+
+		verifiedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockUserStore.On("GetByID", mock.Anything, 1).Return(&types.UserResponse{ID: 1, Username: "JohnDoe", Email: "johndoe@example.com", EmailVerifiedAt: &verifiedAt}, nil)
+		mockUserStore.On("UpdateByID", mock.Anything).
+			Return(&types.UserResponse{ID: 1, Username: "JohnDoe", Email: "someone.else@example.com", Role: types.RoleReader}, nil)
+		mockUserStore.On("CreateEmailVerificationToken", mock.Anything, mock.MatchedBy(func(payload types.CreateEmailVerificationTokenPayload) bool {
+			return payload.UserID == 1
+		})).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPut, "/api/v1/users", bytes.NewBufferString(`{"username":"JohnDoe","email":"someone.else@example.com"}`))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		var response types.UserResponse
+		json.NewDecoder(res.Body).Decode(&response)
+		assert.Nil(t, response.EmailVerifiedAt)
+
+		sent := memoryMailer.Sent()
+		if assert.Len(t, sent, 1) {
+			assert.Equal(t, "someone.else@example.com", sent[0].To)
+		}
+		mockUserStore.AssertExpectations(t)
+	})
+}
+
+func TestHandleChangePassword(t *testing.T) {
+	currentPasswordHash, err := utils.HashPassword(context.Background(), "123mudar")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	setupTestServer := func() (*mocks.MockUserStore, *mocks.MockAuthStore, *httptest.Server, *mux.Router, config.Config) {
+		mockUserStore := new(mocks.MockUserStore)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, mockAuthStore, mailer.NewMemoryMailer())
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, mockAuthStore, ts, router, apiServer.Config
+	}
+
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+
+	t.Run("it should throw an error when the passwords do not match", func(t *testing.T) {
+		_, _, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		payload := `{"current_password":"123mudar","password":"newpassword","confirm_password":"otherpassword"}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/users/password", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":["Field 'ConfirmPassword' is invalid: password_mismatch"]}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the current password is incorrect", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "account:johndoe@example.com", mock.Anything).Return(1, nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, "ip:") }), mock.Anything).Return(1, nil)
+		mockUserStore.On("GetPasswordHashByID", mock.Anything, 1).Return(currentPasswordHash, nil)
+
+		payload := `{"current_password":"wrongpassword","password":"newpassword","confirm_password":"newpassword"}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/users/password", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Current password is incorrect"}`
+		assert.JSONEq(t, expected, string(responseBody))
+		mockAuthStore.AssertExpectations(t)
+		mockAuthStore.AssertNotCalled(t, "LockLogin", mock.Anything, mock.Anything, mock.Anything)
+		mockUserStore.AssertNotCalled(t, "UpdatePasswordByID", mock.Anything, mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should lock the account after too many incorrect current passwords", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "account:johndoe@example.com", mock.Anything).Return(int(config.Envs.LoginMaxFailures), nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, "ip:") }), mock.Anything).Return(1, nil)
+		mockAuthStore.On("LockLogin", mock.Anything, "account:johndoe@example.com", mock.Anything).Return(nil)
+		mockAuthStore.On("CreateSecurityEvent", mock.Anything, mock.MatchedBy(func(payload types.CreateSecurityEventPayload) bool {
+			return payload.UserID == 1 && payload.EventType == types.SecurityEventLoginLockout
+		})).Return(nil)
+		mockUserStore.On("GetPasswordHashByID", mock.Anything, 1).Return(currentPasswordHash, nil)
+
+		payload := `{"current_password":"wrongpassword","password":"newpassword","confirm_password":"newpassword"}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/users/password", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		mockAuthStore.AssertExpectations(t)
+	})
+
+	t.Run("it should refuse to check the current password while the account is locked", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		lockedUntil := time.Now().Add(time.Minute)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, []string{"account:johndoe@example.com", "ip:192.0.2.1"}).Return(&lockedUntil, nil)
+
+		payload := `{"current_password":"123mudar","password":"newpassword","confirm_password":"newpassword"}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/users/password", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		assert.NotEmpty(t, res.Header.Get("Retry-After"))
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Too many failed login attempts, try again later"}`, string(responseBody))
+		mockUserStore.AssertNotCalled(t, "GetPasswordHashByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the user does not exist", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockUserStore.On("GetPasswordHashByID", mock.Anything, 1).Return("", sql.ErrNoRows)
+
+		payload := `{"current_password":"123mudar","password":"newpassword","confirm_password":"newpassword"}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/users/password", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"No user found with ID 1"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should change the password and revoke the other sessions", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router, config := setupTestServer()
+		defer ts.Close()
+
+		sessionToken, err := utils.CreateAccessJWT(1, "JohnDoe", "johndoe@example.com", types.RoleReader, "", "current-family", config.JWTSecret, 3600, &utils.UUIDGeneratorUtil{})
+		assert.NoError(t, err)
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, "account:johndoe@example.com").Return(nil)
+		mockUserStore.On("GetPasswordHashByID", mock.Anything, 1).Return(currentPasswordHash, nil)
+		mockUserStore.On("UpdatePasswordByID", mock.Anything, 1, mock.MatchedBy(func(passwordHash string) bool {
+			_, err := utils.CheckPassword(context.Background(), passwordHash, "newpassword")
+			return err == nil
+		})).Return(nil)
+		mockAuthStore.On("RevokeOtherTokens", mock.Anything, 1, "current-family").Return(nil)
+
+		payload := `{"current_password":"123mudar","password":"newpassword","confirm_password":"newpassword"}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/users/password", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+sessionToken)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		mockUserStore.AssertExpectations(t)
+		mockAuthStore.AssertExpectations(t)
+	})
+
+	t.Run("it should revoke every session when the token has no session ID", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router, _ := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("ResetLoginThrottle", mock.Anything, "account:johndoe@example.com").Return(nil)
+		mockUserStore.On("GetPasswordHashByID", mock.Anything, 1).Return(currentPasswordHash, nil)
+		mockUserStore.On("UpdatePasswordByID", mock.Anything, 1, mock.Anything).Return(nil)
+		mockAuthStore.On("RevokeTokensByUserID", mock.Anything, 1).Return(nil)
+
+		payload := `{"current_password":"123mudar","password":"newpassword","confirm_password":"newpassword"}`
+		req := httptest.NewRequest(http.MethodPut, ts.URL+"/api/v1/users/password", bytes.NewBufferString(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+
+		mockAuthStore.AssertExpectations(t)
+		mockAuthStore.AssertNotCalled(t, "RevokeOtherTokens", mock.Anything, mock.Anything, mock.Anything)
 	})
 }
 
 func TestHandleDeleteUser(t *testing.T) {
 	setupTestServer := func() (*mocks.MockUserStore, *httptest.Server, *mux.Router, config.Config) {
 		mockUserStore := new(mocks.MockUserStore)
-		mockUserHandler := user.NewUserHandler(mockUserStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), mailer.NewMemoryMailer())
 		apiServer := api.NewApiServer(":8080", nil)
-		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
 		ts := httptest.NewServer(router)
 		return mockUserStore, ts, router, apiServer.Config
 	}
@@ -704,3 +1048,614 @@ func TestHandleDeleteUser(t *testing.T) {
 		assert.Equal(t, http.StatusNoContent, res.StatusCode)
 	})
 }
+
+func TestHandleVerifyEmail(t *testing.T) {
+	setupTestServer := func() (*mocks.MockUserStore, *httptest.Server, *mux.Router) {
+		mockUserStore := new(mocks.MockUserStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), mailer.NewMemoryMailer())
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, ts, router
+	}
+
+	t.Run("it should throw an error when the token is missing", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/users/verify", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Verification token is required"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the token is invalid, expired or already used", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("VerifyEmail", mock.Anything, utils.HashOpaqueToken("verification-token")).Return(0, sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/users/verify?token=verification-token", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"error":"Verification token is invalid or has expired"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+
+	t.Run("it should verify the email", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("VerifyEmail", mock.Anything, utils.HashOpaqueToken("verification-token")).Return(1, nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/users/verify?token=verification-token", nil)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		expected := `{"message":"Email successfully verified"}`
+		assert.JSONEq(t, expected, string(responseBody))
+	})
+}
+
+func TestHandleResendVerificationEmail(t *testing.T) {
+	setupTestServer := func() (*mocks.MockUserStore, *mailer.MemoryMailer, *httptest.Server, *mux.Router) {
+		mockUserStore := new(mocks.MockUserStore)
+		memoryMailer := mailer.NewMemoryMailer()
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), memoryMailer)
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, memoryMailer, ts, router
+	}
+
+	expected := `{"message":"If the account is pending verification, a new verification email has been sent"}`
+
+	t.Run("it should answer the same way when the email is not registered", func(t *testing.T) {
+		mockUserStore, memoryMailer, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("GetByEmail", mock.Anything, "unknown@email.com").Return((*types.GetByEmailResponse)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users/verify/resend", bytes.NewBufferString(`{"email":"unknown@email.com"}`))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, expected, string(responseBody))
+		assert.Empty(t, memoryMailer.Sent())
+	})
+
+	t.Run("it should not send anything when the email is already verified", func(t *testing.T) {
+		mockUserStore, memoryMailer, ts, router := setupTestServer()
+		defer ts.Close()
+
+		verifiedAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		mockUserStore.On("GetByEmail", mock.Anything, "johndoe@email.com").Return(&types.GetByEmailResponse{ID: 1, Username: "JohnDoe", Email: "johndoe@email.com", EmailVerifiedAt: &verifiedAt}, nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users/verify/resend", bytes.NewBufferString(`{"email":"johndoe@email.com"}`))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, expected, string(responseBody))
+		assert.Empty(t, memoryMailer.Sent())
+		mockUserStore.AssertNotCalled(t, "CreateEmailVerificationToken", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should send a new verification link", func(t *testing.T) {
+		mockUserStore, memoryMailer, ts, router := setupTestServer()
+		defer ts.Close()
+
+		var storedPayload types.CreateEmailVerificationTokenPayload
+		mockUserStore.On("GetByEmail", mock.Anything, "johndoe@email.com").Return(&types.GetByEmailResponse{ID: 1, Username: "JohnDoe", Email: "johndoe@email.com"}, nil)
+		mockUserStore.On("CreateEmailVerificationToken", mock.Anything, mock.Anything).
+			Run(func(args mock.Arguments) {
+				storedPayload = args.Get(1).(types.CreateEmailVerificationTokenPayload)
+			}).
+			Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users/verify/resend", bytes.NewBufferString(`{"email":"johndoe@email.com"}`))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusAccepted, res.StatusCode)
+
+		sent := memoryMailer.Sent()
+		assert.Len(t, sent, 1)
+		assert.Equal(t, "johndoe@email.com", sent[0].To)
+
+		_, query, found := strings.Cut(sent[0].Body, config.Envs.EmailVerificationURL+"?")
+		assert.True(t, found)
+		values, err := url.ParseQuery(strings.Fields(query)[0])
+		assert.NoError(t, err)
+		assert.Equal(t, utils.HashOpaqueToken(values.Get("token")), storedPayload.TokenHash)
+	})
+}
+
+func TestHandleCreateAPIKey(t *testing.T) {
+	setupTestServer := func() (*mocks.MockUserStore, *httptest.Server, *mux.Router) {
+		mockUserStore := new(mocks.MockUserStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), mailer.NewMemoryMailer())
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, ts, router
+	}
+
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+
+	t.Run("it should throw an error when a scope is unknown", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload := []byte(`{"name":"ci","scopes":["books:delete"]}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users/tokens", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":["Field 'Scopes[0]' is invalid: oneof"]}`, string(responseBody))
+	})
+
+	t.Run("it should throw an error when the expiration is in the past", func(t *testing.T) {
+		_, ts, router := setupTestServer()
+		defer ts.Close()
+
+		payload := []byte(`{"name":"ci","expires_at":"2020-01-01T00:00:00Z"}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users/tokens", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Expiration must be in the future"}`, string(responseBody))
+	})
+
+	t.Run("it should store the key hashed and return it once", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		var stored types.CreateAPIKeyDatabasePayload
+		mockUserStore.On("CreateAPIKey", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(types.CreateAPIKeyDatabasePayload)
+		}).Return(&types.APIKey{ID: 1, Name: "ci", TokenPrefix: "bsk_abcdef", Scopes: []string{types.ScopeBooksRead}}, nil)
+
+		payload := []byte(`{"name":"ci","scopes":["books:read"]}`)
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users/tokens", bytes.NewBuffer(payload))
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusCreated, res.StatusCode)
+
+		var response types.CreateAPIKeyResponse
+		err := json.NewDecoder(res.Body).Decode(&response)
+		assert.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(response.Token, types.APIKeyPrefix))
+		assert.Equal(t, 1, response.ID)
+		assert.Equal(t, 1, stored.UserID)
+		assert.Equal(t, utils.HashOpaqueToken(response.Token), stored.TokenHash)
+		assert.True(t, strings.HasPrefix(response.Token, stored.TokenPrefix))
+		assert.Equal(t, []string{types.ScopeBooksRead}, stored.Scopes)
+	})
+}
+
+func TestHandleGetAPIKeys(t *testing.T) {
+	setupTestServer := func() (*mocks.MockUserStore, *httptest.Server, *mux.Router) {
+		mockUserStore := new(mocks.MockUserStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), mailer.NewMemoryMailer())
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, ts, router
+	}
+
+	t.Run("it should return an empty list when the user has no keys", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("GetAPIKeysByUserID", mock.Anything, 1).Return(([]*types.APIKey)(nil), nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/users/tokens", nil)
+		req.Header.Set("Authorization", "Bearer "+utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"api_keys":[]}`, string(responseBody))
+	})
+
+	t.Run("it should keep an API key without scopes off the token routes", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		utils.SetAPIKeyAuthenticator(mockUserStore)
+		defer utils.SetAPIKeyAuthenticator(nil)
+
+		apiKey := types.APIKeyPrefix + "secret"
+		mockUserStore.On("AuthenticateAPIKey", mock.Anything, utils.HashOpaqueToken(apiKey)).Return(
+			&types.APIKeyOwner{KeyID: 3, UserID: 7, Username: "JohnDoe", Email: "johndoe@example.com", Role: types.RoleReader, Scopes: []string{}},
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/users/tokens", nil)
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+		mockUserStore.AssertExpectations(t)
+		mockUserStore.AssertNotCalled(t, "GetAPIKeysByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should refuse an unknown API key", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		utils.SetAPIKeyAuthenticator(mockUserStore)
+		defer utils.SetAPIKeyAuthenticator(nil)
+
+		mockUserStore.On("AuthenticateAPIKey", mock.Anything, mock.Anything).Return((*types.APIKeyOwner)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/users/tokens", nil)
+		req.Header.Set("Authorization", "Bearer "+types.APIKeyPrefix+"unknown")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusUnauthorized, res.StatusCode)
+		mockUserStore.AssertNotCalled(t, "GetAPIKeysByUserID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should refuse an API key whose scopes do not cover the route", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		utils.SetAPIKeyAuthenticator(mockUserStore)
+		defer utils.SetAPIKeyAuthenticator(nil)
+
+		mockUserStore.On("AuthenticateAPIKey", mock.Anything, mock.Anything).Return(
+			&types.APIKeyOwner{KeyID: 3, UserID: 7, Role: types.RoleReader, Scopes: []string{types.ScopeBooksRead}},
+			nil,
+		)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/users/tokens", nil)
+		req.Header.Set("Authorization", "Bearer "+types.APIKeyPrefix+"secret")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusForbidden, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"This token does not grant access to this resource"}`, string(responseBody))
+	})
+}
+
+func TestHandleDeleteAPIKey(t *testing.T) {
+	setupTestServer := func() (*mocks.MockUserStore, *httptest.Server, *mux.Router) {
+		mockUserStore := new(mocks.MockUserStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), mailer.NewMemoryMailer())
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, ts, router
+	}
+
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+
+	t.Run("it should throw an error when the key does not belong to the user", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("DeleteAPIKey", mock.Anything, 1, 5).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/users/tokens/5", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"No API key found with ID 5"}`, string(responseBody))
+	})
+
+	t.Run("it should revoke the key", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("DeleteAPIKey", mock.Anything, 1, 5).Return(nil)
+
+		req := httptest.NewRequest(http.MethodDelete, ts.URL+"/api/v1/users/tokens/5", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+	})
+}
+
+func TestHandleExportUserData(t *testing.T) {
+	setupTestServer := func() (*mocks.MockUserStore, *httptest.Server, *mux.Router) {
+		mockUserStore := new(mocks.MockUserStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, new(mocks.MockAuthStore), mailer.NewMemoryMailer())
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, ts, router
+	}
+
+	token := utils.GenerateTestToken(1, "JohnDoe", "johndoe@example.com")
+
+	t.Run("it should throw an error when the user does not exist", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockUserStore.On("ExportByID", mock.Anything, 1).Return((*types.UserDataExport)(nil), sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/users/me/export", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"No user found with ID 1"}`, string(responseBody))
+	})
+
+	t.Run("it should return the export as a downloadable file", func(t *testing.T) {
+		mockUserStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		exportedAt := time.Date(2025, 3, 18, 9, 30, 0, 0, time.UTC)
+		createdAt := time.Date(2025, 1, 2, 8, 0, 0, 0, time.UTC)
+		export := &types.UserDataExport{
+			ExportedAt: exportedAt,
+			Profile: types.UserResponse{
+				ID:        1,
+				Username:  "JohnDoe",
+				Email:     "johndoe@example.com",
+				Role:      types.RoleReader,
+				CreatedAt: createdAt,
+			},
+			Books:          []*types.Book{},
+			Sessions:       []*types.SessionResponse{},
+			APIKeys:        []*types.APIKey{},
+			SecurityEvents: []*types.SecurityEvent{{ID: 3, EventType: "password_changed", CreatedAt: createdAt}},
+			AuditLogs:      []*types.AuditLog{},
+		}
+		mockUserStore.On("ExportByID", mock.Anything, 1).Return(export, nil)
+
+		req := httptest.NewRequest(http.MethodGet, ts.URL+"/api/v1/users/me/export", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.Equal(t, `attachment; filename="book-store-export-1-20250318.json"`, res.Header.Get("Content-Disposition"))
+		assert.Equal(t, "no-store", res.Header.Get("Cache-Control"))
+
+		var responseBody types.UserDataExport
+		err := json.NewDecoder(res.Body).Decode(&responseBody)
+		assert.NoError(t, err)
+		assert.Equal(t, "johndoe@example.com", responseBody.Profile.Email)
+		assert.Len(t, responseBody.SecurityEvents, 1)
+		assert.Equal(t, "password_changed", responseBody.SecurityEvents[0].EventType)
+	})
+}
+
+func TestHandleEraseUser(t *testing.T) {
+	passwordHash, err := utils.HashPassword(context.Background(), "123mudar")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+
+	setupTestServer := func() (*mocks.MockUserStore, *mocks.MockAuthStore, *httptest.Server, *mux.Router) {
+		mockUserStore := new(mocks.MockUserStore)
+		mockAuthStore := new(mocks.MockAuthStore)
+		mockUserHandler := user.NewUserHandler(mockUserStore, mockAuthStore, mailer.NewMemoryMailer())
+		apiServer := api.NewApiServer(":8080", nil)
+		router := apiServer.SetupRouter(nil, nil, mockUserHandler, nil, nil, nil, nil)
+		ts := httptest.NewServer(router)
+		return mockUserStore, mockAuthStore, ts, router
+	}
+
+	createToken := func(t *testing.T, userID int, jti string) string {
+		uuidGen := new(mocks.MockUUIDGenerator)
+		uuidGen.On("New").Return(jti)
+
+		token, err := utils.CreateJWT(userID, "JohnDoe", "johndoe@example.com", types.RoleReader, config.Envs.JWTSecret, 3600, uuidGen)
+		if err != nil {
+			t.Fatalf("Failed to create token: %v", err)
+		}
+		return token
+	}
+
+	t.Run("it should throw an error when the password is incorrect", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, "account:johndoe@example.com", mock.Anything).Return(1, nil)
+		mockAuthStore.On("RecordFailedLogin", mock.Anything, mock.MatchedBy(func(key string) bool { return strings.HasPrefix(key, "ip:") }), mock.Anything).Return(1, nil)
+		mockUserStore.On("GetPasswordHashByID", mock.Anything, 1).Return(passwordHash, nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users/me/erase", bytes.NewBufferString(`{"password":"wrongpassword"}`))
+		req.Header.Set("Authorization", "Bearer "+createToken(t, 1, "access-jti"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"Password is incorrect"}`, string(responseBody))
+		mockAuthStore.AssertExpectations(t)
+		mockUserStore.AssertNotCalled(t, "HardDeleteByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should refuse to check the password while the account is locked", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		lockedUntil := time.Now().Add(time.Minute)
+		mockAuthStore.On("GetLoginLockout", mock.Anything, []string{"account:johndoe@example.com", "ip:192.0.2.1"}).Return(&lockedUntil, nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users/me/erase", bytes.NewBufferString(`{"password":"123mudar"}`))
+		req.Header.Set("Authorization", "Bearer "+createToken(t, 1, "access-jti"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusTooManyRequests, res.StatusCode)
+		assert.NotEmpty(t, res.Header.Get("Retry-After"))
+		mockUserStore.AssertNotCalled(t, "GetPasswordHashByID", mock.Anything, mock.Anything)
+		mockUserStore.AssertNotCalled(t, "HardDeleteByID", mock.Anything, mock.Anything)
+	})
+
+	t.Run("it should throw an error when the user does not exist", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockUserStore.On("GetPasswordHashByID", mock.Anything, 1).Return(passwordHash, nil)
+		mockUserStore.On("HardDeleteByID", mock.Anything, 1).Return(sql.ErrNoRows)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users/me/erase", bytes.NewBufferString(`{"password":"123mudar"}`))
+		req.Header.Set("Authorization", "Bearer "+createToken(t, 1, "access-jti"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+
+		responseBody, _ := io.ReadAll(res.Body)
+		assert.JSONEq(t, `{"error":"No user found with ID 1"}`, string(responseBody))
+	})
+
+	t.Run("it should erase the user and revoke the access token", func(t *testing.T) {
+		mockUserStore, mockAuthStore, ts, router := setupTestServer()
+		defer ts.Close()
+
+		mockAuthStore.On("GetLoginLockout", mock.Anything, mock.Anything).Return((*time.Time)(nil), nil)
+		mockUserStore.On("GetPasswordHashByID", mock.Anything, 1).Return(passwordHash, nil)
+		mockUserStore.On("HardDeleteByID", mock.Anything, 1).Return(nil)
+		mockAuthStore.On("RevokeAccessToken", mock.Anything, mock.MatchedBy(func(payload types.RevokeAccessTokenPayload) bool {
+			return payload.UserID == 1 && payload.Jti == "access-jti"
+		})).Return(nil)
+
+		req := httptest.NewRequest(http.MethodPost, ts.URL+"/api/v1/users/me/erase", bytes.NewBufferString(`{"password":"123mudar"}`))
+		req.Header.Set("Authorization", "Bearer "+createToken(t, 1, "access-jti"))
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		res := w.Result()
+		defer res.Body.Close()
+
+		assert.Equal(t, http.StatusNoContent, res.StatusCode)
+		mockUserStore.AssertExpectations(t)
+		mockAuthStore.AssertExpectations(t)
+	})
+}
@@ -8,15 +8,21 @@ import (
 	"time"
 
 	"github.com/hoyci/book-store-api/types"
+	"github.com/hoyci/book-store-api/utils"
+	"github.com/lib/pq"
 	"go.opentelemetry.io/otel"
 )
 
 type UserStore struct {
-	db *sql.DB
+	db     *sql.DB
+	covers types.BlobStore
 }
 
-func NewUserStore(db *sql.DB) *UserStore {
-	return &UserStore{db: db}
+// NewUserStore removes the uploaded covers of the books that go with the users
+// it hard deletes from covers, which is nil when cover uploads are not
+// enabled.
+func NewUserStore(db *sql.DB, covers types.BlobStore) *UserStore {
+	return &UserStore{db: db, covers: covers}
 }
 
 func (s *UserStore) Create(ctx context.Context, newUser types.CreateUserDatabasePayload) (*types.UserResponse, error) {
@@ -27,7 +33,7 @@ func (s *UserStore) Create(ctx context.Context, newUser types.CreateUserDatabase
 	user := &types.UserResponse{}
 	err := s.db.QueryRowContext(
 		ctx,
-		"INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id, username, email, created_at, updated_at, deleted_at",
+		"INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id, username, email, role, email_verified_at, created_at, updated_at, deleted_at",
 		newUser.Username,
 		newUser.Email,
 		newUser.PasswordHash,
@@ -35,6 +41,8 @@ func (s *UserStore) Create(ctx context.Context, newUser types.CreateUserDatabase
 		&user.ID,
 		&user.Username,
 		&user.Email,
+		&user.Role,
+		&user.EmailVerifiedAt,
 		&user.CreatedAt,
 		&user.UpdatedAt,
 		&user.DeletedAt,
@@ -53,11 +61,13 @@ func (s *UserStore) GetByID(ctx context.Context, userID int) (*types.UserRespons
 	defer span.End()
 
 	user := &types.UserResponse{}
-	err := s.db.QueryRowContext(ctx, "SELECT id, username, email, created_at, updated_at, deleted_at  FROM users WHERE id = $1 AND deleted_at IS null", userID).
+	err := s.db.QueryRowContext(ctx, "SELECT id, username, email, role, email_verified_at, created_at, updated_at, deleted_at  FROM users WHERE id = $1 AND deleted_at IS null", userID).
 		Scan(
 			&user.ID,
 			&user.Username,
 			&user.Email,
+			&user.Role,
+			&user.EmailVerifiedAt,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 			&user.DeletedAt,
@@ -75,12 +85,14 @@ func (s *UserStore) GetByEmail(ctx context.Context, email string) (*types.GetByE
 	defer span.End()
 
 	user := &types.GetByEmailResponse{}
-	err := s.db.QueryRowContext(ctx, "SELECT id, username, email, password_hash, created_at, updated_at, deleted_at FROM users WHERE email = $1 AND deleted_at IS null", email).
+	err := s.db.QueryRowContext(ctx, "SELECT id, username, email, password_hash, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE email = $1 AND deleted_at IS null", email).
 		Scan(
 			&user.ID,
 			&user.Username,
 			&user.Email,
 			&user.PasswordHash,
+			&user.Role,
+			&user.EmailVerifiedAt,
 			&user.CreatedAt,
 			&user.UpdatedAt,
 			&user.DeletedAt,
@@ -92,6 +104,8 @@ func (s *UserStore) GetByEmail(ctx context.Context, email string) (*types.GetByE
 	return user, nil
 }
 
+// UpdateByID changes the username and email of the user. A new email address
+// is unverified until its owner confirms it, whatever the old one was.
 func (s *UserStore) UpdateByID(ctx context.Context, userID int, newUser types.UpdateUserPayload) (*types.UserResponse, error) {
 	tracer := otel.Tracer("user-store")
 	ctx, span := tracer.Start(ctx, "UserStore.UpdateByID")
@@ -101,12 +115,15 @@ func (s *UserStore) UpdateByID(ctx context.Context, userID int, newUser types.Up
 			UPDATE users SET 
 			username = $2, 
 			email = $3,
+			email_verified_at = CASE WHEN LOWER(email) = LOWER($3) THEN email_verified_at END,
 			updated_at = $4
 			WHERE id = $1
 			RETURNING 
 				id, 
 				username, 
 				email, 
+				role,
+				email_verified_at,
 				created_at, 
 				deleted_at,
 				updated_at;
@@ -124,6 +141,8 @@ func (s *UserStore) UpdateByID(ctx context.Context, userID int, newUser types.Up
 		&updatedUser.ID,
 		&updatedUser.Username,
 		&updatedUser.Email,
+		&updatedUser.Role,
+		&updatedUser.EmailVerifiedAt,
 		&updatedUser.CreatedAt,
 		&updatedUser.DeletedAt,
 		&updatedUser.UpdatedAt,
@@ -145,7 +164,7 @@ func (s *UserStore) DeleteByID(ctx context.Context, userID int) error {
 
 	result, err := s.db.ExecContext(
 		ctx,
-		"UPDATE users SET deleted_at = $2 WHERE id = $1",
+		"UPDATE users SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL",
 		userID,
 		time.Now(),
 	)
@@ -163,3 +182,558 @@ func (s *UserStore) DeleteByID(ctx context.Context, userID int) error {
 
 	return nil
 }
+
+func (s *UserStore) GetMany(ctx context.Context, options types.GetUsersOptions) ([]*types.UserResponse, int, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.GetMany")
+	defer span.End()
+
+	// An empty search gives the pattern %%, which matches every user.
+	pattern := "%" + utils.EscapeLike(options.Search) + "%"
+
+	var total int
+	err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM users WHERE username ILIKE $1 OR email ILIKE $1", pattern).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		"SELECT id, username, email, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE username ILIKE $1 OR email ILIKE $1 ORDER BY id LIMIT $2 OFFSET $3",
+		pattern,
+		options.Limit,
+		(options.Page-1)*options.Limit,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []*types.UserResponse{}
+
+	for rows.Next() {
+		user := &types.UserResponse{}
+		err := rows.Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.Role,
+			&user.EmailVerifiedAt,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.DeletedAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		users = append(users, user)
+	}
+
+	return users, total, nil
+}
+
+// GetAnyByID works like GetByID but also returns disabled users.
+func (s *UserStore) GetAnyByID(ctx context.Context, userID int) (*types.UserResponse, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.GetAnyByID")
+	defer span.End()
+
+	user := &types.UserResponse{}
+	err := s.db.QueryRowContext(ctx, "SELECT id, username, email, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE id = $1", userID).
+		Scan(
+			&user.ID,
+			&user.Username,
+			&user.Email,
+			&user.Role,
+			&user.EmailVerifiedAt,
+			&user.CreatedAt,
+			&user.UpdatedAt,
+			&user.DeletedAt,
+		)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// HardDeleteByID permanently removes the user together with the books they
+// own, shares of those books included, and the rows that don't reference
+// users through a foreign key, login throttling by email included.
+// Everything else goes away through ON DELETE CASCADE. revoked_tokens is
+// kept so revoked access tokens stay revoked until they expire. The uploaded
+// covers of the books are removed once the rest is.
+func (s *UserStore) HardDeleteByID(ctx context.Context, userID int) error {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.HardDeleteByID")
+	defer span.End()
+
+	coverKeys, err := s.hardDeleteByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	utils.DeleteCoverBlobs(ctx, s.covers, coverKeys...)
+
+	return nil
+}
+
+func (s *UserStore) hardDeleteByID(ctx context.Context, userID int) ([]string, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	rows, err := tx.QueryContext(
+		ctx,
+		"DELETE FROM books WHERE id IN (SELECT book_id FROM users_books WHERE user_id = $1 AND permission = 'owner') RETURNING id, image_url, COALESCE(thumbnail_url, '')",
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	_, coverKeys, err := utils.ScanCoverKeys(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, query := range []string{
+		"DELETE FROM users_books WHERE user_id = $1 OR book_id IN (SELECT book_id FROM users_books WHERE user_id = $1 AND permission = 'owner')",
+		"DELETE FROM refresh_tokens WHERE user_id = $1",
+		"DELETE FROM security_events WHERE user_id = $1",
+		"DELETE FROM login_throttles WHERE throttle_key = (SELECT 'account:' || LOWER(email) FROM users WHERE id = $1)",
+	} {
+		_, err = tx.ExecContext(ctx, query, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := tx.ExecContext(ctx, "DELETE FROM users WHERE id = $1", userID)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, err
+	}
+	if rowsAffected == 0 {
+		err = sql.ErrNoRows
+		return nil, err
+	}
+
+	return coverKeys, nil
+}
+
+// DisableByID soft deletes the user on behalf of an admin. Unlike DeleteByID,
+// the user can't restore the account and the purger won't remove it.
+func (s *UserStore) DisableByID(ctx context.Context, userID int) error {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.DisableByID")
+	defer span.End()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		"UPDATE users SET deleted_at = COALESCE(deleted_at, $2), disabled_by_admin = TRUE, tokens_valid_after = $2 WHERE id = $1 AND NOT disabled_by_admin",
+		userID,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+func (s *UserStore) RestoreByID(ctx context.Context, userID int) error {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.RestoreByID")
+	defer span.End()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		"UPDATE users SET deleted_at = NULL, disabled_by_admin = FALSE, updated_at = $2 WHERE id = $1 AND deleted_at IS NOT NULL",
+		userID,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// GetDeletedByEmail finds a user who deleted their own account after
+// deletedAfter. Accounts disabled by an admin are never returned.
+func (s *UserStore) GetDeletedByEmail(ctx context.Context, email string, deletedAfter time.Time) (*types.GetByEmailResponse, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.GetDeletedByEmail")
+	defer span.End()
+
+	user := &types.GetByEmailResponse{}
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT id, username, email, password_hash, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE email = $1 AND deleted_at > $2 AND NOT disabled_by_admin",
+		email,
+		deletedAfter,
+	).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.PasswordHash,
+		&user.Role,
+		&user.EmailVerifiedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// PurgeDeleted hard deletes every user who deleted their own account before
+// deletedBefore, one at a time through HardDeleteByID so their books and
+// sessions go with them.
+func (s *UserStore) PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.PurgeDeleted")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		"SELECT id FROM users WHERE deleted_at < $1 AND NOT disabled_by_admin ORDER BY id",
+		deletedBefore,
+	)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	var userIDs []int
+	for rows.Next() {
+		var userID int
+		if err := rows.Scan(&userID); err != nil {
+			return 0, err
+		}
+		userIDs = append(userIDs, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, userID := range userIDs {
+		err := s.HardDeleteByID(ctx, userID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return purged, err
+		}
+		purged++
+	}
+
+	return purged, nil
+}
+
+// UpdateRoleByID also refuses the access tokens issued before a change of
+// role, since they carry the old one. Sessions get the new role when they
+// refresh.
+func (s *UserStore) UpdateRoleByID(ctx context.Context, userID int, role string) (*types.UserResponse, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.UpdateRoleByID")
+	defer span.End()
+
+	user := &types.UserResponse{}
+	err := s.db.QueryRowContext(
+		ctx,
+		"UPDATE users SET role = $2, tokens_valid_after = CASE WHEN role = $2 THEN tokens_valid_after ELSE $3 END, updated_at = $3 WHERE id = $1 RETURNING id, username, email, role, email_verified_at, created_at, updated_at, deleted_at",
+		userID,
+		role,
+		time.Now(),
+	).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Role,
+		&user.EmailVerifiedAt,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return user, nil
+}
+
+func (s *UserStore) GetPasswordHashByID(ctx context.Context, userID int) (string, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.GetPasswordHashByID")
+	defer span.End()
+
+	var passwordHash string
+	err := s.db.QueryRowContext(ctx, "SELECT password_hash FROM users WHERE id = $1 AND deleted_at IS NULL", userID).Scan(&passwordHash)
+	if err != nil {
+		return "", err
+	}
+
+	return passwordHash, nil
+}
+
+func (s *UserStore) UpdatePasswordByID(ctx context.Context, userID int, passwordHash string) error {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.UpdatePasswordByID")
+	defer span.End()
+
+	result, err := s.db.ExecContext(
+		ctx,
+		"UPDATE users SET password_hash = $2, updated_at = $3 WHERE id = $1 AND deleted_at IS NULL",
+		userID,
+		passwordHash,
+		time.Now(),
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// CreateEmailVerificationToken stores a new verification token and discards
+// any unused one the user still had, so only the most recent email link works.
+func (s *UserStore) CreateEmailVerificationToken(ctx context.Context, payload types.CreateEmailVerificationTokenPayload) error {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.CreateEmailVerificationToken")
+	defer span.End()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			_ = tx.Commit()
+		}
+	}()
+
+	_, err = tx.ExecContext(
+		ctx,
+		"DELETE FROM email_verification_tokens WHERE user_id = $1 AND used_at IS NULL",
+		payload.UserID,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(
+		ctx,
+		"INSERT INTO email_verification_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)",
+		payload.UserID,
+		payload.TokenHash,
+		payload.ExpiresAt,
+	)
+
+	return err
+}
+
+// VerifyEmail consumes an unused, unexpired token and marks its user's email
+// as verified in a single statement, returning the user ID.
+func (s *UserStore) VerifyEmail(ctx context.Context, tokenHash string) (int, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.VerifyEmail")
+	defer span.End()
+
+	var userID int
+	err := s.db.QueryRowContext(
+		ctx,
+		`
+		WITH token AS (
+			UPDATE email_verification_tokens SET used_at = NOW()
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+			RETURNING user_id
+		)
+		UPDATE users SET email_verified_at = COALESCE(users.email_verified_at, NOW())
+		FROM token
+		WHERE users.id = token.user_id AND users.deleted_at IS NULL
+		RETURNING users.id;
+		`,
+		tokenHash,
+	).Scan(&userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return userID, nil
+}
+
+func (s *UserStore) CreateAPIKey(ctx context.Context, payload types.CreateAPIKeyDatabasePayload) (*types.APIKey, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.CreateAPIKey")
+	defer span.End()
+
+	apiKey := &types.APIKey{}
+	err := s.db.QueryRowContext(
+		ctx,
+		`INSERT INTO api_keys (user_id, name, token_prefix, token_hash, scopes, expires_at)
+         VALUES ($1, $2, $3, $4, $5, $6)
+         RETURNING id, name, token_prefix, scopes, expires_at, last_used_at, created_at`,
+		payload.UserID,
+		payload.Name,
+		payload.TokenPrefix,
+		payload.TokenHash,
+		pq.Array(payload.Scopes),
+		payload.ExpiresAt,
+	).Scan(
+		&apiKey.ID,
+		&apiKey.Name,
+		&apiKey.TokenPrefix,
+		pq.Array(&apiKey.Scopes),
+		&apiKey.ExpiresAt,
+		&apiKey.LastUsedAt,
+		&apiKey.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return apiKey, nil
+}
+
+func (s *UserStore) GetAPIKeysByUserID(ctx context.Context, userID int) ([]*types.APIKey, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.GetAPIKeysByUserID")
+	defer span.End()
+
+	rows, err := s.db.QueryContext(
+		ctx,
+		`SELECT id, name, token_prefix, scopes, expires_at, last_used_at, created_at
+         FROM api_keys
+         WHERE user_id = $1
+         ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var apiKeys []*types.APIKey
+	for rows.Next() {
+		apiKey := &types.APIKey{}
+		if err := rows.Scan(
+			&apiKey.ID,
+			&apiKey.Name,
+			&apiKey.TokenPrefix,
+			pq.Array(&apiKey.Scopes),
+			&apiKey.ExpiresAt,
+			&apiKey.LastUsedAt,
+			&apiKey.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		apiKeys = append(apiKeys, apiKey)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return apiKeys, nil
+}
+
+func (s *UserStore) DeleteAPIKey(ctx context.Context, userID int, id int) error {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.DeleteAPIKey")
+	defer span.End()
+
+	result, err := s.db.ExecContext(ctx, "DELETE FROM api_keys WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AuthenticateAPIKey looks up an unexpired key of an active user and records
+// its use. It returns sql.ErrNoRows for any key that can't be used.
+func (s *UserStore) AuthenticateAPIKey(ctx context.Context, tokenHash string) (*types.APIKeyOwner, error) {
+	tracer := otel.Tracer("user-store")
+	ctx, span := tracer.Start(ctx, "UserStore.AuthenticateAPIKey")
+	defer span.End()
+
+	owner := &types.APIKeyOwner{}
+	err := s.db.QueryRowContext(
+		ctx,
+		`UPDATE api_keys SET last_used_at = NOW()
+         FROM users
+         WHERE api_keys.token_hash = $1
+           AND (api_keys.expires_at IS NULL OR api_keys.expires_at > NOW())
+           AND users.id = api_keys.user_id
+           AND users.deleted_at IS NULL
+         RETURNING api_keys.id, users.id, users.username, users.email, users.role, api_keys.scopes, api_keys.expires_at`,
+		tokenHash,
+	).Scan(
+		&owner.KeyID,
+		&owner.UserID,
+		&owner.Username,
+		&owner.Email,
+		&owner.Role,
+		pq.Array(&owner.Scopes),
+		&owner.ExpiresAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	return owner, nil
+}
@@ -3,12 +3,14 @@ package user
 import (
 	"context"
 	"database/sql"
+	"fmt"
 	"regexp"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/hoyci/book-store-api/blobstore"
 	"github.com/hoyci/book-store-api/types"
 	"github.com/hoyci/book-store-api/utils"
 	"github.com/stretchr/testify/assert"
@@ -21,7 +23,7 @@ func TestCreateUser(t *testing.T) {
 	}
 	defer db.Close()
 
-	store := NewUserStore(db)
+	store := NewUserStore(db, nil)
 	user := types.CreateUserDatabasePayload{
 		Username:     "JohnDoe",
 		Email:        "johndoe@email.com",
@@ -29,7 +31,7 @@ func TestCreateUser(t *testing.T) {
 	}
 
 	t.Run("database connection error", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id, username, email, created_at, updated_at, deleted_at")).
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id, username, email, role, email_verified_at, created_at, updated_at, deleted_at")).
 			WithArgs(user.Username, user.Email, user.PasswordHash).
 			WillReturnError(sql.ErrConnDone)
 
@@ -46,15 +48,17 @@ func TestCreateUser(t *testing.T) {
 
 	t.Run("successfully create user", func(t *testing.T) {
 		mockedDate := time.Date(0001, 1, 1, 0, 0, 0, 0, time.UTC)
-		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id, username, email, created_at, updated_at, deleted_at")).
+		mock.ExpectQuery(regexp.QuoteMeta("INSERT INTO users (username, email, password_hash) VALUES ($1, $2, $3) RETURNING id, username, email, role, email_verified_at, created_at, updated_at, deleted_at")).
 			WithArgs(user.Username, user.Email, user.PasswordHash).
 			WillReturnRows(
 				sqlmock.NewRows([]string{
-					"id", "username", "email", "created_at", "updated_at", "deleted_at",
+					"id", "username", "email", "role", "email_verified_at", "created_at", "updated_at", "deleted_at",
 				}).AddRow(
 					1,
 					"JohnDoe",
 					"johndoe@email.com",
+					"reader",
+					nil,
 					mockedDate,
 					nil,
 					nil,
@@ -66,6 +70,7 @@ func TestCreateUser(t *testing.T) {
 		assert.Equal(t, 1, newUser.ID)
 		assert.Equal(t, user.Username, newUser.Username)
 		assert.Equal(t, user.Email, newUser.Email)
+		assert.Equal(t, types.RoleReader, newUser.Role)
 
 		if err := mock.ExpectationsWereMet(); err != nil {
 			t.Errorf("unmet expectations: %v", err)
@@ -80,7 +85,7 @@ func TestGetUserByID(t *testing.T) {
 	}
 	defer db.Close()
 
-	store := NewUserStore(db)
+	store := NewUserStore(db, nil)
 
 	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
 		ID:               "ID-CRAZY",
@@ -114,7 +119,7 @@ func TestGetUserByID(t *testing.T) {
 	})
 
 	t.Run("database did not find any row", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, created_at, updated_at, deleted_at  FROM users WHERE id = $1 AND deleted_at IS null")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, role, email_verified_at, created_at, updated_at, deleted_at  FROM users WHERE id = $1 AND deleted_at IS null")).
 			WithArgs(1).
 			WillReturnError(sql.ErrNoRows)
 
@@ -130,7 +135,7 @@ func TestGetUserByID(t *testing.T) {
 	})
 
 	t.Run("database connection error", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, created_at, updated_at, deleted_at  FROM users WHERE id = $1 AND deleted_at IS null")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, role, email_verified_at, created_at, updated_at, deleted_at  FROM users WHERE id = $1 AND deleted_at IS null")).
 			WithArgs(1).
 			WillReturnError(sql.ErrConnDone)
 
@@ -148,10 +153,10 @@ func TestGetUserByID(t *testing.T) {
 	t.Run("successfully get user by ID", func(t *testing.T) {
 		expectedCreatedAt := time.Date(0001, 1, 1, 0, 0, 0, 0, time.UTC)
 
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, created_at, updated_at, deleted_at  FROM users WHERE id = $1 AND deleted_at IS null")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, role, email_verified_at, created_at, updated_at, deleted_at  FROM users WHERE id = $1 AND deleted_at IS null")).
 			WithArgs(1).
-			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "created_at", "updated_at", "deleted_at"}).
-				AddRow(1, "johndoe", "johndoe@email.com", expectedCreatedAt, nil, nil))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role", "email_verified_at", "created_at", "updated_at", "deleted_at"}).
+				AddRow(1, "johndoe", "johndoe@email.com", "admin", nil, expectedCreatedAt, nil, nil))
 
 		user, err := store.GetByID(ctx, 1)
 
@@ -160,6 +165,7 @@ func TestGetUserByID(t *testing.T) {
 		assert.Equal(t, 1, user.ID)
 		assert.Equal(t, "johndoe", user.Username)
 		assert.Equal(t, "johndoe@email.com", user.Email)
+		assert.Equal(t, types.RoleAdmin, user.Role)
 		assert.Equal(t, expectedCreatedAt, user.CreatedAt)
 
 		if err := mock.ExpectationsWereMet(); err != nil {
@@ -175,7 +181,7 @@ func TestGetUserByEmail(t *testing.T) {
 	}
 	defer db.Close()
 
-	store := NewUserStore(db)
+	store := NewUserStore(db, nil)
 
 	t.Run("context canceled", func(t *testing.T) {
 		ctx, cancel := context.WithCancel(context.Background())
@@ -193,7 +199,7 @@ func TestGetUserByEmail(t *testing.T) {
 	})
 
 	t.Run("database did not find any row", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, created_at, updated_at, deleted_at FROM users WHERE email = $1 AND deleted_at IS null")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, password_hash, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE email = $1 AND deleted_at IS null")).
 			WithArgs("johndoe@email.com").
 			WillReturnError(sql.ErrNoRows)
 
@@ -209,7 +215,7 @@ func TestGetUserByEmail(t *testing.T) {
 	})
 
 	t.Run("database connection error", func(t *testing.T) {
-		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, created_at, updated_at, deleted_at FROM users WHERE email = $1 AND deleted_at IS null")).
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, password_hash, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE email = $1 AND deleted_at IS null")).
 			WithArgs("johndoe@email.com").
 			WillReturnError(sql.ErrConnDone)
 
@@ -227,10 +233,10 @@ func TestGetUserByEmail(t *testing.T) {
 	t.Run("successfully get user by ID", func(t *testing.T) {
 		expectedCreatedAt := time.Date(0001, 1, 1, 0, 0, 0, 0, time.UTC)
 
-		mock.ExpectQuery("SELECT id, username, email, created_at, updated_at, deleted_at FROM users WHERE email = \\$1 AND deleted_at IS null").
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT id, username, email, password_hash, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE email = $1 AND deleted_at IS null")).
 			WithArgs("johndoe@email.com").
-			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "created_at", "updated_at", "deleted_at"}).
-				AddRow(1, "johndoe", "johndoe@email.com", expectedCreatedAt, nil, nil))
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "password_hash", "role", "email_verified_at", "created_at", "updated_at", "deleted_at"}).
+				AddRow(1, "johndoe", "johndoe@email.com", "hashed-password", "librarian", nil, expectedCreatedAt, nil, nil))
 
 		expectedID := 1
 
@@ -241,6 +247,8 @@ func TestGetUserByEmail(t *testing.T) {
 		assert.Equal(t, expectedID, user.ID)
 		assert.Equal(t, "johndoe", user.Username)
 		assert.Equal(t, "johndoe@email.com", user.Email)
+		assert.Equal(t, "hashed-password", user.PasswordHash)
+		assert.Equal(t, types.RoleLibrarian, user.Role)
 		assert.Equal(t, expectedCreatedAt, user.CreatedAt)
 
 		if err := mock.ExpectationsWereMet(); err != nil {
@@ -256,7 +264,7 @@ func TestUpdateByID(t *testing.T) {
 	}
 	defer db.Close()
 
-	store := NewUserStore(db)
+	store := NewUserStore(db, nil)
 
 	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
 		ID:               "ID-CRAZY",
@@ -296,12 +304,15 @@ func TestUpdateByID(t *testing.T) {
 			UPDATE users SET 
 			username = $2, 
 			email = $3,
+			email_verified_at = CASE WHEN LOWER(email) = LOWER($3) THEN email_verified_at END,
 			updated_at = $4
 			WHERE id = $1
 			RETURNING 
 				id, 
 				username, 
 				email, 
+				role,
+				email_verified_at,
 				created_at, 
 				deleted_at,
 				updated_at;
@@ -310,7 +321,7 @@ func TestUpdateByID(t *testing.T) {
 				1,
 				"Updated Username",
 				"Updated Email",
-				time.Now(),
+				sqlmock.AnyArg(),
 			).
 			WillReturnError(sql.ErrNoRows)
 
@@ -333,12 +344,15 @@ func TestUpdateByID(t *testing.T) {
 			UPDATE users SET 
 			username = $2, 
 			email = $3,
+			email_verified_at = CASE WHEN LOWER(email) = LOWER($3) THEN email_verified_at END,
 			updated_at = $4
 			WHERE id = $1
 			RETURNING 
 				id, 
 				username, 
 				email, 
+				role,
+				email_verified_at,
 				created_at, 
 				deleted_at,
 				updated_at;
@@ -347,7 +361,7 @@ func TestUpdateByID(t *testing.T) {
 				1,
 				"Updated Username",
 				"Updated Email",
-				time.Now(),
+				sqlmock.AnyArg(),
 			).
 			WillReturnError(sql.ErrConnDone)
 
@@ -371,12 +385,15 @@ func TestUpdateByID(t *testing.T) {
 			UPDATE users SET 
 			username = $2, 
 			email = $3,
+			email_verified_at = CASE WHEN LOWER(email) = LOWER($3) THEN email_verified_at END,
 			updated_at = $4
 			WHERE id = $1
 			RETURNING 
 				id, 
 				username, 
 				email, 
+				role,
+				email_verified_at,
 				created_at, 
 				deleted_at,
 				updated_at;
@@ -385,14 +402,16 @@ func TestUpdateByID(t *testing.T) {
 				1,
 				"Updated Username",
 				"Updated Email",
-				time.Now(),
+				sqlmock.AnyArg(),
 			).
 			WillReturnRows(sqlmock.NewRows([]string{
-				"id", "username", "email", "created_at", "deleted_at", "updated_at",
+				"id", "username", "email", "role", "email_verified_at", "created_at", "deleted_at", "updated_at",
 			}).AddRow(
 				1,
 				"Updated Username",
 				"Updated Email",
+				"reader",
+				nil,
 				mockedDate,
 				nil,
 				&mockedDate,
@@ -424,7 +443,7 @@ func TestDeleteByID(t *testing.T) {
 	}
 	defer db.Close()
 
-	store := NewUserStore(db)
+	store := NewUserStore(db, nil)
 
 	ctx := utils.SetClaimsToContext(context.Background(), &types.CustomClaims{
 		ID:               "ID-CRAZY",
@@ -456,7 +475,7 @@ func TestDeleteByID(t *testing.T) {
 	})
 
 	t.Run("database did not find any row", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET deleted_at = $2 WHERE id = $1")).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL")).
 			WithArgs(1, sqlmock.AnyArg()).
 			WillReturnError(ErrUserNotFound)
 
@@ -472,7 +491,7 @@ func TestDeleteByID(t *testing.T) {
 	})
 
 	t.Run("database connection error", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET deleted_at = $2 WHERE id = $1")).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL")).
 			WithArgs(1, sqlmock.AnyArg()).
 			WillReturnError(sql.ErrConnDone)
 
@@ -487,7 +506,7 @@ func TestDeleteByID(t *testing.T) {
 	})
 
 	t.Run("successfully delete user by ID", func(t *testing.T) {
-		mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET deleted_at = $2 WHERE id = $1")).
+		mock.ExpectExec(regexp.QuoteMeta("UPDATE users SET deleted_at = $2 WHERE id = $1 AND deleted_at IS NULL")).
 			WithArgs(1, sqlmock.AnyArg()).
 			WillReturnResult(sqlmock.NewResult(0, 1))
 
@@ -500,3 +519,764 @@ func TestDeleteByID(t *testing.T) {
 		}
 	})
 }
+
+func TestGetManyUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	options := types.GetUsersOptions{Page: 2, Limit: 10}
+	countQuery := regexp.QuoteMeta("SELECT COUNT(*) FROM users WHERE username ILIKE $1 OR email ILIKE $1")
+	query := regexp.QuoteMeta("SELECT id, username, email, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE username ILIKE $1 OR email ILIKE $1 ORDER BY id LIMIT $2 OFFSET $3")
+
+	t.Run("database connection error", func(t *testing.T) {
+		mock.ExpectQuery(countQuery).
+			WithArgs("%%").
+			WillReturnError(sql.ErrConnDone)
+
+		users, total, err := store.GetMany(context.Background(), options)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+		assert.Nil(t, users)
+		assert.Zero(t, total)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully list users", func(t *testing.T) {
+		deletedAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(countQuery).
+			WithArgs("%%").
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12))
+		mock.ExpectQuery(query).
+			WithArgs("%%", 10, 10).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role", "email_verified_at", "created_at", "updated_at", "deleted_at"}).
+				AddRow(11, "johndoe", "johndoe@email.com", "reader", nil, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), nil, nil).
+				AddRow(12, "janedoe", "janedoe@email.com", "admin", nil, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), nil, deletedAt))
+
+		users, total, err := store.GetMany(context.Background(), options)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 12, total)
+		assert.Len(t, users, 2)
+		assert.Equal(t, types.RoleAdmin, users[1].Role)
+		assert.Equal(t, &deletedAt, users[1].DeletedAt)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("search escapes LIKE wildcards", func(t *testing.T) {
+		options := types.GetUsersOptions{Page: 1, Limit: 10, Search: "john_100%"}
+
+		mock.ExpectQuery(countQuery).
+			WithArgs(`%john\_100\%%`).
+			WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+		mock.ExpectQuery(query).
+			WithArgs(`%john\_100\%%`, 10, 0).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role", "email_verified_at", "created_at", "updated_at", "deleted_at"}))
+
+		users, total, err := store.GetMany(context.Background(), options)
+
+		assert.NoError(t, err)
+		assert.Zero(t, total)
+		assert.Empty(t, users)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetAnyByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	query := regexp.QuoteMeta("SELECT id, username, email, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE id = $1")
+
+	t.Run("database did not find any user", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(1).
+			WillReturnError(sql.ErrNoRows)
+
+		user, err := store.GetAnyByID(context.Background(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Nil(t, user)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get disabled user", func(t *testing.T) {
+		createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		deletedAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(query).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role", "email_verified_at", "created_at", "updated_at", "deleted_at"}).
+				AddRow(1, "johndoe", "johndoe@email.com", "reader", nil, createdAt, nil, deletedAt))
+
+		user, err := store.GetAnyByID(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.UserResponse{
+			ID:        1,
+			Username:  "johndoe",
+			Email:     "johndoe@email.com",
+			Role:      types.RoleReader,
+			CreatedAt: createdAt,
+			DeletedAt: &deletedAt,
+		}, user)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestHardDeleteByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	covers := blobstore.NewLocalBlobStore(t.TempDir())
+	store := NewUserStore(db, covers)
+
+	ctx := context.Background()
+	coverKeys := []string{"books/2/0123456789abcdef/full.jpg", "books/2/0123456789abcdef/thumbnail.jpg"}
+	for _, key := range coverKeys {
+		if err := covers.Put(ctx, key, []byte("jpeg"), "image/jpeg"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expectCleanup := func() {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM books WHERE id IN (SELECT book_id FROM users_books WHERE user_id = $1 AND permission = 'owner') RETURNING id, image_url, COALESCE(thumbnail_url, '')")).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "image_url", "thumbnail_url"}).
+				AddRow(2, utils.CoverURL(coverKeys[0]), utils.CoverURL(coverKeys[1])).
+				AddRow(3, "http://google.com/somerandomimage.jpg", ""))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users_books WHERE user_id = $1 OR book_id IN (SELECT book_id FROM users_books WHERE user_id = $1 AND permission = 'owner')")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 4))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM refresh_tokens WHERE user_id = $1")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM security_events WHERE user_id = $1")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM login_throttles")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+
+	t.Run("database did not find any user", func(t *testing.T) {
+		expectCleanup()
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users WHERE id = $1")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectRollback()
+
+		err := store.HardDeleteByID(ctx, 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		for _, key := range coverKeys {
+			_, _, err := covers.Get(ctx, key)
+			assert.NoError(t, err)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully delete user", func(t *testing.T) {
+		expectCleanup()
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users WHERE id = $1")).
+			WithArgs(1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectCommit()
+
+		err := store.HardDeleteByID(ctx, 1)
+
+		assert.NoError(t, err)
+
+		for _, key := range coverKeys {
+			_, _, err := covers.Get(ctx, key)
+			assert.ErrorIs(t, err, types.ErrBlobNotFound)
+		}
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDisableByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	query := regexp.QuoteMeta("UPDATE users SET deleted_at = COALESCE(deleted_at, $2), disabled_by_admin = TRUE, tokens_valid_after = $2 WHERE id = $1 AND NOT disabled_by_admin")
+
+	t.Run("database did not find any enabled user", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.DisableByID(context.Background(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully disable user", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.DisableByID(context.Background(), 1)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetDeletedByEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	query := regexp.QuoteMeta("SELECT id, username, email, password_hash, role, email_verified_at, created_at, updated_at, deleted_at FROM users WHERE email = $1 AND deleted_at > $2 AND NOT disabled_by_admin")
+	deletedAfter := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("database did not find any deleted user", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs("johndoe@email.com", deletedAfter).
+			WillReturnError(sql.ErrNoRows)
+
+		user, err := store.GetDeletedByEmail(context.Background(), "johndoe@email.com", deletedAfter)
+
+		assert.Nil(t, user)
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get deleted user by email", func(t *testing.T) {
+		createdAt := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		deletedAt := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)
+
+		mock.ExpectQuery(query).
+			WithArgs("johndoe@email.com", deletedAfter).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "password_hash", "role", "email_verified_at", "created_at", "updated_at", "deleted_at"}).
+				AddRow(1, "johndoe", "johndoe@email.com", "hash", types.RoleReader, nil, createdAt, nil, deletedAt))
+
+		user, err := store.GetDeletedByEmail(context.Background(), "johndoe@email.com", deletedAfter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.GetByEmailResponse{
+			ID:           1,
+			Username:     "johndoe",
+			Email:        "johndoe@email.com",
+			PasswordHash: "hash",
+			Role:         types.RoleReader,
+			CreatedAt:    createdAt,
+			DeletedAt:    &deletedAt,
+		}, user)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	selectQuery := regexp.QuoteMeta("SELECT id FROM users WHERE deleted_at < $1 AND NOT disabled_by_admin ORDER BY id")
+	deletedBefore := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+
+	expectHardDelete := func(userID int, rowsAffected int64) {
+		mock.ExpectBegin()
+		mock.ExpectQuery(regexp.QuoteMeta("DELETE FROM books")).
+			WithArgs(userID).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "image_url", "thumbnail_url"}))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users_books WHERE user_id = $1")).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM refresh_tokens WHERE user_id = $1")).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM security_events WHERE user_id = $1")).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM login_throttles")).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM users WHERE id = $1")).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, rowsAffected))
+		if rowsAffected == 0 {
+			mock.ExpectRollback()
+		} else {
+			mock.ExpectCommit()
+		}
+	}
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectQuery(selectQuery).
+			WithArgs(deletedBefore).
+			WillReturnError(fmt.Errorf("database connection error"))
+
+		purged, err := store.PurgeDeleted(context.Background(), deletedBefore)
+
+		assert.Error(t, err)
+		assert.Zero(t, purged)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully purge deleted users", func(t *testing.T) {
+		mock.ExpectQuery(selectQuery).
+			WithArgs(deletedBefore).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(3).AddRow(5).AddRow(8))
+		expectHardDelete(3, 1)
+		expectHardDelete(5, 0)
+		expectHardDelete(8, 1)
+
+		purged, err := store.PurgeDeleted(context.Background(), deletedBefore)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 2, purged)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestRestoreByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	query := regexp.QuoteMeta("UPDATE users SET deleted_at = NULL, disabled_by_admin = FALSE, updated_at = $2 WHERE id = $1 AND deleted_at IS NOT NULL")
+
+	t.Run("database did not find any disabled user", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.RestoreByID(context.Background(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully restore user", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.RestoreByID(context.Background(), 1)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestUpdateRoleByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	query := regexp.QuoteMeta("UPDATE users SET role = $2, tokens_valid_after = CASE WHEN role = $2 THEN tokens_valid_after ELSE $3 END, updated_at = $3 WHERE id = $1 RETURNING id, username, email, role, email_verified_at, created_at, updated_at, deleted_at")
+
+	t.Run("database did not find any row", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(1, "librarian", sqlmock.AnyArg()).
+			WillReturnError(sql.ErrNoRows)
+
+		user, err := store.UpdateRoleByID(context.Background(), 1, types.RoleLibrarian)
+
+		assert.Nil(t, user)
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully update role", func(t *testing.T) {
+		updatedAt := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+		mock.ExpectQuery(query).
+			WithArgs(1, "librarian", sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "username", "email", "role", "email_verified_at", "created_at", "updated_at", "deleted_at"}).
+				AddRow(1, "johndoe", "johndoe@email.com", "librarian", nil, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), updatedAt, nil))
+
+		user, err := store.UpdateRoleByID(context.Background(), 1, types.RoleLibrarian)
+
+		assert.NoError(t, err)
+		assert.Equal(t, types.RoleLibrarian, user.Role)
+		assert.Equal(t, &updatedAt, user.UpdatedAt)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestUpdatePasswordByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	query := regexp.QuoteMeta("UPDATE users SET password_hash = $2, updated_at = $3 WHERE id = $1 AND deleted_at IS NULL")
+
+	t.Run("database did not find any active user", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, "new-hash", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.UpdatePasswordByID(context.Background(), 1, "new-hash")
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully update password", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(1, "new-hash", sqlmock.AnyArg()).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.UpdatePasswordByID(context.Background(), 1, "new-hash")
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestCreateEmailVerificationToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	payload := types.CreateEmailVerificationTokenPayload{
+		UserID:    1,
+		TokenHash: "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8",
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	}
+	deleteQuery := regexp.QuoteMeta("DELETE FROM email_verification_tokens WHERE user_id = $1 AND used_at IS NULL")
+	insertQuery := regexp.QuoteMeta("INSERT INTO email_verification_tokens (user_id, token_hash, expires_at) VALUES ($1, $2, $3)")
+
+	t.Run("database unexpected error", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).
+			WithArgs(payload.UserID).
+			WillReturnError(sql.ErrConnDone)
+		mock.ExpectRollback()
+
+		err := store.CreateEmailVerificationToken(context.Background(), payload)
+
+		assert.Equal(t, sql.ErrConnDone, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully replace previous unused tokens", func(t *testing.T) {
+		mock.ExpectBegin()
+		mock.ExpectExec(deleteQuery).
+			WithArgs(payload.UserID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(insertQuery).
+			WithArgs(payload.UserID, payload.TokenHash, payload.ExpiresAt).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		err := store.CreateEmailVerificationToken(context.Background(), payload)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestVerifyEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	tokenHash := "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"
+	query := regexp.QuoteMeta("UPDATE users SET email_verified_at = COALESCE(users.email_verified_at, NOW())")
+
+	t.Run("token is invalid, expired or already used", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(tokenHash).
+			WillReturnError(sql.ErrNoRows)
+
+		userID, err := store.VerifyEmail(context.Background(), tokenHash)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Zero(t, userID)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully verify email", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(tokenHash).
+			WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(1))
+
+		userID, err := store.VerifyEmail(context.Background(), tokenHash)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, userID)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestGetPasswordHashByID(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	query := regexp.QuoteMeta("SELECT password_hash FROM users WHERE id = $1 AND deleted_at IS NULL")
+
+	t.Run("database did not find any active user", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(1).
+			WillReturnError(sql.ErrNoRows)
+
+		passwordHash, err := store.GetPasswordHashByID(context.Background(), 1)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Empty(t, passwordHash)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully get password hash", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"password_hash"}).AddRow("hashed-password"))
+
+		passwordHash, err := store.GetPasswordHashByID(context.Background(), 1)
+
+		assert.NoError(t, err)
+		assert.Equal(t, "hashed-password", passwordHash)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	payload := types.CreateAPIKeyDatabasePayload{
+		UserID:      1,
+		Name:        "ci",
+		TokenPrefix: "bsk_abcdef",
+		TokenHash:   "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8",
+		Scopes:      []string{types.ScopeBooksRead},
+	}
+	query := regexp.QuoteMeta("INSERT INTO api_keys (user_id, name, token_prefix, token_hash, scopes, expires_at)")
+
+	t.Run("successfully create API key", func(t *testing.T) {
+		createdAt := time.Now()
+
+		mock.ExpectQuery(query).
+			WithArgs(payload.UserID, payload.Name, payload.TokenPrefix, payload.TokenHash, sqlmock.AnyArg(), payload.ExpiresAt).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "name", "token_prefix", "scopes", "expires_at", "last_used_at", "created_at"}).
+				AddRow(1, "ci", "bsk_abcdef", "{books:read}", nil, nil, createdAt))
+
+		apiKey, err := store.CreateAPIKey(context.Background(), payload)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.APIKey{
+			ID:          1,
+			Name:        "ci",
+			TokenPrefix: "bsk_abcdef",
+			Scopes:      []string{types.ScopeBooksRead},
+			CreatedAt:   createdAt,
+		}, apiKey)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestDeleteAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	query := regexp.QuoteMeta("DELETE FROM api_keys WHERE id = $1 AND user_id = $2")
+
+	t.Run("key does not exist or belongs to another user", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(5, 1).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		err := store.DeleteAPIKey(context.Background(), 1, 5)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully delete API key", func(t *testing.T) {
+		mock.ExpectExec(query).
+			WithArgs(5, 1).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		err := store.DeleteAPIKey(context.Background(), 1, 5)
+
+		assert.NoError(t, err)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	defer db.Close()
+
+	store := NewUserStore(db, nil)
+	tokenHash := "5e884898da28047151d0e56f8dc6292773603d0d6aabbdd62a11ef721d1542d8"
+	query := regexp.QuoteMeta("UPDATE api_keys SET last_used_at = NOW()")
+
+	t.Run("key is unknown, expired or its owner is disabled", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(tokenHash).
+			WillReturnError(sql.ErrNoRows)
+
+		owner, err := store.AuthenticateAPIKey(context.Background(), tokenHash)
+
+		assert.Equal(t, sql.ErrNoRows, err)
+		assert.Nil(t, owner)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+
+	t.Run("successfully authenticate API key", func(t *testing.T) {
+		mock.ExpectQuery(query).
+			WithArgs(tokenHash).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "id", "username", "email", "role", "scopes", "expires_at"}).
+				AddRow(3, 1, "JohnDoe", "johndoe@example.com", types.RoleLibrarian, "{books:read,books:write}", nil))
+
+		owner, err := store.AuthenticateAPIKey(context.Background(), tokenHash)
+
+		assert.NoError(t, err)
+		assert.Equal(t, &types.APIKeyOwner{
+			KeyID:    3,
+			UserID:   1,
+			Username: "JohnDoe",
+			Email:    "johndoe@example.com",
+			Role:     types.RoleLibrarian,
+			Scopes:   []string{types.ScopeBooksRead, types.ScopeBooksWrite},
+		}, owner)
+
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("unmet expectations: %v", err)
+		}
+	})
+}
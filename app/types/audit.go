@@ -0,0 +1,68 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+type AuditStore interface {
+	CreateAuditLog(ctx context.Context, payload CreateAuditLogPayload) error
+	GetAuditLogs(ctx context.Context, options GetAuditLogsOptions) ([]*AuditLog, int, error)
+}
+
+const (
+	AuditActionUserRoleUpdated = "user.role_updated"
+	AuditActionUserDisabled    = "user.disabled"
+	AuditActionUserRestored    = "user.restored"
+	AuditActionUserLoggedOut   = "user.logged_out"
+	AuditActionUserDeleted     = "user.deleted"
+	AuditActionBookDisabled    = "book.disabled"
+	AuditActionBookRestored    = "book.restored"
+)
+
+const (
+	AuditTargetUser = "user"
+	AuditTargetBook = "book"
+)
+
+// AuditLog records an action taken through the admin API. ActorID is kept
+// even after the admin is deleted, so it may no longer match a user.
+type AuditLog struct {
+	ID         int               `json:"id"`
+	ActorID    int               `json:"actor_id"`
+	Action     string            `json:"action"`
+	TargetType string            `json:"target_type"`
+	TargetID   int               `json:"target_id"`
+	Details    map[string]string `json:"details"`
+	IPAddress  string            `json:"ip_address"`
+	UserAgent  string            `json:"user_agent"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+type CreateAuditLogPayload struct {
+	ActorID    int
+	Action     string
+	TargetType string
+	TargetID   int
+	Details    map[string]string
+	IPAddress  string
+	UserAgent  string
+}
+
+// GetAuditLogsOptions filters by actor or target when ActorID or TargetID are
+// set. TargetID is only used together with TargetType.
+type GetAuditLogsOptions struct {
+	Page       int    `validate:"gte=1"`
+	Limit      int    `validate:"gte=1,lte=100"`
+	ActorID    int    `validate:"gte=0"`
+	TargetType string `validate:"omitempty,oneof=user book"`
+	TargetID   int    `validate:"gte=0"`
+}
+
+type GetAuditLogsResponse struct {
+	AuditLogs  []*AuditLog `json:"audit_logs"`
+	Total      int         `json:"total"`
+	Page       int         `json:"page"`
+	Limit      int         `json:"limit"`
+	TotalPages int         `json:"total_pages"`
+}
@@ -8,8 +8,48 @@ import (
 )
 
 type AuthStore interface {
-	GetRefreshTokenByUserID(ctx context.Context, userID int) (*RefreshToken, error)
-	UpsertRefreshToken(ctx context.Context, payload UpdateRefreshTokenPayload) error
+	GetRefreshTokenByJti(ctx context.Context, jti string) (*RefreshToken, error)
+	GetRefreshTokensByUserID(ctx context.Context, userID int) ([]*RefreshToken, error)
+	CreateRefreshToken(ctx context.Context, payload CreateRefreshTokenPayload) error
+	RotateRefreshToken(ctx context.Context, payload RotateRefreshTokenPayload) error
+	DeleteRefreshToken(ctx context.Context, userID int, jti string) error
+	DeleteRefreshTokenByID(ctx context.Context, userID int, id int) error
+	DeleteRefreshTokensByUserID(ctx context.Context, userID int) error
+	RevokeTokensByUserID(ctx context.Context, userID int) error
+	RevokeOtherTokens(ctx context.Context, userID int, familyID string) error
+	DeleteRefreshTokenFamily(ctx context.Context, userID int, familyID string) error
+	RevokeAccessToken(ctx context.Context, payload RevokeAccessTokenPayload) error
+	CreateSecurityEvent(ctx context.Context, payload CreateSecurityEventPayload) error
+	CreatePasswordResetToken(ctx context.Context, payload CreatePasswordResetTokenPayload) error
+	ConsumePasswordResetToken(ctx context.Context, tokenHash string) (int, error)
+	GetMFAByUserID(ctx context.Context, userID int) (*UserMFA, error)
+	UpsertPendingMFA(ctx context.Context, userID int, encryptedSecret string) error
+	EnableMFA(ctx context.Context, payload EnableMFAPayload) error
+	UpdateMFALastUsedStep(ctx context.Context, userID int, step int64) error
+	ConsumeMFARecoveryCode(ctx context.Context, userID int, codeHash string) error
+	GetLoginLockout(ctx context.Context, keys []string) (*time.Time, error)
+	RecordFailedLogin(ctx context.Context, key string, window time.Duration) (int, error)
+	LockLogin(ctx context.Context, key string, until time.Time) error
+	ResetLoginThrottle(ctx context.Context, key string) error
+	CreateOIDCLoginState(ctx context.Context, payload CreateOIDCLoginStatePayload) error
+	ConsumeOIDCLoginState(ctx context.Context, stateHash string) (*OIDCLoginState, error)
+	GetUserIDByIdentity(ctx context.Context, issuer string, subject string) (int, error)
+	LinkIdentity(ctx context.Context, payload LinkIdentityPayload) error
+	CreateOAuthClient(ctx context.Context, payload CreateOAuthClientDatabasePayload) (*OAuthClient, error)
+	GetOAuthClientByClientID(ctx context.Context, clientID string) (*OAuthClient, error)
+	GetOAuthClients(ctx context.Context) ([]*OAuthClient, error)
+	DeleteOAuthClient(ctx context.Context, clientID string) error
+	CreateOAuthAuthorizationCode(ctx context.Context, payload CreateOAuthAuthorizationCodePayload) error
+	ConsumeOAuthAuthorizationCode(ctx context.Context, codeHash string) (*OAuthAuthorizationCode, error)
+	GetOAuthConsent(ctx context.Context, userID int, clientID string) (*OAuthConsent, error)
+	GetOAuthConsentsByUserID(ctx context.Context, userID int) ([]*OAuthConsent, error)
+	UpsertOAuthConsent(ctx context.Context, userID int, clientID string, scopes []string) error
+	DeleteOAuthConsent(ctx context.Context, userID int, clientID string) error
+	TokenDenylist
+}
+
+type TokenDenylist interface {
+	IsAccessTokenRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error)
 }
 
 type CustomClaims struct {
@@ -17,17 +57,53 @@ type CustomClaims struct {
 	UserID   int    `json:"userId"`
 	Username string `json:"username"`
 	Email    string `json:"email"`
+	Role     string `json:"role"`
+	FamilyID string `json:"fid,omitempty"`
+	// SessionID is set on access tokens only. It holds the family of the
+	// refresh token issued alongside, so handlers can tell the caller's
+	// session apart from the user's other sessions.
+	SessionID string `json:"sid,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	// ClientID is set on tokens issued to a third-party OAuth client.
+	ClientID string `json:"client_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Access tokens without a scope grant full access. Scoped tokens are only
+// accepted on routes that explicitly allow their scope.
+const (
+	ScopeUnverifiedEmail = "unverified_email"
+	// ScopeMFAChallenge tokens are accepted by no route. They can only be
+	// exchanged for a session at /auth/mfa/verify.
+	ScopeMFAChallenge = "mfa_challenge"
+)
+
+const (
+	EmailVerificationModeReject   = "reject"
+	EmailVerificationModeRestrict = "restrict"
+)
+
 type UserLoginPayload struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required,min=8"`
 }
 
+type RestoreAccountPayload struct {
+	Email    string `json:"email" validate:"required,email"`
+	Password string `json:"password" validate:"required,min=8"`
+}
+
+type RestoreAccountResponse struct {
+	Message string `json:"message"`
+}
+
+// UserLoginResponse carries either the session tokens or, for users with MFA
+// enabled, the challenge token to send to /auth/mfa/verify.
 type UserLoginResponse struct {
-	AccessToken  string `json:"access_token"`
-	RefreshToken string `json:"refresh_token"`
+	AccessToken  string `json:"access_token,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	MFARequired  bool   `json:"mfa_required,omitempty"`
+	MFAToken     string `json:"mfa_token,omitempty"`
 }
 
 type RefreshTokenPayload struct {
@@ -35,20 +111,139 @@ type RefreshTokenPayload struct {
 }
 
 type RefreshToken struct {
-	ID        int       `db:"id"`
-	UserID    int       `db:"user_id"`
-	Jti       string    `db:"jti"`
-	ExpiresAt time.Time `db:"expires_at"`
-	CreatedAt time.Time `db:"created_at"`
+	ID         int        `db:"id"`
+	UserID     int        `db:"user_id"`
+	Jti        string     `db:"jti"`
+	FamilyID   string     `db:"family_id"`
+	UserAgent  string     `db:"user_agent"`
+	IPAddress  string     `db:"ip_address"`
+	ClientID   string     `db:"client_id"`
+	Scope      string     `db:"scope"`
+	ExpiresAt  time.Time  `db:"expires_at"`
+	CreatedAt  time.Time  `db:"created_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
 }
 
-type UpdateRefreshTokenPayload struct {
+type CreateRefreshTokenPayload struct {
 	UserID    int       `db:"user_id"`
 	Jti       string    `db:"jti"`
+	FamilyID  string    `db:"family_id"`
+	UserAgent string    `db:"user_agent"`
+	IPAddress string    `db:"ip_address"`
+	ClientID  string    `db:"client_id"`
+	Scope     string    `db:"scope"`
 	ExpiresAt time.Time `db:"expires_at"`
 }
 
+type RotateRefreshTokenPayload struct {
+	PreviousJti string
+	Jti         string    `db:"jti"`
+	UserAgent   string    `db:"user_agent"`
+	IPAddress   string    `db:"ip_address"`
+	ExpiresAt   time.Time `db:"expires_at"`
+}
+
 type UpdateRefreshTokenResponse struct {
 	AccessToken  string `json:"access_token"`
 	RefreshToken string `json:"refresh_token"`
 }
+
+type RevokeAccessTokenPayload struct {
+	UserID    int       `db:"user_id"`
+	Jti       string    `db:"jti"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type SessionResponse struct {
+	ID         int        `json:"id"`
+	UserAgent  string     `json:"user_agent"`
+	IPAddress  string     `json:"ip_address"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+}
+
+type GetSessionsResponse struct {
+	Sessions []*SessionResponse `json:"sessions"`
+}
+
+const (
+	SecurityEventRefreshTokenReuse = "refresh_token_reuse"
+	SecurityEventLoginLockout      = "login_lockout"
+)
+
+type CreateSecurityEventPayload struct {
+	UserID    int    `db:"user_id"`
+	EventType string `db:"event_type"`
+	FamilyID  string `db:"family_id"`
+	UserAgent string `db:"user_agent"`
+	IPAddress string `db:"ip_address"`
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+type JWKSResponse struct {
+	Keys []JWK `json:"keys"`
+}
+
+type ForgotPasswordPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ForgotPasswordResponse struct {
+	Message string `json:"message"`
+}
+
+type ResetPasswordPayload struct {
+	Token           string `json:"token" validate:"required"`
+	Password        string `json:"password" validate:"required,min=8"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,min=8"`
+}
+
+type CreatePasswordResetTokenPayload struct {
+	UserID    int       `db:"user_id"`
+	TokenHash string    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type UserMFA struct {
+	UserID       int        `db:"user_id"`
+	Secret       string     `db:"secret"`
+	EnabledAt    *time.Time `db:"enabled_at"`
+	LastUsedStep *int64     `db:"last_used_step"`
+	CreatedAt    time.Time  `db:"created_at"`
+}
+
+type EnableMFAPayload struct {
+	UserID             int
+	Step               int64
+	RecoveryCodeHashes []string
+}
+
+type EnrollMFAResponse struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+type ConfirmMFAPayload struct {
+	Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+type ConfirmMFAResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type VerifyMFAPayload struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
@@ -0,0 +1,80 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+type AuthorStore interface {
+	Create(ctx context.Context, author CreateAuthorPayload) (int, error)
+	GetByID(ctx context.Context, id int) (*Author, error)
+	GetMany(ctx context.Context, options GetAuthorsOptions) ([]*Author, int, error)
+	UpdateByID(ctx context.Context, id int, author UpdateAuthorPayload) (*Author, error)
+	DeleteByID(ctx context.Context, id int) error
+}
+
+// Roles an author can have on a book through book_authors.
+const (
+	AuthorRoleAuthor      = "author"
+	AuthorRoleTranslator  = "translator"
+	AuthorRoleIllustrator = "illustrator"
+)
+
+// Author is shared by every user, unlike books. Two authors can't have names
+// that only differ in case, spacing or punctuation.
+type Author struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Bio       string     `json:"bio"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+type CreateAuthorPayload struct {
+	Name string `json:"name" validate:"required,min=2,max=255"`
+	Bio  string `json:"bio" validate:"max=5000"`
+}
+
+type CreateAuthorResponse struct {
+	ID int `json:"id"`
+}
+
+type UpdateAuthorPayload struct {
+	Name string `json:"name" validate:"required,min=2,max=255"`
+	Bio  string `json:"bio" validate:"max=5000"`
+}
+
+type GetAuthorsOptions struct {
+	Page   int    `validate:"gte=1"`
+	Limit  int    `validate:"gte=1,lte=100"`
+	Search string `validate:"omitempty,min=1"`
+}
+
+type GetAuthorsResponse struct {
+	Authors    []*Author `json:"authors"`
+	Total      int       `json:"total"`
+	Page       int       `json:"page"`
+	Limit      int       `json:"limit"`
+	TotalPages int       `json:"total_pages"`
+}
+
+// BookAuthor is an author as credited on a book, in the order they are
+// credited.
+type BookAuthor struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+type BookAuthorPayload struct {
+	AuthorID int    `json:"author_id" validate:"required,gte=1"`
+	Role     string `json:"role" validate:"required,oneof=author translator illustrator"`
+}
+
+type SetBookAuthorsPayload struct {
+	Authors []BookAuthorPayload `json:"authors" validate:"required,min=1,max=50,dive"`
+}
+
+type GetBookAuthorsResponse struct {
+	Authors []*BookAuthor `json:"authors"`
+}
@@ -0,0 +1,27 @@
+package types
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+var ErrBlobNotFound = errors.New("blob not found")
+
+// BlobStore keeps files such as book covers, addressed by slash-separated
+// keys like "covers/1/full.jpg".
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	// Get returns ErrBlobNotFound for keys that were never stored or were
+	// deleted. The caller must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, *BlobInfo, error)
+	// Delete succeeds for keys that don't exist.
+	Delete(ctx context.Context, key string) error
+}
+
+type BlobInfo struct {
+	ContentType  string
+	Size         int64
+	LastModified time.Time
+}
@@ -2,39 +2,75 @@ package types
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
 type BookStore interface {
 	Create(ctx context.Context, book CreateBookPayload) (int, error)
 	GetByID(ctx context.Context, id int) (*Book, error)
-	GetMany(ctx context.Context) ([]*Book, error)
+	GetByISBN(ctx context.Context, isbn13 string) (*Book, error)
+	GetMany(ctx context.Context, options GetBooksOptions) ([]*Book, int, error)
+	Search(ctx context.Context, options SearchBooksOptions) ([]*BookSearchResult, int, error)
 	UpdateByID(ctx context.Context, id int, book UpdateBookPayload) (*Book, error)
 	DeleteByID(ctx context.Context, id int) error
+	GetAll(ctx context.Context, options GetAllBooksOptions) ([]*Book, int, error)
+	DisableByID(ctx context.Context, id int) error
+	RestoreByID(ctx context.Context, id int) error
+	GetDeleted(ctx context.Context, options GetDeletedBooksOptions) ([]*Book, int, error)
+	RestoreDeletedByID(ctx context.Context, id int, deletedAfter time.Time) error
+	ShareByID(ctx context.Context, id int, share ShareBookPayload) (*BookShare, error)
+	GetSharesByID(ctx context.Context, id int) ([]*BookShare, error)
+	RevokeShareByID(ctx context.Context, id int, userID int) error
+	GetAuthorsByID(ctx context.Context, id int) ([]*BookAuthor, error)
+	SetAuthorsByID(ctx context.Context, id int, authors []BookAuthorPayload) ([]*BookAuthor, error)
+	CheckCanEditByID(ctx context.Context, id int) error
+	SetCoverByID(ctx context.Context, id int, cover BookCover) (*BookCover, error)
+	TrashPurger
 }
 
+// Permissions a user can hold on a book through users_books. The creator is
+// the owner; editors can update the book and viewers can only read it.
+const (
+	BookPermissionOwner  = "owner"
+	BookPermissionEditor = "editor"
+	BookPermissionViewer = "viewer"
+)
+
+// ErrBookISBNExists is returned when a book would end up in its owner's
+// library next to another book with the same ISBN.
+var ErrBookISBNExists = errors.New("book ISBN already in the owner's library")
+
 type Book struct {
-	ID            int        `json:"id"`
-	Name          string     `json:"name"`
-	Description   string     `json:"description"`
-	Author        string     `json:"author"`
-	Genres        []string   `json:"genres"`
-	ReleaseYear   int        `json:"release_year"`
-	NumberOfPages int        `json:"number_of_pages"`
-	ImageUrl      string     `json:"image_url"`
-	CreatedAt     time.Time  `json:"created_at"`
-	DeletedAt     *time.Time `json:"deleted_at"`
-	UpdatedAt     *time.Time `json:"updated_at"`
+	ID            int          `json:"id"`
+	Name          string       `json:"name"`
+	Description   string       `json:"description"`
+	Author        string       `json:"author"`
+	Genres        []*BookGenre `json:"genres"`
+	ReleaseYear   int          `json:"release_year"`
+	NumberOfPages int          `json:"number_of_pages"`
+	ImageUrl      string       `json:"image_url"`
+	ThumbnailUrl  *string      `json:"thumbnail_url"`
+	ISBN10        *string      `json:"isbn_10"`
+	ISBN13        *string      `json:"isbn_13"`
+	CreatedAt     time.Time    `json:"created_at"`
+	DeletedAt     *time.Time   `json:"deleted_at"`
+	UpdatedAt     *time.Time   `json:"updated_at"`
 }
 
 type CreateBookPayload struct {
-	Name          string   `json:"name" validate:"required,min=3"`
-	Description   string   `json:"description" validate:"required,min=5"`
-	Author        string   `json:"author" validate:"required,min=3"`
-	Genres        []string `json:"genres" validate:"required,dive,min=1"`
-	ReleaseYear   int      `json:"release_year" validate:"required,gte=1500,lte=2099"`
-	NumberOfPages int      `json:"number_of_pages" validate:"required,gte=1"`
-	ImageUrl      string   `json:"image_url" validate:"required,url"`
+	Name          string `json:"name" validate:"required,min=3"`
+	Description   string `json:"description" validate:"required,min=5"`
+	Author        string `json:"author" validate:"required,min=3"`
+	GenreIDs      []int  `json:"genre_ids" validate:"required,min=1,max=20,dive,gte=1"`
+	ReleaseYear   int    `json:"release_year" validate:"required,gte=1500,lte=2099"`
+	NumberOfPages int    `json:"number_of_pages" validate:"required,gte=1"`
+	ImageUrl      string `json:"image_url" validate:"omitempty,url"`
+	ISBN10        string `json:"isbn_10" validate:"omitempty,isbn=10"`
+	ISBN13        string `json:"isbn_13" validate:"omitempty,isbn=13"`
+	// Authors, when set, are credited on the book in order instead of
+	// Author. Imports use it, as catalogs list authors apart.
+	Authors []string `json:"-"`
 }
 
 type CreateBookResponse struct {
@@ -42,19 +78,107 @@ type CreateBookResponse struct {
 }
 
 type UpdateBookPayload struct {
-	Name          string   `json:"name" validate:"required,min=3"`
-	Description   string   `json:"description" validate:"required,min=5"`
-	Author        string   `json:"author" validate:"required,min=3"`
-	Genres        []string `json:"genres" validate:"required,dive,min=2"`
-	ReleaseYear   int      `json:"release_year" validate:"required,gte=1500,lte=2099"`
-	NumberOfPages int      `json:"number_of_pages" validate:"required,gte=1"`
-	ImageUrl      string   `json:"image_url" validate:"required,url"`
+	Name          string `json:"name" validate:"required,min=3"`
+	Description   string `json:"description" validate:"required,min=5"`
+	Author        string `json:"author" validate:"required,min=3"`
+	GenreIDs      []int  `json:"genre_ids" validate:"required,min=1,max=20,dive,gte=1"`
+	ReleaseYear   int    `json:"release_year" validate:"required,gte=1500,lte=2099"`
+	NumberOfPages int    `json:"number_of_pages" validate:"required,gte=1"`
+	ImageUrl      string `json:"image_url" validate:"omitempty,url"`
+	ISBN10        string `json:"isbn_10" validate:"omitempty,isbn=10"`
+	ISBN13        string `json:"isbn_13" validate:"omitempty,isbn=13"`
 }
 
 type DeleteBookByIDResponse struct {
 	ID int `json:"id"`
 }
 
+type GetBooksOptions struct {
+	Page           int    `validate:"gte=1"`
+	Limit          int    `validate:"gte=1,lte=100"`
+	Author         string `validate:"omitempty,min=1"`
+	AuthorID       int    `validate:"omitempty,gte=1"`
+	Genre          string `validate:"omitempty,min=1"`
+	MinReleaseYear int    `validate:"omitempty,gte=1500,lte=2099"`
+	MaxReleaseYear int    `validate:"omitempty,gte=1500,lte=2099,gtefield=MinReleaseYear"`
+	MinPages       int    `validate:"omitempty,gte=1"`
+	MaxPages       int    `validate:"omitempty,gte=1,gtefield=MinPages"`
+	Sort           string `validate:"oneof=name release_year created_at"`
+	Order          string `validate:"oneof=asc desc"`
+}
+
 type GetBooksResponse struct {
-	Books []*Book `json:"books"`
+	Books      []*Book `json:"books"`
+	Total      int     `json:"total"`
+	Page       int     `json:"page"`
+	Limit      int     `json:"limit"`
+	TotalPages int     `json:"total_pages"`
+	Next       *string `json:"next"`
+	Prev       *string `json:"prev"`
+}
+
+type SearchBooksOptions struct {
+	Query string `validate:"required,min=1"`
+	Page  int    `validate:"gte=1"`
+	Limit int    `validate:"gte=1,lte=100"`
+}
+
+type BookSearchResult struct {
+	Book
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+type SearchBooksResponse struct {
+	Results    []*BookSearchResult `json:"results"`
+	Total      int                 `json:"total"`
+	Page       int                 `json:"page"`
+	Limit      int                 `json:"limit"`
+	TotalPages int                 `json:"total_pages"`
+	Next       *string             `json:"next"`
+	Prev       *string             `json:"prev"`
+}
+
+// GetDeletedBooksOptions lists the books the caller deleted after
+// DeletedAfter, which are the ones that can still be restored.
+type GetDeletedBooksOptions struct {
+	Page         int `validate:"gte=1"`
+	Limit        int `validate:"gte=1,lte=100"`
+	DeletedAfter time.Time
+}
+
+type ShareBookPayload struct {
+	Email      string `json:"email" validate:"required,email"`
+	Permission string `json:"permission" validate:"required,oneof=editor viewer"`
+}
+
+type BookShare struct {
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	Email      string    `json:"email"`
+	Permission string    `json:"permission"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type GetBookSharesResponse struct {
+	Shares []*BookShare `json:"shares"`
+}
+
+// BookCover holds the URLs an uploaded cover is served from.
+type BookCover struct {
+	ImageUrl     string `json:"image_url"`
+	ThumbnailUrl string `json:"thumbnail_url"`
+}
+
+type GetAllBooksOptions struct {
+	Page  int `validate:"gte=1"`
+	Limit int `validate:"gte=1,lte=100"`
+}
+
+type GetAllBooksResponse struct {
+	Books      []*Book `json:"books"`
+	Total      int     `json:"total"`
+	Page       int     `json:"page"`
+	Limit      int     `json:"limit"`
+	TotalPages int     `json:"total_pages"`
 }
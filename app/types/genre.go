@@ -0,0 +1,55 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+type GenreStore interface {
+	Create(ctx context.Context, genre CreateGenrePayload) (int, error)
+	GetByID(ctx context.Context, id int) (*Genre, error)
+	GetAll(ctx context.Context) ([]*Genre, error)
+	UpdateByID(ctx context.Context, id int, genre UpdateGenrePayload) (*Genre, error)
+	DeleteByID(ctx context.Context, id int) error
+}
+
+// Genre is shared by every user and managed by admins. Genres form a tree
+// through ParentID, e.g. Fiction > Fantasy > Epic, and books filtered by a
+// genre include the ones in its subgenres.
+type Genre struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Slug      string     `json:"slug"`
+	ParentID  *int       `json:"parent_id"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// CreateGenrePayload defaults Slug to the slug of Name. A nil ParentID puts
+// the genre at the top of the hierarchy.
+type CreateGenrePayload struct {
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Slug     string `json:"slug" validate:"required,max=100"`
+	ParentID *int   `json:"parent_id" validate:"omitempty,gte=1"`
+}
+
+type CreateGenreResponse struct {
+	ID int `json:"id"`
+}
+
+type UpdateGenrePayload struct {
+	Name     string `json:"name" validate:"required,min=2,max=100"`
+	Slug     string `json:"slug" validate:"required,max=100"`
+	ParentID *int   `json:"parent_id" validate:"omitempty,gte=1"`
+}
+
+type GetGenresResponse struct {
+	Genres []*Genre `json:"genres"`
+}
+
+// BookGenre is a genre as listed on a book.
+type BookGenre struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	Slug string `json:"slug"`
+}
@@ -0,0 +1,13 @@
+package types
+
+import "context"
+
+type Mailer interface {
+	Send(ctx context.Context, email Email) error
+}
+
+type Email struct {
+	To      string
+	Subject string
+	Body    string
+}
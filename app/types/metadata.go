@@ -0,0 +1,40 @@
+package types
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrMetadataNotFound is returned by a MetadataProvider whose catalog has no
+// entry for the ISBN.
+var ErrMetadataNotFound = errors.New("no catalog entry for ISBN")
+
+// MetadataProvider looks books up in an external catalog by ISBN-13.
+type MetadataProvider interface {
+	LookupISBN(ctx context.Context, isbn13 string) (*BookMetadata, error)
+}
+
+// BookMetadata is what a catalog knows about a book. Any field the catalog
+// doesn't have is left zero.
+type BookMetadata struct {
+	ISBN13        string   `json:"isbn_13"`
+	Name          string   `json:"name"`
+	Description   string   `json:"description"`
+	Authors       []string `json:"authors"`
+	ReleaseYear   int      `json:"release_year"`
+	NumberOfPages int      `json:"number_of_pages"`
+	ImageUrl      string   `json:"image_url"`
+}
+
+// ImportBookByISBNPayload creates a book from its catalog entry. The other
+// fields override what the catalog has, or fill in what it's missing.
+type ImportBookByISBNPayload struct {
+	ISBN          string `json:"isbn" validate:"required,isbn"`
+	GenreIDs      []int  `json:"genre_ids" validate:"required,min=1,max=20,dive,gte=1"`
+	Name          string `json:"name" validate:"omitempty,min=3"`
+	Description   string `json:"description" validate:"omitempty,min=5"`
+	Author        string `json:"author" validate:"omitempty,min=3"`
+	ReleaseYear   int    `json:"release_year" validate:"omitempty,gte=1500,lte=2099"`
+	NumberOfPages int    `json:"number_of_pages" validate:"omitempty,gte=1"`
+	ImageUrl      string `json:"image_url" validate:"omitempty,url"`
+}
@@ -0,0 +1,141 @@
+package types
+
+import "time"
+
+const (
+	OAuthGrantAuthorizationCode = "authorization_code"
+	OAuthGrantClientCredentials = "client_credentials"
+	OAuthGrantRefreshToken      = "refresh_token"
+)
+
+// OAuthClient is a third-party application allowed to request access to
+// users' accounts. Confidential clients authenticate with a secret, public
+// ones (mobile and browser apps) only rely on PKCE.
+type OAuthClient struct {
+	ID               int       `json:"id"`
+	ClientID         string    `json:"client_id"`
+	ClientSecretHash string    `json:"-"`
+	Name             string    `json:"name"`
+	RedirectURIs     []string  `json:"redirect_uris"`
+	Scopes           []string  `json:"scopes"`
+	GrantTypes       []string  `json:"grant_types"`
+	Confidential     bool      `json:"confidential"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+type CreateOAuthClientPayload struct {
+	Name         string   `json:"name" validate:"required,min=3,max=100"`
+	RedirectURIs []string `json:"redirect_uris" validate:"required,min=1,dive,url"`
+	Scopes       []string `json:"scopes" validate:"required,min=1,dive,oneof=books:read books:write"`
+	GrantTypes   []string `json:"grant_types" validate:"required,min=1,dive,oneof=authorization_code client_credentials refresh_token"`
+	Confidential bool     `json:"confidential"`
+}
+
+type CreateOAuthClientDatabasePayload struct {
+	ClientID         string
+	ClientSecretHash string
+	Name             string
+	RedirectURIs     []string
+	Scopes           []string
+	GrantTypes       []string
+	CreatedBy        int
+}
+
+// CreateOAuthClientResponse is the only response that carries the client
+// secret. Only its hash is stored.
+type CreateOAuthClientResponse struct {
+	OAuthClient
+	ClientSecret string `json:"client_secret,omitempty"`
+}
+
+type GetOAuthClientsResponse struct {
+	Clients []*OAuthClient `json:"clients"`
+}
+
+type OAuthAuthorizationCode struct {
+	ClientID      string
+	UserID        int
+	RedirectURI   string
+	Scope         string
+	CodeChallenge string
+}
+
+type CreateOAuthAuthorizationCodePayload struct {
+	CodeHash      string
+	ClientID      string
+	UserID        int
+	RedirectURI   string
+	Scope         string
+	CodeChallenge string
+	ExpiresAt     time.Time
+}
+
+type OAuthConsent struct {
+	ClientID   string     `json:"client_id"`
+	ClientName string     `json:"client_name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  *time.Time `json:"updated_at"`
+}
+
+type GetOAuthConsentsResponse struct {
+	Consents []*OAuthConsent `json:"consents"`
+}
+
+// OAuthAuthorizeRequest holds the parameters of an authorization request.
+// PKCE with S256 is required from every client.
+type OAuthAuthorizeRequest struct {
+	ResponseType        string `json:"response_type" validate:"required,eq=code"`
+	ClientID            string `json:"client_id" validate:"required"`
+	RedirectURI         string `json:"redirect_uri" validate:"required,url"`
+	Scope               string `json:"scope" validate:"required"`
+	State               string `json:"state"`
+	CodeChallenge       string `json:"code_challenge" validate:"required,min=43,max=128"`
+	CodeChallengeMethod string `json:"code_challenge_method" validate:"required,eq=S256"`
+}
+
+type OAuthConsentPayload struct {
+	OAuthAuthorizeRequest
+	Approved *bool `json:"approved" validate:"required"`
+}
+
+type OAuthAuthorizeResponse struct {
+	ClientID   string   `json:"client_id"`
+	ClientName string   `json:"client_name"`
+	Scopes     []string `json:"scopes"`
+	// ConsentGranted tells whether the user already agreed to every
+	// requested scope, in which case the app may skip the consent screen.
+	ConsentGranted bool `json:"consent_granted"`
+}
+
+type OAuthRedirectResponse struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+type OAuthTokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope"`
+}
+
+// OAuthErrorResponse follows RFC 6749 section 5.2, which the token,
+// introspection and revocation endpoints answer with instead of the usual
+// error body.
+type OAuthErrorResponse struct {
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description,omitempty"`
+}
+
+type OAuthIntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Username  string `json:"username,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+	Sub       string `json:"sub,omitempty"`
+	Jti       string `json:"jti,omitempty"`
+}
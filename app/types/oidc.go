@@ -0,0 +1,43 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// OIDCProvider runs the authorization code flow with PKCE against an
+// external OpenID Connect provider.
+type OIDCProvider interface {
+	AuthCodeURL(state string, nonce string, codeChallenge string) string
+	// Exchange redeems the code and returns the identity found in the
+	// verified ID token, which must carry the given nonce.
+	Exchange(ctx context.Context, code string, codeVerifier string, nonce string) (*OIDCIdentity, error)
+}
+
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+}
+
+// OIDCLoginState is what the callback needs from the request that started
+// the login. Only the hash of the state sent to the provider is stored.
+type OIDCLoginState struct {
+	Nonce        string
+	CodeVerifier string
+}
+
+type CreateOIDCLoginStatePayload struct {
+	StateHash    string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+type LinkIdentityPayload struct {
+	UserID  int
+	Issuer  string
+	Subject string
+	Email   string
+}
@@ -0,0 +1,13 @@
+package types
+
+import (
+	"context"
+	"time"
+)
+
+// TrashPurger is implemented by the stores whose soft-deleted rows are
+// hard-deleted once the retention period is over. Rows disabled by an admin
+// are left alone, since only an admin can decide what happens to them.
+type TrashPurger interface {
+	PurgeDeleted(ctx context.Context, deletedBefore time.Time) (int, error)
+}
@@ -11,35 +11,68 @@ type UserStore interface {
 	GetByEmail(ctx context.Context, email string) (*GetByEmailResponse, error)
 	UpdateByID(ctx context.Context, userID int, user UpdateUserPayload) (*UserResponse, error)
 	DeleteByID(ctx context.Context, userID int) error
+	GetMany(ctx context.Context, options GetUsersOptions) ([]*UserResponse, int, error)
+	GetAnyByID(ctx context.Context, userID int) (*UserResponse, error)
+	HardDeleteByID(ctx context.Context, userID int) error
+	DisableByID(ctx context.Context, userID int) error
+	RestoreByID(ctx context.Context, userID int) error
+	GetDeletedByEmail(ctx context.Context, email string, deletedAfter time.Time) (*GetByEmailResponse, error)
+	ExportByID(ctx context.Context, userID int) (*UserDataExport, error)
+	UpdateRoleByID(ctx context.Context, userID int, role string) (*UserResponse, error)
+	GetPasswordHashByID(ctx context.Context, userID int) (string, error)
+	UpdatePasswordByID(ctx context.Context, userID int, passwordHash string) error
+	CreateEmailVerificationToken(ctx context.Context, payload CreateEmailVerificationTokenPayload) error
+	VerifyEmail(ctx context.Context, tokenHash string) (int, error)
+	CreateAPIKey(ctx context.Context, payload CreateAPIKeyDatabasePayload) (*APIKey, error)
+	GetAPIKeysByUserID(ctx context.Context, userID int) ([]*APIKey, error)
+	DeleteAPIKey(ctx context.Context, userID int, id int) error
+	APIKeyAuthenticator
+	TrashPurger
 }
 
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, tokenHash string) (*APIKeyOwner, error)
+}
+
+const (
+	RoleAdmin     = "admin"
+	RoleLibrarian = "librarian"
+	RoleReader    = "reader"
+)
+
 type User struct {
-	ID           int        `json:"id"`
-	Username     string     `json:"username"`
-	Email        string     `json:"email"`
-	PasswordHash string     `json:"passwordHash"`
-	CreatedAt    time.Time  `json:"createdAt"`
-	DeletedAt    *time.Time `json:"deletedAt"`
-	UpdatedAt    *time.Time `json:"updatedAt"`
+	ID              int        `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	PasswordHash    string     `json:"passwordHash"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	DeletedAt       *time.Time `json:"deletedAt"`
+	UpdatedAt       *time.Time `json:"updatedAt"`
 }
 
 type GetByEmailResponse struct {
-	ID           int        `json:"id"`
-	Username     string     `json:"username"`
-	Email        string     `json:"email"`
-	PasswordHash string     `json:"passwordHash"`
-	CreatedAt    time.Time  `json:"createdAt"`
-	DeletedAt    *time.Time `json:"deletedAt"`
-	UpdatedAt    *time.Time `json:"updatedAt"`
+	ID              int        `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	PasswordHash    string     `json:"passwordHash"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	DeletedAt       *time.Time `json:"deletedAt"`
+	UpdatedAt       *time.Time `json:"updatedAt"`
 }
 
 type UserResponse struct {
-	ID        int        `json:"id"`
-	Username  string     `json:"username"`
-	Email     string     `json:"email"`
-	CreatedAt time.Time  `json:"createdAt"`
-	DeletedAt *time.Time `json:"deletedAt"`
-	UpdatedAt *time.Time `json:"updatedAt"`
+	ID              int        `json:"id"`
+	Username        string     `json:"username"`
+	Email           string     `json:"email"`
+	Role            string     `json:"role"`
+	EmailVerifiedAt *time.Time `json:"emailVerifiedAt"`
+	CreatedAt       time.Time  `json:"createdAt"`
+	DeletedAt       *time.Time `json:"deletedAt"`
+	UpdatedAt       *time.Time `json:"updatedAt"`
 }
 
 type CreateUserRequestPayload struct {
@@ -59,6 +92,12 @@ type CreateUserDatabasePayload struct {
 	PasswordHash string `json:"passwor_hash"`
 }
 
+type ChangePasswordPayload struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	Password        string `json:"password" validate:"required,min=8"`
+	ConfirmPassword string `json:"confirm_password" validate:"required,min=8"`
+}
+
 type UpdateUserPayload struct {
 	Username string `json:"username" validate:"required,min=5"`
 	Email    string `json:"email" validate:"required,email"`
@@ -67,3 +106,125 @@ type UpdateUserPayload struct {
 type DeleteUserByIDResponse struct {
 	ID int `json:"id"`
 }
+
+// GetUsersOptions lists every user when Search is empty, otherwise only the
+// ones whose username or email contains it, ignoring case.
+type GetUsersOptions struct {
+	Page   int    `validate:"gte=1"`
+	Limit  int    `validate:"gte=1,lte=100"`
+	Search string `validate:"max=100"`
+}
+
+type GetUsersResponse struct {
+	Users      []*UserResponse `json:"users"`
+	Total      int             `json:"total"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	TotalPages int             `json:"total_pages"`
+}
+
+type UpdateUserRolePayload struct {
+	Role string `json:"role" validate:"required,oneof=admin librarian reader"`
+}
+
+type CreateEmailVerificationTokenPayload struct {
+	UserID    int       `db:"user_id"`
+	TokenHash string    `db:"token_hash"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+type ResendVerificationEmailPayload struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type ResendVerificationEmailResponse struct {
+	Message string `json:"message"`
+}
+
+type VerifyEmailResponse struct {
+	Message string `json:"message"`
+}
+
+// APIKeyPrefix starts every API key, which is how AuthMiddleware tells them
+// apart from JWTs.
+const APIKeyPrefix = "bsk_"
+
+// Scopes an API key can be restricted to. A key without scopes has both, but
+// like any scoped token it can't reach the account, token or admin routes.
+const (
+	ScopeBooksRead  = "books:read"
+	ScopeBooksWrite = "books:write"
+)
+
+type APIKey struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	TokenPrefix string     `json:"token_prefix"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at"`
+	LastUsedAt  *time.Time `json:"last_used_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// APIKeyOwner is what AuthMiddleware needs to build the claims of a request
+// authenticated with an API key.
+type APIKeyOwner struct {
+	KeyID     int
+	UserID    int
+	Username  string
+	Email     string
+	Role      string
+	Scopes    []string
+	ExpiresAt *time.Time
+}
+
+type CreateAPIKeyPayload struct {
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"omitempty,unique,dive,oneof=books:read books:write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type CreateAPIKeyDatabasePayload struct {
+	UserID      int
+	Name        string
+	TokenPrefix string
+	TokenHash   string
+	Scopes      []string
+	ExpiresAt   *time.Time
+}
+
+// CreateAPIKeyResponse is the only response that carries the key itself. It
+// can't be retrieved again.
+type CreateAPIKeyResponse struct {
+	APIKey
+	Token string `json:"token"`
+}
+
+type GetAPIKeysResponse struct {
+	APIKeys []*APIKey `json:"api_keys"`
+}
+
+type SecurityEvent struct {
+	ID        int       `json:"id"`
+	EventType string    `json:"event_type"`
+	UserAgent string    `json:"user_agent"`
+	IPAddress string    `json:"ip_address"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserDataExport is everything stored about a user, as handed to them by
+// GET /users/me/export. Secrets such as the password hash, MFA secret and
+// token hashes are left out on purpose.
+type UserDataExport struct {
+	ExportedAt     time.Time          `json:"exported_at"`
+	Profile        UserResponse       `json:"profile"`
+	Books          []*Book            `json:"books"`
+	Sessions       []*SessionResponse `json:"sessions"`
+	APIKeys        []*APIKey          `json:"api_keys"`
+	SecurityEvents []*SecurityEvent   `json:"security_events"`
+	AuditLogs      []*AuditLog        `json:"audit_logs"`
+}
+
+type EraseUserPayload struct {
+	Password string `json:"password" validate:"required"`
+}
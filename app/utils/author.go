@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// AuthorNameKey reduces an author name to the key authors are told apart by,
+// so "J. R. R. Tolkien" and "J.R.R. Tolkien" are the same author. It must
+// stay in line with the backfill in the add-authors-tables migration.
+func AuthorNameKey(name string) string {
+	var key strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			key.WriteRune(r)
+		}
+	}
+
+	return key.String()
+}
+
+// BookAuthorCreditSQL is the books.author credit line of the book b: the
+// names of the authors it credits with the author role, in order. Every write
+// to book_authors or to an author's name sets books.author from it, since
+// filtering and full-text search still go by that column.
+const BookAuthorCreditSQL = `(
+		SELECT COALESCE(string_agg(a.name, ', ' ORDER BY ba.position, a.name), '')
+		FROM book_authors ba
+		INNER JOIN authors a ON a.id = ba.author_id
+		WHERE ba.book_id = b.id AND ba.role = 'author'
+	)`
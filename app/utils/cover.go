@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hoyci/book-store-api/config"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// CoverURL is where the cover blob at key is served.
+func CoverURL(key string) string {
+	return strings.TrimSuffix(config.Envs.CoverBaseURL, "/") + "/" + key
+}
+
+// coverKeyPattern matches the keys covers are stored under:
+// books/{book ID}/{16 hex digits}/full.jpg or thumbnail.jpg.
+var coverKeyPattern = regexp.MustCompile(`^books/([1-9][0-9]*)/[0-9a-f]{16}/(full|thumbnail)\.jpg$`)
+
+// IsCoverKey tells whether covers are stored under key. Nothing else is read
+// from or deleted in the cover storage, whatever a request or a row says.
+func IsCoverKey(key string) bool {
+	return coverKeyPattern.MatchString(key)
+}
+
+// CoverKeys is the reverse of CoverURL for the covers of the book. Other
+// URLs, images hosted elsewhere and covers of other books included, are
+// skipped.
+func CoverKeys(bookID int, urls ...string) []string {
+	prefix := strings.TrimSuffix(config.Envs.CoverBaseURL, "/") + "/"
+
+	var keys []string
+	for _, url := range urls {
+		key, ok := strings.CutPrefix(url, prefix)
+		if !ok {
+			continue
+		}
+		if match := coverKeyPattern.FindStringSubmatch(key); match != nil && match[1] == strconv.Itoa(bookID) {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// ScanCoverKeys reads rows of book id, image_url and thumbnail_url, closing
+// them, and returns how many there were along with the keys of the covers
+// among them.
+func ScanCoverKeys(rows *sql.Rows) (int, []string, error) {
+	defer rows.Close()
+
+	n := 0
+	var keys []string
+	for rows.Next() {
+		var bookID int
+		var imageURL, thumbnailURL string
+		if err := rows.Scan(&bookID, &imageURL, &thumbnailURL); err != nil {
+			return 0, nil, err
+		}
+		keys = append(keys, CoverKeys(bookID, imageURL, thumbnailURL)...)
+		n++
+	}
+
+	return n, keys, rows.Err()
+}
+
+// DeleteCoverBlobs removes the cover blobs at keys, logging the ones that
+// can't be. It goes on when ctx is canceled, since the rows pointing at them
+// are already gone by then.
+func DeleteCoverBlobs(ctx context.Context, covers types.BlobStore, keys ...string) {
+	if covers == nil {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	for _, key := range keys {
+		if err := covers.Delete(ctx, key); err != nil {
+			Log.WithFields(logrus.Fields{
+				"key":   key,
+				"error": err.Error(),
+			}).Error("Failed to delete a book cover")
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/hoyci/book-store-api/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverKeys(t *testing.T) {
+	defer func(baseURL string) { config.Envs.CoverBaseURL = baseURL }(config.Envs.CoverBaseURL)
+	config.Envs.CoverBaseURL = "https://api.example.com/api/v1/covers/"
+
+	t.Run("it should only accept the keys covers are stored under", func(t *testing.T) {
+		assert.True(t, IsCoverKey("books/1/0123456789abcdef/full.jpg"))
+		assert.True(t, IsCoverKey("books/12/0123456789abcdef/thumbnail.jpg"))
+
+		for _, key := range []string{
+			"",
+			"books/1/0123456789abcdef/full.png",
+			"books/1/0123456789ABCDEF/full.jpg",
+			"books/1/0123456789abcde/full.jpg",
+			"books/0/0123456789abcdef/full.jpg",
+			"books/1/0123456789abcdef/../../secret/full.jpg",
+			"/books/1/0123456789abcdef/full.jpg",
+			"other/1/0123456789abcdef/full.jpg",
+		} {
+			assert.False(t, IsCoverKey(key), key)
+		}
+	})
+
+	t.Run("it should return the keys of the book's own covers", func(t *testing.T) {
+		keys := CoverKeys(
+			1,
+			"https://api.example.com/api/v1/covers/books/1/0123456789abcdef/full.jpg",
+			"https://api.example.com/api/v1/covers/books/2/0123456789abcdef/thumbnail.jpg",
+			"https://api.example.com/api/v1/covers/secret.txt",
+			"https://images.example.com/books/1/0123456789abcdef/full.jpg",
+			"",
+		)
+
+		assert.Equal(t, []string{"books/1/0123456789abcdef/full.jpg"}, keys)
+		assert.Equal(t, "https://api.example.com/api/v1/covers/books/1/0123456789abcdef/full.jpg", CoverURL(keys[0]))
+	})
+}
@@ -0,0 +1,17 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// GenreSlug turns a genre name into the slug it is looked up by, e.g.
+// "Science Fiction" becomes "science-fiction". It must stay in line with the
+// backfill in the add-genres-tables migration.
+func GenreSlug(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	return strings.Join(words, "-")
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrInvalidISBN  = errors.New("invalid ISBN")
+	ErrISBNMismatch = errors.New("ISBN-10 and ISBN-13 are not the same book")
+)
+
+// CleanISBN drops the hyphens and spaces ISBNs are usually printed with, and
+// uppercases the X check digit of an ISBN-10.
+func CleanISBN(isbn string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(isbn))
+}
+
+// ValidISBN tells whether the cleaned isbn is an ISBN-10 or an ISBN-13 with a
+// correct check digit.
+func ValidISBN(isbn string) bool {
+	switch len(isbn) {
+	case 10:
+		return isbn10CheckDigit(isbn[:9]) == isbn[9]
+	case 13:
+		return isDigits(isbn) && isbn13CheckDigit(isbn[:12]) == isbn[12]
+	default:
+		return false
+	}
+}
+
+// NormalizeISBN turns a valid ISBN-10 or ISBN-13, hyphenated or not, into the
+// ISBN-13 books are stored and looked up by. It returns false when the ISBN is
+// not valid.
+func NormalizeISBN(isbn string) (string, bool) {
+	isbn = CleanISBN(isbn)
+	if !ValidISBN(isbn) {
+		return "", false
+	}
+
+	if len(isbn) == 10 {
+		isbn13 := "978" + isbn[:9]
+		return isbn13 + string(isbn13CheckDigit(isbn13)), true
+	}
+
+	return isbn, true
+}
+
+// ISBN10 returns the ISBN-10 of an ISBN-13, or an empty string for the
+// 979-prefixed ones that have no ISBN-10.
+func ISBN10(isbn13 string) string {
+	if len(isbn13) != 13 || !strings.HasPrefix(isbn13, "978") {
+		return ""
+	}
+
+	return isbn13[3:12] + string(isbn10CheckDigit(isbn13[3:12]))
+}
+
+// ResolveISBNs validates the ISBN-10 and ISBN-13 a book was sent with, either
+// of which can be empty, and returns both in their normalized form. When both
+// are given they must be the same book.
+func ResolveISBNs(isbn10, isbn13 string) (string, string, error) {
+	var normalized string
+	for _, isbn := range []string{isbn10, isbn13} {
+		if isbn == "" {
+			continue
+		}
+
+		n, ok := NormalizeISBN(isbn)
+		if !ok {
+			return "", "", ErrInvalidISBN
+		}
+		if normalized != "" && normalized != n {
+			return "", "", ErrISBNMismatch
+		}
+		normalized = n
+	}
+
+	return ISBN10(normalized), normalized, nil
+}
+
+func isbn10CheckDigit(digits string) byte {
+	if !isDigits(digits) {
+		return 0
+	}
+
+	sum := 0
+	for i := range digits {
+		sum += int(digits[i]-'0') * (10 - i)
+	}
+
+	check := (11 - sum%11) % 11
+	if check == 10 {
+		return 'X'
+	}
+
+	return byte('0' + check)
+}
+
+func isbn13CheckDigit(digits string) byte {
+	sum := 0
+	for i := range digits {
+		weight := 1
+		if i%2 == 1 {
+			weight = 3
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+
+	return byte('0' + (10-sum%10)%10)
+}
+
+func isDigits(s string) bool {
+	for i := range s {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
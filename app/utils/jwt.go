@@ -1,6 +1,7 @@
 package utils
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -10,11 +11,16 @@ import (
 )
 
 func GenerateTestToken(userID int, username, email string) string {
+	return GenerateTestTokenWithRole(userID, username, email, types.RoleReader)
+}
+
+func GenerateTestTokenWithRole(userID int, username, email, role string) string {
 	claims := types.CustomClaims{
 		ID:       "mocked-id",
 		UserID:   userID,
 		Username: username,
 		Email:    email,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: &jwt.NumericDate{Time: time.Now().Add(1 * time.Hour)},
 		},
@@ -27,6 +33,10 @@ func GenerateTestToken(userID int, username, email string) string {
 }
 
 func CreateJWTFromClaims(claims types.CustomClaims, secretKey string) (string, error) {
+	if jwtKeySet != nil {
+		return jwtKeySet.Sign(claims)
+	}
+
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 
 	signedToken, err := token.SignedString([]byte(secretKey))
@@ -37,32 +47,59 @@ func CreateJWTFromClaims(claims types.CustomClaims, secretKey string) (string, e
 	return signedToken, nil
 }
 
-func CreateJWT(userID int, username string, email string, secretKey string, expTimeInSeconds int64, uuidGen types.UUIDGenerator) (string, error) {
-	jti := uuidGen.New()
+func CreateJWT(userID int, username string, email string, role string, secretKey string, expTimeInSeconds int64, uuidGen types.UUIDGenerator) (string, error) {
+	return CreateJWTFromClaims(newClaims(userID, username, email, role, expTimeInSeconds, uuidGen), secretKey)
+}
 
-	claims := types.CustomClaims{
+// CreateAccessJWT issues an access token bound to the session (refresh token
+// family) it was issued for. A non-empty scope restricts the token to the
+// routes that allow it.
+func CreateAccessJWT(userID int, username string, email string, role string, scope string, sessionID string, secretKey string, expTimeInSeconds int64, uuidGen types.UUIDGenerator) (string, error) {
+	claims := newClaims(userID, username, email, role, expTimeInSeconds, uuidGen)
+	claims.Scope = scope
+	claims.SessionID = sessionID
+
+	return CreateJWTFromClaims(claims, secretKey)
+}
+
+// CreateOAuthAccessJWT issues an access token to a third-party client. Tokens
+// from the client credentials grant have no user and no session.
+func CreateOAuthAccessJWT(userID int, username string, email string, role string, scope string, sessionID string, clientID string, secretKey string, expTimeInSeconds int64, uuidGen types.UUIDGenerator) (string, error) {
+	claims := newClaims(userID, username, email, role, expTimeInSeconds, uuidGen)
+	claims.Scope = scope
+	claims.SessionID = sessionID
+	claims.ClientID = clientID
+
+	return CreateJWTFromClaims(claims, secretKey)
+}
+
+func CreateRefreshJWT(userID int, username string, email string, role string, familyID string, secretKey string, expTimeInSeconds int64, uuidGen types.UUIDGenerator) (string, error) {
+	claims := newClaims(userID, username, email, role, expTimeInSeconds, uuidGen)
+	claims.FamilyID = familyID
+
+	return CreateJWTFromClaims(claims, secretKey)
+}
+
+func newClaims(userID int, username string, email string, role string, expTimeInSeconds int64, uuidGen types.UUIDGenerator) types.CustomClaims {
+	return types.CustomClaims{
 		UserID:   userID,
 		Username: username,
 		Email:    email,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expTimeInSeconds) * time.Second)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "book-store-api",
-			ID:        jti,
+			ID:        uuidGen.New(),
 		},
 	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	signedToken, err := token.SignedString([]byte(secretKey))
-	if err != nil {
-		return "", fmt.Errorf("error signing token: %w", err)
-	}
-
-	return signedToken, nil
 }
 
 func VerifyJWT(tokenString, secretKey string) (*types.CustomClaims, error) {
+	if jwtKeySet != nil {
+		return jwtKeySet.Verify(tokenString)
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &types.CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
@@ -79,3 +116,15 @@ func VerifyJWT(tokenString, secretKey string) (*types.CustomClaims, error) {
 	}
 	return nil, fmt.Errorf("invalid token")
 }
+
+// IsAccessTokenRevoked asks denylist about the token the claims come from.
+// Tokens without an issue time count as issued at the zero time, so they are
+// refused once any of the user's tokens have been revoked.
+func IsAccessTokenRevoked(ctx context.Context, denylist types.TokenDenylist, claims *types.CustomClaims) (bool, error) {
+	var issuedAt time.Time
+	if claims.RegisteredClaims.IssuedAt != nil {
+		issuedAt = claims.RegisteredClaims.IssuedAt.Time
+	}
+
+	return denylist.IsAccessTokenRevoked(ctx, claims.RegisteredClaims.ID, claims.UserID, issuedAt)
+}
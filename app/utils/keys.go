@@ -0,0 +1,209 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hoyci/book-store-api/types"
+)
+
+type JWTKey struct {
+	ID         string
+	Method     jwt.SigningMethod
+	PrivateKey crypto.Signer
+	PublicKey  crypto.PublicKey
+}
+
+// JWTKeySet holds every key accepted when verifying tokens, identified by
+// their kid, plus the single key used to sign new tokens. Keeping previous
+// keys in the set while a new one signs is how keys are rotated.
+type JWTKeySet struct {
+	signingKey *JWTKey
+	keys       map[string]*JWTKey
+}
+
+var jwtKeySet *JWTKeySet
+
+// SetJWTKeySet switches token signing and verification from the shared HS256
+// secret to the given asymmetric keys.
+func SetJWTKeySet(keySet *JWTKeySet) {
+	jwtKeySet = keySet
+}
+
+func GetJWTKeySet() *JWTKeySet {
+	return jwtKeySet
+}
+
+// NewJWTKey wraps an RSA or Ed25519 key, private or public. Public-only keys
+// can verify tokens but can't sign them.
+func NewJWTKey(kid string, key any) (*JWTKey, error) {
+	if kid == "" {
+		return nil, fmt.Errorf("key id must not be empty")
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return &JWTKey{ID: kid, Method: jwt.SigningMethodRS256, PrivateKey: k, PublicKey: &k.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &JWTKey{ID: kid, Method: jwt.SigningMethodRS256, PublicKey: k}, nil
+	case ed25519.PrivateKey:
+		return &JWTKey{ID: kid, Method: jwt.SigningMethodEdDSA, PrivateKey: k, PublicKey: k.Public()}, nil
+	case ed25519.PublicKey:
+		return &JWTKey{ID: kid, Method: jwt.SigningMethodEdDSA, PublicKey: k}, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %T for key %s", key, kid)
+	}
+}
+
+func NewJWTKeySet(signingKeyID string, keys ...*JWTKey) (*JWTKeySet, error) {
+	keySet := &JWTKeySet{keys: make(map[string]*JWTKey, len(keys))}
+
+	for _, key := range keys {
+		if _, ok := keySet.keys[key.ID]; ok {
+			return nil, fmt.Errorf("duplicated key id %s", key.ID)
+		}
+		keySet.keys[key.ID] = key
+	}
+
+	signingKey, ok := keySet.keys[signingKeyID]
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not part of the key set", signingKeyID)
+	}
+	if signingKey.PrivateKey == nil {
+		return nil, fmt.Errorf("signing key %s has no private key", signingKeyID)
+	}
+	keySet.signingKey = signingKey
+
+	return keySet, nil
+}
+
+// LoadJWTKeySet reads PEM files described as "kid=path" pairs separated by
+// commas, e.g. "2025-02=/keys/current.pem,2024-11=/keys/previous.pub.pem".
+func LoadJWTKeySet(signingKeyID string, spec string) (*JWTKeySet, error) {
+	var keys []*JWTKey
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		kid, path, ok := strings.Cut(entry, "=")
+		if !ok {
+			return nil, fmt.Errorf("key entry %q is not in the kid=path format", entry)
+		}
+
+		key, err := loadJWTKeyFromPEM(strings.TrimSpace(kid), strings.TrimSpace(path))
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, key)
+	}
+
+	return NewJWTKeySet(signingKeyID, keys...)
+}
+
+func loadJWTKeyFromPEM(kid string, path string) (*JWTKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key %s: %w", kid, err)
+	}
+
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s is not PEM encoded", kid)
+	}
+
+	var key any
+	switch block.Type {
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unsupported PEM block %q for key %s", block.Type, kid)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error parsing key %s: %w", kid, err)
+	}
+
+	return NewJWTKey(kid, key)
+}
+
+func (ks *JWTKeySet) Sign(claims types.CustomClaims) (string, error) {
+	token := jwt.NewWithClaims(ks.signingKey.Method, claims)
+	token.Header["kid"] = ks.signingKey.ID
+
+	signedToken, err := token.SignedString(ks.signingKey.PrivateKey)
+	if err != nil {
+		return "", fmt.Errorf("error signing token: %w", err)
+	}
+
+	return signedToken, nil
+}
+
+func (ks *JWTKeySet) Verify(tokenString string) (*types.CustomClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &types.CustomClaims{}, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		key, ok := ks.keys[kid]
+		if !ok {
+			return nil, fmt.Errorf("unknown key id %q", kid)
+		}
+		if token.Method.Alg() != key.Method.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return key.PublicKey, nil
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("error parsing token: %w", err)
+	}
+
+	if claims, ok := token.Claims.(*types.CustomClaims); ok && token.Valid {
+		return claims, nil
+	}
+	return nil, fmt.Errorf("invalid token")
+}
+
+// JWKS returns the public part of every key in the set, sorted by kid so the
+// document is stable between requests.
+func (ks *JWTKeySet) JWKS() types.JWKSResponse {
+	jwks := types.JWKSResponse{Keys: make([]types.JWK, 0, len(ks.keys))}
+
+	for _, key := range ks.keys {
+		jwk := types.JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+
+		switch publicKey := key.PublicKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes())
+			jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes())
+		case ed25519.PublicKey:
+			jwk.Kty = "OKP"
+			jwk.Crv = "Ed25519"
+			jwk.X = base64.RawURLEncoding.EncodeToString(publicKey)
+		}
+
+		jwks.Keys = append(jwks.Keys, jwk)
+	}
+
+	sort.Slice(jwks.Keys, func(i, j int) bool {
+		return jwks.Keys[i].Kid < jwks.Keys[j].Kid
+	})
+
+	return jwks
+}
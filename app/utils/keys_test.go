@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func writePEM(t *testing.T, name string, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+
+	return path
+}
+
+func testClaims(userID int) types.CustomClaims {
+	return types.CustomClaims{
+		UserID: userID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}
+}
+
+func TestLoadJWTKeySet(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Failed to generate RSA key: %v", err)
+	}
+	edPublicKey, edPrivateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate Ed25519 key: %v", err)
+	}
+
+	edPKCS8, _ := x509.MarshalPKCS8PrivateKey(edPrivateKey)
+	edPKIX, _ := x509.MarshalPKIXPublicKey(edPublicKey)
+	rsaPKIX, _ := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+
+	rsaPrivatePath := writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+	rsaPublicPath := writePEM(t, "rsa.pub.pem", "PUBLIC KEY", rsaPKIX)
+	edPrivatePath := writePEM(t, "ed25519.pem", "PRIVATE KEY", edPKCS8)
+	edPublicPath := writePEM(t, "ed25519.pub.pem", "PUBLIC KEY", edPKIX)
+
+	t.Run("it should sign and verify with an RSA key", func(t *testing.T) {
+		keySet, err := LoadJWTKeySet("rsa", "rsa="+rsaPrivatePath)
+		assert.NoError(t, err)
+
+		token, err := keySet.Sign(testClaims(1))
+		assert.NoError(t, err)
+
+		claims, err := keySet.Verify(token)
+		assert.NoError(t, err)
+		assert.Equal(t, 1, claims.UserID)
+
+		jwks := keySet.JWKS()
+		assert.Len(t, jwks.Keys, 1)
+		assert.Equal(t, types.JWK{Kty: "RSA", Kid: "rsa", Use: "sig", Alg: "RS256", N: jwks.Keys[0].N, E: "AQAB"}, jwks.Keys[0])
+	})
+
+	t.Run("it should sign and verify with an Ed25519 key", func(t *testing.T) {
+		keySet, err := LoadJWTKeySet("ed", " ed = "+edPrivatePath+" ")
+		assert.NoError(t, err)
+
+		token, err := keySet.Sign(testClaims(2))
+		assert.NoError(t, err)
+
+		claims, err := keySet.Verify(token)
+		assert.NoError(t, err)
+		assert.Equal(t, 2, claims.UserID)
+
+		jwks := keySet.JWKS()
+		assert.Equal(t, "OKP", jwks.Keys[0].Kty)
+		assert.Equal(t, "Ed25519", jwks.Keys[0].Crv)
+		assert.Equal(t, "EdDSA", jwks.Keys[0].Alg)
+	})
+
+	t.Run("it should keep verifying tokens of a retired key after a rotation", func(t *testing.T) {
+		before, err := LoadJWTKeySet("2024-11", "2024-11="+rsaPrivatePath)
+		assert.NoError(t, err)
+		oldToken, err := before.Sign(testClaims(3))
+		assert.NoError(t, err)
+
+		after, err := LoadJWTKeySet("2025-02", "2025-02="+edPrivatePath+",2024-11="+rsaPublicPath)
+		assert.NoError(t, err)
+
+		claims, err := after.Verify(oldToken)
+		assert.NoError(t, err)
+		assert.Equal(t, 3, claims.UserID)
+
+		newToken, err := after.Sign(testClaims(4))
+		assert.NoError(t, err)
+		parsed, _, _ := jwt.NewParser().ParseUnverified(newToken, &types.CustomClaims{})
+		assert.Equal(t, "2025-02", parsed.Header["kid"])
+
+		jwks := after.JWKS()
+		assert.Equal(t, "2024-11", jwks.Keys[0].Kid)
+		assert.Equal(t, "2025-02", jwks.Keys[1].Kid)
+	})
+
+	t.Run("it should reject tokens signed with a key that is not in the set", func(t *testing.T) {
+		other, err := LoadJWTKeySet("other", "other="+edPrivatePath)
+		assert.NoError(t, err)
+		token, err := other.Sign(testClaims(5))
+		assert.NoError(t, err)
+
+		keySet, err := LoadJWTKeySet("ed", "ed="+edPrivatePath)
+		assert.NoError(t, err)
+
+		_, err = keySet.Verify(token)
+		assert.ErrorContains(t, err, `unknown key id "other"`)
+	})
+
+	t.Run("it should reject a token whose algorithm doesn't match its key", func(t *testing.T) {
+		keySet, err := LoadJWTKeySet("ed", "ed="+edPrivatePath+",rsa="+rsaPrivatePath)
+		assert.NoError(t, err)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, testClaims(6))
+		token.Header["kid"] = "ed"
+		signed, err := token.SignedString(rsaKey)
+		assert.NoError(t, err)
+
+		_, err = keySet.Verify(signed)
+		assert.Error(t, err)
+	})
+
+	t.Run("it should fail on invalid key sets", func(t *testing.T) {
+		_, err := LoadJWTKeySet("ed", "ed:"+edPrivatePath)
+		assert.ErrorContains(t, err, "kid=path")
+
+		_, err = LoadJWTKeySet("ed", "ed="+edPublicPath)
+		assert.ErrorContains(t, err, "has no private key")
+
+		_, err = LoadJWTKeySet("missing", "ed="+edPrivatePath)
+		assert.ErrorContains(t, err, "not part of the key set")
+
+		_, err = LoadJWTKeySet("ed", "ed="+edPrivatePath+",ed="+rsaPrivatePath)
+		assert.ErrorContains(t, err, "duplicated key id")
+
+		_, err = LoadJWTKeySet("ec", "ec="+writePEM(t, "ec.pem", "EC PRIVATE KEY", []byte("key")))
+		assert.ErrorContains(t, err, "unsupported PEM block")
+	})
+}
@@ -1,11 +1,15 @@
 package utils
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 	"time"
 
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/hoyci/book-store-api/config"
 	"github.com/hoyci/book-store-api/types"
 	"github.com/prometheus/client_golang/prometheus"
@@ -33,7 +37,46 @@ func LoggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+var tokenDenylist types.TokenDenylist
+
+// SetTokenDenylist registers the store AuthMiddleware consults to reject
+// access tokens that were revoked before their expiration.
+func SetTokenDenylist(denylist types.TokenDenylist) {
+	tokenDenylist = denylist
+}
+
+var apiKeyAuthenticator types.APIKeyAuthenticator
+
+// SetAPIKeyAuthenticator registers the store AuthMiddleware uses to accept API
+// keys in place of JWTs. Without it, API keys are rejected.
+func SetAPIKeyAuthenticator(authenticator types.APIKeyAuthenticator) {
+	apiKeyAuthenticator = authenticator
+}
+
 func AuthMiddleware(next http.Handler) http.Handler {
+	return AuthMiddlewareWithScopes()(next)
+}
+
+// AuthMiddlewareWithScopes works like AuthMiddleware but also accepts scoped
+// access tokens and API keys carrying one of the given scopes. Tokens without
+// a scope are always accepted.
+func AuthMiddlewareWithScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authMiddleware(next, scopes, false)
+	}
+}
+
+// ClientAuthMiddlewareWithScopes works like AuthMiddlewareWithScopes but also
+// accepts the tokens OAuth clients get for themselves with the
+// client_credentials grant. Those carry no user, so only routes that don't
+// act on behalf of one may take them.
+func ClientAuthMiddlewareWithScopes(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return authMiddleware(next, scopes, true)
+	}
+}
+
+func authMiddleware(next http.Handler, scopes []string, acceptClientTokens bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		authHeader := r.Header.Get("Authorization")
 		if authHeader == "" {
@@ -61,14 +104,107 @@ func AuthMiddleware(next http.Handler) http.Handler {
 
 		token := parts[1]
 
-		claims, err := VerifyJWT(token, config.Envs.JWTSecret)
-		if err != nil {
+		var claims *types.CustomClaims
+		if strings.HasPrefix(token, types.APIKeyPrefix) {
+			var err error
+			claims, err = authenticateAPIKey(r.Context(), token)
+			if err != nil {
+				if err == sql.ErrNoRows {
+					WriteError(
+						w,
+						http.StatusUnauthorized,
+						fmt.Errorf("user sent an unknown, revoked or expired API key"),
+						"AuthMiddleware",
+						types.UnauthorizedResponse{Error: "Invalid or expired token"},
+					)
+					return
+				}
+
+				WriteError(
+					w,
+					http.StatusInternalServerError,
+					err,
+					"AuthMiddleware",
+					types.InternalServerErrorResponse{Error: "An unexpected error occurred"},
+				)
+				return
+			}
+		} else {
+			var err error
+			claims, err = VerifyJWT(token, config.Envs.JWTSecret)
+			if err != nil {
+				WriteError(
+					w,
+					http.StatusUnauthorized,
+					fmt.Errorf("user sent an invalid or expired authorization header"),
+					"AuthMiddleware",
+					types.UnauthorizedResponse{Error: "Invalid or expired token"},
+				)
+				return
+			}
+
+			// Refresh tokens are signed like access tokens, but they are only
+			// meant to be exchanged at the refresh endpoints.
+			if claims.FamilyID != "" {
+				WriteError(
+					w,
+					http.StatusUnauthorized,
+					fmt.Errorf("user %d sent a refresh token as authorization header", claims.UserID),
+					"AuthMiddleware",
+					types.UnauthorizedResponse{Error: "Invalid or expired token"},
+				)
+				return
+			}
+
+			if tokenDenylist != nil {
+				revoked, err := IsAccessTokenRevoked(r.Context(), tokenDenylist, claims)
+				if err != nil {
+					WriteError(
+						w,
+						http.StatusInternalServerError,
+						err,
+						"AuthMiddleware",
+						types.InternalServerErrorResponse{Error: "An unexpected error occurred"},
+					)
+					return
+				}
+
+				if revoked {
+					WriteError(
+						w,
+						http.StatusUnauthorized,
+						fmt.Errorf("user sent a revoked authorization header"),
+						"AuthMiddleware",
+						types.UnauthorizedResponse{Error: "Invalid or expired token"},
+					)
+					return
+				}
+			}
+		}
+
+		if claims.UserID == 0 && !acceptClientTokens {
 			WriteError(
 				w,
-				http.StatusUnauthorized,
-				fmt.Errorf("user sent an invalid or expired authorization header"),
+				http.StatusForbidden,
+				fmt.Errorf("client %s sent a token without a user to a route that acts on behalf of one", claims.ClientID),
+				"AuthMiddleware",
+				types.ForbiddenResponse{Error: "This token does not grant access to this resource"},
+			)
+			return
+		}
+
+		if claims.Scope != "" && !hasAllowedScope(claims.Scope, scopes) {
+			clientError := types.ForbiddenResponse{Error: "This token does not grant access to this resource"}
+			if claims.Scope == types.ScopeUnverifiedEmail {
+				clientError.Error = "Email address is not verified"
+			}
+
+			WriteError(
+				w,
+				http.StatusForbidden,
+				fmt.Errorf("user %d sent a token with scope %q to a route that does not allow it", claims.UserID, claims.Scope),
 				"AuthMiddleware",
-				types.UnauthorizedResponse{Error: "Invalid or expired token"},
+				clientError,
 			)
 			return
 		}
@@ -81,8 +217,55 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+// authenticateAPIKey builds the claims of a request made with an API key, the
+// same way VerifyJWT does for access tokens. The key's scopes end up in Scope,
+// separated by spaces.
+func authenticateAPIKey(ctx context.Context, token string) (*types.CustomClaims, error) {
+	if apiKeyAuthenticator == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	owner, err := apiKeyAuthenticator.AuthenticateAPIKey(ctx, HashOpaqueToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	// A key without scopes gets every scope a key can have, which still keeps
+	// it off the account, token and admin routes that accept no scope.
+	scopes := owner.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{types.ScopeBooksRead, types.ScopeBooksWrite}
+	}
+
+	claims := &types.CustomClaims{
+		UserID:   owner.UserID,
+		Username: owner.Username,
+		Email:    owner.Email,
+		Role:     owner.Role,
+		Scope:    strings.Join(scopes, " "),
+	}
+	if owner.ExpiresAt != nil {
+		claims.RegisteredClaims.ExpiresAt = jwt.NewNumericDate(*owner.ExpiresAt)
+	}
+
+	return claims, nil
+}
+
+func hasAllowedScope(tokenScope string, allowed []string) bool {
+	for _, scope := range strings.Fields(tokenScope) {
+		if slices.Contains(allowed, scope) {
+			return true
+		}
+	}
+
+	return false
+}
+
 type Middleware interface {
 	WrapHandler(handlerName string, handler http.Handler) http.HandlerFunc
+	// Register exposes collectors owned by handlers, such as business
+	// counters, on the same registry as the HTTP metrics.
+	Register(collectors ...prometheus.Collector)
 }
 
 type middleware struct {
@@ -139,6 +322,10 @@ func (m *middleware) WrapHandler(handlerName string, handler http.Handler) http.
 	return base.ServeHTTP
 }
 
+func (m *middleware) Register(collectors ...prometheus.Collector) {
+	m.registry.MustRegister(collectors...)
+}
+
 func New(registry prometheus.Registerer, buckets []float64) Middleware {
 	if buckets == nil {
 		buckets = prometheus.ExponentialBuckets(0.1, 1.5, 5)
@@ -149,3 +336,36 @@ func New(registry prometheus.Registerer, buckets []float64) Middleware {
 		registry: registry,
 	}
 }
+
+// RequireRole only lets through requests whose token carries one of the given
+// roles. It must run after AuthMiddleware, which puts the claims in context.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := GetClaimsFromContext(r.Context())
+			if !ok {
+				WriteError(
+					w,
+					http.StatusUnauthorized,
+					fmt.Errorf("claims not found in request context"),
+					"RequireRole",
+					types.UnauthorizedResponse{Error: "Invalid or expired token"},
+				)
+				return
+			}
+
+			if !slices.Contains(roles, claims.Role) {
+				WriteError(
+					w,
+					http.StatusForbidden,
+					fmt.Errorf("user %d with role %q tried to access a route restricted to %v", claims.UserID, claims.Role, roles),
+					"RequireRole",
+					types.ForbiddenResponse{Error: "You do not have permission to access this resource"},
+				)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hoyci/book-store-api/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAuthMiddleware(t *testing.T) {
+	InitLogger()
+
+	handler := AuthMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	clientHandler := ClientAuthMiddlewareWithScopes("books:read")(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serveWith := func(handler http.Handler, token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/books", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w.Code
+	}
+	serve := func(token string) int {
+		return serveWith(handler, token)
+	}
+
+	t.Run("it should accept an access token", func(t *testing.T) {
+		token, err := CreateAccessJWT(1, "JohnDoe", "johndoe@example.com", "reader", "", "family-1", config.Envs.JWTSecret, 60, &UUIDGeneratorUtil{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusNoContent, serve(token))
+	})
+
+	t.Run("it should reject a refresh token used as access token", func(t *testing.T) {
+		token, err := CreateRefreshJWT(1, "JohnDoe", "johndoe@example.com", "reader", "family-1", config.Envs.JWTSecret, 60, &UUIDGeneratorUtil{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusUnauthorized, serve(token))
+	})
+
+	t.Run("it should reject a client credentials token on routes that act for a user", func(t *testing.T) {
+		token, err := CreateOAuthAccessJWT(0, "", "", "", "books:read", "", "client-1", config.Envs.JWTSecret, 60, &UUIDGeneratorUtil{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusForbidden, serve(token))
+		assert.Equal(t, http.StatusNoContent, serveWith(clientHandler, token))
+	})
+
+	t.Run("it should still require a granted scope from client credentials tokens", func(t *testing.T) {
+		token, err := CreateOAuthAccessJWT(0, "", "", "", "books:write", "", "client-1", config.Envs.JWTSecret, 60, &UUIDGeneratorUtil{})
+		assert.NoError(t, err)
+
+		assert.Equal(t, http.StatusForbidden, serveWith(clientHandler, token))
+	})
+
+	t.Run("it should reject an access token issued before the user's tokens were revoked", func(t *testing.T) {
+		denylist := &revokedBeforeDenylist{userID: 1, validAfter: time.Now().Add(time.Hour)}
+		SetTokenDenylist(denylist)
+		defer SetTokenDenylist(nil)
+
+		token, err := CreateAccessJWT(1, "JohnDoe", "johndoe@example.com", "reader", "", "family-1", config.Envs.JWTSecret, 60, &UUIDGeneratorUtil{})
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, serve(token))
+
+		denylist.validAfter = time.Now().Add(-time.Hour)
+		assert.Equal(t, http.StatusNoContent, serve(token))
+	})
+}
+
+// revokedBeforeDenylist revokes the tokens of one user issued before
+// validAfter, like tokens_valid_after does.
+type revokedBeforeDenylist struct {
+	userID     int
+	validAfter time.Time
+}
+
+func (d *revokedBeforeDenylist) IsAccessTokenRevoked(ctx context.Context, jti string, userID int, issuedAt time.Time) (bool, error) {
+	return userID == d.userID && d.validAfter.After(issuedAt), nil
+}
@@ -2,28 +2,168 @@ package utils
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
 
+	"github.com/hoyci/book-store-api/config"
 	"go.opentelemetry.io/otel"
+	"golang.org/x/crypto/argon2"
 	"golang.org/x/crypto/bcrypt"
 )
 
+const (
+	PasswordHasherArgon2id = "argon2id"
+	PasswordHasherBcrypt   = "bcrypt"
+
+	argon2SaltLength = 16
+	argon2KeyLength  = 32
+)
+
+// PasswordHasher describes how new passwords are hashed. Every parameter is
+// encoded in the stored hash, so hashes made with older settings can still be
+// checked and are reported as outdated.
+type PasswordHasher struct {
+	Algorithm     string
+	BcryptCost    int
+	Argon2Memory  uint32
+	Argon2Time    uint32
+	Argon2Threads uint8
+}
+
+var passwordHasher = &PasswordHasher{
+	Algorithm:     config.Envs.PasswordHasher,
+	BcryptCost:    int(config.Envs.BcryptCost),
+	Argon2Memory:  uint32(config.Envs.Argon2Memory),
+	Argon2Time:    uint32(config.Envs.Argon2Time),
+	Argon2Threads: uint8(config.Envs.Argon2Threads),
+}
+
+func SetPasswordHasher(hasher *PasswordHasher) {
+	passwordHasher = hasher
+}
+
+func GetPasswordHasher() *PasswordHasher {
+	return passwordHasher
+}
+
+func (h *PasswordHasher) Validate() error {
+	switch h.Algorithm {
+	case PasswordHasherArgon2id:
+		if h.Argon2Memory == 0 || h.Argon2Time == 0 || h.Argon2Threads == 0 {
+			return fmt.Errorf("argon2id memory, time and threads must be greater than zero")
+		}
+	case PasswordHasherBcrypt:
+		if h.BcryptCost < bcrypt.MinCost || h.BcryptCost > bcrypt.MaxCost {
+			return fmt.Errorf("bcrypt cost must be between %d and %d", bcrypt.MinCost, bcrypt.MaxCost)
+		}
+	default:
+		return fmt.Errorf("unsupported password hasher %q", h.Algorithm)
+	}
+
+	return nil
+}
+
 func HashPassword(ctx context.Context, password string) (string, error) {
 	tracer := otel.Tracer("utils")
 	_, span := tracer.Start(ctx, "Utils.HashPassword")
 	defer span.End()
 
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
-	if err != nil {
-		return "", err
+	switch passwordHasher.Algorithm {
+	case PasswordHasherBcrypt:
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), passwordHasher.BcryptCost)
+		if err != nil {
+			return "", err
+		}
+		return string(hashedPassword), nil
+	case PasswordHasherArgon2id:
+		salt := make([]byte, argon2SaltLength)
+		if _, err := rand.Read(salt); err != nil {
+			return "", fmt.Errorf("error generating salt: %w", err)
+		}
+
+		key := argon2.IDKey([]byte(password), salt, passwordHasher.Argon2Time, passwordHasher.Argon2Memory, passwordHasher.Argon2Threads, argon2KeyLength)
+		return fmt.Sprintf(
+			"$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+			argon2.Version,
+			passwordHasher.Argon2Memory,
+			passwordHasher.Argon2Time,
+			passwordHasher.Argon2Threads,
+			base64.RawStdEncoding.EncodeToString(salt),
+			base64.RawStdEncoding.EncodeToString(key),
+		), nil
+	default:
+		return "", fmt.Errorf("unsupported password hasher %q", passwordHasher.Algorithm)
 	}
-	return string(hashedPassword), nil
 }
 
-func CheckPassword(ctx context.Context, hashedPassword, password string) error {
+// CheckPassword compares a password with a hash made by any supported
+// algorithm. When they match, needsRehash tells whether the hash was made
+// with a different algorithm or parameters than the ones configured now.
+func CheckPassword(ctx context.Context, hashedPassword, password string) (needsRehash bool, err error) {
 	tracer := otel.Tracer("utils")
 	_, span := tracer.Start(ctx, "Utils.CheckPassword")
 	defer span.End()
 
-	err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password))
-	return err
+	if strings.HasPrefix(hashedPassword, "$argon2id$") {
+		return checkArgon2idPassword(hashedPassword, password)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)); err != nil {
+		return false, err
+	}
+
+	if passwordHasher.Algorithm != PasswordHasherBcrypt {
+		return true, nil
+	}
+	cost, err := bcrypt.Cost([]byte(hashedPassword))
+	if err != nil {
+		return false, err
+	}
+	return cost != passwordHasher.BcryptCost, nil
+}
+
+func checkArgon2idPassword(hashedPassword, password string) (bool, error) {
+	// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+	parts := strings.Split(hashedPassword, "$")
+	if len(parts) != 6 {
+		return false, fmt.Errorf("malformed argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, fmt.Errorf("malformed argon2id hash: %w", err)
+	}
+	if version != argon2.Version {
+		return false, fmt.Errorf("unsupported argon2 version %d", version)
+	}
+
+	var memory, time uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &time, &threads); err != nil {
+		return false, fmt.Errorf("malformed argon2id hash: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, fmt.Errorf("malformed argon2id salt: %w", err)
+	}
+	expectedKey, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, fmt.Errorf("malformed argon2id key: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, time, memory, threads, uint32(len(expectedKey)))
+	if subtle.ConstantTimeCompare(key, expectedKey) != 1 {
+		return false, bcrypt.ErrMismatchedHashAndPassword
+	}
+
+	needsRehash := passwordHasher.Algorithm != PasswordHasherArgon2id ||
+		memory != passwordHasher.Argon2Memory ||
+		time != passwordHasher.Argon2Time ||
+		threads != passwordHasher.Argon2Threads ||
+		len(expectedKey) != argon2KeyLength
+	return needsRehash, nil
 }
@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hoyci/book-store-api/config"
+)
+
+// PasswordPolicy is checked whenever a user picks a new password. Blocked
+// holds known breached passwords, lowercased.
+type PasswordPolicy struct {
+	MinLength int
+	MaxLength int
+	Blocked   map[string]struct{}
+}
+
+var passwordPolicy = &PasswordPolicy{
+	MinLength: int(config.Envs.PasswordMinLength),
+	MaxLength: int(config.Envs.PasswordMaxLength),
+}
+
+func SetPasswordPolicy(policy *PasswordPolicy) {
+	passwordPolicy = policy
+}
+
+func GetPasswordPolicy() *PasswordPolicy {
+	return passwordPolicy
+}
+
+// LoadPasswordBlocklist reads one password per line. Blank lines and lines
+// starting with # are ignored.
+func LoadPasswordBlocklist(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening password blocklist: %w", err)
+	}
+	defer file.Close()
+
+	blocked := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		blocked[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading password blocklist: %w", err)
+	}
+
+	return blocked, nil
+}
+
+// CheckPasswordPolicy returns an error whose message can be shown to the user
+// as is.
+func CheckPasswordPolicy(password string) error {
+	length := utf8.RuneCountInString(password)
+
+	if length < passwordPolicy.MinLength {
+		return fmt.Errorf("Password must be at least %d characters long", passwordPolicy.MinLength)
+	}
+
+	if passwordPolicy.MaxLength > 0 && length > passwordPolicy.MaxLength {
+		return fmt.Errorf("Password must be at most %d characters long", passwordPolicy.MaxLength)
+	}
+
+	if _, ok := passwordPolicy.Blocked[strings.ToLower(password)]; ok {
+		return fmt.Errorf("Password is too common, choose a different one")
+	}
+
+	return nil
+}
@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes the stores react to.
+const (
+	PQForeignKeyViolation pq.ErrorCode = "23503"
+	PQUniqueViolation     pq.ErrorCode = "23505"
+)
+
+// IsPQError reports whether err is a Postgres error with the given code.
+func IsPQError(err error, code pq.ErrorCode) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == code
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// EscapeLike escapes the LIKE wildcards in user input, so a search for "a_b"
+// doesn't match "axb". Postgres uses the backslash as LIKE escape by default.
+func EscapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -0,0 +1,16 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeLike(t *testing.T) {
+	t.Run("it should escape the LIKE wildcards and the escape character", func(t *testing.T) {
+		assert.Equal(t, `50\% off`, EscapeLike("50% off"))
+		assert.Equal(t, `snake\_case`, EscapeLike("snake_case"))
+		assert.Equal(t, `C:\\books`, EscapeLike(`C:\books`))
+		assert.Equal(t, "Frank Herbert", EscapeLike("Frank Herbert"))
+	})
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hoyci/book-store-api/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClientIP(t *testing.T) {
+	defer func(proxies string) { config.Envs.TrustedProxies = proxies }(config.Envs.TrustedProxies)
+
+	newRequest := func(remoteAddr string, forwardedFor ...string) *http.Request {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.RemoteAddr = remoteAddr
+		for _, header := range forwardedFor {
+			req.Header.Add("X-Forwarded-For", header)
+		}
+		return req
+	}
+
+	t.Run("it should ignore X-Forwarded-For without trusted proxies", func(t *testing.T) {
+		config.Envs.TrustedProxies = ""
+
+		assert.Equal(t, "203.0.113.7", ClientIP(newRequest("203.0.113.7:4321", "198.51.100.1")))
+	})
+
+	t.Run("it should ignore X-Forwarded-For from a peer that is not a trusted proxy", func(t *testing.T) {
+		config.Envs.TrustedProxies = "10.0.0.0/8"
+
+		assert.Equal(t, "203.0.113.7", ClientIP(newRequest("203.0.113.7:4321", "198.51.100.1")))
+	})
+
+	t.Run("it should take the last hop that is not a trusted proxy", func(t *testing.T) {
+		config.Envs.TrustedProxies = "10.0.0.0/8, 192.0.2.10"
+
+		req := newRequest("10.0.0.2:4321", "1.1.1.1, 198.51.100.1", "192.0.2.10")
+
+		assert.Equal(t, "198.51.100.1", ClientIP(req))
+	})
+
+	t.Run("it should stop at a hop that is not an IP address", func(t *testing.T) {
+		config.Envs.TrustedProxies = "10.0.0.0/8"
+
+		req := newRequest("10.0.0.2:4321", "198.51.100.1, "+strings.Repeat("a", 60), "10.0.0.3")
+
+		assert.Equal(t, "10.0.0.3", ClientIP(req))
+	})
+
+	t.Run("it should normalize IPv6 addresses", func(t *testing.T) {
+		config.Envs.TrustedProxies = ""
+
+		assert.Equal(t, "2001:db8::1", ClientIP(newRequest("[2001:0db8:0000::1]:4321")))
+	})
+
+	t.Run("it should return an empty string for a peer that is not an IP address", func(t *testing.T) {
+		config.Envs.TrustedProxies = ""
+
+		assert.Equal(t, "", ClientIP(newRequest("pipe")))
+	})
+}
@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncryptSecret(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", SecretKeySize)))
+	otherKey := base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", SecretKeySize)))
+
+	t.Run("it should decrypt what it encrypted", func(t *testing.T) {
+		ciphertext, err := EncryptSecret("JBSWY3DPEHPK3PXP", key)
+		assert.NoError(t, err)
+		assert.NotContains(t, ciphertext, "JBSWY3DPEHPK3PXP")
+
+		plaintext, err := DecryptSecret(ciphertext, key)
+		assert.NoError(t, err)
+		assert.Equal(t, "JBSWY3DPEHPK3PXP", plaintext)
+	})
+
+	t.Run("it should not decrypt with another key", func(t *testing.T) {
+		ciphertext, err := EncryptSecret("JBSWY3DPEHPK3PXP", key)
+		assert.NoError(t, err)
+
+		_, err = DecryptSecret(ciphertext, otherKey)
+		assert.Error(t, err)
+	})
+
+	t.Run("it should refuse keys that are not 32 bytes in base64", func(t *testing.T) {
+		for _, key := range []string{
+			"",
+			"ABRACADABARA",
+			base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 16))),
+			base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 64))),
+		} {
+			_, err := EncryptSecret("JBSWY3DPEHPK3PXP", key)
+			assert.ErrorIs(t, err, ErrInvalidSecretKey, key)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+package utils
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hoyci/book-store-api/config"
+	"github.com/hoyci/book-store-api/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	LoginThrottleScopeAccount = "account"
+	LoginThrottleScopeIP      = "ip"
+	LoginThrottleScopeMFA     = "mfa"
+)
+
+type LoginThrottle struct {
+	Key         string
+	Scope       string
+	MaxFailures int64
+}
+
+// LoginThrottles lists the counters a password check is checked and recorded
+// against. The account one always comes first. Every endpoint that takes a
+// password shares them, so none can be used to guess it past the limit.
+func LoginThrottles(email string, ip string) []LoginThrottle {
+	return []LoginThrottle{
+		{
+			Key:         LoginThrottleScopeAccount + ":" + strings.ToLower(strings.TrimSpace(email)),
+			Scope:       LoginThrottleScopeAccount,
+			MaxFailures: config.Envs.LoginMaxFailures,
+		},
+		{
+			Key:         LoginThrottleScopeIP + ":" + ip,
+			Scope:       LoginThrottleScopeIP,
+			MaxFailures: config.Envs.LoginMaxFailuresPerIP,
+		},
+	}
+}
+
+// MFAThrottle is the counter second factor codes of the user are checked and
+// recorded against. A challenge token is spent on every guess, but getting a
+// new one only takes the password, so guesses are limited per user as well.
+func MFAThrottle(userID int) LoginThrottle {
+	return LoginThrottle{
+		Key:         LoginThrottleScopeMFA + ":" + strconv.Itoa(userID),
+		Scope:       LoginThrottleScopeMFA,
+		MaxFailures: config.Envs.LoginMaxFailures,
+	}
+}
+
+func LoginThrottleKeys(throttles []LoginThrottle) []string {
+	keys := make([]string, 0, len(throttles))
+	for _, throttle := range throttles {
+		keys = append(keys, throttle.Key)
+	}
+
+	return keys
+}
+
+// loginLockoutDuration doubles the base lockout for every failure past the
+// limit, up to the configured maximum.
+func loginLockoutDuration(failuresOverLimit int) time.Duration {
+	maxLockout := time.Duration(config.Envs.LoginLockoutMax) * time.Second
+	lockout := time.Duration(config.Envs.LoginLockoutBase) * time.Second
+
+	for range failuresOverLimit {
+		lockout *= 2
+		if lockout >= maxLockout {
+			return maxLockout
+		}
+	}
+
+	return min(lockout, maxLockout)
+}
+
+// RecordFailedLogin counts a wrong password or MFA code against every
+// throttle and locks the ones past their limit, which it returns. Locking the
+// account or the second factor of a known user is also kept as a security
+// event of that user.
+func RecordFailedLogin(r *http.Request, authStore types.AuthStore, throttles []LoginThrottle, userID int) ([]LoginThrottle, error) {
+	var locked []LoginThrottle
+
+	for _, throttle := range throttles {
+		failures, err := authStore.RecordFailedLogin(r.Context(), throttle.Key, time.Duration(config.Envs.LoginFailureWindow)*time.Second)
+		if err != nil {
+			return nil, err
+		}
+
+		if int64(failures) < throttle.MaxFailures {
+			continue
+		}
+
+		lockedUntil := time.Now().Add(loginLockoutDuration(failures - int(throttle.MaxFailures)))
+		if err := authStore.LockLogin(r.Context(), throttle.Key, lockedUntil); err != nil {
+			return nil, err
+		}
+
+		locked = append(locked, throttle)
+		Log.WithFields(logrus.Fields{
+			"throttle_key": throttle.Key,
+			"failures":     failures,
+			"locked_until": lockedUntil,
+		}).Warn("Too many failed logins, login locked")
+
+		if throttle.Scope != LoginThrottleScopeIP && userID != 0 {
+			err := authStore.CreateSecurityEvent(
+				r.Context(),
+				types.CreateSecurityEventPayload{
+					UserID:    userID,
+					EventType: types.SecurityEventLoginLockout,
+					UserAgent: r.UserAgent(),
+					IPAddress: ClientIP(r),
+				},
+			)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return locked, nil
+}